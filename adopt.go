@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// containerPortKeys maps well-known container ports to the Sail port key
+// whose base they get in the suffix scheme, so adopted projects line up
+// with Sail projects.
+var containerPortKeys = map[int]string{
+	80:   "APP_PORT",
+	8000: "APP_PORT",
+	3306: "FORWARD_DB_PORT",
+	5432: "FORWARD_DB_PORT",
+	6379: "FORWARD_REDIS_PORT",
+	7700: "FORWARD_MEILISEARCH_PORT",
+	8025: "FORWARD_MAILPIT_DASHBOARD_PORT",
+	1025: "FORWARD_MAILPIT_PORT",
+	5173: "VITE_PORT",
+}
+
+// overrideMarker is the first line of override files written by adopt, so
+// they can be regenerated without clobbering hand-written overrides.
+const overrideMarker = "# Generated by sailinit adopt"
+
+// adoptedPort is a published port remapped into the suffix scheme.
+type adoptedPort struct {
+	Service string
+	HostIP  string
+	Host    int
+	Target  int
+}
+
+func handleAdopt(args []string) {
+	fs := commandFlags("adopt")
+	dryRun := fs.Bool("dry-run", false, "Print the override file without writing it or registering the project")
+	fs.StringVar(&composeFileOverride, "compose-file", "", "Compose file to use instead of the default (also honors COMPOSE_FILE)")
+	fs.Parse(args)
+	if !*dryRun {
+		requireWritableState()
+	}
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+
+	project, err := loadCompose(projectDir)
+	if err != nil {
+		exitWithError("Error reading compose file", err)
+	}
+	if project == nil {
+		printError("No compose file found in the current directory.")
+		exit(ExitGeneral)
+	}
+	if isSailProject(project) {
+		printError("This project already uses Laravel Sail. Run sailinit without arguments instead.")
+		exit(ExitGeneral)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	suffix, err := chooseSuffix(reader, projectDir)
+	if err != nil {
+		exitWithError("Error determining suffix", err)
+	}
+
+	mapped, skipped := planAdoption(project, suffix)
+	if len(mapped) == 0 {
+		printError("No published ports with a known role were found to adopt.")
+		exit(ExitGeneral)
+	}
+	for _, s := range skipped {
+		printWarning("Skipping " + s)
+	}
+
+	overridePath := overrideFilePath(projectDir)
+	content := renderOverride(mapped)
+	if *dryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would write %s:", overridePath))
+		fmt.Print(content)
+		printInfo(fmt.Sprintf("[dry-run] Would save suffix %d for project %s", suffix, projectDir))
+		return
+	}
+
+	if err := writeOverride(overridePath, content); err != nil {
+		exitWithError("Error writing override file", err)
+	}
+	if err := saveProjectSuffix(projectDir, suffix); err != nil {
+		exitWithError("Error saving suffix", err)
+	}
+
+	printSuccess(fmt.Sprintf("Adopted project with suffix %d. Wrote %s:", suffix, filepath.Base(overridePath)))
+	for _, p := range mapped {
+		printInfo(fmt.Sprintf("  %s: %d -> %d", p.Service, p.Target, p.Host))
+	}
+}
+
+// isSailProject reports whether the compose project is a Laravel Sail stack.
+func isSailProject(project *composeProject) bool {
+	for _, svc := range project.Services {
+		if svc.Name == "laravel.test" || svc.phpVersion() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// planAdoption maps every published port with a known container port into
+// the suffix scheme. Several container ports share a key, e.g. 80 and 8000,
+// and two services may publish the same port, so only the first claimant
+// of a host port gets it. Ports left out are returned as "service:port:
+// reason".
+func planAdoption(project *composeProject, suffix int) ([]adoptedPort, []string) {
+	var mapped []adoptedPort
+	var skipped []string
+	bases := portBases()
+	claimed := make(map[int]adoptedPort)
+	for _, svc := range project.Services {
+		for _, port := range svc.Ports {
+			key, ok := containerPortKeys[port.Target]
+			if !ok {
+				skipped = append(skipped, fmt.Sprintf("%s:%d: no base port for this container port", svc.Name, port.Target))
+				continue
+			}
+			p := adoptedPort{
+				Service: svc.Name,
+				HostIP:  port.HostIP,
+				Host:    baseOf(bases, key) + suffix,
+				Target:  port.Target,
+			}
+			if first, taken := claimed[p.Host]; taken {
+				skipped = append(skipped, fmt.Sprintf("%s:%d: host port %d already goes to %s:%d", svc.Name, port.Target, p.Host, first.Service, first.Target))
+				continue
+			}
+			claimed[p.Host] = p
+			mapped = append(mapped, p)
+		}
+	}
+	return mapped, skipped
+}
+
+// overrideFilePath returns the override file docker compose picks up
+// automatically next to the project's main compose file.
+func overrideFilePath(projectDir string) string {
+	for _, path := range composeFiles(projectDir) {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		ext := filepath.Ext(path)
+		return strings.TrimSuffix(path, ext) + ".override" + ext
+	}
+	return filepath.Join(projectDir, "compose.override.yaml")
+}
+
+// renderOverride produces a compose override that replaces the published
+// ports of each adopted service.
+func renderOverride(mapped []adoptedPort) string {
+	byService := make(map[string][]adoptedPort)
+	var services []string
+	for _, p := range mapped {
+		if _, ok := byService[p.Service]; !ok {
+			services = append(services, p.Service)
+		}
+		byService[p.Service] = append(byService[p.Service], p)
+	}
+	sort.Strings(services)
+
+	var b strings.Builder
+	b.WriteString(overrideMarker + ": maps published ports into the sailinit suffix scheme.\n")
+	b.WriteString("services:\n")
+	for _, name := range services {
+		fmt.Fprintf(&b, "  %s:\n", name)
+		b.WriteString("    ports: !override\n")
+		for _, p := range byService[name] {
+			if p.HostIP != "" {
+				host := p.HostIP
+				if strings.Contains(host, ":") {
+					host = "[" + host + "]"
+				}
+				fmt.Fprintf(&b, "      - \"%s:%d:%d\"\n", host, p.Host, p.Target)
+			} else {
+				fmt.Fprintf(&b, "      - \"%d:%d\"\n", p.Host, p.Target)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeOverride writes the override file, refusing to replace an existing
+// file that sailinit did not generate.
+func writeOverride(path, content string) error {
+	if data, err := os.ReadFile(path); err == nil && !strings.HasPrefix(string(data), overrideMarker) {
+		return fmt.Errorf("%s already exists and was not generated by sailinit", filepath.Base(path))
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanAdoption(t *testing.T) {
+	project, err := parseCompose([]byte(`
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+  db:
+    image: postgres
+    ports:
+      - "127.0.0.1:5432:5432"
+  grafana:
+    image: grafana/grafana
+    ports:
+      - "3000:3000"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isSailProject(project) {
+		t.Fatal("Plain compose project should not be detected as Sail")
+	}
+
+	mapped, skipped := planAdoption(project, 52)
+	if len(mapped) != 2 {
+		t.Fatalf("Expected 2 mapped ports, got %+v", mapped)
+	}
+	if mapped[0].Host != 8052 || mapped[1].Host != 3352 {
+		t.Errorf("Expected hosts 8052 and 3352, got %d and %d", mapped[0].Host, mapped[1].Host)
+	}
+	if len(skipped) != 1 || skipped[0] != "grafana:3000: no base port for this container port" {
+		t.Errorf("Expected grafana:3000 to be skipped, got %v", skipped)
+	}
+
+	content := renderOverride(mapped)
+	if !strings.HasPrefix(content, overrideMarker) {
+		t.Error("Override should start with the sailinit marker")
+	}
+	if !strings.Contains(content, "  db:\n    ports: !override\n      - \"127.0.0.1:3352:5432\"\n") {
+		t.Errorf("Unexpected db override:\n%s", content)
+	}
+	if !strings.Contains(content, "  web:\n    ports: !override\n      - \"8052:80\"\n") {
+		t.Errorf("Unexpected web override:\n%s", content)
+	}
+
+	content = renderOverride([]adoptedPort{{Service: "web", HostIP: "::1", Host: 8052, Target: 80}})
+	if !strings.Contains(content, "      - \"[::1]:8052:80\"\n") {
+		t.Errorf("Expected the IPv6 host in brackets:\n%s", content)
+	}
+}
+
+func TestPlanAdoptionSharedHostPort(t *testing.T) {
+	project, err := parseCompose([]byte(`
+services:
+  cache:
+    image: redis
+    ports:
+      - "6379:6379"
+  queue:
+    image: redis
+    ports:
+      - "6380:6379"
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+      - "8000:8000"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mapped, skipped := planAdoption(project, 52)
+	hosts := make(map[int]string)
+	for _, p := range mapped {
+		if other, dup := hosts[p.Host]; dup {
+			t.Errorf("Host port %d mapped for both %s and %s", p.Host, other, p.Service)
+		}
+		hosts[p.Host] = p.Service
+	}
+	if hosts[6352] != "cache" || hosts[8052] != "web" || len(mapped) != 2 {
+		t.Errorf("Expected cache on 6352 and web on 8052, got %+v", mapped)
+	}
+	want := []string{
+		"queue:6379: host port 6352 already goes to cache:6379",
+		"web:8000: host port 8052 already goes to web:80",
+	}
+	if len(skipped) != len(want) || skipped[0] != want[0] || skipped[1] != want[1] {
+		t.Errorf("Expected %q, got %q", want, skipped)
+	}
+	if content := renderOverride(mapped); strings.Contains(content, "queue:") {
+		t.Errorf("Expected no override for queue:\n%s", content)
+	}
+}
+
+func TestIsSailProject(t *testing.T) {
+	project, err := parseCompose([]byte("services:\n  laravel.test:\n    image: sail-8.4/app\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSailProject(project) {
+		t.Error("Expected Sail project to be detected")
+	}
+}
+
+func TestOverrideFilePathAndWrite(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-adopt-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	original := composeFileOverride
+	defer func() { composeFileOverride = original }()
+	composeFileOverride = ""
+	t.Setenv("COMPOSE_FILE", "")
+
+	os.WriteFile(filepath.Join(tempDir, "docker-compose.yml"), []byte("services: {}\n"), 0644)
+	path := overrideFilePath(tempDir)
+	if path != filepath.Join(tempDir, "docker-compose.override.yml") {
+		t.Errorf("Expected docker-compose.override.yml, got %s", path)
+	}
+
+	if err := writeOverride(path, overrideMarker+"\n"); err != nil {
+		t.Fatalf("Writing a fresh override failed: %v", err)
+	}
+	if err := writeOverride(path, overrideMarker+"\nservices: {}\n"); err != nil {
+		t.Errorf("Regenerating a sailinit override should succeed: %v", err)
+	}
+
+	os.WriteFile(path, []byte("services:\n  web: {}\n"), 0644)
+	if err := writeOverride(path, overrideMarker+"\n"); err == nil {
+		t.Error("Expected refusal to overwrite a hand-written override")
+	}
+}
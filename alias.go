@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Markers around the block sailinit manages in a shell rc file.
+const (
+	aliasBlockStart = "# >>> sailinit >>>"
+	aliasBlockEnd   = "# <<< sailinit <<<"
+)
+
+// aliasBlock is the shell code `alias install` writes. sail runs the
+// vendor/bin/sail of the project sailinit resolves from the current
+// directory, so it works from subdirectories too. It replaces the alias
+// Sail's documentation suggests, which would break the function.
+const aliasBlock = aliasBlockStart + `
+# Managed by sailinit alias install; changes here are overwritten.
+unalias sail 2>/dev/null
+sail() {
+    local root
+    root="$(command sailinit root 2>/dev/null)" || root="$PWD"
+    if [ ! -x "$root/vendor/bin/sail" ]; then
+        echo "sail: no vendor/bin/sail in $root" >&2
+        return 127
+    fi
+    "$root/vendor/bin/sail" "$@"
+}
+alias si=sailinit
+` + aliasBlockEnd
+
+// shellRCFile returns the rc file of shell, which is a name or path such
+// as "zsh" or "/bin/bash".
+func shellRCFile(home, shell string) (string, error) {
+	switch filepath.Base(shell) {
+	case "zsh":
+		if dir := os.Getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zshrc"), nil
+		}
+		return filepath.Join(home, ".zshrc"), nil
+	case "bash":
+		return filepath.Join(home, ".bashrc"), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (use bash or zsh, or pass --rc)", shell)
+}
+
+// replaceAliasBlock returns content with the sailinit block set to block,
+// replacing an existing one in place or appending it. An empty block
+// removes it.
+func replaceAliasBlock(content, block string) string {
+	start := strings.Index(content, aliasBlockStart)
+	end := strings.Index(content, aliasBlockEnd)
+	if start >= 0 && end > start {
+		before, after := content[:start], content[end+len(aliasBlockEnd):]
+		if block == "" {
+			// Also drop the line break after the block and the blank line
+			// install put in front of it
+			after = strings.TrimPrefix(after, "\n")
+			if strings.HasSuffix(before, "\n\n") {
+				before = before[:len(before)-1]
+			}
+		}
+		return before + block + after
+	}
+	if block == "" {
+		return content
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return content + block + "\n"
+}
+
+// resolveRCFile picks the rc file from --rc, --shell or $SHELL.
+func resolveRCFile(rc, shell string) (string, error) {
+	if rc != "" {
+		return rc, nil
+	}
+	if shell == "" {
+		shell = os.Getenv("SHELL")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return shellRCFile(home, shell)
+}
+
+func handleAlias(args []string) {
+	if helpRequested("alias", args) {
+		return
+	}
+	if len(args) == 0 || (args[0] != "install" && args[0] != "uninstall") {
+		printError("Usage: sailinit alias <install|uninstall> [--shell bash|zsh] [--rc <file>] [--print]")
+		exit(ExitUsage)
+	}
+
+	fset := flag.NewFlagSet("alias "+args[0], flag.ExitOnError)
+	shell := fset.String("shell", "", "Shell to install for (bash or zsh; default: $SHELL)")
+	rc := fset.String("rc", "", "rc file to edit instead of the shell's default")
+	printOnly := fset.Bool("print", false, "Print the shell code instead of editing an rc file")
+	fset.Parse(reorderArgs(args[1:]))
+
+	if *printOnly {
+		fmt.Println(aliasBlock)
+		return
+	}
+	path, err := resolveRCFile(*rc, *shell)
+	if err != nil {
+		exitWithError("Cannot find the rc file", usageError(err))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		exitWithError("Error reading "+path, err)
+	}
+
+	block := aliasBlock
+	if args[0] == "uninstall" {
+		block = ""
+	}
+	updated := replaceAliasBlock(string(data), block)
+	if updated == string(data) {
+		printInfo(fmt.Sprintf("%s is already up to date.", shortenHome(path)))
+		return
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		exitWithError("Error writing "+path, err)
+	}
+	if args[0] == "uninstall" {
+		printSuccess(fmt.Sprintf("Removed the sail and si shortcuts from %s.", shortenHome(path)))
+		return
+	}
+	printSuccess(fmt.Sprintf("Added the sail and si shortcuts to %s. Open a new shell or run: source %s", shortenHome(path), shortenHome(path)))
+}
+
+// handleRoot prints the root of the project containing the current
+// directory; the alias's sail function relies on it.
+func handleRoot(args []string) {
+	commandFlags("root").Parse(args)
+	root, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	fmt.Println(root)
+}
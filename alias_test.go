@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplaceAliasBlock(t *testing.T) {
+	rc := "export PATH=$HOME/bin:$PATH"
+	installed := replaceAliasBlock(rc, aliasBlock)
+	if installed != rc+"\n\n"+aliasBlock+"\n" {
+		t.Errorf("Unexpected install:\n%s", installed)
+	}
+	if again := replaceAliasBlock(installed, aliasBlock); again != installed {
+		t.Errorf("Expected installing twice to change nothing, got:\n%s", again)
+	}
+
+	edited := strings.Replace(installed, "alias si=sailinit", "alias si=old", 1) + "export EDITOR=vim\n"
+	refreshed := replaceAliasBlock(edited, aliasBlock)
+	if strings.Contains(refreshed, "alias si=old") || !strings.HasSuffix(refreshed, aliasBlockEnd+"\nexport EDITOR=vim\n") {
+		t.Errorf("Expected the block to be replaced in place, got:\n%s", refreshed)
+	}
+
+	if removed := replaceAliasBlock(installed, ""); removed != rc+"\n" {
+		t.Errorf("Expected uninstall to restore the file, got %q", removed)
+	}
+	if removed := replaceAliasBlock(rc, ""); removed != rc {
+		t.Errorf("Expected uninstall without a block to change nothing, got %q", removed)
+	}
+}
+
+func TestShellRCFile(t *testing.T) {
+	t.Setenv("ZDOTDIR", "")
+	if rc, err := shellRCFile("/home/dev", "/bin/zsh"); err != nil || rc != "/home/dev/.zshrc" {
+		t.Errorf("Expected .zshrc, got %q, %v", rc, err)
+	}
+	if rc, err := shellRCFile("/home/dev", "bash"); err != nil || rc != "/home/dev/.bashrc" {
+		t.Errorf("Expected .bashrc, got %q, %v", rc, err)
+	}
+	t.Setenv("ZDOTDIR", "/home/dev/.config/zsh")
+	if rc, _ := shellRCFile("/home/dev", "zsh"); rc != "/home/dev/.config/zsh/.zshrc" {
+		t.Errorf("Expected ZDOTDIR to be honored, got %q", rc)
+	}
+	if _, err := shellRCFile("/home/dev", "fish"); err == nil {
+		t.Error("Expected fish to be unsupported")
+	}
+}
+
+func TestAliasBlockRunsProjectSail(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	dir := t.TempDir()
+	binDir := filepath.Join(dir, "bin")
+	projectDir := filepath.Join(dir, "shop")
+	os.MkdirAll(binDir, 0755)
+	os.MkdirAll(filepath.Join(projectDir, "vendor", "bin"), 0755)
+	os.MkdirAll(filepath.Join(projectDir, "resources", "js"), 0755)
+	os.WriteFile(filepath.Join(binDir, "sailinit"), []byte("#!/bin/sh\necho "+projectDir+"\n"), 0755)
+	os.WriteFile(filepath.Join(projectDir, "vendor", "bin", "sail"), []byte("#!/bin/sh\necho \"sail $*\"\n"), 0755)
+
+	cmd := exec.Command("bash", "-c", "shopt -s expand_aliases\nalias sail='sh vendor/bin/sail'\n"+aliasBlock+"\nsail artisan migrate")
+	cmd.Dir = filepath.Join(projectDir, "resources", "js")
+	cmd.Env = append(os.Environ(), "PATH="+binDir+":"+os.Getenv("PATH"))
+	out, err := cmd.CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) != "sail artisan migrate" {
+		t.Errorf("Expected the project's sail to run, got %q (%v)", out, err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// answerFileVersion is the format version of answer files.
+const answerFileVersion = 1
+
+// answerFile holds the answers given to setup's prompts, in the order the
+// prompts were asked. Prompts are kept for people reading the file; replay
+// goes by order only.
+type answerFile struct {
+	Version int              `json:"version"`
+	Answers []recordedAnswer `json:"answers"`
+}
+
+type recordedAnswer struct {
+	Prompt string `json:"prompt"`
+	Answer string `json:"answer"`
+}
+
+// Answers replayed from --answers and recorded for --record. Both are
+// empty unless the flags are given.
+var (
+	replayAnswers []recordedAnswer
+	recording     *answerFile
+)
+
+// assumeYes (--yes) and noInput (--no-input) answer every prompt without
+// reading stdin: both take each prompt's default, and assumeYes confirms
+// yes/no questions that noInput declines.
+var (
+	assumeYes bool
+	noInput   bool
+)
+
+// readAnswer prints prompt and returns the trimmed answer: the next
+// replayed one, the prompt's default ("") under --yes or --no-input, or
+// else a line read from reader.
+func readAnswer(reader *bufio.Reader, prompt string) string {
+	return promptAnswer(reader, prompt, "")
+}
+
+// promptAnswer is readAnswer with auto as the answer --yes and --no-input
+// give.
+func promptAnswer(reader *bufio.Reader, prompt, auto string) string {
+	fmt.Print(prompt)
+	var input string
+	switch {
+	case len(replayAnswers) > 0:
+		input = replayAnswers[0].Answer
+		replayAnswers = replayAnswers[1:]
+		fmt.Println(input)
+	case assumeYes || noInput:
+		input = auto
+		fmt.Println(input)
+	default:
+		input, _ = reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+	}
+	if recording != nil {
+		prompt = strings.TrimSuffix(strings.TrimSpace(prompt), ":")
+		recording.Answers = append(recording.Answers, recordedAnswer{Prompt: prompt, Answer: input})
+	}
+	return input
+}
+
+// recordYesNo replaces the last recorded answer with "y" or "n", so an
+// answer typed in one language replays in any other.
+func recordYesNo(yes bool) {
+	if recording == nil || len(recording.Answers) == 0 {
+		return
+	}
+	last := &recording.Answers[len(recording.Answers)-1]
+	last.Answer = "n"
+	if yes {
+		last.Answer = "y"
+	}
+}
+
+// loadAnswers reads an answer file for replay.
+func loadAnswers(path string) ([]recordedAnswer, error) {
+	data, err := readTextFile(path)
+	if err != nil {
+		return nil, usageError(err)
+	}
+	var file answerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, usageError(fmt.Errorf("invalid answer file %s: %w", path, err))
+	}
+	if file.Version != answerFileVersion {
+		return nil, usageError(fmt.Errorf("answer file %s has version %d, expected %d", path, file.Version, answerFileVersion))
+	}
+	return file.Answers, nil
+}
+
+// saveAnswers writes the recorded answers to path.
+func saveAnswers(path string) error {
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// saveRecordedAnswers saves the answers for --record, once setup has
+// asked everything. Failing to save is reported but doesn't stop setup.
+func saveRecordedAnswers(path string) {
+	if recording == nil {
+		return
+	}
+	if err := saveAnswers(path); err != nil {
+		printError(fmt.Sprintf("Error saving answers: %v", err))
+		return
+	}
+	printInfo(fmt.Sprintf("Recorded %d answer(s) to %s; replay them with --answers %s", len(recording.Answers), path, path))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withAnswers sets the replayed answers and starts a recording for the
+// duration of the test.
+func withAnswers(t *testing.T, replay ...string) {
+	t.Helper()
+	replayAnswers = nil
+	for _, a := range replay {
+		replayAnswers = append(replayAnswers, recordedAnswer{Answer: a})
+	}
+	recording = &answerFile{Version: answerFileVersion}
+	t.Cleanup(func() { replayAnswers, recording = nil, nil })
+}
+
+func TestReadAnswerReplaysThenReadsInput(t *testing.T) {
+	withAnswers(t, "60")
+	reader := bufio.NewReader(strings.NewReader("  83 \n"))
+
+	if got := readAnswer(reader, "Suffix: "); got != "60" {
+		t.Errorf("Expected the replayed answer, got %q", got)
+	}
+	if got := readAnswer(reader, "PHP version: "); got != "83" {
+		t.Errorf("Expected the trimmed input once replay ran out, got %q", got)
+	}
+	if got := readAnswer(reader, "More: "); got != "" {
+		t.Errorf("Expected an empty answer at end of input, got %q", got)
+	}
+	want := []recordedAnswer{{"Suffix", "60"}, {"PHP version", "83"}, {"More", ""}}
+	if len(recording.Answers) != len(want) {
+		t.Fatalf("Expected %v recorded, got %v", want, recording.Answers)
+	}
+	for i, a := range want {
+		if recording.Answers[i] != a {
+			t.Errorf("Answer %d: expected %v, got %v", i, a, recording.Answers[i])
+		}
+	}
+}
+
+func TestAskYesNoRecordsCanonicalAnswer(t *testing.T) {
+	withAnswers(t)
+	orig := locale
+	locale = "hu"
+	t.Cleanup(func() { locale = orig })
+
+	if !askYesNo(bufio.NewReader(strings.NewReader("igen\nnem\n")), "Folytatod?") {
+		t.Error("Expected igen to count as yes")
+	}
+	askYesNo(bufio.NewReader(strings.NewReader("nem\n")), "Folytatod?")
+	if recording.Answers[0].Answer != "y" || recording.Answers[1].Answer != "n" {
+		t.Errorf("Expected y and n recorded, got %v", recording.Answers)
+	}
+}
+
+func TestSaveAndLoadAnswers(t *testing.T) {
+	withAnswers(t)
+	recording.Answers = []recordedAnswer{{"Use port suffix 51?", ""}, {"Continue anyway?", "y"}}
+	path := filepath.Join(t.TempDir(), "answers.json")
+	if err := saveAnswers(path); err != nil {
+		t.Fatal(err)
+	}
+	answers, err := loadAnswers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 2 || answers[1].Answer != "y" {
+		t.Errorf("Expected the saved answers back, got %v", answers)
+	}
+
+	os.WriteFile(path, []byte(`{"version": 2, "answers": []}`), 0644)
+	if _, err := loadAnswers(path); err == nil || !strings.Contains(err.Error(), "version 2") {
+		t.Errorf("Expected a version error, got %v", err)
+	}
+	if _, err := loadAnswers(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestYesAndNoInputSkipStdin(t *testing.T) {
+	withAnswers(t, "61")
+	t.Cleanup(func() { assumeYes, noInput = false, false })
+	reader := bufio.NewReader(strings.NewReader("typed\ny\n"))
+
+	noInput = true
+	if got := readAnswer(reader, "Suffix: "); got != "61" {
+		t.Errorf("Expected replayed answers to win over --no-input, got %q", got)
+	}
+	if got := readAnswer(reader, "PHP version: "); got != "" {
+		t.Errorf("Expected the default under --no-input, got %q", got)
+	}
+	if askYesNo(reader, "Continue?") {
+		t.Error("Expected --no-input to decline questions")
+	}
+
+	noInput, assumeYes = false, true
+	if got := readAnswer(reader, "Suffix: "); got != "" {
+		t.Errorf("Expected the default under --yes, got %q", got)
+	}
+	if !askYesNo(reader, "Continue?") {
+		t.Error("Expected --yes to confirm questions")
+	}
+	if line, _ := reader.ReadString('\n'); line != "typed\n" {
+		t.Errorf("Expected stdin to be left unread, got %q", line)
+	}
+}
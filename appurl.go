@@ -0,0 +1,112 @@
+package main
+
+import (
+	"cmp"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// defaultVitePort is the port Vite serves on when VITE_PORT is not set.
+const defaultVitePort = "5173"
+
+// envValues returns the values of doc's assignments, unquoted.
+func envValues(doc *envDocument) map[string]string {
+	values := make(map[string]string)
+	for _, e := range doc.Entries() {
+		if _, seen := values[e.Key]; !seen {
+			values[e.Key] = unquoteEnvValue(e.Value)
+		}
+	}
+	return values
+}
+
+// localURL parses value when it is a URL naming localhost, returning it
+// with the port it reaches: its own, or the scheme's default. References
+// such as ${APP_URL} are not URLs and follow what they name.
+func localURL(value string) (*url.URL, string, bool) {
+	u, err := url.Parse(value)
+	if err != nil || !isLocalHost(u.Hostname()) {
+		return nil, "", false
+	}
+	port := u.Port()
+	switch {
+	case port != "":
+	case u.Scheme == "http":
+		port = "80"
+	case u.Scheme == "https":
+		port = "443"
+	default:
+		return nil, "", false
+	}
+	return u, port, true
+}
+
+// withPort returns u pointing at port, leaving the port out when it is the
+// scheme's default.
+func withPort(u *url.URL, port string) string {
+	moved := *u
+	host := u.Hostname()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		moved.Host = host
+	} else {
+		moved.Host = net.JoinHostPort(host, port)
+	}
+	return moved.String()
+}
+
+// syncEnvURLs points the localhost URLs of doc at the ports now in it,
+// given the values before the ports were written: APP_URL at APP_PORT, and
+// VITE_ URLs at the port that replaced the one they named, APP_URL's,
+// APP_PORT's or VITE_PORT's. A quoted value stays quoted. It returns the
+// changed assignments.
+func syncEnvURLs(doc *envDocument, previous map[string]string, locks envLocks) []string {
+	current := envValues(doc)
+	appPort, ok := current["APP_PORT"]
+	if !ok || appPort == "" {
+		return nil
+	}
+	moved := make(map[string]string)
+	if p := previous["APP_PORT"]; p != "" {
+		moved[p] = appPort
+	}
+	if _, p, ok := localURL(previous["APP_URL"]); ok {
+		moved[p] = appPort
+	}
+	if vite, ok := current["VITE_PORT"]; ok && vite != "" {
+		moved[cmp.Or(previous["VITE_PORT"], defaultVitePort)] = vite
+	}
+
+	var changed []string
+	for _, e := range doc.Entries() {
+		if e.Key != "APP_URL" && !strings.HasPrefix(e.Key, "VITE_") {
+			continue
+		}
+		value := unquoteEnvValue(e.Value)
+		u, port, ok := localURL(value)
+		if !ok {
+			continue
+		}
+		target := moved[port]
+		if e.Key == "APP_URL" {
+			target = appPort
+		}
+		if target == "" || target == port {
+			continue
+		}
+		updated := withPort(u, target)
+		if locks.skip(e.Key, value, updated) {
+			continue
+		}
+		written := updated
+		if value != e.Value {
+			written = e.Value[:1] + updated + e.Value[:1]
+		}
+		doc.Set(e.Key, written)
+		changed = append(changed, e.Key+"="+written)
+	}
+	return changed
+}
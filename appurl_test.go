@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSyncEnvURLs(t *testing.T) {
+	doc := parseEnvDocument([]byte("APP_URL=http://localhost\n" +
+		"VITE_APP_URL=\"${APP_URL}\"\n" +
+		"VITE_DEV_SERVER_URL=\"http://localhost:5173\"\n" +
+		"VITE_API_URL=http://127.0.0.1/api\n" +
+		"VITE_CDN_URL=https://cdn.example.com\n" +
+		"APP_PORT=80\nVITE_PORT=5173\n"))
+	previous := envValues(doc)
+	doc.Set("APP_PORT", "8055")
+	doc.Set("VITE_PORT", "5155")
+
+	changed := syncEnvURLs(doc, previous, nil)
+	want := []string{
+		"APP_URL=http://localhost:8055",
+		`VITE_DEV_SERVER_URL="http://localhost:5155"`,
+		"VITE_API_URL=http://127.0.0.1:8055/api",
+	}
+	if !slices.Equal(changed, want) {
+		t.Errorf("Expected %q, got %q", want, changed)
+	}
+	if v, _ := doc.Get("VITE_APP_URL"); v != `"${APP_URL}"` {
+		t.Errorf("Expected the reference to be kept, got %s", v)
+	}
+	if v, _ := doc.Get("VITE_CDN_URL"); v != "https://cdn.example.com" {
+		t.Errorf("Expected other hosts to be kept, got %s", v)
+	}
+
+	// Back on the default port, the port is left out again
+	previous = envValues(doc)
+	doc.Set("APP_PORT", "80")
+	syncEnvURLs(doc, previous, nil)
+	if v, _ := doc.Get("APP_URL"); v != "http://localhost" {
+		t.Errorf("Expected http://localhost, got %s", v)
+	}
+}
+
+func TestSyncEnvURLsKeepsLockedKeys(t *testing.T) {
+	doc := parseEnvDocument([]byte("APP_URL=http://localhost:8001\nAPP_PORT=8001\n"))
+	previous := envValues(doc)
+	doc.Set("APP_PORT", "8055")
+	if changed := syncEnvURLs(doc, previous, envLocks{"APP_URL"}); len(changed) != 0 {
+		t.Errorf("Expected a locked APP_URL to be kept, got %q", changed)
+	}
+}
+
+func TestSetupEnvUpdatesAppURL(t *testing.T) {
+	tempDir := t.TempDir()
+	envPath := filepath.Join(tempDir, ".env")
+	os.WriteFile(envPath, []byte("APP_URL=http://localhost\nAPP_PORT=80\n"), 0644)
+
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readEnvValues(envPath)["APP_URL"]; got != "http://localhost:8055" {
+		t.Errorf("Expected APP_URL to follow APP_PORT, got %s", got)
+	}
+}
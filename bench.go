@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// benchHistoryLimit is how many benchmark runs are kept per project.
+const benchHistoryLimit = 20
+
+// benchRun is one timed startup recorded by `sailinit bench`. Kind is
+// "cold" (after down -v, nothing cached) or "warm" (after stop, cache on).
+type benchRun struct {
+	At     time.Time     `json:"at"`
+	Kind   string        `json:"kind"`
+	Phases []startPhase  `json:"phases"`
+	Total  time.Duration `json:"total"`
+}
+
+// newBenchRun sums phases into a run of kind.
+func newBenchRun(kind string, at time.Time, phases []startPhase) benchRun {
+	run := benchRun{At: at.UTC().Truncate(time.Second), Kind: kind, Phases: phases}
+	for _, p := range phases {
+		run.Total += p.Duration
+	}
+	return run
+}
+
+// lastBenchRun returns the most recent run of kind in history.
+func lastBenchRun(history []benchRun, kind string) (benchRun, bool) {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Kind == kind {
+			return history[i], true
+		}
+	}
+	return benchRun{}, false
+}
+
+// recordBenchRuns appends runs to projectDir's history, keeping the newest
+// benchHistoryLimit. Unregistered projects are not tracked.
+func recordBenchRuns(projectDir string, runs []benchRun) error {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+
+	return updatePortState(func(state *PortState) error {
+		if _, ok := state.Projects[absDir]; !ok {
+			return nil
+		}
+		if state.Benchmarks == nil {
+			state.Benchmarks = make(map[string][]benchRun)
+		}
+		history := append(state.Benchmarks[absDir], runs...)
+		state.Benchmarks[absDir] = history[max(0, len(history)-benchHistoryLimit):]
+		return nil
+	})
+}
+
+// formatSeconds renders d as seconds with one decimal.
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// formatBenchReport lays out runs side by side, phase by phase, and
+// compares each total with the previous run of the same kind in history.
+func formatBenchReport(runs []benchRun, history []benchRun) string {
+	var names []string
+	for _, run := range runs {
+		for _, p := range run.Phases {
+			if !slices.Contains(names, p.Name) {
+				names = append(names, p.Name)
+			}
+		}
+	}
+
+	var b strings.Builder
+	row := func(label string, cells []string) {
+		fmt.Fprintf(&b, "%-18s", label)
+		for _, c := range cells {
+			fmt.Fprintf(&b, " %12s", c)
+		}
+		b.WriteString("\n")
+	}
+
+	header := make([]string, len(runs))
+	for i, run := range runs {
+		header[i] = run.Kind
+	}
+	row("Phase", header)
+	for _, name := range names {
+		cells := make([]string, len(runs))
+		for i, run := range runs {
+			cells[i] = "-"
+			for _, p := range run.Phases {
+				if p.Name != name {
+					continue
+				}
+				cells[i] = formatSeconds(p.Duration)
+				if p.Cached {
+					cells[i] = "cached"
+				}
+			}
+		}
+		row(name, cells)
+	}
+
+	totals := make([]string, len(runs))
+	previous := make([]string, len(runs))
+	changes := make([]string, len(runs))
+	compared := false
+	for i, run := range runs {
+		totals[i] = formatSeconds(run.Total)
+		previous[i], changes[i] = "-", "-"
+		if last, ok := lastBenchRun(history, run.Kind); ok {
+			compared = true
+			previous[i] = formatSeconds(last.Total)
+			delta := run.Total - last.Total
+			changes[i] = formatSeconds(delta)
+			if delta >= 0 {
+				changes[i] = "+" + changes[i]
+			}
+		}
+	}
+	row("Total", totals)
+	if compared {
+		row("Previous", previous)
+		row("Change", changes)
+	}
+	return b.String()
+}
+
+// runSailDownVolumes removes the project's containers and volumes.
+func runSailDownVolumes(projectDir string) error {
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
+		return sailNotFoundError(sailPath)
+	}
+
+	printInfo("Running sail down -v...")
+	cmd := exec.Command(sailPath, "down", "-v")
+	cmd.Dir = projectDir
+	cmd.Env = sailEnv(projectDir)
+	return runStreaming(cmd)
+}
+
+func handleBench(args []string) {
+	fset := commandFlags("bench")
+	warmOnly := fset.Bool("warm-only", false, "Skip the cold run, which deletes the project's volumes")
+	yes := fset.Bool("yes", false, "Do not ask before deleting volumes for the cold run")
+	fset.Parse(reorderArgs(args))
+
+	target, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	projects, err := listRegisteredProjects()
+	if err != nil {
+		exitWithError("Error reading registry", err)
+	}
+	if fset.NArg() > 0 {
+		if target, err = resolveSwitchTarget(projects, fset.Arg(0)); err != nil {
+			exitWithError("Error resolving project", err)
+		}
+	}
+
+	var history []benchRun
+	if state, _, err := loadPortState(); err == nil {
+		history = state.Benchmarks[target]
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	var runs []benchRun
+	if !*warmOnly && (*yes || askYesNo(reader, tr("bench_cold_prompt", shortenHome(target)))) {
+		printHeader("Cold start")
+		if err := runSailDownVolumes(target); err != nil {
+			exitWithError("Error tearing the stack down", err)
+		}
+		began := time.Now()
+		phases, err := startProject(reader, target, true)
+		if err != nil {
+			exitWithError("Error during the cold start", err)
+		}
+		runs = append(runs, newBenchRun("cold", began, phases))
+	}
+
+	printHeader("Warm start")
+	if err := runSailStop(target); err != nil {
+		exitWithError("Error stopping the stack", err)
+	}
+	began := time.Now()
+	phases, err := startProject(reader, target, false)
+	if err != nil {
+		exitWithError("Error during the warm start", err)
+	}
+	runs = append(runs, newBenchRun("warm", began, phases))
+
+	fmt.Print("\n" + formatBenchReport(runs, history))
+	if err := recordBenchRuns(target, runs); err != nil {
+		printError(fmt.Sprintf("Error saving benchmark results: %v", err))
+	}
+}
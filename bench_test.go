@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatBenchReport(t *testing.T) {
+	at := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	cold := newBenchRun("cold", at, []startPhase{
+		{Name: stepComposer, Duration: 40 * time.Second},
+		{Name: stepSailUp, Duration: 20 * time.Second},
+	})
+	warm := newBenchRun("warm", at, []startPhase{
+		{Name: stepComposer, Duration: 10 * time.Millisecond, Cached: true},
+		{Name: stepSailUp, Duration: 4 * time.Second},
+		{Name: stepNPM, Duration: time.Second},
+	})
+	if cold.Total != time.Minute {
+		t.Errorf("Expected the phases to add up to 1m, got %s", cold.Total)
+	}
+
+	report := formatBenchReport([]benchRun{cold, warm}, nil)
+	for _, want := range []string{"cold", "warm", "40.0s", "cached", "Total", "60.0s", "5.0s"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in report:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "Previous") {
+		t.Errorf("Expected no comparison without history:\n%s", report)
+	}
+	lines := strings.Split(report, "\n")
+	if !strings.HasPrefix(lines[3], stepNPM) || !strings.Contains(lines[3], "-") {
+		t.Errorf("Expected npm ci to be missing from the cold run, got %q", lines[3])
+	}
+
+	history := []benchRun{
+		newBenchRun("warm", at, []startPhase{{Name: stepSailUp, Duration: 6 * time.Second}}),
+		newBenchRun("cold", at, []startPhase{{Name: stepSailUp, Duration: 50 * time.Second}}),
+	}
+	report = formatBenchReport([]benchRun{cold, warm}, history)
+	for _, want := range []string{"Previous", "50.0s", "+10.0s", "-1.0s"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in report:\n%s", want, report)
+		}
+	}
+}
+
+func TestRecordBenchRuns(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(projectDir, 0755)
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < benchHistoryLimit+3; i++ {
+		run := newBenchRun("warm", time.Now(), []startPhase{{Name: stepSailUp, Duration: time.Duration(i) * time.Second}})
+		if err := recordBenchRuns(projectDir, []benchRun{run}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, _, err := loadPortState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := state.Benchmarks[projectDir]
+	if len(history) != benchHistoryLimit {
+		t.Fatalf("Expected %d runs kept, got %d", benchHistoryLimit, len(history))
+	}
+	if last, _ := lastBenchRun(history, "warm"); last.Total != time.Duration(benchHistoryLimit+2)*time.Second {
+		t.Errorf("Expected the newest run last, got %s", last.Total)
+	}
+	if _, ok := lastBenchRun(history, "cold"); ok {
+		t.Error("Expected no cold run")
+	}
+}
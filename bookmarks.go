@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// dbBookmark is one project's database as a GUI client should connect to it.
+type dbBookmark struct {
+	ID         string
+	Name       string
+	Connection string
+	Port       string
+	User       string
+	Password   string
+	Database   string
+}
+
+// collectBookmarks builds a bookmark for every registered project that
+// still exists and uses a network database, sorted by name.
+func collectBookmarks() ([]dbBookmark, error) {
+	projects, err := ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	var bookmarks []dbBookmark
+	for _, p := range projects {
+		if !p.Exists {
+			continue
+		}
+		env := readEnvValues(filepath.Join(p.Path, ".env"))
+		connection := dbConnection(env)
+		if _, ok := dsnSchemes[connection]; !ok {
+			continue
+		}
+		def := "3306"
+		if connection == "pgsql" {
+			def = "5432"
+		}
+		sum := sha1.Sum([]byte(p.Path))
+		bookmarks = append(bookmarks, dbBookmark{
+			ID:         fmt.Sprintf("%X-%X-%X-%X-%X", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]),
+			Name:       filepath.Base(p.Path),
+			Connection: connection,
+			Port:       forwardedPort(env, "FORWARD_DB_PORT", p.Suffix, def),
+			User:       env["DB_USERNAME"],
+			Password:   env["DB_PASSWORD"],
+			Database:   env["DB_DATABASE"],
+		})
+	}
+	slices.SortFunc(bookmarks, func(a, b dbBookmark) int { return strings.Compare(a.Name, b.Name) })
+	return bookmarks, nil
+}
+
+// tablePlusDrivers maps DB_CONNECTION to TablePlus driver names.
+var tablePlusDrivers = map[string]string{"mysql": "MySQL", "mariadb": "MariaDB", "pgsql": "PostgreSQL", "sqlsrv": "SQLServer"}
+
+// tablePlusConnections renders the bookmarks as a TablePlus connection
+// list. TablePlus keeps passwords in the keychain, so none are included.
+func tablePlusConnections(bookmarks []dbBookmark) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	buf.WriteString("<plist version=\"1.0\">\n<array>\n")
+	for _, b := range bookmarks {
+		fields := [][2]string{
+			{"ConnectionName", b.Name},
+			{"DatabaseHost", "127.0.0.1"},
+			{"DatabaseName", b.Database},
+			{"DatabasePort", b.Port},
+			{"DatabaseUser", b.User},
+			{"Driver", tablePlusDrivers[b.Connection]},
+			{"Enviroment", "local"}, // sic, TablePlus' own key
+			{"ID", b.ID},
+		}
+		buf.WriteString("\t<dict>\n")
+		for _, f := range fields {
+			buf.WriteString("\t\t<key>" + f[0] + "</key>\n\t\t<string>")
+			xml.EscapeText(&buf, []byte(f[1]))
+			buf.WriteString("</string>\n")
+		}
+		buf.WriteString("\t</dict>\n")
+	}
+	buf.WriteString("</array>\n</plist>\n")
+	return buf.Bytes()
+}
+
+// dbeaverDrivers maps DB_CONNECTION to DBeaver's provider, driver and JDBC
+// scheme.
+var dbeaverDrivers = map[string][3]string{
+	"mysql":   {"mysql", "mysql8", "mysql"},
+	"mariadb": {"mysql", "mariaDB", "mariadb"},
+	"pgsql":   {"postgresql", "postgres-jdbc", "postgresql"},
+	"sqlsrv":  {"mssql", "mssql_jdbc_ms_new", "sqlserver"},
+}
+
+// dbeaverDataSources renders the bookmarks in the format of DBeaver's
+// .dbeaver/data-sources.json.
+func dbeaverDataSources(bookmarks []dbBookmark) ([]byte, error) {
+	connections := make(map[string]any)
+	for _, b := range bookmarks {
+		driver := dbeaverDrivers[b.Connection]
+		url := fmt.Sprintf("jdbc:%s://127.0.0.1:%s/%s", driver[2], b.Port, b.Database)
+		if b.Connection == "sqlsrv" {
+			url = fmt.Sprintf("jdbc:sqlserver://127.0.0.1:%s;databaseName=%s", b.Port, b.Database)
+		}
+		connections["sailinit-"+strings.ToLower(b.ID)] = map[string]any{
+			"provider":      driver[0],
+			"driver":        driver[1],
+			"name":          b.Name,
+			"save-password": true,
+			"folder":        "sailinit",
+			"configuration": map[string]any{
+				"host":              "127.0.0.1",
+				"port":              b.Port,
+				"database":          b.Database,
+				"url":               url,
+				"user":              b.User,
+				"password":          b.Password,
+				"configurationType": "MANUAL",
+				"type":              "dev",
+				"auth-model":        "native",
+			},
+		}
+	}
+	doc := map[string]any{
+		"folders":     map[string]any{"sailinit": map[string]any{}},
+		"connections": connections,
+	}
+	data, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func handleExport(args []string) {
+	if helpRequested("export", args) {
+		return
+	}
+	usage := "Usage: sailinit export <tableplus|dbeaver|vscode> [--out <file>] [--group <dir>]"
+	if len(args) == 0 || (args[0] != "tableplus" && args[0] != "dbeaver" && args[0] != "vscode") {
+		printError(usage)
+		exit(ExitUsage)
+	}
+	format := args[0]
+	defaultOut := "sailinit.tableplusconnection"
+	switch format {
+	case "dbeaver":
+		defaultOut = "data-sources.json"
+	case "vscode":
+		defaultOut = ""
+	}
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("out", defaultOut, "Where to write the export (- for stdout)")
+	group := fs.String("group", ".", "vscode: directory whose registered projects the workspace contains")
+	fs.Parse(reorderArgs(args[1:]))
+	if fs.NArg() != 0 {
+		printError(usage)
+		exit(ExitUsage)
+	}
+
+	if format == "vscode" {
+		exportWorkspace(*group, *out)
+		return
+	}
+
+	bookmarks, err := collectBookmarks()
+	if err != nil {
+		exitWithError("Error reading registry", err)
+	}
+
+	var data []byte
+	if format == "tableplus" {
+		data = tablePlusConnections(bookmarks)
+	} else if data, err = dbeaverDataSources(bookmarks); err != nil {
+		exitWithError("Error encoding bookmarks", err)
+	}
+
+	if *out == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	// DBeaver's file holds passwords, so keep it private
+	if err := os.WriteFile(*out, data, 0600); err != nil {
+		exitWithError("Error writing bookmarks", err)
+	}
+	printSuccess(fmt.Sprintf("Wrote %d connection(s) to %s.", len(bookmarks), *out))
+}
+
+// exportWorkspace writes the VS Code workspace of the projects under
+// groupDir to out, by default <group>.code-workspace inside groupDir.
+func exportWorkspace(groupDir, out string) {
+	groupDir, err := filepath.Abs(groupDir)
+	if err != nil {
+		exitWithError("Error resolving --group", err)
+	}
+	projects, err := ListProjects()
+	if err != nil {
+		exitWithError("Error reading registry", err)
+	}
+	group := groupProjects(projects, groupDir)
+	if len(group) == 0 {
+		exitWithError("Nothing to export", usageError(fmt.Errorf("no registered projects in %s", groupDir)))
+	}
+	if out == "" {
+		out = filepath.Join(groupDir, filepath.Base(groupDir)+".code-workspace")
+	}
+	dir := groupDir
+	if out != "-" {
+		if dir, err = filepath.Abs(filepath.Dir(out)); err != nil {
+			exitWithError("Error resolving --out", err)
+		}
+	}
+	data, err := vscodeWorkspace(group, dir)
+	if err != nil {
+		exitWithError("Error encoding workspace", err)
+	}
+
+	if out == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		exitWithError("Error writing workspace", err)
+	}
+	printSuccess(fmt.Sprintf("Wrote %d project(s) to %s.", len(group), shortenHome(out)))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollectBookmarks(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	shop := filepath.Join(tempDir, "shop")
+	blog := filepath.Join(tempDir, "blog")
+	notes := filepath.Join(tempDir, "notes")
+	for _, dir := range []string{shop, blog, notes} {
+		os.MkdirAll(dir, 0755)
+	}
+	os.WriteFile(filepath.Join(shop, ".env"), []byte("DB_CONNECTION=mysql\nFORWARD_DB_PORT=3360\nDB_DATABASE=shop\nDB_USERNAME=sail\nDB_PASSWORD=password\n"), 0644)
+	os.WriteFile(filepath.Join(blog, ".env"), []byte("DB_CONNECTION=pgsql\nDB_DATABASE=blog\nDB_USERNAME=sail\n"), 0644)
+	os.WriteFile(filepath.Join(notes, ".env"), []byte("DB_CONNECTION=sqlite\n"), 0644)
+	saveProjectSuffix(shop, 60)
+	saveProjectSuffix(blog, 61)
+	saveProjectSuffix(notes, 62)
+	saveProjectSuffix(filepath.Join(tempDir, "gone"), 63)
+
+	bookmarks, err := collectBookmarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 2 || bookmarks[0].Name != "blog" || bookmarks[1].Name != "shop" {
+		t.Fatalf("Expected blog and shop bookmarks, got %+v", bookmarks)
+	}
+	if bookmarks[0].Port != "3361" || bookmarks[1].Port != "3360" {
+		t.Errorf("Expected ports from the suffix and .env, got %s and %s", bookmarks[0].Port, bookmarks[1].Port)
+	}
+}
+
+func TestTablePlusConnections(t *testing.T) {
+	out := string(tablePlusConnections([]dbBookmark{{ID: "AB", Name: "R&D", Connection: "pgsql", Port: "3361", User: "sail", Password: "secret", Database: "app"}}))
+	for _, want := range []string{"<key>Driver</key>\n\t\t<string>PostgreSQL</string>", "<string>R&amp;D</string>", "<string>3361</string>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Error("Expected passwords to stay out of the TablePlus export")
+	}
+}
+
+func TestDBeaverDataSources(t *testing.T) {
+	data, err := dbeaverDataSources([]dbBookmark{{ID: "AB", Name: "shop", Connection: "mysql", Port: "3360", User: "sail", Password: "password", Database: "shop"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Connections map[string]struct {
+			Driver        string            `json:"driver"`
+			Name          string            `json:"name"`
+			Configuration map[string]string `json:"configuration"`
+		} `json:"connections"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	conn, ok := doc.Connections["sailinit-ab"]
+	if !ok || conn.Driver != "mysql8" || conn.Name != "shop" {
+		t.Fatalf("Unexpected connections: %s", data)
+	}
+	if conn.Configuration["url"] != "jdbc:mysql://127.0.0.1:3360/shop" || conn.Configuration["password"] != "password" {
+		t.Errorf("Unexpected configuration: %v", conn.Configuration)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// bootstrapManifest lists the projects `sailinit bootstrap` provisions.
+type bootstrapManifest struct {
+	Projects []bootstrapProject `yaml:"projects"`
+}
+
+// bootstrapProject is one repository of a manifest.
+type bootstrapProject struct {
+	Repo string `yaml:"repo"`
+	// Dir is where the repository is cloned; relative paths are relative
+	// to the manifest, and an empty one is the repository's name next to it
+	Dir string `yaml:"dir"`
+	// PHP is the version passed to setup, e.g. "8.3"; empty detects it
+	PHP string `yaml:"php"`
+	// Suffix is the port suffix; nil takes the suggested one
+	Suffix *int `yaml:"suffix"`
+}
+
+// bootstrapResult is what happened to one project, for the summary.
+type bootstrapResult struct {
+	Project bootstrapProject
+	Cloned  bool
+	Err     error
+}
+
+// bootstrapManifestSchema describes the manifest.
+func bootstrapManifestSchema() *jsonSchema {
+	project := schemaObject("A repository to clone and set up", map[string]*jsonSchema{
+		"repo":   schemaString("Git URL to clone", ""),
+		"dir":    schemaString("Where to clone it; relative to the manifest, ~ for the home directory", ""),
+		"php":    schemaString(`PHP version to set the project up with, e.g. "8.3"`, `^[0-9]\.?[0-9]$`),
+		"suffix": schemaInteger("Port suffix of the project", 0, MaxPortSuffix()),
+	})
+	s := schemaObject("sailinit bootstrap manifest", map[string]*jsonSchema{
+		"projects": {Type: "array", Description: "Projects to provision", Items: project},
+	})
+	s.Schema = schemaDraft
+	s.Title = "sailinit bootstrap manifest"
+	return s
+}
+
+// loadBootstrapManifest reads the manifest at path, resolving each
+// project's directory.
+func loadBootstrapManifest(path string) (*bootstrapManifest, error) {
+	data, err := readTextFile(path)
+	if err != nil {
+		return nil, usageError(err)
+	}
+	if err := validateYAML(data, bootstrapManifestSchema()); err != nil {
+		return nil, usageError(fmt.Errorf("invalid manifest %s: %w", path, err))
+	}
+	m := &bootstrapManifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, usageError(fmt.Errorf("invalid manifest %s: %w", path, err))
+	}
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.resolve(base); err != nil {
+		return nil, usageError(fmt.Errorf("invalid manifest %s: %w", path, err))
+	}
+	return m, nil
+}
+
+// resolve makes the project directories absolute and checks that no two
+// projects share a directory or a suffix.
+func (m *bootstrapManifest) resolve(base string) error {
+	if len(m.Projects) == 0 {
+		return errors.New("projects: no projects listed")
+	}
+	dirs := make(map[string]int)
+	suffixes := make(map[int]int)
+	for i := range m.Projects {
+		p := &m.Projects[i]
+		if p.Repo == "" {
+			return fmt.Errorf("projects[%d]: repo is missing", i)
+		}
+		if p.Dir == "" {
+			p.Dir = strings.TrimSuffix(filepath.Base(strings.TrimRight(p.Repo, "/")), ".git")
+		}
+		if rest, ok := strings.CutPrefix(p.Dir, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+			p.Dir = filepath.Join(home, rest)
+		} else if !filepath.IsAbs(p.Dir) {
+			p.Dir = filepath.Join(base, p.Dir)
+		}
+		if j, ok := dirs[p.Dir]; ok {
+			return fmt.Errorf("projects[%d]: %s is also the directory of projects[%d]", i, p.Dir, j)
+		}
+		dirs[p.Dir] = i
+		if p.Suffix != nil {
+			if j, ok := suffixes[*p.Suffix]; ok {
+				return fmt.Errorf("projects[%d]: suffix %d is also used by projects[%d]", i, *p.Suffix, j)
+			}
+			suffixes[*p.Suffix] = i
+		}
+	}
+	return nil
+}
+
+// setupArgs returns the arguments of the `sailinit init` that sets p up
+// without asking anything.
+func (p bootstrapProject) setupArgs() []string {
+	args := []string{"init"}
+	if p.PHP != "" {
+		args = append(args, p.PHP)
+	}
+	if p.Suffix != nil {
+		args = append(args, "--suffix", strconv.Itoa(*p.Suffix))
+	}
+	return append(args, "--no-input")
+}
+
+// bootstrapSetupCommand returns the command setting up a cloned project.
+// Setup runs as a separate sailinit, so one project's failure can't end
+// the whole bootstrap. Tests replace it.
+var bootstrapSetupCommand = func(p bootstrapProject) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(self, p.setupArgs()...)
+	cmd.Dir = p.Dir
+	cmd.Env = os.Environ()
+	if contextName != defaultContext {
+		cmd.Env = append(cmd.Env, "SAILINIT_CONTEXT="+contextName)
+	}
+	return cmd, nil
+}
+
+// cloneProject clones p.Repo into p.Dir unless a git checkout is already
+// there. It reports whether it cloned.
+func cloneProject(p bootstrapProject) (bool, error) {
+	if _, err := os.Stat(filepath.Join(p.Dir, ".git")); err == nil {
+		return false, nil
+	}
+	if entries, err := os.ReadDir(p.Dir); err == nil && len(entries) > 0 {
+		return false, fmt.Errorf("%s exists and is not a git checkout", shortenHome(p.Dir))
+	}
+	if err := os.MkdirAll(filepath.Dir(p.Dir), 0755); err != nil {
+		return false, err
+	}
+	if err := runStreaming(exec.Command("git", "clone", p.Repo, p.Dir)); err != nil {
+		return false, fmt.Errorf("git clone %s: %w", p.Repo, err)
+	}
+	return true, nil
+}
+
+// runBootstrap clones and sets up every project of m. Projects with a
+// suffix go first, so the suffixes suggested to the others avoid theirs.
+// A failing project is reported and the next one is tried. Results are in
+// manifest order.
+func runBootstrap(m *bootstrapManifest, dryRun bool) []bootstrapResult {
+	order := make([]int, len(m.Projects))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(boolRank(m.Projects[a].Suffix == nil), boolRank(m.Projects[b].Suffix == nil))
+	})
+
+	results := make([]bootstrapResult, len(m.Projects))
+	for _, i := range order {
+		p := m.Projects[i]
+		results[i].Project = p
+		printHeader(fmt.Sprintf("%s -> %s", p.Repo, shortenHome(p.Dir)))
+		if dryRun {
+			printInfo(fmt.Sprintf("[dry-run] Would clone %s and run: sailinit %s", p.Repo, strings.Join(p.setupArgs(), " ")))
+			continue
+		}
+		cloned, err := cloneProject(p)
+		results[i].Cloned = cloned
+		if err == nil {
+			var cmd *exec.Cmd
+			if cmd, err = bootstrapSetupCommand(p); err == nil {
+				err = runStreaming(cmd)
+			}
+		}
+		if err != nil {
+			printError(fmt.Sprintf("%s: %v", shortenHome(p.Dir), err))
+		}
+		results[i].Err = err
+	}
+	return results
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// printBootstrapSummary lists each project with whether it was cloned, its
+// suffix and app URL, or why it failed.
+func printBootstrapSummary(results []bootstrapResult) {
+	state, _, err := loadPortState()
+	if err != nil {
+		state = &PortState{}
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", colorize(colorBold, "Project"), colorize(colorBold, "Checkout"), colorize(colorBold, "Suffix"), colorize(colorBold, "Result"))
+	for _, r := range results {
+		entry, registered := state.Projects[r.Project.Dir]
+		checkout, shown, result := "existing", "-", colorize(colorGreen, "ready")
+		if r.Cloned {
+			checkout = "cloned"
+		}
+		if registered {
+			env := readEnvValues(filepath.Join(r.Project.Dir, ".env"))
+			shown = strconv.Itoa(entry.Suffix)
+			result = colorize(colorGreen, "http://localhost:"+forwardedPort(env, "APP_PORT", entry.Suffix, ""))
+		}
+		if r.Err != nil {
+			result = colorize(colorRed, "failed: "+r.Err.Error())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", shortenHome(r.Project.Dir), checkout, shown, result)
+	}
+	w.Flush()
+}
+
+func handleBootstrap(args []string) {
+	fset := commandFlags("bootstrap")
+	dryRun := fset.Bool("dry-run", false, "Show what would be cloned and set up without doing it")
+	fset.Parse(reorderArgs(args))
+	if fset.NArg() != 1 {
+		printError("Usage: sailinit bootstrap <manifest.yaml> [--dry-run]")
+		exit(ExitUsage)
+	}
+	m, err := loadBootstrapManifest(fset.Arg(0))
+	if err != nil {
+		exitWithError("Error reading manifest", err)
+	}
+	if !*dryRun {
+		requireWritableState()
+		if _, err := exec.LookPath("git"); err != nil {
+			exitWithError("Error", errors.New("git is not installed"))
+		}
+	}
+
+	results := runBootstrap(m, *dryRun)
+	if *dryRun {
+		return
+	}
+	fmt.Println()
+	printBootstrapSummary(results)
+	failed := slices.IndexFunc(results, func(r bootstrapResult) bool { return r.Err != nil }) >= 0
+	if failed {
+		exit(ExitGeneral)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadBootstrapManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "machine.yaml")
+	os.WriteFile(path, []byte(`projects:
+  - repo: git@github.com:acme/shop.git
+    php: "8.3"
+    suffix: 12
+  - repo: https://github.com/acme/blog/
+    dir: code/blog
+  - repo: git@github.com:acme/api.git
+    dir: /srv/api
+`), 0644)
+
+	m, err := loadBootstrapManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "shop"), filepath.Join(dir, "code", "blog"), "/srv/api"}
+	for i, p := range m.Projects {
+		if p.Dir != want[i] {
+			t.Errorf("projects[%d]: expected %s, got %s", i, want[i], p.Dir)
+		}
+	}
+	if args := strings.Join(m.Projects[0].setupArgs(), " "); args != "init 8.3 --suffix 12 --no-input" {
+		t.Errorf("Unexpected setup arguments %q", args)
+	}
+	if args := strings.Join(m.Projects[1].setupArgs(), " "); args != "init --no-input" {
+		t.Errorf("Unexpected setup arguments %q", args)
+	}
+}
+
+func TestLoadBootstrapManifestErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		manifest string
+		want     string
+	}{
+		{"projects:\n  - repo: a.git\n    sufix: 1\n", "line 3: projects[0].sufix: unknown key"},
+		{"projects:\n  - repo: a.git\n    suffix: 1\n  - repo: b.git\n    suffix: 1\n", "suffix 1 is also used by projects[0]"},
+		{"projects:\n  - repo: a.git\n  - repo: other/a.git\n", "is also the directory of projects[0]"},
+		{"projects:\n  - dir: shop\n", "projects[0]: repo is missing"},
+		{"projects: []\n", "no projects listed"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "machine.yaml")
+		os.WriteFile(path, []byte(tt.manifest), 0644)
+		if _, err := loadBootstrapManifest(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("For %q expected %q, got %v", tt.manifest, tt.want, err)
+		}
+	}
+}
+
+// gitRepo creates a repository with one commit to clone from.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return repo
+}
+
+func TestRunBootstrap(t *testing.T) {
+	repo := gitRepo(t)
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing")
+	os.MkdirAll(filepath.Join(existing, ".git"), 0755)
+	occupied := filepath.Join(dir, "occupied")
+	os.MkdirAll(occupied, 0755)
+	os.WriteFile(filepath.Join(occupied, "notes.txt"), []byte("x"), 0644)
+
+	suffix := 12
+	m := &bootstrapManifest{Projects: []bootstrapProject{
+		{Repo: repo, Dir: filepath.Join(dir, "fresh")},
+		{Repo: repo, Dir: existing, Suffix: &suffix},
+		{Repo: repo, Dir: occupied},
+	}}
+
+	var setUp []string
+	original := bootstrapSetupCommand
+	bootstrapSetupCommand = func(p bootstrapProject) (*exec.Cmd, error) {
+		setUp = append(setUp, filepath.Base(p.Dir))
+		return exec.Command("true"), nil
+	}
+	defer func() { bootstrapSetupCommand = original }()
+
+	results := runBootstrap(m, false)
+	if strings.Join(setUp, ",") != "existing,fresh" {
+		t.Errorf("Expected the pinned project first and no setup of the occupied one, got %v", setUp)
+	}
+	if !results[0].Cloned || results[0].Err != nil {
+		t.Errorf("Expected the fresh project to be cloned, got %+v", results[0])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "fresh", ".git")); err != nil {
+		t.Errorf("Expected a clone, got %v", err)
+	}
+	if results[1].Cloned || results[1].Err != nil {
+		t.Errorf("Expected the existing checkout to be used as is, got %+v", results[1])
+	}
+	if results[2].Err == nil || !strings.Contains(results[2].Err.Error(), "not a git checkout") {
+		t.Errorf("Expected the occupied directory to fail, got %+v", results[2])
+	}
+}
+
+func TestRunBootstrapReportsSetupFailures(t *testing.T) {
+	repo := gitRepo(t)
+	dir := t.TempDir()
+	m := &bootstrapManifest{Projects: []bootstrapProject{
+		{Repo: repo, Dir: filepath.Join(dir, "a")},
+		{Repo: repo, Dir: filepath.Join(dir, "b")},
+	}}
+
+	original := bootstrapSetupCommand
+	bootstrapSetupCommand = func(p bootstrapProject) (*exec.Cmd, error) {
+		if filepath.Base(p.Dir) == "a" {
+			return nil, errors.New("setup broke")
+		}
+		return exec.Command("true"), nil
+	}
+	defer func() { bootstrapSetupCommand = original }()
+
+	results := runBootstrap(m, false)
+	if results[0].Err == nil || results[1].Err != nil {
+		t.Errorf("Expected only the first project to fail, got %+v", results)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// findSubcommand returns the subcommand called name.
+func findSubcommand(name string) (subcommand, bool) {
+	commands := subcommands()
+	i := slices.IndexFunc(commands, func(c subcommand) bool { return c.Name == name })
+	if i < 0 {
+		return subcommand{}, false
+	}
+	return commands[i], true
+}
+
+// commandHelp returns the usage line and summary of a subcommand.
+func commandHelp(c subcommand) string {
+	return fmt.Sprintf("Usage: %s\n\n%s\n", strings.TrimSpace("sailinit "+c.Name+" "+c.Usage), c.Summary)
+}
+
+// commandFlags returns the flag set of subcommand name, whose -h output
+// starts with the command's usage line and summary.
+func commandFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		if c, ok := findSubcommand(name); ok {
+			fmt.Fprint(out, commandHelp(c))
+		}
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out, "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// isHelpArg reports whether arg asks for help.
+func isHelpArg(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// helpRequested prints the help of subcommand name and returns true when
+// args ask for it, for commands that take their arguments without a flag set.
+func helpRequested(name string, args []string) bool {
+	if len(args) == 0 || !isHelpArg(args[0]) {
+		return false
+	}
+	if c, ok := findSubcommand(name); ok {
+		fmt.Print(commandHelp(c))
+	}
+	return true
+}
+
+// overviewHelp lists every subcommand.
+func overviewHelp() string {
+	var b strings.Builder
+	b.WriteString("Usage: sailinit <command> [arguments]\n\nCommands:\n")
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, c := range subcommands() {
+		fmt.Fprintf(w, "  %s\t%s\n", c.Name, c.Summary)
+	}
+	w.Flush()
+	b.WriteString("\nRun 'sailinit help <command>' for a command's arguments and flags.\n")
+	b.WriteString("Without a command, sailinit runs init. --read-only, --profile and --context work with every command.\n")
+	return b.String()
+}
+
+func handleHelp(args []string) {
+	if len(args) == 0 {
+		fmt.Print(overviewHelp())
+		return
+	}
+	if _, ok := findSubcommand(args[0]); !ok {
+		exitWithError("Unknown command", usageError(fmt.Errorf("%q (run sailinit help for the list)", args[0])))
+	}
+	runCommand(args[0], []string{"--help"})
+}
+
+// legacyFlags are the action flags of the interface before subcommands,
+// each of which now has a subcommand of its own.
+type legacyFlags struct {
+	version, list, status, clean, remove, stop, down bool
+	resetPorts, resetXdebug                          bool
+	newName                                          string
+}
+
+// legacyFlagSet defines the flags accepted without a subcommand: the setup
+// flags plus the legacy action flags.
+func legacyFlagSet(opts *setupOptions, legacy *legacyFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet("sailinit", flag.ExitOnError)
+	fs.BoolVar(&legacy.version, "version", false, "Print version and exit (same as: sailinit version)")
+	fs.BoolVar(&legacy.list, "list", false, "List all registered projects with their port suffixes (same as: sailinit list)")
+	fs.BoolVar(&legacy.status, "status", false, "Show status of all registered projects (same as: sailinit status)")
+	fs.BoolVar(&legacy.clean, "clean", false, "Remove entries for project directories that no longer exist (same as: sailinit clean)")
+	fs.BoolVar(&legacy.remove, "remove", false, "Remove the current project from port registry (same as: sailinit remove)")
+	fs.BoolVar(&legacy.stop, "stop", false, "Run sail stop in the current project (same as: sailinit stop)")
+	fs.BoolVar(&legacy.down, "down", false, "Run sail down in the current project (same as: sailinit down)")
+	fs.BoolVar(&legacy.resetPorts, "reset-ports", false, "Only restore the port settings for the registered suffix in .env (same as: sailinit reset --ports)")
+	fs.BoolVar(&legacy.resetXdebug, "reset-xdebug", false, "Only restore SAIL_XDEBUG_MODE in .env (same as: sailinit reset --xdebug)")
+	fs.StringVar(&legacy.newName, "new", "", "Create a new Laravel project with the given `name` (same as: sailinit new)")
+	opts.register(fs)
+	fs.BoolVar(&readOnlyFlag, "read-only", readOnlyFlag, "Refuse every change to the port registry (also SAILINIT_READ_ONLY=1)")
+	fs.BoolVar(&profileFlag, "profile", profileFlag, "Report how long registry IO, port scans, external commands and file writes took")
+	fs.StringVar(&contextFlag, "context", contextFlag, "Use the registry and config overrides of context `name` (also SAILINIT_CONTEXT)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), overviewHelp())
+		fmt.Fprintln(fs.Output(), "\nFlags without a command:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runLegacy handles a command line without a subcommand: the setup flow,
+// or the subcommand a legacy action flag stands for.
+func runLegacy(args []string) {
+	var opts setupOptions
+	var legacy legacyFlags
+	fs := legacyFlagSet(&opts, &legacy)
+	fs.Parse(args)
+	if fs.NArg() > 0 && !looksLikePHPVersion(fs.Arg(0)) {
+		exitWithError("Unknown command", usageError(fmt.Errorf("%q (run sailinit help for the list)", fs.Arg(0))))
+	}
+	if fs.NArg() > 1 {
+		exitWithError("Usage: sailinit [init] [php_version] [flags]", usageError(errors.New("expected at most one PHP version")))
+	}
+	opts.validate()
+
+	switch {
+	case legacy.version:
+		handleVersion(nil)
+	case legacy.list:
+		handleList(nil)
+	case legacy.status:
+		if err := showProjectStatus(); err != nil {
+			exitWithError("Error showing status", err)
+		}
+	case legacy.clean:
+		cleanProjects(opts.Force)
+	case legacy.remove:
+		removeProject(opts.Force)
+	case legacy.stop:
+		stopProject("stop", runSailStop)
+	case legacy.down:
+		stopProject("down", runSailDown)
+	case legacy.resetPorts || legacy.resetXdebug:
+		resetProject(resetOptions{DB: opts.ResetDB, Ports: legacy.resetPorts, Xdebug: legacy.resetXdebug})
+	case legacy.newName != "":
+		newProject(legacy.newName, &opts)
+		runSetup(&opts, fs.Arg(0))
+	default:
+		runSetup(&opts, fs.Arg(0))
+	}
+}
+
+// looksLikePHPVersion reports whether arg can be a PHP version such as 83
+// or 8.3, rather than a mistyped command.
+func looksLikePHPVersion(arg string) bool {
+	return arg != "" && strings.Trim(arg, "0123456789.") == ""
+}
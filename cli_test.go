@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLooksLikePHPVersion(t *testing.T) {
+	for arg, want := range map[string]bool{"83": true, "8.3": true, "": false, "lsit": false, "8x": false} {
+		if got := looksLikePHPVersion(arg); got != want {
+			t.Errorf("looksLikePHPVersion(%q) = %v, want %v", arg, got, want)
+		}
+	}
+}
+
+func TestOverviewHelpListsCommands(t *testing.T) {
+	help := overviewHelp()
+	for _, c := range subcommands() {
+		if !strings.Contains(help, "  "+c.Name+" ") {
+			t.Errorf("Expected %s in the overview:\n%s", c.Name, help)
+		}
+	}
+}
+
+func TestCommandHelp(t *testing.T) {
+	c, ok := findSubcommand("reset")
+	if !ok {
+		t.Fatal("Expected a reset subcommand")
+	}
+	if got := commandHelp(c); !strings.HasPrefix(got, "Usage: sailinit reset [--ports]") || !strings.Contains(got, c.Summary) {
+		t.Errorf("Unexpected help %q", got)
+	}
+	stop, _ := findSubcommand("stop")
+	if got := commandHelp(stop); !strings.HasPrefix(got, "Usage: sailinit stop\n") {
+		t.Errorf("Expected no trailing space without usage, got %q", got)
+	}
+	if _, ok := findSubcommand("--list"); ok {
+		t.Error("Expected no subcommand called --list")
+	}
+}
+
+func TestHelpRequested(t *testing.T) {
+	if helpRequested("dsn", []string{"db"}) || helpRequested("dsn", nil) {
+		t.Error("Expected no help without -h")
+	}
+	if !helpRequested("dsn", []string{"--help"}) {
+		t.Error("Expected --help to ask for help")
+	}
+}
+
+func TestLegacyFlagSet(t *testing.T) {
+	var opts setupOptions
+	var legacy legacyFlags
+	fs := legacyFlagSet(&opts, &legacy)
+	if err := fs.Parse([]string{"--new", "blog", "--dry-run", "--db", "sqlite", "--reset-ports", "83"}); err != nil {
+		t.Fatal(err)
+	}
+	if legacy.newName != "blog" || !legacy.resetPorts || !opts.DryRun || opts.DB != "sqlite" {
+		t.Errorf("Unexpected flags %+v %+v", legacy, opts)
+	}
+	if fs.NArg() != 1 || fs.Arg(0) != "83" {
+		t.Errorf("Expected the PHP version as the argument, got %v", fs.Args())
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+// subcommand is one `sailinit <name>` command. Usage and Summary feed the
+// generated man page and shell completions.
+type subcommand struct {
+	Name    string
+	Usage   string
+	Summary string
+	Run     func(args []string)
+}
+
+// subcommands returns every subcommand, sorted by name.
+func subcommands() []subcommand {
+	return []subcommand{
+		{"adopt", "[--dry-run] [--compose-file <file>]", "Register a hand-rolled compose project and remap its published ports into the suffix scheme", handleAdopt},
+		{"alias", "<install|uninstall> [--shell bash|zsh] [--rc <file>] [--print]", "Install the sail and si shell shortcuts", handleAlias},
+		{"apply", "[plan_file] [--dry-run]", "Execute a plan written by sailinit plan without asking anything", handleApply},
+		{"bench", "[--warm-only] [--yes]", "Measure cold and warm startup times of the project's stack", handleBench},
+		{"bootstrap", "<manifest.yaml> [--dry-run]", "Clone and set up every project of a manifest, e.g. on a new machine", handleBootstrap},
+		{"clean", "[--force]", "Remove entries for project directories that no longer exist", handleClean},
+		{"config", "schema [--project]", "Print the JSON Schema of the config file or .sailinit.yaml", handleConfig},
+		{"context", "<list|current|create|use> [name]", "Manage separate registries, e.g. one per client", handleContext},
+		{"db", "<import|engine> ...", "Import a database dump or switch the database engine", handleDB},
+		{"debug", "<on|off>", "Turn APP_DEBUG, Telescope and Debugbar on or off together", handleDebug},
+		{"diagnose", "", "Print a redacted report for bug reports", handleDiagnose},
+		{"docs", "[--out <file>] [--template <file>]", "Generate the project's local development documentation", handleDocs},
+		{"doctor", "", "Check the host and the project for common problems", handleDoctor},
+		{"down", "", "Run sail down in the current project", handleDown},
+		{"drift", "", "Show what changed since the last setup", handleDrift},
+		{"dsn", "[db|redis]", "Print host-side connection strings", handleDSN},
+		{"env", "<sync-example|tighten>", "Sync .env.example or tighten .env permissions", handleEnv},
+		{"export", "<tableplus|dbeaver|vscode> [--out <file>] [--group <dir>]", "Export database bookmarks or an editor workspace", handleExport},
+		{"fix-perms", "[--dry-run] [--user <uid:gid>]", "Give root-owned project files back to the user", handleFixPerms},
+		{"generate-docs", "[--out <dir>]", "Write the man page and shell completions", handleGenerateDocs},
+		{"get", "<selector> [-p <project>]", "Print a project value such as app_port for scripts", handleGet},
+		{"help", "[command]", "Show the commands, or a command's arguments and flags", handleHelp},
+		{"history", "[project] [-n <count>]", "Show the operations sailinit ran on a project", handleHistory},
+		{"init", "[php_version] [flags]", "Set up the project in the current directory (the default command)", handleInit},
+		{"lint", "", "Check the project against the team's conventions, e.g. in a pre-commit hook", handleLint},
+		{"list", "", "List registered projects with their port suffixes", handleList},
+		{"new", "<name> [php_version] [flags]", "Create a new Laravel project and set it up", handleNew},
+		{"orphans", "[--containers [--adopt] [--down]]", "List registry entries or Sail stacks without a counterpart", handleOrphans},
+		{"plan", "[php_version] [--out <file>]", "Detect and prompt, then write a setup plan without changing anything", handlePlan},
+		{"remove", "[--force]", "Remove the current project from the port registry", handleRemove},
+		{"repair-state", "[--dry-run]", "Repair a damaged port registry", handleRepairState},
+		{"reset", "[--ports] [--xdebug] [--db[=scopes]]", "Restore .env defaults without a full setup", handleReset},
+		{"rollback", "[--list] [--yes]", "Restore .env from the backup taken before sailinit last changed it", handleRollback},
+		{"root", "", "Print the root of the project containing the current directory", handleRoot},
+		{"scale", "[<service=replicas>...]", "Run several replicas of worker services", handleScale},
+		{"status", "[project] [--json]", "Show projects with their containers and URLs", handleStatus},
+		{"stop", "", "Run sail stop in the current project", handleStop},
+		{"switch", "<project> [--only-current] [--no-browser] [--dry-run] [-- <sail up args>]", "Stop other projects and start this one", handleSwitch},
+		{"up", "[--no-cache] [-- <sail up args>]", "Start the project, skipping unchanged steps", handleUp},
+		{"upgrade-check", "[--yes]", "Check whether laravel/sail is too old for the Laravel version", handleUpgradeCheck},
+		{"version", "", "Print the version", handleVersion},
+		{"vite", "<start|stop|status>", "Run the Vite dev server in the background on VITE_PORT", handleVite},
+	}
+}
+
+// runCommand dispatches subcommands. It returns false when name is not a
+// known subcommand so the flag interface without one can handle the
+// arguments.
+func runCommand(name string, args []string) bool {
+	for _, c := range subcommands() {
+		if c.Name == name {
+			c.Run(args)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultComposeFiles are the file names Sail and docker compose look for,
+// in order of preference.
+var defaultComposeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// composeFileOverride is set by --compose-file. When empty, COMPOSE_FILE
+// from the environment is honored instead.
+var composeFileOverride string
+
+// composeFiles returns the compose files to inspect for a project. Relative
+// entries are resolved against projectDir.
+func composeFiles(projectDir string) []string {
+	spec := composeFileOverride
+	if spec == "" {
+		spec = os.Getenv("COMPOSE_FILE")
+	}
+	if spec == "" {
+		var files []string
+		for _, f := range defaultComposeFiles {
+			files = append(files, filepath.Join(projectDir, f))
+		}
+		return files
+	}
+
+	var files []string
+	for _, f := range strings.Split(spec, string(os.PathListSeparator)) {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(projectDir, f)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// sailEnv returns the environment for sail invocations in projectDir. With
+// --compose-file set, COMPOSE_FILE points docker compose at the selected
+// file; otherwise nil is returned so the current environment is inherited.
+func sailEnv(projectDir string) []string {
+	if composeFileOverride == "" {
+		return nil
+	}
+	files := composeFiles(projectDir)
+	return append(os.Environ(), "COMPOSE_FILE="+strings.Join(files, string(os.PathListSeparator)))
+}
+
+// composeService is the part of a compose service definition sailinit uses.
+type composeService struct {
+	Name         string
+	Image        string
+	BuildContext string
+	Ports        []composePort
+}
+
+// composePort is a published port mapping. Published is kept verbatim since
+// it may be an interpolation like "${APP_PORT:-80}" or empty when the port
+// is only exposed to a random host port.
+type composePort struct {
+	HostIP    string
+	Published string
+	Target    int
+}
+
+// composeProject holds the services of one or more merged compose files, in
+// the order they are first defined.
+type composeProject struct {
+	Services []composeService
+}
+
+// Service returns the named service, or nil when the project doesn't define it.
+func (p *composeProject) Service(name string) *composeService {
+	for i := range p.Services {
+		if p.Services[i].Name == name {
+			return &p.Services[i]
+		}
+	}
+	return nil
+}
+
+// ServiceNames returns the names of all defined services in file order.
+func (p *composeProject) ServiceNames() []string {
+	var names []string
+	for _, svc := range p.Services {
+		names = append(names, svc.Name)
+	}
+	return names
+}
+
+// serviceFields mirrors the YAML shape of a service. build may be either a
+// context string or a mapping, so it is decoded as a raw node.
+type serviceFields struct {
+	Image string      `yaml:"image"`
+	Build yaml.Node   `yaml:"build"`
+	Ports []yaml.Node `yaml:"ports"`
+}
+
+// parseCompose decodes compose file content, preserving service order.
+func parseCompose(data []byte) (*composeProject, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	project := &composeProject{}
+	if len(root.Content) == 0 {
+		return project, nil
+	}
+
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("compose file is not a mapping")
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "services" {
+			continue
+		}
+		services := doc.Content[i+1]
+		if services.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("services is not a mapping (line %d)", services.Line)
+		}
+		for j := 0; j+1 < len(services.Content); j += 2 {
+			var fields serviceFields
+			if err := services.Content[j+1].Decode(&fields); err != nil {
+				return nil, fmt.Errorf("service %s: %w", services.Content[j].Value, err)
+			}
+			svc := composeService{
+				Name:         services.Content[j].Value,
+				Image:        fields.Image,
+				BuildContext: buildContext(&fields.Build),
+			}
+			for _, node := range fields.Ports {
+				port, err := parseComposePort(&node)
+				if err != nil {
+					return nil, fmt.Errorf("service %s: %w", svc.Name, err)
+				}
+				svc.Ports = append(svc.Ports, port)
+			}
+			project.Services = append(project.Services, svc)
+		}
+	}
+	return project, nil
+}
+
+// buildContext extracts the context from either "build: ./path" or
+// "build: {context: ./path}".
+func buildContext(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "context" {
+				return node.Content[i+1].Value
+			}
+		}
+	}
+	return ""
+}
+
+// parseComposePort understands both the short ("127.0.0.1:8080:80/tcp",
+// "${APP_PORT:-80}:80") and the long ({target: 80, published: 8080}) syntax.
+func parseComposePort(node *yaml.Node) (composePort, error) {
+	if node.Kind == yaml.MappingNode {
+		var long struct {
+			HostIP    string `yaml:"host_ip"`
+			Published string `yaml:"published"`
+			Target    int    `yaml:"target"`
+		}
+		if err := node.Decode(&long); err != nil {
+			return composePort{}, err
+		}
+		return composePort{HostIP: long.HostIP, Published: long.Published, Target: long.Target}, nil
+	}
+
+	spec, _, _ := strings.Cut(node.Value, "/")
+	fields := splitPortSpec(spec)
+	invalid := fmt.Errorf("invalid port %q (line %d)", node.Value, node.Line)
+	if fields == nil || len(fields) > 3 {
+		return composePort{}, invalid
+	}
+	var port composePort
+	if _, err := fmt.Sscanf(interpolationDefault(fields[len(fields)-1]), "%d", &port.Target); err != nil || port.Target < 1 {
+		return composePort{}, invalid
+	}
+	if len(fields) >= 2 {
+		port.Published = fields[len(fields)-2]
+	}
+	if len(fields) == 3 {
+		port.HostIP = strings.TrimSuffix(strings.TrimPrefix(fields[0], "["), "]")
+	}
+	return port, nil
+}
+
+// splitPortSpec splits a short port spec like "127.0.0.1:8080:80" on the
+// colons between its fields. Colons inside interpolations such as
+// ${APP_PORT:-80} or a bracketed IPv6 address such as [::1] don't split.
+// It returns nil when the braces or brackets don't balance.
+func splitPortSpec(spec string) []string {
+	var fields []string
+	depth, start := 0, 0
+	for i, r := range spec {
+		switch r {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth--; depth < 0 {
+				return nil
+			}
+		case ':':
+			if depth == 0 {
+				fields = append(fields, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil
+	}
+	return append(fields, spec[start:])
+}
+
+// interpolationDefault returns the default of an interpolation like
+// ${VITE_PORT:-5173}, which is what the container listens on when it is
+// used as a target port, or field itself when it isn't one.
+func interpolationDefault(field string) string {
+	if !strings.HasPrefix(field, "${") || !strings.HasSuffix(field, "}") {
+		return field
+	}
+	inner := field[2 : len(field)-1]
+	if _, def, ok := strings.Cut(inner, ":-"); ok {
+		return def
+	}
+	if _, def, ok := strings.Cut(inner, "-"); ok {
+		return def
+	}
+	return ""
+}
+
+// merge overlays other onto p the way docker compose combines multiple -f
+// files: later definitions override earlier fields of the same service.
+func (p *composeProject) merge(other *composeProject) {
+	for _, svc := range other.Services {
+		existing := p.Service(svc.Name)
+		if existing == nil {
+			p.Services = append(p.Services, svc)
+			continue
+		}
+		if svc.Image != "" {
+			existing.Image = svc.Image
+		}
+		if svc.BuildContext != "" {
+			existing.BuildContext = svc.BuildContext
+		}
+		existing.Ports = append(existing.Ports, svc.Ports...)
+	}
+}
+
+// loadCompose reads the project's compose configuration. With an explicit
+// compose file selection every listed file is merged; otherwise the first
+// default file found is used. It returns nil when no compose file exists.
+func loadCompose(projectDir string) (*composeProject, error) {
+	explicit := composeFileOverride != "" || os.Getenv("COMPOSE_FILE") != ""
+
+	var project *composeProject
+	for _, path := range composeFiles(projectDir) {
+		data, err := readTextFile(path)
+		if err != nil {
+			continue
+		}
+		parsed, err := parseCompose(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if project == nil {
+			project = parsed
+		} else {
+			project.merge(parsed)
+		}
+		if !explicit {
+			break
+		}
+	}
+	return project, nil
+}
+
+var (
+	// Build context pointing at Sail's vendor runtimes or a published docker/ dir
+	reRuntimeContext = regexp.MustCompile(`(?:runtimes|(?:^|/)docker)/([0-9]+\.[0-9]+)`)
+	// Image name built by Sail
+	reSailImage = regexp.MustCompile(`sail-([0-9]+\.[0-9]+)/app`)
+)
+
+// phpVersion returns the PHP runtime a service is built from (e.g. "83"),
+// or "" when it isn't a Sail PHP service.
+func (s composeService) phpVersion() string {
+	if match := reRuntimeContext.FindStringSubmatch(s.BuildContext); len(match) > 1 {
+		return strings.ReplaceAll(match[1], ".", "")
+	}
+	if match := reSailImage.FindStringSubmatch(s.Image); len(match) > 1 {
+		return strings.ReplaceAll(match[1], ".", "")
+	}
+	return ""
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestComposeFilesDefault(t *testing.T) {
+	t.Setenv("COMPOSE_FILE", "")
+
+	files := composeFiles("/project")
+	if len(files) != 4 {
+		t.Fatalf("Expected 4 default compose files, got %v", files)
+	}
+	if files[0] != filepath.Join("/project", "compose.yaml") {
+		t.Errorf("Expected compose.yaml first, got %s", files[0])
+	}
+}
+
+func TestComposeFilesOverride(t *testing.T) {
+	t.Setenv("COMPOSE_FILE", "env-compose.yml")
+
+	files := composeFiles("/project")
+	if len(files) != 1 || files[0] != filepath.Join("/project", "env-compose.yml") {
+		t.Errorf("Expected COMPOSE_FILE to be honored, got %v", files)
+	}
+
+	original := composeFileOverride
+	defer func() { composeFileOverride = original }()
+	composeFileOverride = "docker/sail.yml" + string(os.PathListSeparator) + "/abs/extra.yml"
+
+	files = composeFiles("/project")
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 compose files, got %v", files)
+	}
+	if files[0] != filepath.Join("/project", "docker", "sail.yml") || files[1] != "/abs/extra.yml" {
+		t.Errorf("Flag should take precedence over COMPOSE_FILE, got %v", files)
+	}
+}
+
+func TestSailEnv(t *testing.T) {
+	original := composeFileOverride
+	defer func() { composeFileOverride = original }()
+
+	composeFileOverride = ""
+	if env := sailEnv("/project"); env != nil {
+		t.Error("Expected nil env without --compose-file")
+	}
+
+	composeFileOverride = "custom-compose.yml"
+	env := sailEnv("/project")
+	want := "COMPOSE_FILE=" + filepath.Join("/project", "custom-compose.yml")
+	if len(env) == 0 || env[len(env)-1] != want {
+		t.Errorf("Expected %q as last env entry, got %v", want, env[len(env)-1:])
+	}
+}
+
+func TestDetectPHPVersionCustomComposeFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "php-detect-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	original := composeFileOverride
+	defer func() { composeFileOverride = original }()
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "docker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "services:\n  laravel.test:\n    image: 'sail-8.2/app'"
+	if err := os.WriteFile(filepath.Join(tempDir, "docker", "sail.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	composeFileOverride = ""
+	if got := detectPHPVersion(tempDir); got != "" {
+		t.Errorf("Expected no detection from default files, got %q", got)
+	}
+
+	composeFileOverride = "docker/sail.yml"
+	if got := detectPHPVersion(tempDir); got != "82" {
+		t.Errorf("Expected 82 from custom compose file, got %q", got)
+	}
+}
+
+func TestParseCompose(t *testing.T) {
+	content := `
+services:
+    "laravel.test":
+        build:
+            context: "./vendor/laravel/sail/runtimes/8.4"
+            dockerfile: Dockerfile
+        image: sail-8.4/app
+    mysql:
+        image: 'mysql/mysql-server:8.0'
+    worker:
+        build: ./docker/8.2
+    redis: {image: "redis:alpine"}
+`
+	project, err := parseCompose([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := project.ServiceNames()
+	want := []string{"laravel.test", "mysql", "worker", "redis"}
+	if len(names) != len(want) {
+		t.Fatalf("Expected services %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("Expected service %d to be %s, got %s", i, want[i], names[i])
+		}
+	}
+
+	if v := project.Service("laravel.test").phpVersion(); v != "84" {
+		t.Errorf("Expected laravel.test on 84, got %q", v)
+	}
+	if v := project.Service("worker").phpVersion(); v != "82" {
+		t.Errorf("Expected worker on 82 from short build syntax, got %q", v)
+	}
+	if v := project.Service("mysql").phpVersion(); v != "" {
+		t.Errorf("Expected no PHP version for mysql, got %q", v)
+	}
+	if project.Service("meilisearch") != nil {
+		t.Error("Expected nil for undefined service")
+	}
+}
+
+func TestParseComposeInvalid(t *testing.T) {
+	if _, err := parseCompose([]byte("services:\n  app: [unterminated")); err == nil {
+		t.Error("Expected error for invalid YAML")
+	}
+	if _, err := parseCompose([]byte("services: nope")); err == nil {
+		t.Error("Expected error when services is not a mapping")
+	}
+}
+
+func TestLoadComposeMergesExplicitFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "compose-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	original := composeFileOverride
+	defer func() { composeFileOverride = original }()
+
+	base := "services:\n  laravel.test:\n    image: sail-8.2/app\n  mysql:\n    image: mysql\n"
+	override := "services:\n  laravel.test:\n    image: sail-8.3/app\n  redis:\n    image: redis\n"
+	os.WriteFile(filepath.Join(tempDir, "base.yml"), []byte(base), 0644)
+	os.WriteFile(filepath.Join(tempDir, "override.yml"), []byte(override), 0644)
+
+	composeFileOverride = "base.yml" + string(os.PathListSeparator) + "override.yml"
+	project, err := loadCompose(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(project.Services); got != 3 {
+		t.Errorf("Expected 3 merged services, got %d", got)
+	}
+	if v := project.Service("laravel.test").phpVersion(); v != "83" {
+		t.Errorf("Expected override image to win, got %q", v)
+	}
+}
+
+func TestParseComposePorts(t *testing.T) {
+	content := `
+services:
+  web:
+    ports:
+      - "8080:80"
+      - "127.0.0.1:3307:3306/tcp"
+      - "${APP_PORT:-80}:80"
+      - "5173"
+      - target: 6379
+        published: "6380"
+`
+	project, err := parseCompose([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ports := project.Service("web").Ports
+	want := []composePort{
+		{Published: "8080", Target: 80},
+		{HostIP: "127.0.0.1", Published: "3307", Target: 3306},
+		{Published: "${APP_PORT:-80}", Target: 80},
+		{Target: 5173},
+		{Published: "6380", Target: 6379},
+	}
+	if len(ports) != len(want) {
+		t.Fatalf("Expected %d ports, got %+v", len(want), ports)
+	}
+	for i := range want {
+		if ports[i] != want[i] {
+			t.Errorf("Port %d: expected %+v, got %+v", i, want[i], ports[i])
+		}
+	}
+
+	if _, err := parseCompose([]byte("services:\n  web:\n    ports: [\"abc:def\"]\n")); err == nil {
+		t.Error("Expected error for invalid port")
+	}
+}
+
+func TestParseComposePortShortSyntax(t *testing.T) {
+	cases := []struct {
+		spec string
+		want composePort
+	}{
+		{"${VITE_PORT:-5173}:${VITE_PORT:-5173}", composePort{Published: "${VITE_PORT:-5173}", Target: 5173}},
+		{"${FORWARD_DB_PORT:-3306}:3306", composePort{Published: "${FORWARD_DB_PORT:-3306}", Target: 3306}},
+		{"127.0.0.1:${APP_PORT:-80}:80", composePort{HostIP: "127.0.0.1", Published: "${APP_PORT:-80}", Target: 80}},
+		{"[::1]:8080:80", composePort{HostIP: "::1", Published: "8080", Target: 80}},
+		{"[::1]:${APP_PORT:-80}:80/tcp", composePort{HostIP: "::1", Published: "${APP_PORT:-80}", Target: 80}},
+		{"8000-8010:8000-8010", composePort{Published: "8000-8010", Target: 8000}},
+	}
+	for _, c := range cases {
+		got, err := parseComposePort(&yaml.Node{Kind: yaml.ScalarNode, Value: c.spec})
+		if err != nil || got != c.want {
+			t.Errorf("parseComposePort(%q) = %+v, %v; want %+v", c.spec, got, err, c.want)
+		}
+	}
+
+	for _, bad := range []string{"8080:-80", "${VITE_PORT:-5173", "[::1:8080:80", "1:2:3:4", "${VITE_PORT}:${VITE_PORT}"} {
+		if got, err := parseComposePort(&yaml.Node{Kind: yaml.ScalarNode, Value: bad}); err == nil {
+			t.Errorf("Expected %q to be rejected, got %+v", bad, got)
+		}
+	}
+}
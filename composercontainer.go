@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// composerContainerPrefix names the containers composer runs in, e.g.
+// sailinit-composer-3fa2c91b.
+const composerContainerPrefix = "sailinit-composer-"
+
+// composerLabel marks a composer container with its project, and
+// composerPIDLabel and composerHostLabel with the sailinit that started it
+// and the machine it ran on, so containers left by an aborted run can be
+// told from those of a running one.
+const (
+	composerLabel     = "sailinit.composer"
+	composerPIDLabel  = "sailinit.pid"
+	composerHostLabel = "sailinit.host"
+)
+
+// composerTimeout bounds a composer container run, set by
+// --composer-timeout. Zero means no limit.
+var composerTimeout time.Duration
+
+var (
+	composerMu sync.Mutex
+	// composerContainers are the containers started by this command and
+	// not removed yet.
+	composerContainers []string
+)
+
+// newComposerContainerName returns a container name no other run uses.
+func newComposerContainerName() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return composerContainerPrefix + hex.EncodeToString(b)
+}
+
+func trackComposerContainer(name string) {
+	composerMu.Lock()
+	defer composerMu.Unlock()
+	composerContainers = append(composerContainers, name)
+}
+
+// removeComposerContainers force-removes the containers this command
+// started. --rm already removed those that exited on their own, so errors
+// are ignored.
+func removeComposerContainers() {
+	composerMu.Lock()
+	names := composerContainers
+	composerContainers = nil
+	composerMu.Unlock()
+	if len(names) > 0 {
+		commandRun(exec.Command("docker", append([]string{"rm", "-f"}, names...)...))
+	}
+}
+
+// composerHostname returns the name of this machine for composerHostLabel.
+func composerHostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+// staleComposerContainers sorts the containers in psOutput, lines of name,
+// state, sailinit pid and host, that no running sailinit owns. Stopped ones
+// are safe to remove. Running ones are only orphaned when they were started
+// on host by a sailinit that is gone: a container from another machine,
+// sharing a remote docker host, may belong to a live run there, and its pid
+// means nothing here.
+func staleComposerContainers(psOutput, host string, alive func(pid int) bool) (stopped, orphaned []string) {
+	for line := range strings.Lines(psOutput) {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if fields[0] == "" {
+			continue
+		}
+		if len(fields) < 2 || fields[1] != "running" {
+			stopped = append(stopped, fields[0])
+			continue
+		}
+		if len(fields) < 4 || host == "" || fields[3] != host {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[2]); err == nil && !alive(pid) {
+			orphaned = append(orphaned, fields[0])
+		}
+	}
+	return stopped, orphaned
+}
+
+// removeStaleComposerContainers removes the stopped composer containers
+// earlier runs were killed before removing, and asks before removing
+// running ones whose sailinit is gone.
+func removeStaleComposerContainers(reader *bufio.Reader) {
+	out, err := commandOutput(exec.Command("docker", "ps", "-a", "--filter", "label="+composerLabel,
+		"--format", `{{.Names}}\t{{.State}}\t{{.Label "`+composerPIDLabel+`"}}\t{{.Label "`+composerHostLabel+`"}}`))
+	if err != nil {
+		return
+	}
+	stopped, orphaned := staleComposerContainers(string(out), composerHostname(), processAlive)
+	if len(orphaned) > 0 && askYesNo(reader, tr("composer_orphan_prompt", strings.Join(orphaned, ", "))) {
+		stopped = append(stopped, orphaned...)
+	}
+	if len(stopped) == 0 {
+		return
+	}
+	printWarning(tr("composer_stale_removed", strings.Join(stopped, ", ")))
+	commandRun(exec.Command("docker", append([]string{"rm", "-f"}, stopped...)...))
+}
+
+// removeComposerOnSignal removes the composer containers and exits when
+// sailinit is interrupted or terminated, until stop is called. docker run
+// only forwards the signal, so the container would otherwise keep running
+// whenever composer ignores it.
+func removeComposerOnSignal() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			removeComposerContainers()
+			printWarning(tr("composer_interrupted", sig))
+			exit(ExitGeneral)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// runComposerContainer runs command in the container of
+// composerContainerCommand and makes sure the container doesn't outlive the
+// run: --rm only applies once it exits on its own, so it is force-removed
+// when sailinit is interrupted, when composerTimeout passes and when docker
+// run fails. Containers left by earlier aborted runs are removed first.
+func runComposerContainer(phpVersion, projectDir, user string, command ...string) error {
+	removeStaleComposerContainers(bufio.NewReader(os.Stdin))
+	stop := removeComposerOnSignal()
+	defer stop()
+	defer removeComposerContainers()
+
+	var timedOut atomic.Bool
+	if composerTimeout > 0 {
+		timer := time.AfterFunc(composerTimeout, func() {
+			timedOut.Store(true)
+			removeComposerContainers()
+		})
+		defer timer.Stop()
+	}
+	err := runWithLoginRetry(func() *exec.Cmd {
+		return composerContainerCommand(phpVersion, projectDir, user, command...)
+	})
+	if timedOut.Load() {
+		return fmt.Errorf("%s did not finish within %s; its container was removed", strings.Join(command, " "), composerTimeout)
+	}
+	return err
+}
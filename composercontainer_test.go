@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStaleComposerContainers(t *testing.T) {
+	ps := "sailinit-composer-aa\texited\t100\there\n" +
+		"sailinit-composer-bb\trunning\t200\there\n" +
+		"sailinit-composer-cc\trunning\t300\there\n" +
+		"sailinit-composer-dd\tcreated\t\t\n" +
+		"sailinit-composer-ee\trunning\t300\tbuild-box\n" +
+		"sailinit-composer-ff\trunning\t300\n"
+	alive := func(pid int) bool { return pid == 200 }
+
+	stopped, orphaned := staleComposerContainers(ps, "here", alive)
+	if want := []string{"sailinit-composer-aa", "sailinit-composer-dd"}; !slices.Equal(stopped, want) {
+		t.Errorf("Expected stopped %v, got %v", want, stopped)
+	}
+	// Running containers of other machines, or without a host, are left alone
+	if want := []string{"sailinit-composer-cc"}; !slices.Equal(orphaned, want) {
+		t.Errorf("Expected orphaned %v, got %v", want, orphaned)
+	}
+	if stopped, orphaned := staleComposerContainers("", "here", alive); len(stopped)+len(orphaned) != 0 {
+		t.Errorf("Expected no stale containers, got %v and %v", stopped, orphaned)
+	}
+}
+
+func TestRemoveStaleComposerContainersAsks(t *testing.T) {
+	host := composerHostname()
+	logPath := fakeDocker(t, "sailinit-composer-old\texited\t1\t"+host+"\n"+
+		"sailinit-composer-live\trunning\t999999999\t"+host+"\n")
+
+	removeStaleComposerContainers(bufio.NewReader(strings.NewReader("n\n")))
+	if data, _ := os.ReadFile(logPath); strings.TrimSpace(string(data)) != "rm -f sailinit-composer-old" {
+		t.Errorf("Expected only the stopped container removed, got %q", data)
+	}
+
+	os.Remove(logPath)
+	removeStaleComposerContainers(bufio.NewReader(strings.NewReader("y\n")))
+	if data, _ := os.ReadFile(logPath); strings.TrimSpace(string(data)) != "rm -f sailinit-composer-old sailinit-composer-live" {
+		t.Errorf("Expected both containers removed, got %q", data)
+	}
+}
+
+func TestComposerContainerCommandIsNamed(t *testing.T) {
+	defer func() { composerContainers = nil }()
+
+	cmd := composerContainerCommand("84", "/projects/shop", "", "composer", "install")
+	args := strings.Join(cmd.Args, " ")
+	if !strings.Contains(args, "run --rm --name "+composerContainerPrefix) {
+		t.Errorf("Expected a named container, got %s", args)
+	}
+	if !strings.Contains(args, "--label "+composerLabel+"=/projects/shop") {
+		t.Errorf("Expected the project label, got %s", args)
+	}
+	other := composerContainerCommand("84", "/projects/shop", "", "composer", "install")
+	if cmd.Args[4] == other.Args[4] {
+		t.Errorf("Expected each run to get its own name, got %s twice", cmd.Args[4])
+	}
+	if len(composerContainers) != 2 {
+		t.Errorf("Expected both containers to be tracked, got %v", composerContainers)
+	}
+}
+
+func TestRunComposerContainerRemovesContainers(t *testing.T) {
+	logPath := fakeDocker(t, "sailinit-composer-old\texited\t1\n")
+
+	if err := runComposerContainer("84", t.TempDir(), "", "composer", "install"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(calls) != 3 {
+		t.Fatalf("Expected stale removal, run and cleanup, got %q", calls)
+	}
+	if calls[0] != "rm -f sailinit-composer-old" {
+		t.Errorf("Expected the stale container to be removed first, got %q", calls[0])
+	}
+	name := strings.Fields(calls[1])[3]
+	if calls[2] != "rm -f "+name {
+		t.Errorf("Expected %s to be removed after the run, got %q", name, calls[2])
+	}
+	if len(composerContainers) != 0 {
+		t.Errorf("Expected no tracked containers left, got %v", composerContainers)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// userConfig is the user's global sailinit configuration, read from
+// ~/.config/sailinit/config.yaml.
+type userConfig struct {
+	// LockedKeys lists .env keys sailinit must never add, change or move
+	LockedKeys []string `yaml:"locked_keys"`
+	// Timezone is written to APP_TIMEZONE and the containers' TZ during
+	// setup; "host" uses the host's timezone
+	Timezone string `yaml:"timezone"`
+	// User is the uid:gid mapping for the composer container and Sail
+	// (auto, none or uid:gid), overridden by --user
+	User string `yaml:"user"`
+	// OpenBrowser opens the app URL once setup completes
+	OpenBrowser bool `yaml:"open_browser"`
+	// CopyURLs copies the app and Mailpit URLs to the clipboard once setup
+	// completes
+	CopyURLs bool `yaml:"copy_urls"`
+	// MaxRunning is how many projects may run at once; starting another
+	// offers to stop the least recently used one. 0 means no limit.
+	MaxRunning int `yaml:"max_running"`
+	// SailUpArgs are passed to every `sail up -d`, unless arguments are
+	// given after -- on the command line
+	SailUpArgs []string `yaml:"sail_up_args"`
+	// Env is written to every project's .env during setup. Values are Go
+	// templates over envTemplateData, e.g. "http://{{.Name}}.localhost"
+	Env map[string]string `yaml:"env"`
+	// ReservedPorts names ports used by services outside the registry,
+	// e.g. a host Postgres on 5432; suggested suffixes never land on them
+	ReservedPorts map[string]int `yaml:"reserved_ports"`
+	// PortBases overrides the base ports of the suffix scheme by Sail port
+	// key, e.g. APP_PORT: 9000 puts the app on 9000 + suffix
+	PortBases map[string]int `yaml:"port_bases"`
+	// EnvBaseline is the company-standard .env.example fetched for projects
+	// that have neither .env nor .env.example
+	EnvBaseline envBaseline `yaml:"env_baseline"`
+	// Services adds services to the suffix scheme: service name to .env
+	// key to base port, e.g. minio: {FORWARD_MINIO_PORT: 9100}
+	Services map[string]map[string]int `yaml:"services"`
+	// DockerRetries is how often composer install, image pulls and sail up
+	// are retried after a transient failure; nil means the default
+	DockerRetries *int `yaml:"docker_retries"`
+	// MinFreeDiskGB is the free space, in GB, below which composer install
+	// and sail up are preceded by a warning; nil means the default, 0 off
+	MinFreeDiskGB *int `yaml:"min_free_disk_gb"`
+	// SyncTestingPorts carries the ports setup writes into .env.testing
+	// and phpunit.xml, for test suites run from the host
+	SyncTestingPorts bool `yaml:"sync_testing_ports"`
+	// Pager shows long output such as list and status, e.g. "less -R";
+	// empty turns paging off. nil falls back to PAGER, then less
+	Pager *string `yaml:"pager"`
+	// SuffixStrategy is how new suffixes are suggested: fill-gaps (the
+	// default) reuses the lowest free one, increment goes past the highest
+	SuffixStrategy string `yaml:"suffix_strategy"`
+	// SuppressWarnings lists warning IDs or names, e.g. W001 or port-busy,
+	// that are never shown
+	SuppressWarnings []string `yaml:"suppress_warnings"`
+	// Language is the message language, e.g. hu; SAILINIT_LANG wins, and
+	// the system locale applies without it
+	Language string `yaml:"language"`
+	// Lint holds the team conventions sailinit lint checks every project
+	// against
+	Lint *lintConfig `yaml:"lint"`
+	// Tracing sends spans of every command to an OpenTelemetry collector
+	Tracing *tracingConfig `yaml:"tracing"`
+}
+
+// testConfigPathOverride is used only for testing to override the config file path
+var testConfigPathOverride string
+
+func getConfigPath() (string, error) {
+	if testConfigPathOverride != "" {
+		return testConfigPathOverride, nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "sailinit", "config.yaml"), nil
+}
+
+// loadUserConfig returns the config file's settings. The file is read
+// once per process; callers must not modify the result.
+var loadUserConfig = sync.OnceValues(readUserConfig)
+
+// userConfigOrDefaults is loadUserConfig for settings that fall back to
+// their defaults: a broken config file is reported once and otherwise
+// ignored.
+var userConfigOrDefaults = sync.OnceValue(reportedUserConfig)
+
+func reportedUserConfig() *userConfig {
+	cfg, err := loadUserConfig()
+	if err != nil {
+		printError(fmt.Sprintf("Error reading config: %v", err))
+		return &userConfig{}
+	}
+	return cfg
+}
+
+// resetUserConfig makes the next load read the config file again, for
+// tests that change it.
+func resetUserConfig() {
+	loadUserConfig = sync.OnceValues(readUserConfig)
+	userConfigOrDefaults = sync.OnceValue(reportedUserConfig)
+}
+
+// readUserConfig reads the config file, then the active context's
+// config.yaml on top of it: keys set there replace the plain file's (maps
+// gain their entries). A missing file yields the defaults.
+func readUserConfig() (*userConfig, error) {
+	cfg := &userConfig{}
+	path, err := getConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{path}
+	if contextName != defaultContext {
+		dir, err := contextDir(contextName)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, filepath.Join(dir, "config.yaml"))
+	}
+	for _, path := range paths {
+		data, err := readTextFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := validateYAML(data, userConfigSchema()); err != nil {
+			return nil, usageError(fmt.Errorf("invalid config file %s: %w", path, err))
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, usageError(fmt.Errorf("invalid config file %s: %w", path, err))
+		}
+		for _, err := range []error{
+			atKey(data, "port_bases", validatePortBases(cfg.PortBases)),
+			atKey(data, "env_baseline", cfg.EnvBaseline.validate()),
+			atKey(data, "services", validateServices(cfg.Services)),
+			atKey(data, "docker_retries", validateDockerRetries(cfg.DockerRetries)),
+		} {
+			if err != nil {
+				return nil, usageError(fmt.Errorf("invalid config file %s: %w", path, err))
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// lockedEnvKeys returns the keys locked by the config file and the
+// comma-separated SAILINIT_LOCKED_KEYS variable. A broken config file is
+// reported once and otherwise ignored.
+func lockedEnvKeys() []string {
+	keys := slices.Clone(userConfigOrDefaults().LockedKeys)
+	for _, key := range strings.Split(os.Getenv("SAILINIT_LOCKED_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// envLocks answers whether a key is locked. It is loaded once per command.
+type envLocks []string
+
+func loadEnvLocks() envLocks {
+	return envLocks(lockedEnvKeys())
+}
+
+func (l envLocks) locked(key string) bool {
+	return slices.Contains(l, key)
+}
+
+// skip reports whether a change of key from current to wanted must be
+// skipped because the key is locked, warning when the values differ.
+func (l envLocks) skip(key, current, wanted string) bool {
+	if !l.locked(key) {
+		return false
+	}
+	if current != wanted {
+		printWarning(tr("key_locked", key))
+	}
+	return true
+}
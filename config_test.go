@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain keeps the suite independent of the developer's own config file.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "sail-config-test-*")
+	if err != nil {
+		panic(err)
+	}
+	testConfigPathOverride = filepath.Join(dir, "config.yaml")
+	os.Unsetenv("SAILINIT_LOCKED_KEYS")
+	// Fake sail scripts start no containers to wait for
+	readyTimeout = 0
+	// Transient docker failures are retried without waiting
+	retrySleep = func(time.Duration) {}
+	// Docker's data root is not checked for free space
+	dockerDataRoot = func() string { return "" }
+	// Every project runs on this machine's docker
+	dockerHost = func() string { return "" }
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// writeTestConfig writes content to the test config file for one test.
+func writeTestConfig(t *testing.T, content string) {
+	t.Helper()
+	if err := os.WriteFile(testConfigPathOverride, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resetUserConfig()
+	t.Cleanup(func() {
+		os.Remove(testConfigPathOverride)
+		resetUserConfig()
+	})
+}
+
+func TestLoadUserConfig(t *testing.T) {
+	cfg, err := loadUserConfig()
+	if err != nil || len(cfg.LockedKeys) != 0 {
+		t.Fatalf("Expected empty defaults without a config file, got %+v (err=%v)", cfg, err)
+	}
+
+	writeTestConfig(t, "locked_keys:\n  - DB_PASSWORD\n")
+	t.Setenv("SAILINIT_LOCKED_KEYS", "APP_URL, VITE_PORT")
+	if got := strings.Join(lockedEnvKeys(), ","); got != "DB_PASSWORD,APP_URL,VITE_PORT" {
+		t.Errorf("Unexpected locked keys: %s", got)
+	}
+
+	writeTestConfig(t, "locked_keys: [unterminated\n")
+	if _, err := loadUserConfig(); err == nil {
+		t.Error("Expected error for invalid YAML")
+	}
+}
+
+func TestUserConfigIsReadOnce(t *testing.T) {
+	writeTestConfig(t, "port_bases:\n  WEB: 1\n")
+	if _, err := loadUserConfig(); err == nil {
+		t.Fatal("Expected the broken config file to fail")
+	}
+	if cfg := userConfigOrDefaults(); cfg != userConfigOrDefaults() || len(cfg.PortBases) != 0 {
+		t.Errorf("Expected one set of defaults for a broken config file, got %+v", cfg)
+	}
+	if basePort("APP_PORT") != 8000 || len(loadEnvLocks()) != 0 {
+		t.Error("Expected the defaults to apply")
+	}
+
+	// A changed file is read again only after a reset
+	os.WriteFile(testConfigPathOverride, []byte("port_bases:\n  APP_PORT: 9000\n"), 0644)
+	if basePort("APP_PORT") != 8000 {
+		t.Error("Expected the config file to be read once")
+	}
+	resetUserConfig()
+	if basePort("APP_PORT") != 9000 {
+		t.Error("Expected a reset to read the config file again")
+	}
+}
+
+func TestSetupEnvRespectsLockedKeys(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-config-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	original := "APP_NAME=Shop\nVITE_PORT=5999\nDB_PASSWORD=vault-managed\nAPP_URL=http://shop.test\n\nAPP_PORT=8000\n"
+	os.WriteFile(envPath, []byte(original), 0644)
+	t.Setenv("SAILINIT_LOCKED_KEYS", "VITE_PORT,DB_PASSWORD,APP_URL")
+
+	if err := setupEnv(tempDir, 51, allDBResetGroups(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := setEnvValue(envPath, "APP_URL", "http://localhost:8051"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(envPath)
+	content := string(data)
+	if !strings.HasPrefix(content, "APP_NAME=Shop\nVITE_PORT=5999\nDB_PASSWORD=vault-managed\nAPP_URL=http://shop.test\n") {
+		t.Errorf("Expected locked keys to keep value and position, got:\n%s", content)
+	}
+	if strings.Count(content, "VITE_PORT=") != 1 || !strings.Contains(content, "APP_PORT=8051") {
+		t.Errorf("Expected other ports to be updated and VITE_PORT left alone, got:\n%s", content)
+	}
+	if !strings.Contains(content, "DB_USERNAME=sail") {
+		t.Errorf("Expected unlocked DB settings to be reset, got:\n%s", content)
+	}
+}
+
+func TestEnsureAppKeyRespectsLock(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-config-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	os.WriteFile(envPath, []byte("APP_KEY=\n"), 0644)
+	writeTestConfig(t, "locked_keys: [APP_KEY]\n")
+
+	generated, err := ensureAppKey(envPath)
+	if err != nil || generated {
+		t.Errorf("Expected locked APP_KEY to be left empty, got generated=%v err=%v", generated, err)
+	}
+}
+
+func TestMissingExampleKeysSkipsLocked(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-config-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	os.WriteFile(filepath.Join(tempDir, ".env"), []byte("A=1\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, ".env.example"), []byte("A=1\nB=2\nSECRET=\n"), 0644)
+	t.Setenv("SAILINIT_LOCKED_KEYS", "SECRET")
+
+	missing, err := missingExampleKeys(filepath.Join(tempDir, ".env"), filepath.Join(tempDir, ".env.example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0].Key != "B" {
+		t.Errorf("Expected only B to be missing, got %v", missing)
+	}
+}
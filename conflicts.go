@@ -0,0 +1,220 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// portExplainer identifies what holds a busy port. Registry and docker
+// information is loaded once so explaining several ports stays cheap.
+type portExplainer struct {
+	projects   []ProjectInfo
+	containers map[int]containerPort
+	bases      []PortBase
+}
+
+// newPortExplainer explains ports with the registry and containers, the
+// containerPorts result the busy check already used.
+func newPortExplainer(containers map[int]containerPort) *portExplainer {
+	projects, _ := listRegisteredProjects()
+	return &portExplainer{
+		projects:   projects,
+		containers: containers,
+		bases:      portBases(),
+	}
+}
+
+// portOwner is whatever currently holds a port.
+type portOwner struct {
+	Description string
+	// Project is set when the port belongs to another registered project.
+	Project string
+	Running bool
+}
+
+// owner identifies what holds port, ignoring the project in currentDir.
+func (e *portExplainer) owner(port int, currentDir string) (portOwner, bool) {
+	for _, p := range e.projects {
+		if p.Path == currentDir || p.DockerHost != dockerHost() {
+			continue
+		}
+		for _, b := range e.bases {
+			if b.Base+p.Suffix != port {
+				continue
+			}
+			owner := portOwner{Project: p.Path}
+			if n, err := countRunningContainers(p.Path); err == nil && n > 0 {
+				owner.Running = true
+			}
+			state := "not running"
+			if owner.Running {
+				state = "running"
+			}
+			owner.Description = fmt.Sprintf("project %s (%s)", shortenHome(p.Path), state)
+			return owner, true
+		}
+	}
+
+	if c, ok := e.containers[port]; ok {
+		return portOwner{Description: c.description()}, true
+	}
+
+	if process := portProcess(port); process != "" {
+		if isDockerProxy(process) {
+			return portOwner{Description: fmt.Sprintf("a docker container, published through %s", process)}, true
+		}
+		return portOwner{Description: fmt.Sprintf("process %s", process)}, true
+	}
+	return portOwner{}, false
+}
+
+// explain returns a human readable description of the port's owner, or ""
+// when nothing could be identified.
+func (e *portExplainer) explain(port int, currentDir string) string {
+	owner, _ := e.owner(port, currentDir)
+	return owner.Description
+}
+
+// portProcess names the process listening on port using lsof or ss,
+// whichever is available, then /proc on Linux and netstat on Windows. It
+// returns "" when the owner can't be determined.
+func portProcess(port int) string {
+	if out, err := commandOutput(exec.Command("lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN", "-Fpc")); err == nil {
+		if process := parseLsofOutput(string(out)); process != "" {
+			return process
+		}
+	}
+	if out, err := commandOutput(exec.Command("ss", "-ltnpH", fmt.Sprintf("sport = :%d", port))); err == nil {
+		if process := parseSSOutput(string(out)); process != "" {
+			return process
+		}
+	}
+	if process := procPortProcess("/proc", port); process != "" {
+		return process
+	}
+	if runtime.GOOS == "windows" {
+		return netstatPortProcess(port)
+	}
+	return ""
+}
+
+// netstatPortProcess names the process listening on port from netstat -ano,
+// with its name from tasklist.
+func netstatPortProcess(port int) string {
+	out, err := commandOutput(exec.Command("netstat", "-ano", "-p", "TCP"))
+	if err != nil {
+		return ""
+	}
+	pid := parseNetstatOutput(string(out), port)
+	if pid == "" {
+		return ""
+	}
+	name := "unknown"
+	if out, err := commandOutput(exec.Command("tasklist", "/FI", "PID eq "+pid, "/FO", "CSV", "/NH")); err == nil {
+		name = cmp.Or(parseTasklistOutput(string(out), pid), name)
+	}
+	return fmt.Sprintf("%s (pid %s)", name, pid)
+}
+
+// parseNetstatOutput returns the pid listening on port in netstat -ano
+// output ("TCP  0.0.0.0:8051  0.0.0.0:0  LISTENING  1234"). Windows
+// translates the state column, so listening sockets are told by their
+// foreign address instead.
+func parseNetstatOutput(output string, port int) string {
+	suffix := ":" + strconv.Itoa(port)
+	for _, line := range splitLines(output) {
+		fields := strings.Fields(line)
+		if len(fields) != 5 || !strings.EqualFold(fields[0], "TCP") {
+			continue
+		}
+		if strings.HasSuffix(fields[1], suffix) && strings.HasSuffix(fields[2], ":0") {
+			return fields[4]
+		}
+	}
+	return ""
+}
+
+// parseTasklistOutput reads tasklist /FO CSV output
+// ("nginx.exe","1234",...) into the image name of pid.
+func parseTasklistOutput(output, pid string) string {
+	records, _ := csv.NewReader(strings.NewReader(output)).ReadAll()
+	for _, record := range records {
+		if len(record) >= 2 && record[1] == pid {
+			return record[0]
+		}
+	}
+	return ""
+}
+
+// dockerProxyPrefixes start the names of the processes that listen on a
+// port for a container: docker-proxy, Docker Desktop's backend, vpnkit,
+// rootless docker, colima and OrbStack. lsof cuts names to nine
+// characters, so none is longer.
+var dockerProxyPrefixes = []string{"docker-pr", "com.docke", "vpnkit", "rootlessk", "limactl", "OrbStack", "wslrelay"}
+
+// isDockerProxy reports whether process, a portProcess result, is docker
+// forwarding a published container port.
+func isDockerProxy(process string) bool {
+	return slices.ContainsFunc(dockerProxyPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(process, prefix)
+	})
+}
+
+// parseLsofOutput reads lsof -F output ("p1234\ncnginx\n") into "nginx (pid 1234)".
+func parseLsofOutput(output string) string {
+	var pid, command string
+	for _, line := range splitLines(output) {
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			if pid == "" {
+				pid = line[1:]
+			}
+		case 'c':
+			if command == "" {
+				command = line[1:]
+			}
+		}
+	}
+	if command == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (pid %s)", command, pid)
+}
+
+var reSSUser = regexp.MustCompile(`users:\(\("([^"]+)",pid=(\d+)`)
+
+// parseSSOutput reads ss -p output into "nginx (pid 1234)".
+func parseSSOutput(output string) string {
+	m := reSSUser.FindStringSubmatch(output)
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s (pid %s)", m[1], m[2])
+}
+
+// shortenHome replaces the user's home directory prefix with ~.
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
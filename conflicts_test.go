@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProcessOutputs(t *testing.T) {
+	if got := parseLsofOutput("p4242\ncnginx\nf6\n"); got != "nginx (pid 4242)" {
+		t.Errorf("Unexpected lsof result %q", got)
+	}
+	if got := parseLsofOutput(""); got != "" {
+		t.Errorf("Expected empty result for empty lsof output, got %q", got)
+	}
+
+	ss := `LISTEN 0 511 0.0.0.0:8051 0.0.0.0:* users:(("php",pid=987,fd=4))`
+	if got := parseSSOutput(ss); got != "php (pid 987)" {
+		t.Errorf("Unexpected ss result %q", got)
+	}
+
+	netstat := "Active Connections\r\n\r\n  Proto  Local Address          Foreign Address        State           PID\r\n" +
+		"  TCP    127.0.0.1:8051         127.0.0.1:50212        ESTABLISHED     77\r\n" +
+		"  TCP    0.0.0.0:18051          0.0.0.0:0              ABHÖREN         55\r\n" +
+		"  TCP    [::]:8051              [::]:0                 LISTENING       4321\r\n"
+	if got := parseNetstatOutput(netstat, 8051); got != "4321" {
+		t.Errorf("Unexpected netstat result %q", got)
+	}
+	if got := parseNetstatOutput(netstat, 3351); got != "" {
+		t.Errorf("Expected no pid for a free port, got %q", got)
+	}
+
+	tasklist := `"nginx.exe","4321","Console","1","10,240 K"` + "\r\n"
+	if got := parseTasklistOutput(tasklist, "4321"); got != "nginx.exe" {
+		t.Errorf("Unexpected tasklist result %q", got)
+	}
+	if got := parseTasklistOutput("INFO: No tasks are running which match the specified criteria.\r\n", "4321"); got != "" {
+		t.Errorf("Expected no name without a matching task, got %q", got)
+	}
+}
+
+func TestIsDockerProxy(t *testing.T) {
+	for _, process := range []string{"docker-proxy (pid 1200)", "docker-pr (pid 1200)", "com.docke (pid 88)", "wslrelay.exe (pid 9)"} {
+		if !isDockerProxy(process) {
+			t.Errorf("Expected %q to be a docker proxy", process)
+		}
+	}
+	if isDockerProxy("nginx (pid 4242)") {
+		t.Error("Expected nginx not to be a docker proxy")
+	}
+}
+
+func TestPortExplainerRegistry(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	shopDir := filepath.Join(tempDir, "shop")
+	currentDir := filepath.Join(tempDir, "current")
+	os.MkdirAll(shopDir, 0755)
+	os.MkdirAll(currentDir, 0755)
+	saveProjectSuffix(shopDir, 51)
+	saveProjectSuffix(currentDir, 52)
+
+	explainer := &portExplainer{containers: map[int]containerPort{9999: {Name: "grafana", Running: true}, 9998: {Name: "old-db"}}, bases: sailPortBases}
+	explainer.projects, _ = ListProjects()
+
+	if got := explainer.explain(3351, currentDir); got != "project "+shortenHome(shopDir)+" (not running)" {
+		t.Errorf("Unexpected explanation %q", got)
+	}
+	if got := explainer.explain(9999, currentDir); got != "docker container grafana" {
+		t.Errorf("Unexpected explanation %q", got)
+	}
+	if got := explainer.explain(9998, currentDir); got != "docker container old-db (stopped)" {
+		t.Errorf("Unexpected explanation %q", got)
+	}
+}
+
+func TestShortenHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	if got := shortenHome(filepath.Join(home, "code", "shop")); got != "~/code/shop" {
+		t.Errorf("Expected ~/code/shop, got %q", got)
+	}
+	if got := shortenHome("/opt/project"); got != "/opt/project" {
+		t.Errorf("Paths outside home should be unchanged, got %q", got)
+	}
+}
+
+func TestConfirmPortsAvailableStealsFromRunningProject(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+	if port <= 8000 {
+		t.Skipf("Listener port %d too low for the test", port)
+	}
+	suffix := port - 8000
+
+	// A registered project whose fake sail reports running containers and
+	// records when it is stopped
+	otherDir := filepath.Join(tempDir, "other")
+	sailDir := filepath.Join(otherDir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	stopped := filepath.Join(tempDir, "stopped")
+	script := "#!/bin/sh\nif [ \"$1\" = ps ]; then echo running; fi\nif [ \"$1\" = stop ]; then touch " + stopped + "; fi\n"
+	if err := os.WriteFile(filepath.Join(sailDir, "sail"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	state := &PortState{Projects: map[string]projectEntry{otherDir: {Suffix: suffix}}}
+	if err := state.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	currentDir := filepath.Join(tempDir, "current")
+	bases := []PortBase{{"APP_PORT", 8000}}
+
+	// The port stays busy (the test holds it), so after stealing the user
+	// is offered the next free suffix, then asked whether to continue
+	reader := bufio.NewReader(strings.NewReader("n\ny\n"))
+	if got, ok := confirmPortsAvailable(reader, currentDir, bases, suffix, portCheckOptions{Steal: true}); !ok || got != suffix {
+		t.Errorf("Expected to continue with suffix %d after confirming, got %d (%v)", suffix, got, ok)
+	}
+	if _, err := os.Stat(stopped); err != nil {
+		t.Error("Expected the other project to be stopped with Steal")
+	}
+}
+
+func TestConfirmPortsAvailableOffersNextFreeSuffix(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+	bases := []PortBase{{"APP_PORT", port - 10}}
+
+	// Suffix 11 belongs to another project and 12 lands on a reserved port
+	state := &PortState{Projects: map[string]projectEntry{filepath.Join(tempDir, "other"): {Suffix: 11}}}
+	if err := state.save(); err != nil {
+		t.Fatal(err)
+	}
+	reserved := map[int]string{port + 2: "postgres"}
+	currentDir := filepath.Join(tempDir, "current")
+	if next, ok := nextSuffixWithFreePorts(currentDir, bases, 10, reserved, nil); !ok || next != 13 {
+		t.Errorf("Expected 13, got %d (%v)", next, ok)
+	}
+
+	reader := bufio.NewReader(strings.NewReader("y\n"))
+	got, ok := confirmPortsAvailable(reader, currentDir, bases, 10, portCheckOptions{})
+	if !ok || got <= 11 {
+		t.Fatalf("Expected a free suffix past 11, got %d (%v)", got, ok)
+	}
+	if state, _, _ := loadPortState(); state.Reservations[currentDir].Suffix != got {
+		t.Errorf("Expected suffix %d to be reserved, got %v", got, state.Reservations)
+	}
+
+	// A suffix given with --suffix is never swapped
+	requestedSuffix = 10
+	defer func() { requestedSuffix = -1 }()
+	reader = bufio.NewReader(strings.NewReader("n\n"))
+	if got, ok := confirmPortsAvailable(reader, currentDir, bases, 10, portCheckOptions{}); ok || got != 10 {
+		t.Errorf("Expected only the continue question, got %d (%v)", got, ok)
+	}
+}
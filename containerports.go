@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// containerPort is a container that publishes a host port, whether or not
+// it is running.
+type containerPort struct {
+	Name    string
+	Running bool
+}
+
+// description names the container for a busy-port warning.
+func (c containerPort) description() string {
+	if c.Running {
+		return "docker container " + c.Name
+	}
+	return "docker container " + c.Name + " (stopped)"
+}
+
+// containerPorts maps the host ports published by docker containers,
+// running or stopped, to the container. A listen check misses a stopped
+// container's ports until it is started again, and may miss ports bound to
+// one interface only. Containers of projectDir and of the registered
+// projects are left out: the registry already accounts for their ports. It
+// returns an empty map when docker is unavailable.
+func containerPorts(projectDir string) map[int]containerPort {
+	out, err := commandOutput(exec.Command("docker", "ps", "-a", "-q", "--no-trunc"))
+	if err != nil {
+		return map[int]containerPort{}
+	}
+	ids := splitLines(string(out))
+	if len(ids) == 0 {
+		return map[int]containerPort{}
+	}
+	format := "{{.Name}}\t{{.State.Running}}\t{{index .Config.Labels \"" + composeWorkingDirLabel + "\"}}\t{{json .HostConfig.PortBindings}}"
+	out, err = commandOutput(exec.Command("docker", append([]string{"inspect", "--format", format}, ids...)...))
+	if err != nil {
+		return map[int]containerPort{}
+	}
+	skip := []string{projectDir}
+	if projects, err := listRegisteredProjects(); err == nil {
+		for _, p := range projects {
+			skip = append(skip, p.Path)
+		}
+	}
+	return parseContainerPortBindings(string(out), skip)
+}
+
+// parseContainerPortBindings reads lines of container name, running state,
+// compose working directory and port bindings as JSON
+// ({"80/tcp":[{"HostIp":"","HostPort":"8051"}]}) into a host port to
+// container map, expanding ranges. Containers of the compose projects in
+// skip and bindings to a random host port are left out.
+func parseContainerPortBindings(output string, skip []string) map[int]containerPort {
+	var skipped []string
+	for _, dir := range skip {
+		if abs, err := filepath.Abs(dir); err == nil && dir != "" {
+			skipped = append(skipped, abs)
+		}
+	}
+	ports := make(map[int]containerPort)
+	for _, line := range splitLines(output) {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		if fields[2] != "" && slices.Contains(skipped, filepath.Clean(fields[2])) {
+			continue
+		}
+		var bindings map[string][]struct{ HostPort string }
+		if json.Unmarshal([]byte(fields[3]), &bindings) != nil {
+			continue
+		}
+		c := containerPort{Name: strings.TrimPrefix(fields[0], "/"), Running: fields[1] == "true"}
+		for _, hosts := range bindings {
+			for _, h := range hosts {
+				first, last, isRange := strings.Cut(h.HostPort, "-")
+				start, err := strconv.Atoi(first)
+				if err != nil {
+					continue
+				}
+				end := start
+				if isRange {
+					if end, err = strconv.Atoi(last); err != nil {
+						continue
+					}
+				}
+				for p := start; p <= end; p++ {
+					ports[p] = c
+				}
+			}
+		}
+	}
+	return ports
+}
+
+// containerConflicts returns the ports of bases for suffix that a container
+// publishes.
+func containerConflicts(bases []PortBase, suffix int, containers map[int]containerPort) []BusyPort {
+	var busy []BusyPort
+	for _, b := range bases {
+		if _, ok := containers[b.Base+suffix]; ok {
+			busy = append(busy, BusyPort{Name: b.Name, Port: b.Base + suffix})
+		}
+	}
+	return busy
+}
+
+// withContainerConflicts adds the ports of bases for suffix that a
+// container publishes to busy.
+func withContainerConflicts(busy []BusyPort, bases []PortBase, suffix int, containers map[int]containerPort) []BusyPort {
+	for _, bp := range containerConflicts(bases, suffix, containers) {
+		if !slices.Contains(busy, bp) {
+			busy = append(busy, bp)
+		}
+	}
+	return busy
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseContainerPortBindings(t *testing.T) {
+	output := "/grafana\tfalse\t\t" + `{"3000/tcp":[{"HostIp":"127.0.0.1","HostPort":"8051"}]}` + "\n" +
+		"/shop-vite-1\ttrue\t/code/shop\t" + `{"5173/tcp":[{"HostIp":"","HostPort":"5151-5152"}]}` + "\n" +
+		"/current-mysql-1\tfalse\t/code/current\t" + `{"3306/tcp":[{"HostIp":"","HostPort":"3351"}]}` + "\n" +
+		"/random\ttrue\t\t" + `{"80/tcp":[{"HostIp":"","HostPort":""}]}` + "\n" +
+		"/worker\tfalse\t\tnull\n"
+
+	ports := parseContainerPortBindings(output, []string{"/code/current"})
+	want := map[int]containerPort{
+		8051: {Name: "grafana"},
+		5151: {Name: "shop-vite-1", Running: true},
+		5152: {Name: "shop-vite-1", Running: true},
+	}
+	if len(ports) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, ports)
+	}
+	for port, c := range want {
+		if ports[port] != c {
+			t.Errorf("Port %d: expected %v, got %v", port, c, ports[port])
+		}
+	}
+	if got := ports[8051].description(); got != "docker container grafana (stopped)" {
+		t.Errorf("Unexpected description %q", got)
+	}
+}
+
+func TestContainerConflicts(t *testing.T) {
+	bases := []PortBase{{"APP_PORT", 8000}, {"FORWARD_DB_PORT", 3300}}
+	containers := map[int]containerPort{3351: {Name: "mysql"}}
+
+	busy := withContainerConflicts([]BusyPort{{"APP_PORT", 8051}, {"FORWARD_DB_PORT", 3351}}, bases, 51, containers)
+	if len(busy) != 2 {
+		t.Errorf("Expected the container port not to be listed twice, got %v", busy)
+	}
+	if got := containerConflicts(bases, 52, containers); len(got) != 0 {
+		t.Errorf("Expected no conflicts for suffix 52, got %v", got)
+	}
+}
+
+func TestConfirmPortsAvailableSeesStoppedContainers(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	// A stopped container publishes APP_PORT of suffix 10
+	binDir := t.TempDir()
+	inspect := "/grafana\tfalse\t\t" + `{"3000/tcp":[{"HostIp":"","HostPort":"48010"}]}`
+	os.WriteFile(filepath.Join(binDir, "inspect.txt"), []byte(inspect+"\n"), 0644)
+	script := "#!/bin/sh\ncase \"$1\" in\nps) echo abc123 ;;\ninspect) cat " + filepath.Join(binDir, "inspect.txt") + " ;;\nesac\n"
+	os.WriteFile(filepath.Join(binDir, "docker"), []byte(script), 0755)
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	currentDir := filepath.Join(tempDir, "current")
+	bases := []PortBase{{"APP_PORT", 48000}}
+	containers := containerPorts(currentDir)
+	if containers[48010].Name != "grafana" {
+		t.Fatalf("Expected the stopped container's port, got %v", containers)
+	}
+	if next, ok := nextSuffixWithFreePorts(currentDir, bases, 9, nil, containers); !ok || next != 11 {
+		t.Errorf("Expected 11, got %d (%v)", next, ok)
+	}
+
+	reader := bufio.NewReader(strings.NewReader("n\nn\n"))
+	if got, ok := confirmPortsAvailable(reader, currentDir, bases, 10, portCheckOptions{}); ok || got != 10 {
+		t.Errorf("Expected the stopped container's port to count as busy, got %d (%v)", got, ok)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// defaultContext is the context of the registry in the home directory and
+// the plain config file.
+const defaultContext = "default"
+
+// contextFlag is the context given with --context.
+var contextFlag string
+
+// contextName is the active context, resolved once at startup. Each named
+// context has a registry (and so a suffix space) of its own and may
+// override the config file.
+var contextName = defaultContext
+
+var contextNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// extractContextFlag removes --context <name> (or --context=<name>) from
+// args wherever it appears, so it works with every command.
+func extractContextFlag(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--context" || a == "-context" {
+			if i+1 < len(args) {
+				contextFlag = args[i+1]
+				i++
+			}
+			continue
+		}
+		if v, ok := strings.CutPrefix(a, "--context="); ok {
+			contextFlag = v
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// sailinitConfigDir returns the directory holding config.yaml, the
+// contexts and the current context's name.
+func sailinitConfigDir() (string, error) {
+	path, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(path), nil
+}
+
+// contextDir returns the directory of a named context.
+func contextDir(name string) (string, error) {
+	dir, err := sailinitConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "contexts", name), nil
+}
+
+// currentContextPath returns the file `sailinit context use` writes.
+func currentContextPath() (string, error) {
+	dir, err := sailinitConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "context"), nil
+}
+
+// validateContextName checks that name can name a context directory.
+func validateContextName(name string) error {
+	if !contextNamePattern.MatchString(name) {
+		return usageError(fmt.Errorf("invalid context name %q (use letters, digits, '.', '_' and '-')", name))
+	}
+	return nil
+}
+
+// resolveContext returns the active context: --context, else
+// SAILINIT_CONTEXT, else the one chosen with `sailinit context use`. A named
+// context must have been created.
+func resolveContext() (string, error) {
+	name := contextFlag
+	if name == "" {
+		name = os.Getenv("SAILINIT_CONTEXT")
+	}
+	if name == "" {
+		path, err := currentContextPath()
+		if err != nil {
+			return "", err
+		}
+		if data, err := readTextFile(path); err == nil {
+			name = strings.TrimSpace(string(data))
+		}
+	}
+	if name == "" || name == defaultContext {
+		return defaultContext, nil
+	}
+	if err := validateContextName(name); err != nil {
+		return "", err
+	}
+	dir, err := contextDir(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return "", usageError(fmt.Errorf("context %q does not exist (create it with: sailinit context create %s)", name, name))
+	}
+	return name, nil
+}
+
+// listContexts returns the default context and every created one, sorted.
+func listContexts() ([]string, error) {
+	dir, err := sailinitConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "contexts"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	names := []string{defaultContext}
+	for _, e := range entries {
+		if e.IsDir() && validateContextName(e.Name()) == nil && e.Name() != defaultContext {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names[1:])
+	return names, nil
+}
+
+// createContext makes the directory of a new context.
+func createContext(name string) error {
+	if err := validateContextName(name); err != nil {
+		return err
+	}
+	if name == defaultContext {
+		return usageError(errors.New("the default context always exists"))
+	}
+	dir, err := contextDir(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return usageError(fmt.Errorf("context %q already exists", name))
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// useContext makes name the context of later commands.
+func useContext(name string) error {
+	path, err := currentContextPath()
+	if err != nil {
+		return err
+	}
+	if name == defaultContext {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := validateContextName(name); err != nil {
+		return err
+	}
+	dir, err := contextDir(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return usageError(fmt.Errorf("context %q does not exist (create it with: sailinit context create %s)", name, name))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0644)
+}
+
+func handleContext(args []string) {
+	if helpRequested("context", args) {
+		return
+	}
+	usage := "Usage: sailinit context <list|current|create|use> [name]"
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		names, err := listContexts()
+		if err != nil {
+			exitWithError("Error listing contexts", err)
+		}
+		for _, name := range names {
+			marker := " "
+			if name == contextName {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, name)
+		}
+	case args[0] == "current" && len(args) == 1:
+		fmt.Println(contextName)
+	case args[0] == "create" && len(args) == 2:
+		if err := createContext(args[1]); err != nil {
+			exitWithError("Error creating context", err)
+		}
+		printSuccess(fmt.Sprintf("Created context %s. Switch to it with: sailinit context use %s", args[1], args[1]))
+	case args[0] == "use" && len(args) == 2:
+		if err := useContext(args[1]); err != nil {
+			exitWithError("Error switching context", err)
+		}
+		printSuccess(fmt.Sprintf("Switched to context %s.", args[1]))
+	default:
+		printError(usage)
+		exit(ExitUsage)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// withContext switches to the named context for the duration of the test.
+func withContext(t *testing.T, name string) {
+	t.Helper()
+	orig := contextName
+	contextName = name
+	resetUserConfig()
+	t.Cleanup(func() {
+		contextName = orig
+		resetUserConfig()
+	})
+}
+
+func TestExtractContextFlag(t *testing.T) {
+	t.Cleanup(func() { contextFlag = "" })
+	got := extractContextFlag([]string{"sailinit", "--context", "clientA", "list"})
+	if !slices.Equal(got, []string{"sailinit", "list"}) || contextFlag != "clientA" {
+		t.Errorf("Unexpected args %v (context %q)", got, contextFlag)
+	}
+	got = extractContextFlag([]string{"sailinit", "status", "--context=clientB"})
+	if !slices.Equal(got, []string{"sailinit", "status"}) || contextFlag != "clientB" {
+		t.Errorf("Unexpected args %v (context %q)", got, contextFlag)
+	}
+}
+
+func TestContextLifecycle(t *testing.T) {
+	t.Setenv("SAILINIT_CONTEXT", "")
+	dir, _ := sailinitConfigDir()
+	t.Cleanup(func() {
+		os.RemoveAll(filepath.Join(dir, "contexts"))
+		os.Remove(filepath.Join(dir, "context"))
+	})
+
+	if name, err := resolveContext(); err != nil || name != defaultContext {
+		t.Fatalf("Expected the default context, got %q (err=%v)", name, err)
+	}
+	if err := useContext("clientA"); err == nil {
+		t.Error("Expected switching to a missing context to fail")
+	}
+	if err := createContext("../escape"); err == nil {
+		t.Error("Expected an invalid name to be rejected")
+	}
+	if err := createContext("clientA"); err != nil {
+		t.Fatal(err)
+	}
+	if err := createContext("clientA"); err == nil {
+		t.Error("Expected creating an existing context to fail")
+	}
+	if err := useContext("clientA"); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := resolveContext(); name != "clientA" {
+		t.Errorf("Expected clientA after use, got %q", name)
+	}
+	t.Setenv("SAILINIT_CONTEXT", "default")
+	if name, _ := resolveContext(); name != defaultContext {
+		t.Errorf("Expected SAILINIT_CONTEXT to win over use, got %q", name)
+	}
+	if names, _ := listContexts(); !slices.Equal(names, []string{"default", "clientA"}) {
+		t.Errorf("Unexpected contexts %v", names)
+	}
+	if err := useContext(defaultContext); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "context")); !os.IsNotExist(err) {
+		t.Error("Expected use default to remove the current context file")
+	}
+}
+
+func TestContextHasOwnRegistryAndConfig(t *testing.T) {
+	dir, _ := sailinitConfigDir()
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(dir, "contexts")) })
+	if err := createContext("clientA"); err != nil {
+		t.Fatal(err)
+	}
+	writeTestConfig(t, "port_bases:\n  APP_PORT: 9000\n  VITE_PORT: 9100\nopen_browser: true\n")
+	ctxDir, _ := contextDir("clientA")
+	os.WriteFile(filepath.Join(ctxDir, "config.yaml"), []byte("port_bases:\n  APP_PORT: 10000\nopen_browser: false\n"), 0644)
+
+	withContext(t, "clientA")
+	path, err := getPortStatePath()
+	if err != nil || path != filepath.Join(ctxDir, "ports.json") {
+		t.Errorf("Expected the context's registry, got %s (err=%v)", path, err)
+	}
+	cfg, err := loadUserConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PortBases["APP_PORT"] != 10000 || cfg.PortBases["VITE_PORT"] != 9100 || cfg.OpenBrowser {
+		t.Errorf("Expected the context to override the config file, got %+v", cfg)
+	}
+
+	os.WriteFile(filepath.Join(ctxDir, "config.yaml"), []byte("port_bases:\n  WEB: 1\n"), 0644)
+	resetUserConfig()
+	if _, err := loadUserConfig(); err == nil || !strings.Contains(err.Error(), ctxDir) {
+		t.Errorf("Expected the context's config file to be named in the error, got %v", err)
+	}
+}
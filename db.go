@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+func handleDB(args []string) {
+	if helpRequested("db", args) {
+		return
+	}
+	if len(args) == 0 {
+		printError("Usage: sailinit db <import|engine> ...")
+		exit(ExitUsage)
+	}
+
+	switch args[0] {
+	case "engine":
+		handleDBEngine(args[1:])
+	case "import":
+		fs := flag.NewFlagSet("db import", flag.ExitOnError)
+		checksum := fs.String("sha256", "", "Expected SHA-256 checksum of the downloaded dump")
+		fs.StringVar(&composeFileOverride, "compose-file", "", "Compose file to use instead of the default (also honors COMPOSE_FILE)")
+		fs.Parse(reorderArgs(args[1:]))
+		if fs.NArg() != 1 {
+			printError("Usage: sailinit db import <url|path> [--sha256 <checksum>]")
+			exit(ExitUsage)
+		}
+
+		projectDir, err := projectDirectory()
+		if err != nil {
+			exitWithError("Error getting current directory", err)
+		}
+		if err := importDatabase(projectDir, fs.Arg(0), *checksum); err != nil {
+			exitWithError("Error importing database", err)
+		}
+		printSuccess("Database import complete.")
+	default:
+		printError(fmt.Sprintf("Unknown db command: %s", args[0]))
+		exit(ExitUsage)
+	}
+}
+
+// reorderArgs moves flags in front of positional arguments so that
+// "import dump.sql --sha256 abc" parses the same as "import --sha256 abc dump.sql".
+func reorderArgs(args []string) []string {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			flags = append(flags, arg)
+			if !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				flags = append(flags, args[i+1])
+				i++
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	return append(flags, positional...)
+}
+
+func importDatabase(projectDir, source, checksum string) error {
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
+		return sailNotFoundError(sailPath)
+	}
+
+	printInfo(fmt.Sprintf("Fetching dump from %s ...", source))
+	dump, cleanup, err := fetchDump(source)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if checksum != "" {
+		if err := verifyChecksum(dump, checksum); err != nil {
+			return err
+		}
+		if _, err := dump.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		printInfo("Checksum verified.")
+	}
+
+	sql, err := decompressDump(dump)
+	if err != nil {
+		return err
+	}
+
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	service, command, err := dbRestoreCommand(env)
+	if err != nil {
+		return err
+	}
+
+	printInfo(fmt.Sprintf("Restoring the dump into the %s container...", service))
+	cmd := exec.Command(sailPath, "exec", "-T", service, "sh", "-c", command)
+	cmd.Dir = projectDir
+	cmd.Env = sailEnv(projectDir)
+	cmd.Stdin = sql
+	return runStreaming(cmd)
+}
+
+// downloadIdleTimeout is how long a download over http(s) may go without
+// receiving data, so a stalled server fails the command instead of hanging
+// it while a large dump that keeps arriving is never cut off.
+var downloadIdleTimeout = 2 * time.Minute
+
+// downloadClient fetches remote sources; a server that never answers fails
+// after ResponseHeaderTimeout, one that stops sending after
+// downloadIdleTimeout.
+var downloadClient = &http.Client{
+	Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, ResponseHeaderTimeout: 30 * time.Second},
+}
+
+// idleReader is a response body that cancels its request once no read
+// has returned data for downloadIdleTimeout.
+type idleReader struct {
+	body    io.ReadCloser
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	stalled atomic.Bool
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		r.timer.Reset(downloadIdleTimeout)
+	}
+	if err != nil && err != io.EOF && r.stalled.Load() {
+		err = fmt.Errorf("download stalled: no data for %s", downloadIdleTimeout)
+	}
+	return n, err
+}
+
+func (r *idleReader) Close() error {
+	r.timer.Stop()
+	r.cancel()
+	return r.body.Close()
+}
+
+// openSource opens source, a local path or an http(s) URL. what names the
+// file in error hints.
+func openSource(source, what string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &idleReader{cancel: cancel}
+	r.timer = time.AfterFunc(downloadIdleTimeout, func() {
+		r.stalled.Store(true)
+		cancel()
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		r.timer.Stop()
+		cancel()
+		return nil, err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		r.timer.Stop()
+		cancel()
+		return nil, &CLIError{Category: CategoryNetwork, Err: err, Hint: fmt.Sprintf("Check your network connection and the %s URL.", what)}
+	}
+	r.body = resp.Body
+	if resp.StatusCode != http.StatusOK {
+		r.Close()
+		return nil, &CLIError{Category: CategoryNetwork, Err: fmt.Errorf("download failed: %s", resp.Status), Hint: fmt.Sprintf("Check the %s URL and that you have access to it.", what)}
+	}
+	return r, nil
+}
+
+// fetchSource reads source, a local path or an http(s) URL, into memory.
+// It is meant for small files such as env baselines; dumps go through
+// fetchDump.
+func fetchSource(source, what string) ([]byte, error) {
+	r, err := openSource(source, what)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// fetchDump opens the dump at source. A download is streamed into a
+// temporary file, which cleanup removes, so the dump never has to fit in
+// memory and can be checksummed before it is restored.
+func fetchDump(source string) (*os.File, func(), error) {
+	r, err := openSource(source, "dump")
+	if err != nil {
+		return nil, nil, err
+	}
+	if f, ok := r.(*os.File); ok {
+		return f, func() { f.Close() }, nil
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp("", "sailinit-dump-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		cleanup()
+		return nil, nil, &CLIError{Category: CategoryNetwork, Err: err, Hint: "Check your network connection and the dump URL."}
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return tmp, cleanup, nil
+}
+
+// verifyChecksum compares the SHA-256 of what r reads against an expected
+// hex digest, optionally prefixed with "sha256:".
+func verifyChecksum(r io.Reader, expected string) error {
+	expected = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(expected), "sha256:"))
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// decompressDump transparently gunzips r when it starts with the gzip
+// magic bytes.
+func decompressDump(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// dbRestoreCommand returns the compose service and shell command that pipe
+// stdin into the project's database client.
+func dbRestoreCommand(env map[string]string) (string, string, error) {
+	connection := env["DB_CONNECTION"]
+	if connection == "" {
+		connection = "mysql"
+	}
+	database := env["DB_DATABASE"]
+	if database == "" {
+		database = "laravel"
+	}
+	username := env["DB_USERNAME"]
+	if username == "" {
+		username = "sail"
+	}
+	password := env["DB_PASSWORD"]
+
+	switch connection {
+	case "mysql", "mariadb":
+		return connection, fmt.Sprintf("MYSQL_PWD=%s mysql -u %s %s", shellQuote(password), shellQuote(username), shellQuote(database)), nil
+	case "pgsql":
+		return "pgsql", fmt.Sprintf("PGPASSWORD=%s psql -q -U %s %s", shellQuote(password), shellQuote(username), shellQuote(database)), nil
+	default:
+		return "", "", fmt.Errorf("unsupported DB_CONNECTION %q for import", connection)
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
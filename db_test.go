@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	// sha256 of the empty string
+	if err := verifyChecksum(strings.NewReader("CREATE TABLE users (id INT);"), "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"); err == nil {
+		t.Error("Expected mismatch error for wrong checksum")
+	}
+
+	if err := verifyChecksum(strings.NewReader(""), "sha256:E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855"); err != nil {
+		t.Errorf("Expected checksum of empty input to match, got: %v", err)
+	}
+}
+
+func TestDecompressDump(t *testing.T) {
+	plain := []byte("INSERT INTO users VALUES (1);")
+
+	r, err := decompressDump(bytes.NewReader(plain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); !bytes.Equal(got, plain) {
+		t.Errorf("Plain dump should pass through unchanged, got %q", got)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(plain)
+	zw.Close()
+
+	r, err = decompressDump(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); !bytes.Equal(got, plain) {
+		t.Errorf("Expected gunzipped %q, got %q", plain, got)
+	}
+
+	r, err = decompressDump(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); len(got) != 0 {
+		t.Errorf("Expected an empty dump to stay empty, got %q", got)
+	}
+}
+
+func TestDbRestoreCommand(t *testing.T) {
+	service, cmd, err := dbRestoreCommand(map[string]string{
+		"DB_CONNECTION": "mysql",
+		"DB_DATABASE":   "shop",
+		"DB_USERNAME":   "sail",
+		"DB_PASSWORD":   "it's",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service != "mysql" {
+		t.Errorf("Expected service mysql, got %q", service)
+	}
+	if !strings.Contains(cmd, `MYSQL_PWD='it'\''s'`) || !strings.Contains(cmd, "'shop'") {
+		t.Errorf("Unexpected restore command: %s", cmd)
+	}
+
+	service, _, err = dbRestoreCommand(map[string]string{"DB_CONNECTION": "pgsql"})
+	if err != nil || service != "pgsql" {
+		t.Errorf("Expected pgsql service, got %q (err=%v)", service, err)
+	}
+
+	if _, _, err := dbRestoreCommand(map[string]string{"DB_CONNECTION": "sqlite"}); err == nil {
+		t.Error("Expected error for unsupported connection")
+	}
+}
+
+func TestReorderArgs(t *testing.T) {
+	got := reorderArgs([]string{"dump.sql", "--sha256", "abc"})
+	want := []string{"--sha256", "abc", "dump.sql"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestImportDatabaseNoSail(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-db-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	err = importDatabase(tempDir, "dump.sql", "")
+	if err == nil || !strings.Contains(err.Error(), "sail binary not found") {
+		t.Errorf("Expected 'sail binary not found' error, got: %v", err)
+	}
+}
+
+func TestFetchDumpStalledServer(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("CREATE"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	defer func(orig time.Duration) { downloadIdleTimeout = orig }(downloadIdleTimeout)
+	downloadIdleTimeout = 100 * time.Millisecond
+	if _, _, err := fetchDump(server.URL + "/dump.sql"); err == nil || !strings.Contains(err.Error(), "stalled") {
+		t.Errorf("Expected a stalled download to time out, got %v", err)
+	}
+}
+
+func TestFetchDumpSlowServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Longer in total than the idle timeout, but never idle for that long
+		for range 6 {
+			w.Write([]byte("INSERT;\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	defer func(orig time.Duration) { downloadIdleTimeout = orig }(downloadIdleTimeout)
+	downloadIdleTimeout = 100 * time.Millisecond
+	dump, cleanup, err := fetchDump(server.URL + "/dump.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if err := verifyChecksum(dump, fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Repeat("INSERT;\n", 6))))); err != nil {
+		t.Errorf("Expected the whole dump to be downloaded: %v", err)
+	}
+
+	name := dump.Name()
+	cleanup()
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file to be removed, got %v", err)
+	}
+}
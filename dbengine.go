@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dbEngine is a MySQL-compatible database server and its image tag.
+type dbEngine struct {
+	Name    string
+	Version string
+}
+
+// String returns e as an image reference, e.g. "mariadb:11".
+func (e dbEngine) String() string {
+	return e.Name + ":" + e.Version
+}
+
+// dbEngineHealthchecks are the healthcheck tests Sail's stubs use per engine.
+var dbEngineHealthchecks = map[string]string{
+	"mysql":   `["CMD", "mysqladmin", "ping", "-p${DB_PASSWORD}"]`,
+	"mariadb": `["CMD", "healthcheck.sh", "--connect", "--innodb_initialized"]`,
+}
+
+// parseDBEngine parses "mysql:8.4" or "mariadb:11".
+func parseDBEngine(s string) (dbEngine, error) {
+	name, version, _ := strings.Cut(s, ":")
+	if _, ok := dbEngineHealthchecks[name]; !ok {
+		return dbEngine{}, fmt.Errorf("unsupported engine %q (use mysql:<version> or mariadb:<version>)", name)
+	}
+	if version == "" {
+		return dbEngine{}, fmt.Errorf("%s needs a version, e.g. mysql:8.4 or mariadb:11", name)
+	}
+	return dbEngine{Name: name, Version: version}, nil
+}
+
+// imageEngine reads the engine from an image such as
+// "mysql/mysql-server:8.0" or "mariadb:10". ok is false for other images.
+func imageEngine(image string) (dbEngine, bool) {
+	repo, version, found := strings.Cut(image, ":")
+	if !found {
+		version = "latest"
+	}
+	switch name := repo[strings.LastIndex(repo, "/")+1:]; name {
+	case "mysql", "mysql-server":
+		return dbEngine{"mysql", version}, true
+	case "mariadb":
+		return dbEngine{"mariadb", version}, true
+	}
+	return dbEngine{}, false
+}
+
+// compareVersions compares dotted numeric versions. ok is false when
+// either is not numeric, such as "latest".
+func compareVersions(a, b string) (cmp int, ok bool) {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		var err error
+		if i < len(as) {
+			if x, err = strconv.Atoi(as[i]); err != nil {
+				return 0, false
+			}
+		}
+		if i < len(bs) {
+			if y, err = strconv.Atoi(bs[i]); err != nil {
+				return 0, false
+			}
+		}
+		if x != y {
+			if x < y {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// dbEngineWarnings explains what switching from one engine to the other
+// means for the data volume.
+func dbEngineWarnings(from, to dbEngine) []string {
+	if from.Name != to.Name {
+		return []string{fmt.Sprintf("The data volume of %s can't be opened by %s; it has to be recreated, losing the database unless you pass --migrate.", from, to)}
+	}
+	cmp, ok := compareVersions(from.Version, to.Version)
+	switch {
+	case !ok:
+		return []string{fmt.Sprintf("Can't compare %s with %s; if the data volume was written by a newer server, %s won't start until it is recreated.", from.Version, to.Version, to)}
+	case cmp > 0:
+		return []string{fmt.Sprintf("Downgrading from %s to %s: an older server can't read the newer data volume, so it has to be recreated (pass --migrate to keep the data).", from, to)}
+	case cmp < 0:
+		return []string{fmt.Sprintf("Upgrading to %s converts the data volume on first start, after which %s can't read it anymore.", to, from)}
+	}
+	return nil
+}
+
+// lineEdit replaces the text of the zero-based line Line from column
+// Column (zero-based) to the end.
+type lineEdit struct {
+	Line   int
+	Column int
+	Text   string
+}
+
+// dbEngineEdit finds the database service in compose file lines and the
+// edits that switch it to engine. It returns an empty service when the
+// file has no mysql or mariadb service. Warnings cover parts that can't be
+// rewritten in place.
+func dbEngineEdit(lines []string, engine dbEngine) (service string, from dbEngine, edits []lineEdit, warnings []string, err error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &root); err != nil {
+		return "", dbEngine{}, nil, nil, err
+	}
+	if len(root.Content) == 0 {
+		return "", dbEngine{}, nil, nil, nil
+	}
+	services := mappingValue(root.Content[0], "services")
+	if services == nil {
+		return "", dbEngine{}, nil, nil, nil
+	}
+	var svc *yaml.Node
+	for _, name := range []string{"mysql", "mariadb"} {
+		if svc = mappingValue(services, name); svc != nil {
+			service = name
+			break
+		}
+	}
+	if svc == nil {
+		return "", dbEngine{}, nil, nil, nil
+	}
+
+	image := mappingValue(svc, "image")
+	if image == nil || image.Kind != yaml.ScalarNode {
+		return "", dbEngine{}, nil, nil, fmt.Errorf("service %s has no image to replace", service)
+	}
+	var ok bool
+	if from, ok = imageEngine(image.Value); !ok {
+		return "", dbEngine{}, nil, nil, fmt.Errorf("service %s runs %s, which is neither MySQL nor MariaDB", service, image.Value)
+	}
+	edits = append(edits, lineEdit{image.Line - 1, image.Column - 1, "'" + engine.String() + "'"})
+
+	if from.Name != engine.Name {
+		test := mappingValue(mappingValue(svc, "healthcheck"), "test")
+		switch {
+		case test == nil:
+		case test.Kind == yaml.ScalarNode || test.Style&yaml.FlowStyle != 0 && lastLine(test) == test.Line:
+			edits = append(edits, lineEdit{test.Line - 1, test.Column - 1, dbEngineHealthchecks[engine.Name]})
+		default:
+			warnings = append(warnings, fmt.Sprintf("Update the healthcheck of %s by hand; %s uses %s.", service, engine.Name, dbEngineHealthchecks[engine.Name]))
+		}
+	}
+	return service, from, edits, warnings, nil
+}
+
+// lastLine returns the line of the last scalar inside node.
+func lastLine(node *yaml.Node) int {
+	if len(node.Content) == 0 {
+		return node.Line
+	}
+	return lastLine(node.Content[len(node.Content)-1])
+}
+
+// dbDumpCommand returns the shell command that writes the database to
+// stdout inside a container running engine.
+func dbDumpCommand(env map[string]string, engine dbEngine) string {
+	tool := "mysqldump"
+	if engine.Name == "mariadb" {
+		tool = "mariadb-dump"
+	}
+	return fmt.Sprintf("MYSQL_PWD=%s %s --no-tablespaces -u %s %s", shellQuote(env["DB_PASSWORD"]), tool, shellQuote(orDefault(env["DB_USERNAME"], "sail")), shellQuote(orDefault(env["DB_DATABASE"], "laravel")))
+}
+
+// dbLoadCommand returns the shell command that pipes stdin into the
+// database inside a container running engine.
+func dbLoadCommand(env map[string]string, engine dbEngine) string {
+	tool := "mysql"
+	if engine.Name == "mariadb" {
+		tool = "mariadb"
+	}
+	return fmt.Sprintf("MYSQL_PWD=%s %s -u %s %s", shellQuote(env["DB_PASSWORD"]), tool, shellQuote(orDefault(env["DB_USERNAME"], "sail")), shellQuote(orDefault(env["DB_DATABASE"], "laravel")))
+}
+
+// orDefault returns value, or def when value is empty.
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// sailExec runs command in service through sail exec, feeding it stdin and
+// returning its stdout.
+func sailExec(projectDir, service, command string, stdin []byte) ([]byte, error) {
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
+		return nil, sailNotFoundError(sailPath)
+	}
+	cmd := exec.Command(sailPath, "exec", "-T", service, "sh", "-c", command)
+	cmd.Dir = projectDir
+	cmd.Env = sailEnv(projectDir)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = os.Stderr
+	return commandOutput(cmd)
+}
+
+// switchDBEngine rewrites the database image of projectDir's compose file.
+// With migrate the database is dumped first, the volumes are recreated and
+// the dump is loaded into the new engine. It reports whether anything was
+// changed, which is not the case when the engine already runs or the user
+// declines.
+func switchDBEngine(reader *bufio.Reader, projectDir string, engine dbEngine, migrate, yes bool) (bool, error) {
+	var path string
+	var lines []string
+	var layout textLayout
+	var service string
+	var from dbEngine
+	var edits []lineEdit
+	var warnings []string
+	for _, f := range composeFiles(projectDir) {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		lines, layout = splitTextFile(data)
+		if service, from, edits, warnings, err = dbEngineEdit(lines, engine); err != nil {
+			return false, fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		if service != "" {
+			path = f
+			break
+		}
+	}
+	if service == "" {
+		return false, &CLIError{
+			Category: CategoryUsage,
+			Err:      errors.New("no mysql or mariadb service in the compose file"),
+			Hint:     fmt.Sprintf("Add one with: sail artisan sail:install --with=%s", engine.Name),
+		}
+	}
+	if from == engine {
+		printInfo(fmt.Sprintf("%s already runs %s.", service, engine))
+		return false, nil
+	}
+
+	for _, w := range append(dbEngineWarnings(from, engine), warnings...) {
+		printWarning(w)
+	}
+	prompt := tr("db_engine_prompt", service, from, engine)
+	if migrate {
+		prompt = tr("db_engine_migrate", service, from, engine, shortenHome(projectDir))
+	}
+	if !yes && !askYesNo(reader, prompt) {
+		return false, nil
+	}
+
+	var dump []byte
+	var dumpPath string
+	if migrate {
+		if err := runSailUp(projectDir); err != nil {
+			return false, err
+		}
+		env := readEnvValues(filepath.Join(projectDir, ".env"))
+		printInfo(fmt.Sprintf("Dumping the database from %s...", from))
+		var err error
+		if dump, err = sailExec(projectDir, service, dbDumpCommand(env, from), nil); err != nil {
+			return false, fmt.Errorf("dumping the database: %w", err)
+		}
+		dumpPath = filepath.Join(projectDir, "storage", fmt.Sprintf("sailinit-%s-%s.sql", from.Name, time.Now().Format("20060102-150405")))
+		if err := os.WriteFile(dumpPath, dump, 0600); err != nil {
+			return false, err
+		}
+		printInfo(fmt.Sprintf("Saved the dump to %s", shortenHome(dumpPath)))
+	}
+
+	for _, e := range edits {
+		lines[e.Line] = lines[e.Line][:e.Column] + e.Text
+	}
+	if err := writeEnvFile(path, joinTextFile(lines, layout)); err != nil {
+		return false, err
+	}
+	printSuccess(fmt.Sprintf("%s now uses %s in %s.", service, engine.String(), filepath.Base(path)))
+
+	if !migrate {
+		printInfo("Recreate the container with: sail up -d")
+		return true, nil
+	}
+	if err := runSailDownVolumes(projectDir); err != nil {
+		return true, err
+	}
+	if err := runSailUp(projectDir); err != nil {
+		return true, err
+	}
+	printInfo(fmt.Sprintf("Loading the dump into %s...", engine))
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	if _, err := sailExec(projectDir, service, dbLoadCommand(env, engine), dump); err != nil {
+		return true, fmt.Errorf("loading %s: %w", shortenHome(dumpPath), err)
+	}
+	printSuccess(fmt.Sprintf("Loaded the database into %s.", engine))
+	return true, nil
+}
+
+func handleDBEngine(args []string) {
+	fs := flag.NewFlagSet("db engine", flag.ExitOnError)
+	migrate := fs.Bool("migrate", false, "Dump the database, recreate the volumes and load the dump into the new engine")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	fs.StringVar(&composeFileOverride, "compose-file", "", "Compose file to use instead of the default (also honors COMPOSE_FILE)")
+	fs.Parse(reorderArgs(args))
+	if fs.NArg() != 1 {
+		printError("Usage: sailinit db engine <mysql:version|mariadb:version> [--migrate] [--yes]")
+		exit(ExitUsage)
+	}
+	engine, err := parseDBEngine(fs.Arg(0))
+	if err != nil {
+		exitWithError("Invalid engine", usageError(err))
+	}
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	changed, err := switchDBEngine(bufio.NewReader(os.Stdin), projectDir, engine, *migrate, *yes)
+	if changed || err != nil {
+		recordHistory(projectDir, "db-engine", engine.String(), err)
+	}
+	if err != nil {
+		exitWithError("Error switching the database engine", err)
+	}
+}
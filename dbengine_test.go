@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const mysqlCompose = `services:
+    laravel.test:
+        image: 'sail-8.3/app'
+        depends_on:
+            - mysql
+    mysql:
+        image: 'mysql/mysql-server:8.0'
+        environment:
+            MYSQL_ROOT_PASSWORD: '${DB_PASSWORD}'
+        volumes:
+            - 'sail-mysql:/var/lib/mysql'
+        healthcheck:
+            test: ["CMD", "mysqladmin", "ping", "-p${DB_PASSWORD}"]
+            retries: 3
+volumes:
+    sail-mysql:
+        driver: local
+`
+
+func TestParseDBEngine(t *testing.T) {
+	if e, err := parseDBEngine("mariadb:11"); err != nil || e != (dbEngine{"mariadb", "11"}) {
+		t.Errorf("Expected mariadb:11, got %v, %v", e, err)
+	}
+	for _, bad := range []string{"pgsql:16", "mysql", "mysql:"} {
+		if _, err := parseDBEngine(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestImageEngine(t *testing.T) {
+	cases := map[string]dbEngine{
+		"mysql/mysql-server:8.0": {"mysql", "8.0"},
+		"mysql:8.4":              {"mysql", "8.4"},
+		"mariadb":                {"mariadb", "latest"},
+	}
+	for image, want := range cases {
+		if got, ok := imageEngine(image); !ok || got != want {
+			t.Errorf("imageEngine(%q) = %v, %v; want %v", image, got, ok, want)
+		}
+	}
+	if _, ok := imageEngine("postgres:16"); ok {
+		t.Error("Expected postgres not to be a MySQL engine")
+	}
+}
+
+func TestDBEngineWarnings(t *testing.T) {
+	cases := []struct {
+		from, to dbEngine
+		want     string
+	}{
+		{dbEngine{"mysql", "8.0"}, dbEngine{"mariadb", "11"}, "can't be opened"},
+		{dbEngine{"mysql", "8.4"}, dbEngine{"mysql", "8.0"}, "Downgrading"},
+		{dbEngine{"mariadb", "10.11"}, dbEngine{"mariadb", "11"}, "Upgrading"},
+		{dbEngine{"mariadb", "latest"}, dbEngine{"mariadb", "11"}, "Can't compare"},
+	}
+	for _, c := range cases {
+		warnings := dbEngineWarnings(c.from, c.to)
+		if len(warnings) != 1 || !strings.Contains(warnings[0], c.want) {
+			t.Errorf("%s -> %s: expected a warning containing %q, got %v", c.from, c.to, c.want, warnings)
+		}
+	}
+	if warnings := dbEngineWarnings(dbEngine{"mysql", "8.0"}, dbEngine{"mysql", "8.0.0"}); warnings != nil {
+		t.Errorf("Expected no warning for the same version, got %v", warnings)
+	}
+}
+
+func TestSwitchDBEngine(t *testing.T) {
+	tempDir := t.TempDir()
+	composePath := filepath.Join(tempDir, "docker-compose.yml")
+	os.WriteFile(composePath, []byte(mysqlCompose), 0644)
+
+	changed, err := switchDBEngine(bufio.NewReader(strings.NewReader("")), tempDir, dbEngine{"mariadb", "11"}, false, true)
+	if err != nil || !changed {
+		t.Fatalf("Expected the engine to change, got %v, %v", changed, err)
+	}
+
+	data, _ := os.ReadFile(composePath)
+	want := strings.NewReplacer(
+		"image: 'mysql/mysql-server:8.0'", "image: 'mariadb:11'",
+		`test: ["CMD", "mysqladmin", "ping", "-p${DB_PASSWORD}"]`, `test: ["CMD", "healthcheck.sh", "--connect", "--innodb_initialized"]`,
+	).Replace(mysqlCompose)
+	if string(data) != want {
+		t.Errorf("Unexpected compose file:\n%s", data)
+	}
+
+	changed, err = switchDBEngine(bufio.NewReader(strings.NewReader("")), tempDir, dbEngine{"mariadb", "11"}, false, true)
+	if err != nil || changed {
+		t.Errorf("Expected nothing to change the second time, got %v, %v", changed, err)
+	}
+}
+
+func TestSwitchDBEngineDeclined(t *testing.T) {
+	tempDir := t.TempDir()
+	composePath := filepath.Join(tempDir, "docker-compose.yml")
+	os.WriteFile(composePath, []byte(mysqlCompose), 0644)
+
+	changed, err := switchDBEngine(bufio.NewReader(strings.NewReader("n\n")), tempDir, dbEngine{"mysql", "8.4"}, false, false)
+	if err != nil || changed {
+		t.Errorf("Expected nothing to change, got %v, %v", changed, err)
+	}
+	if data, _ := os.ReadFile(composePath); string(data) != mysqlCompose {
+		t.Errorf("Expected the compose file to be untouched, got:\n%s", data)
+	}
+}
+
+func TestSwitchDBEngineWithoutService(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "docker-compose.yml"), []byte(mailhogCompose), 0644)
+
+	_, err := switchDBEngine(bufio.NewReader(strings.NewReader("")), tempDir, dbEngine{"mysql", "8.4"}, false, true)
+	if err == nil || !strings.Contains(err.Error(), "no mysql or mariadb service") {
+		t.Errorf("Expected a missing service error, got %v", err)
+	}
+}
+
+func TestDBDumpAndLoadCommands(t *testing.T) {
+	env := map[string]string{"DB_USERNAME": "sail", "DB_PASSWORD": "pa'ss", "DB_DATABASE": "shop"}
+	if got := dbDumpCommand(env, dbEngine{"mariadb", "11"}); got != `MYSQL_PWD='pa'\''ss' mariadb-dump --no-tablespaces -u 'sail' 'shop'` {
+		t.Errorf("Unexpected dump command: %s", got)
+	}
+	if got := dbLoadCommand(env, dbEngine{"mysql", "8.4"}); got != `MYSQL_PWD='pa'\''ss' mysql -u 'sail' 'shop'` {
+		t.Errorf("Unexpected load command: %s", got)
+	}
+}
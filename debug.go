@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// debugToggle is an .env key `sailinit debug` flips. Keys of a package are
+// only written when composer.json requires it or .env already has them.
+type debugToggle struct {
+	Key     string
+	Package string
+}
+
+var debugToggles = []debugToggle{
+	{Key: "APP_DEBUG"},
+	{Key: "TELESCOPE_ENABLED", Package: "laravel/telescope"},
+	{Key: "DEBUGBAR_ENABLED", Package: "barryvdh/laravel-debugbar"},
+}
+
+// setDebugMode sets the debug keys of projectDir's .env to on, leaving
+// locked keys alone, and returns the lines it wrote.
+func setDebugMode(projectDir string, on bool) ([]string, error) {
+	envPath := filepath.Join(projectDir, ".env")
+	if _, err := os.Stat(envPath); err != nil {
+		return nil, envError(fmt.Errorf(".env not found in %s", projectDir))
+	}
+	doc, err := readEnvDocument(envPath)
+	if err != nil {
+		return nil, envError(err)
+	}
+
+	value := "false"
+	if on {
+		value = "true"
+	}
+	packages := composerPackages(projectDir)
+	locks := loadEnvLocks()
+	var changed []string
+	for _, t := range debugToggles {
+		current, exists := doc.Get(t.Key)
+		if !exists && t.Package != "" && !slices.Contains(packages, t.Package) {
+			continue
+		}
+		if current == value || locks.skip(t.Key, current, value) {
+			continue
+		}
+		doc.Set(t.Key, value)
+		changed = append(changed, fmt.Sprintf("%s=%s", t.Key, value))
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	if err := doc.write(envPath); err != nil {
+		return nil, envError(err)
+	}
+	return changed, nil
+}
+
+// clearConfigCache makes the running app pick up .env changes with
+// `sail artisan config:clear`. A stopped stack reads .env when it starts,
+// unless a cached config is left over.
+func clearConfigCache(projectDir string) error {
+	if running, err := countRunningContainers(projectDir); err != nil || running == 0 {
+		if slices.Contains(staleCacheFiles(projectDir), "bootstrap/cache/config.php") {
+			printInfo(tr("debug_clear_later"))
+		}
+		return nil
+	}
+	cmd, err := sailCommand(projectDir, "artisan", "config:clear")
+	if err != nil {
+		return err
+	}
+	printInfo(tr("debug_clearing"))
+	return runStreaming(cmd)
+}
+
+func handleDebug(args []string) {
+	if helpRequested("debug", args) {
+		return
+	}
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		exitWithError("Usage: sailinit debug <on|off>", usageError(errors.New("expected on or off")))
+	}
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+
+	on := args[0] == "on"
+	changed, err := setDebugMode(projectDir, on)
+	recordHistory(projectDir, "debug", args[0], err)
+	if err != nil {
+		exitWithError("Error updating .env", err)
+	}
+	for _, line := range changed {
+		printInfo(line)
+	}
+	if len(changed) == 0 {
+		printInfo(tr("debug_already_" + args[0]))
+		return
+	}
+	if err := clearConfigCache(projectDir); err != nil {
+		printWarning(tr("debug_clear_failed", err))
+	}
+	printSuccess(tr("debug_turned_" + args[0]))
+}
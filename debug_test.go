@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSetDebugMode(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	os.WriteFile(filepath.Join(dir, "composer.json"), []byte(`{"require-dev": {"laravel/telescope": "^5.0"}}`), 0644)
+	os.WriteFile(envPath, []byte("APP_NAME=Shop\nAPP_DEBUG=false\n"), 0644)
+
+	changed, err := setDebugMode(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(changed, []string{"APP_DEBUG=true", "TELESCOPE_ENABLED=true"}) {
+		t.Errorf("Unexpected changes %v", changed)
+	}
+	env := readEnvValues(envPath)
+	if env["APP_DEBUG"] != "true" || env["TELESCOPE_ENABLED"] != "true" {
+		t.Errorf("Unexpected .env %v", env)
+	}
+	if _, ok := env["DEBUGBAR_ENABLED"]; ok {
+		t.Error("Expected DEBUGBAR_ENABLED left out without the package")
+	}
+
+	// A second run has nothing to do
+	if changed, _ := setDebugMode(dir, true); changed != nil {
+		t.Errorf("Expected no changes, got %v", changed)
+	}
+
+	t.Setenv("SAILINIT_LOCKED_KEYS", "APP_DEBUG")
+	if changed, _ := setDebugMode(dir, false); !slices.Equal(changed, []string{"TELESCOPE_ENABLED=false"}) {
+		t.Errorf("Expected the locked APP_DEBUG skipped, got %v", changed)
+	}
+	if env := readEnvValues(envPath); env["APP_DEBUG"] != "true" || env["TELESCOPE_ENABLED"] != "false" {
+		t.Errorf("Unexpected .env %v", env)
+	}
+}
+
+func TestSetDebugModeWithoutEnv(t *testing.T) {
+	if _, err := setDebugMode(t.TempDir(), true); err == nil {
+		t.Error("Expected an error without .env")
+	}
+}
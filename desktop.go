@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// lookPath finds desktop helpers. Tests replace it to simulate platforms.
+var lookPath = exec.LookPath
+
+// openerCommand returns the command that opens url in the default browser.
+func openerCommand(goos, url string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", url), nil
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url), nil
+	}
+	if path, err := lookPath("xdg-open"); err == nil {
+		return exec.Command(path, url), nil
+	}
+	return nil, fmt.Errorf("xdg-open not found")
+}
+
+// clipboardCommand returns the command that copies its stdin to the
+// clipboard. On Linux Wayland and X11 tools are tried in turn.
+func clipboardCommand(goos string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		return exec.Command("pbcopy"), nil
+	case "windows":
+		return exec.Command("clip"), nil
+	}
+	candidates := [][]string{
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	for _, c := range candidates {
+		if path, err := lookPath(c[0]); err == nil {
+			return exec.Command(path, c[1:]...), nil
+		}
+	}
+	return nil, fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+}
+
+// openBrowser opens url without waiting for the browser.
+func openBrowser(url string) error {
+	cmd, err := openerCommand(runtime.GOOS, url)
+	if err != nil {
+		return err
+	}
+	return cmd.Start()
+}
+
+// copyToClipboard puts text on the system clipboard.
+func copyToClipboard(text string) error {
+	cmd, err := clipboardCommand(runtime.GOOS)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return commandRun(cmd)
+}
+
+// finishDesktop opens the app and copies the summary as configured.
+// Failures are only warnings since setup itself succeeded.
+func finishDesktop(cfg *userConfig, appURL, summary string) {
+	if cfg.CopyURLs {
+		if err := copyToClipboard(summary); err != nil {
+			printWarning(tr("clipboard_failed", err))
+		} else {
+			printInfo(tr("urls_copied"))
+		}
+	}
+	if cfg.OpenBrowser {
+		if err := openBrowser(appURL); err != nil {
+			printWarning(tr("browser_failed", err))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubLookPath makes only the given tools available.
+func stubLookPath(t *testing.T, available ...string) {
+	t.Helper()
+	orig := lookPath
+	lookPath = func(name string) (string, error) {
+		for _, a := range available {
+			if a == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	t.Cleanup(func() { lookPath = orig })
+}
+
+func TestOpenerCommand(t *testing.T) {
+	stubLookPath(t, "xdg-open")
+	cases := map[string]string{
+		"darwin":  "open http://localhost:8048",
+		"windows": "rundll32 url.dll,FileProtocolHandler http://localhost:8048",
+		"linux":   "/usr/bin/xdg-open http://localhost:8048",
+	}
+	for goos, want := range cases {
+		cmd, err := openerCommand(goos, "http://localhost:8048")
+		if err != nil {
+			t.Fatalf("%s: %v", goos, err)
+		}
+		if got := strings.Join(cmd.Args, " "); got != want {
+			t.Errorf("%s: got %q, want %q", goos, got, want)
+		}
+	}
+
+	stubLookPath(t)
+	if _, err := openerCommand("linux", "http://localhost:8048"); err == nil {
+		t.Error("Expected an error without xdg-open")
+	}
+}
+
+func TestClipboardCommand(t *testing.T) {
+	stubLookPath(t, "xclip", "xsel")
+	cmd, err := clipboardCommand("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cmd.Args, " "); got != "/usr/bin/xclip -selection clipboard" {
+		t.Errorf("Expected xclip before xsel, got %q", got)
+	}
+
+	stubLookPath(t, "wl-copy", "xclip")
+	cmd, _ = clipboardCommand("linux")
+	if cmd.Args[0] != "/usr/bin/wl-copy" {
+		t.Errorf("Expected wl-copy first, got %q", cmd.Args[0])
+	}
+
+	stubLookPath(t)
+	if _, err := clipboardCommand("linux"); err == nil {
+		t.Error("Expected an error without clipboard tools")
+	}
+	if cmd, _ := clipboardCommand("darwin"); cmd.Args[0] != "pbcopy" {
+		t.Errorf("Expected pbcopy on macOS, got %q", cmd.Args[0])
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// diagnosticEnvPrefixes select the .env keys worth sharing when a project
+// does not start. Secrets among them are masked.
+var diagnosticEnvPrefixes = []string{"APP_", "DB_", "REDIS_", "FORWARD_", "SAIL_", "VITE_", "CACHE_", "SESSION_", "QUEUE_", "MAIL_HOST", "MAIL_PORT", "WWWUSER", "WWWGROUP", "TZ"}
+
+// diagnosticLogLines is how much of each failing service's log is kept.
+const diagnosticLogLines = 100
+
+// diagnosticEnv returns the relevant .env lines with secrets masked.
+func diagnosticEnv(entries []envEntry) []string {
+	secrets := secretEnvKeys(entries)
+	var lines []string
+	for _, e := range entries {
+		if !slices.ContainsFunc(diagnosticEnvPrefixes, func(p string) bool { return strings.HasPrefix(e.Key, p) }) {
+			continue
+		}
+		value := e.Value
+		if slices.Contains(secrets, e.Key) {
+			value = "********"
+		}
+		lines = append(lines, e.Key+"="+value)
+	}
+	return lines
+}
+
+// sailOutput runs sail with args in projectDir and returns its combined
+// output, with the error appended when it fails.
+func sailOutput(projectDir string, args ...string) string {
+	cmd := exec.Command(filepath.Join(projectDir, "vendor", "bin", "sail"), args...)
+	cmd.Dir = projectDir
+	cmd.Env = sailEnv(projectDir)
+	output, err := commandCombinedOutput(cmd)
+	text := strings.TrimRight(string(output), "\n")
+	if err != nil {
+		text += fmt.Sprintf("\n(%v)", err)
+	}
+	return text
+}
+
+// buildDiagnostics assembles the report for projectDir. cause is the error
+// that prompted it, or nil when requested by hand.
+func buildDiagnostics(projectDir string, cause error, now time.Time) []byte {
+	var b bytes.Buffer
+	section := func(title string) { fmt.Fprintf(&b, "\n== %s ==\n", title) }
+
+	fmt.Fprintf(&b, "sailinit %s diagnostics, %s\n", version, now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Project: %s\n", projectDir)
+	fmt.Fprintf(&b, "Host: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	if suffix, registered, _, err := getSuggestedSuffix(projectDir); err == nil && registered {
+		fmt.Fprintf(&b, "Suffix: %d\n", suffix)
+	}
+	if cause != nil {
+		fmt.Fprintf(&b, "Error: %v\n", cause)
+	}
+
+	section("docker compose ps")
+	b.WriteString(sailOutput(projectDir, "ps", "--all") + "\n")
+
+	states, _ := containerStates(projectDir)
+	for _, c := range states {
+		if c.ready() {
+			continue
+		}
+		section(fmt.Sprintf("logs: %s (%s)", c.Service, c.status()))
+		b.WriteString(sailOutput(projectDir, "logs", "--no-color", "--tail", fmt.Sprint(diagnosticLogLines), c.Service) + "\n")
+	}
+
+	section(".env (secrets masked)")
+	if entries, err := readEnvEntries(filepath.Join(projectDir, ".env")); err != nil {
+		fmt.Fprintf(&b, "(%v)\n", err)
+	} else {
+		for _, line := range diagnosticEnv(entries) {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// writeDiagnostics saves the report to storage/logs, which Laravel keeps
+// out of git, or the project root, and returns its path. It stays private
+// since logs may still contain sensitive data.
+func writeDiagnostics(projectDir string, cause error) (string, error) {
+	now := time.Now()
+	dir := filepath.Join(projectDir, "storage", "logs")
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = projectDir
+	}
+	path := filepath.Join(dir, fmt.Sprintf("sailinit-diagnostics-%s.txt", now.Format("20060102-150405")))
+	if err := os.WriteFile(path, buildDiagnostics(projectDir, cause, now), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func handleDiagnose(args []string) {
+	commandFlags("diagnose").Parse(args)
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	if _, err := os.Stat(sailPath); err != nil {
+		exitWithError("Error collecting diagnostics", sailNotFoundError(sailPath))
+	}
+	path, err := writeDiagnostics(projectDir, nil)
+	if err != nil {
+		exitWithError("Error writing diagnostics", err)
+	}
+	printSuccess(fmt.Sprintf("Diagnostics written to %s. Review it before sharing.", path))
+}
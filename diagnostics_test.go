@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiagnosticEnv(t *testing.T) {
+	entries := []envEntry{
+		{"APP_NAME", "Shop"}, {"APP_KEY", "base64:abc"}, {"DB_PASSWORD", "password"},
+		{"DB_PORT", "3306"}, {"AWS_SECRET_ACCESS_KEY", "xyz"}, {"MAIL_PASSWORD", "smtp"}, {"REDIS_PASSWORD", ""},
+	}
+	got := strings.Join(diagnosticEnv(entries), "\n")
+	want := "APP_NAME=Shop\nAPP_KEY=********\nDB_PASSWORD=********\nDB_PORT=3306\nREDIS_PASSWORD="
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuildDiagnostics(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := readinessProject(t, "laravel.test\trunning\t\nmysql\texited\t\n")
+	os.WriteFile(filepath.Join(projectDir, ".env"), []byte("APP_KEY=base64:abc\nDB_PORT=3306\nAWS_BUCKET=x\n"), 0644)
+	saveProjectSuffix(projectDir, 57)
+
+	report := string(buildDiagnostics(projectDir, errors.New("sail up failed"), time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)))
+	for _, want := range []string{
+		"2026-10-17T12:00:00Z", "Suffix: 57", "Error: sail up failed",
+		"== docker compose ps ==\nlaravel.test\trunning",
+		"== logs: mysql (exited) ==\n[ERROR] InnoDB",
+		"APP_KEY=********\nDB_PORT=3306\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in report:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "logs: laravel.test") || strings.Contains(report, "AWS_BUCKET") {
+		t.Errorf("Expected only failing services and relevant keys, got:\n%s", report)
+	}
+}
+
+func TestWriteDiagnostics(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := readinessProject(t, "")
+	path, err := writeDiagnostics(projectDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != projectDir {
+		t.Errorf("Expected the project root without storage/logs, got %s", path)
+	}
+
+	os.MkdirAll(filepath.Join(projectDir, "storage", "logs"), 0755)
+	path, err = writeDiagnostics(projectDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != filepath.Join(projectDir, "storage", "logs") {
+		t.Errorf("Expected storage/logs, got %s", path)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a private file, got %04o", info.Mode().Perm())
+	}
+}
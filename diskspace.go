@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// defaultMinFreeDiskGB is the free space, in GB, below which setup warns
+// when the config file doesn't set min_free_disk_gb.
+const defaultMinFreeDiskGB = 5
+
+// diskSpace is the free space of a filesystem.
+type diskSpace struct {
+	Free uint64
+	// Device tells filesystems apart, so one shared by the project and
+	// Docker is reported once
+	Device uint64
+}
+
+// lowDiskSpace is a filesystem with less free space than the threshold.
+type lowDiskSpace struct {
+	// Label says what lives there, e.g. "the Docker data root"
+	Label string
+	Path  string
+	Free  uint64
+}
+
+// diskSpaceChecked is set once a command has checked the disk space, so a
+// setup running composer install and sail up warns once.
+var diskSpaceChecked bool
+
+// minFreeDiskGB returns min_free_disk_gb from the config file, or the
+// default. 0 turns the check off.
+func minFreeDiskGB() int {
+	cfg, err := loadUserConfig()
+	if err != nil || cfg.MinFreeDiskGB == nil {
+		return defaultMinFreeDiskGB
+	}
+	return *cfg.MinFreeDiskGB
+}
+
+// dockerDataRoot returns where Docker keeps images and volumes, or "" when
+// docker doesn't say. With Docker Desktop it is a path inside its VM, which
+// diskUsage can't read, so only the project is checked there. Tests
+// replace it.
+var dockerDataRoot = func() string {
+	out, err := commandOutput(exec.Command("docker", "info", "--format", "{{.DockerRootDir}}"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// findLowDiskSpace returns the filesystems of paths, label to path, with
+// less than minimum bytes free, in the order of labels. Paths whose space
+// can't be read are skipped.
+func findLowDiskSpace(labels []string, paths map[string]string, minimum uint64, usage func(string) (diskSpace, error)) []lowDiskSpace {
+	var low []lowDiskSpace
+	seen := make(map[uint64]bool)
+	for _, label := range labels {
+		path := paths[label]
+		if path == "" {
+			continue
+		}
+		space, err := usage(path)
+		if err != nil || seen[space.Device] {
+			continue
+		}
+		seen[space.Device] = true
+		if space.Free < minimum {
+			low = append(low, lowDiskSpace{Label: label, Path: path, Free: space.Free})
+		}
+	}
+	return low
+}
+
+// formatGB renders a byte count in GB with one decimal.
+func formatGB(bytes uint64) string {
+	return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+}
+
+// warnLowDiskSpace warns before composer install or sail up when the
+// Docker data root or the project's filesystem is short of space. A full
+// disk otherwise surfaces late, as a cryptic image pull or extraction
+// error.
+func warnLowDiskSpace(projectDir string) {
+	if diskSpaceChecked {
+		return
+	}
+	diskSpaceChecked = true
+	gb := minFreeDiskGB()
+	if gb <= 0 || warnDiskSpace.suppressed(projectDir) {
+		return
+	}
+	labels := []string{"the Docker data root", "the project"}
+	paths := map[string]string{labels[0]: dockerDataRoot(), labels[1]: projectDir}
+	for _, low := range findLowDiskSpace(labels, paths, uint64(gb)<<30, diskUsage) {
+		warnDiskSpace.warn(projectDir, tr("disk_space_low", formatGB(low.Free), low.Label, shortenHome(low.Path), gb))
+	}
+}
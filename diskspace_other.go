@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux)
+
+package main
+
+import "errors"
+
+// diskUsage is not implemented here (NetBSD and OpenBSD lack a common
+// statfs), so the disk space check is skipped.
+func diskUsage(path string) (diskSpace, error) {
+	return diskSpace{}, errors.ErrUnsupported
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindLowDiskSpace(t *testing.T) {
+	usage := func(path string) (diskSpace, error) {
+		switch path {
+		case "/var/lib/docker":
+			return diskSpace{Free: 1 << 30, Device: 1}, nil
+		case "/home/me/shop", "/home/me/blog":
+			return diskSpace{Free: 20 << 30, Device: 2}, nil
+		}
+		return diskSpace{}, errors.New("no such file or directory")
+	}
+	labels := []string{"docker", "project", "other", "missing"}
+	paths := map[string]string{"docker": "/var/lib/docker", "project": "/home/me/shop", "other": "/home/me/blog", "missing": "/nowhere"}
+
+	low := findLowDiskSpace(labels, paths, 5<<30, usage)
+	if len(low) != 1 || low[0].Label != "docker" || low[0].Free != 1<<30 {
+		t.Errorf("Expected only the Docker data root to be low, got %+v", low)
+	}
+	if low := findLowDiskSpace(labels, paths, 50<<30, usage); len(low) != 2 {
+		t.Errorf("Expected each filesystem to be reported once, got %+v", low)
+	}
+	if low := findLowDiskSpace(labels, map[string]string{"project": "/home/me/shop"}, 5<<30, usage); len(low) != 0 {
+		t.Errorf("Expected an unknown data root to be skipped, got %+v", low)
+	}
+}
+
+func TestMinFreeDiskGB(t *testing.T) {
+	writeTestConfig(t, "")
+	if got := minFreeDiskGB(); got != defaultMinFreeDiskGB {
+		t.Errorf("Expected the default, got %d", got)
+	}
+	writeTestConfig(t, "min_free_disk_gb: 0\n")
+	if got := minFreeDiskGB(); got != 0 {
+		t.Errorf("Expected 0 to turn the check off, got %d", got)
+	}
+	writeTestConfig(t, "min_free_disk_gb: -1\n")
+	if _, err := loadUserConfig(); err == nil {
+		t.Error("Expected a negative threshold to be rejected")
+	}
+}
+
+func TestFormatGB(t *testing.T) {
+	if got := formatGB(3 << 29); got != "1.5 GB" {
+		t.Errorf("Expected 1.5 GB, got %s", got)
+	}
+}
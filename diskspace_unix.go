@@ -0,0 +1,19 @@
+//go:build darwin || dragonfly || freebsd || linux
+
+package main
+
+import "syscall"
+
+// diskUsage returns the space available to sailinit on the filesystem
+// holding path, and that filesystem's device.
+func diskUsage(path string) (diskSpace, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return diskSpace{}, err
+	}
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return diskSpace{}, err
+	}
+	return diskSpace{Free: uint64(fs.Bavail) * uint64(fs.Bsize), Device: uint64(st.Dev)}, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// dockerHost names the host whose network the current docker context
+// publishes ports on, or "" for this machine. Projects only compete for a
+// suffix with projects on the same host. A variable so tests can stub it.
+var dockerHost = sync.OnceValue(detectDockerHost)
+
+// detectDockerHost reads the endpoint from DOCKER_HOST or else the current
+// docker context, honoring DOCKER_CONTEXT. Docker being unavailable counts
+// as this machine.
+func detectDockerHost() string {
+	endpoint := os.Getenv("DOCKER_HOST")
+	if endpoint == "" {
+		out, err := commandOutput(exec.Command("docker", "context", "inspect", "--format", "{{.Endpoints.docker.Host}}"))
+		if err != nil {
+			return ""
+		}
+		endpoint = strings.TrimSpace(string(out))
+	}
+	return dockerHostKey(endpoint)
+}
+
+// dockerHostKey returns the host a docker endpoint publishes ports on: ""
+// for a local socket or pipe, whichever daemon listens there, since Docker
+// Desktop, colima and rootless docker all publish on this machine's ports;
+// otherwise the remote machine's name, as in ssh://me@build-box.
+func dockerHostKey(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "tcp", "ssh", "http", "https":
+	default:
+		return ""
+	}
+	host := u.Hostname()
+	if host == "" || isLocalHost(host) {
+		return ""
+	}
+	return strings.ToLower(host)
+}
+
+// dockerHostOf returns the docker host the project at path was registered
+// on.
+func (s *PortState) dockerHostOf(path string) string {
+	return s.DockerHosts[path]
+}
+
+// setDockerHost records that absDir runs on host, leaving projects on this
+// machine out so existing registries stay as they are.
+func (s *PortState) setDockerHost(absDir, host string) {
+	if host == "" {
+		delete(s.DockerHosts, absDir)
+		return
+	}
+	if s.DockerHosts == nil {
+		s.DockerHosts = make(map[string]string)
+	}
+	s.DockerHosts[absDir] = host
+}
+
+// maxSuffixOn returns the highest suffix registered on docker host, or
+// held by a project there that was registered before it was tracked.
+func (s *PortState) maxSuffixOn(host string) int {
+	highest := s.MaxSuffix
+	if host != "" {
+		highest = s.HostMaxSuffix[host]
+	}
+	for path, e := range s.Projects {
+		if e.Suffix > highest && s.dockerHostOf(path) == host {
+			highest = e.Suffix
+		}
+	}
+	return highest
+}
+
+// raiseMaxSuffix records that suffix was registered on docker host.
+func (s *PortState) raiseMaxSuffix(host string, suffix int) {
+	if host == "" {
+		s.MaxSuffix = max(s.MaxSuffix, suffix)
+		return
+	}
+	if suffix <= s.HostMaxSuffix[host] {
+		return
+	}
+	if s.HostMaxSuffix == nil {
+		s.HostMaxSuffix = make(map[string]int)
+	}
+	s.HostMaxSuffix[host] = suffix
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDockerHostKey(t *testing.T) {
+	cases := map[string]string{
+		"":                                     "",
+		"unix:///var/run/docker.sock":          "",
+		"unix:///Users/me/.colima/docker.sock": "",
+		"npipe:////./pipe/docker_engine":       "",
+		"tcp://127.0.0.1:2375":                 "",
+		"tcp://localhost:2376":                 "",
+		"ssh://me@Build-Box":                   "build-box",
+		"tcp://10.0.0.5:2376":                  "10.0.0.5",
+	}
+	for endpoint, want := range cases {
+		if got := dockerHostKey(endpoint); got != want {
+			t.Errorf("dockerHostKey(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestSuffixSharedAcrossDockerHosts(t *testing.T) {
+	defer func(orig func() string) { dockerHost = orig }(dockerHost)
+	now := time.Now()
+	state := &PortState{
+		MaxSuffix:   52,
+		Projects:    map[string]projectEntry{"/code/local": {Suffix: 51}, "/code/remote": {Suffix: 52}},
+		DockerHosts: map[string]string{"/code/remote": "build-box"},
+	}
+
+	dockerHost = func() string { return "" }
+	if _, taken := state.suffixOwner("/code/new", 52, now); taken {
+		t.Error("Expected a suffix used on another docker host to be free here")
+	}
+	if owner, _ := state.suffixOwner("/code/new", 51, now); owner != "/code/local" {
+		t.Errorf("Expected /code/local to own 51, got %q", owner)
+	}
+
+	dockerHost = func() string { return "build-box" }
+	if _, taken := state.suffixOwner("/code/new", 51, now); taken {
+		t.Error("Expected a local project's suffix to be free on build-box")
+	}
+	state.reserve("/code/new", 51, now)
+	if r := state.Reservations["/code/new"]; r.DockerHost != "build-box" {
+		t.Errorf("Expected the reservation to record build-box, got %q", r.DockerHost)
+	}
+	dockerHost = func() string { return "" }
+	if _, taken := state.suffixOwner("/code/other", 51, now); !taken {
+		t.Error("Expected /code/local to still own 51 locally")
+	}
+	if _, taken := state.suffixOwners("/code/other", now)[52]; taken {
+		t.Error("Expected the remote project left out of local owners")
+	}
+}
+
+func TestIncrementingSuffixPerDockerHost(t *testing.T) {
+	defer func(orig func() string) { dockerHost = orig }(dockerHost)
+	now := time.Now()
+	state := &PortState{
+		MaxSuffix:     58,
+		HostMaxSuffix: map[string]int{"build-box": 51},
+		Projects:      map[string]projectEntry{"/code/local": {Suffix: 58}, "/code/remote": {Suffix: 51}},
+		DockerHosts:   map[string]string{"/code/remote": "build-box"},
+	}
+
+	// Each host counts up from its own projects
+	dockerHost = func() string { return "" }
+	if got, _ := state.nextFreeSuffix("/code/new", now, nil, false); got != 59 {
+		t.Errorf("Expected 59 locally, got %d", got)
+	}
+	dockerHost = func() string { return "build-box" }
+	if got, _ := state.nextFreeSuffix("/code/new", now, nil, false); got != 52 {
+		t.Errorf("Expected 52 on build-box, got %d", got)
+	}
+
+	// Two hosts past the same suffix are offered the same next one
+	state.raiseMaxSuffix("ci-box", 58)
+	state.Projects["/code/ci"] = projectEntry{Suffix: 58}
+	state.DockerHosts["/code/ci"] = "ci-box"
+	dockerHost = func() string { return "" }
+	local, _ := state.nextFreeSuffix("/code/new", now, nil, false)
+	dockerHost = func() string { return "ci-box" }
+	if remote, _ := state.nextFreeSuffix("/code/new", now, nil, false); remote != local || local != 59 {
+		t.Errorf("Expected both hosts offered 59, got %d locally and %d on ci-box", local, remote)
+	}
+
+	// A removed project's suffix is not reused on its host
+	state.forget("/code/remote")
+	dockerHost = func() string { return "build-box" }
+	if got, _ := state.nextFreeSuffix("/code/new", now, nil, false); got != 52 {
+		t.Errorf("Expected 52 after removing build-box's project, got %d", got)
+	}
+}
+
+func TestRepairStateKeepsSuffixPerDockerHost(t *testing.T) {
+	data := []byte(`{"max_suffix": 51, "projects": {"/code/a": 51, "/code/b": 51}, "docker_hosts": {"/code/b": "build-box"}}`)
+	state, notes := repairPortState(data, time.Now())
+	if len(state.Projects) != 2 {
+		t.Fatalf("Expected both projects kept, got %v (%v)", state.Projects, notes)
+	}
+	if state.dockerHostOf("/code/b") != "build-box" || state.dockerHostOf("/code/a") != "" {
+		t.Errorf("Unexpected docker hosts %v", state.DockerHosts)
+	}
+	if state.MaxSuffix != 51 || state.HostMaxSuffix["build-box"] != 51 {
+		t.Errorf("Expected max suffix 51 on each host, got %d and %v", state.MaxSuffix, state.HostMaxSuffix)
+	}
+}
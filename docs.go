@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Markers around the section `sailinit docs --out` maintains in a file.
+const (
+	docsBlockStart = "<!-- sailinit:docs -->"
+	docsBlockEnd   = "<!-- /sailinit:docs -->"
+)
+
+// defaultDocsTemplate renders the "Local development" section.
+const defaultDocsTemplate = `## Local development
+
+The project runs on Laravel Sail. Set it up (installs the dependencies, writes the ports to ` + "`.env`" + ` and starts the containers) with:
+
+` + "```bash" + `
+sailinit
+` + "```" + `
+{{if .URLs}}
+| Service | URL |
+|---------|-----|
+{{range .URLs}}| {{.Label}} | {{.URL}} |
+{{end}}{{end}}{{if .PortList}}
+Ports in ` + "`.env`" + `{{if .Registered}} (port suffix {{.Suffix}}){{end}}:
+
+| Variable | Port |
+|----------|------|
+{{range .PortList}}| ` + "`{{.Key}}`" + ` | {{.Port}} |
+{{end}}{{end}}
+Common commands:
+
+` + "```bash" + `
+{{range .Commands}}{{.}}
+{{end}}` + "```" + `
+`
+
+// docsPort is one forwarded port, for templates that list them in order.
+type docsPort struct {
+	Key  string
+	Port int
+}
+
+// docsData is what the docs template can refer to: the project's status
+// plus its name, PHP version, ports in order and the usual commands.
+type docsData struct {
+	ProjectStatus
+	Name       string
+	PHPVersion string
+	PortList   []docsPort
+	Commands   []string
+}
+
+// projectCommands lists the commands a new developer runs day to day,
+// based on what the project contains.
+func projectCommands(projectDir string) []string {
+	commands := []string{"sail up -d"}
+	if _, err := os.Stat(filepath.Join(projectDir, "database", "seeders", "DatabaseSeeder.php")); err == nil {
+		commands = append(commands, "sail artisan migrate --seed")
+	} else {
+		commands = append(commands, "sail artisan migrate")
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "package.json")); err == nil {
+		commands = append(commands, "sail npm install", "sail npm run dev")
+	}
+	return append(commands, "sail artisan test", "sail stop")
+}
+
+// newDocsData collects the template data for projectDir.
+func newDocsData(projectDir string) (docsData, error) {
+	st, err := Status(projectDir)
+	if err != nil {
+		return docsData{}, err
+	}
+	data := docsData{
+		ProjectStatus: st,
+		Name:          filepath.Base(st.Path),
+		PHPVersion:    detectPHPVersion(projectDir),
+		Commands:      projectCommands(projectDir),
+	}
+	for _, key := range slices.Sorted(maps.Keys(st.Ports)) {
+		data.PortList = append(data.PortList, docsPort{key, st.Ports[key]})
+	}
+	return data, nil
+}
+
+// renderDocs executes tmpl, a text/template, with data.
+func renderDocs(tmpl string, data docsData) (string, error) {
+	t, err := template.New("docs").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// replaceDocsBlock puts section between the docs markers of content,
+// replacing what they held or appending them.
+func replaceDocsBlock(content, section string) string {
+	block := docsBlockStart + "\n" + strings.TrimRight(section, "\n") + "\n" + docsBlockEnd
+	start := strings.Index(content, docsBlockStart)
+	end := strings.Index(content, docsBlockEnd)
+	if start >= 0 && end > start {
+		return content[:start] + block + content[end+len(docsBlockEnd):]
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return content + block + "\n"
+}
+
+func handleDocs(args []string) {
+	fset := commandFlags("docs")
+	out := fset.String("out", "", "File to write the section into, between sailinit:docs markers (default: stdout)")
+	templatePath := fset.String("template", "", "text/template file to render instead of the built-in section")
+	fset.Parse(reorderArgs(args))
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	tmpl := defaultDocsTemplate
+	if *templatePath != "" {
+		raw, err := os.ReadFile(*templatePath)
+		if err != nil {
+			exitWithError("Error reading template", usageError(err))
+		}
+		tmpl = string(raw)
+	}
+	data, err := newDocsData(projectDir)
+	if err != nil {
+		exitWithError("Error reading project", err)
+	}
+	section, err := renderDocs(tmpl, data)
+	if err != nil {
+		exitWithError("Error rendering template", usageError(err))
+	}
+
+	if *out == "" {
+		fmt.Print(section)
+		return
+	}
+	current, err := os.ReadFile(*out)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		exitWithError("Error reading "+*out, err)
+	}
+	if err := os.WriteFile(*out, []byte(replaceDocsBlock(string(current), section)), 0644); err != nil {
+		exitWithError("Error writing "+*out, err)
+	}
+	printSuccess(fmt.Sprintf("Updated the local development section in %s.", *out))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewDocsData(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := readinessProject(t, "laravel.test\trunning\t\n")
+	os.WriteFile(filepath.Join(projectDir, ".env"), []byte("APP_PORT=8051\nFORWARD_DB_PORT=3351\n"), 0644)
+	os.WriteFile(filepath.Join(projectDir, "package.json"), []byte("{}"), 0644)
+	os.MkdirAll(filepath.Join(projectDir, "database", "seeders"), 0755)
+	os.WriteFile(filepath.Join(projectDir, "database", "seeders", "DatabaseSeeder.php"), []byte("<?php\n"), 0644)
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := newDocsData(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Name != filepath.Base(projectDir) || data.Suffix != 51 {
+		t.Errorf("Unexpected name or suffix in %+v", data)
+	}
+	if len(data.PortList) == 0 || data.PortList[0].Key != "APP_PORT" || data.PortList[0].Port != 8051 {
+		t.Errorf("Expected ports sorted by key, got %v", data.PortList)
+	}
+	commands := strings.Join(data.Commands, "\n")
+	for _, want := range []string{"sail artisan migrate --seed", "sail npm run dev"} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("Expected %q in %v", want, data.Commands)
+		}
+	}
+
+	section, err := renderDocs(defaultDocsTemplate, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Local development", "| `APP_PORT` | 8051 |", "(port suffix 51)", "http://localhost:8051"} {
+		if !strings.Contains(section, want) {
+			t.Errorf("Expected %q in:\n%s", want, section)
+		}
+	}
+}
+
+func TestProjectCommandsWithoutSeeder(t *testing.T) {
+	commands := projectCommands(t.TempDir())
+	if !strings.Contains(strings.Join(commands, "\n"), "sail artisan migrate\n") {
+		t.Errorf("Expected a plain migrate, got %v", commands)
+	}
+	for _, c := range commands {
+		if strings.Contains(c, "npm") {
+			t.Errorf("Expected no npm commands without package.json, got %v", commands)
+		}
+	}
+}
+
+func TestRenderDocsCustomTemplate(t *testing.T) {
+	got, err := renderDocs("{{.Name}} on {{.Suffix}}", docsData{Name: "shop", ProjectStatus: ProjectStatus{Suffix: 7}})
+	if err != nil || got != "shop on 7" {
+		t.Errorf("Expected %q, got %q (%v)", "shop on 7", got, err)
+	}
+	if _, err := renderDocs("{{.Nope}}", docsData{}); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestReplaceDocsBlock(t *testing.T) {
+	appended := replaceDocsBlock("# Shop\nIntro", "## Local development\nold\n")
+	want := "# Shop\nIntro\n\n" + docsBlockStart + "\n## Local development\nold\n" + docsBlockEnd + "\n"
+	if appended != want {
+		t.Errorf("Expected %q, got %q", want, appended)
+	}
+
+	replaced := replaceDocsBlock(appended+"\n## License\n", "## Local development\nnew\n")
+	if strings.Contains(replaced, "old") || !strings.Contains(replaced, "new\n"+docsBlockEnd) {
+		t.Errorf("Expected the section replaced in place, got %q", replaced)
+	}
+	if !strings.HasPrefix(replaced, "# Shop\nIntro\n\n") || !strings.HasSuffix(replaced, "\n## License\n") {
+		t.Errorf("Expected the surrounding text kept, got %q", replaced)
+	}
+	if strings.Count(replaceDocsBlock(replaced, "x"), docsBlockStart) != 1 {
+		t.Error("Expected a single docs block after rewriting")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// doctorCheck inspects a project and returns the problems it found.
+type doctorCheck struct {
+	name string
+	run  func(projectDir string) []string
+}
+
+var doctorChecks = []doctorCheck{
+	{"Env matches .env.example", checkEnvExampleDrift},
+	{"No Herd/Valet conflict", checkHerdValet},
+	{"APP_URL reaches Sail, not a server on 80/443", checkWebPorts},
+	{"Env secrets not readable by others", checkEnvPermissions},
+	{"Env matches compose services", checkEnvServices},
+	{"Project files owned by you", checkFileOwnership},
+}
+
+func handleDoctor(args []string) {
+	commandFlags("doctor").Parse(args)
+	defer startPager()()
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+
+	printHeader(fmt.Sprintf("Checking %s", projectDir))
+	if problems := runDoctor(projectDir); problems > 0 {
+		printError(fmt.Sprintf("\n%d problem(s) found.", problems))
+		exit(ExitGeneral)
+	}
+	printSuccess("\nNo problems found.")
+}
+
+// runDoctor runs every check against projectDir, prints the results and
+// returns the total number of problems.
+func runDoctor(projectDir string) int {
+	total := 0
+	for _, check := range doctorChecks {
+		problems := check.run(projectDir)
+		if len(problems) == 0 {
+			fmt.Printf("%s %s\n", colorize(colorGreen, "OK "), check.name)
+			continue
+		}
+		fmt.Printf("%s %s\n", colorize(colorRed, "[X]"), check.name)
+		for _, p := range problems {
+			fmt.Printf("    %s\n", p)
+		}
+		total += len(problems)
+	}
+	return total
+}
+
+func checkEnvExampleDrift(projectDir string) []string {
+	envPath := filepath.Join(projectDir, ".env")
+	examplePath := filepath.Join(projectDir, ".env.example")
+	if _, err := os.Stat(examplePath); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(envPath); err != nil {
+		return []string{".env does not exist (run sailinit to create it)"}
+	}
+
+	missing, err := missingExampleKeys(envPath, examplePath)
+	if err != nil {
+		return []string{fmt.Sprintf("could not compare env files: %v", err)}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var keys []string
+	for _, e := range missing {
+		keys = append(keys, e.Key)
+	}
+	return []string{fmt.Sprintf("missing from .env: %s", strings.Join(keys, ", "))}
+}
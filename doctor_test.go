@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckEnvExampleDrift(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-doctor-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// No .env.example -> nothing to compare
+	if problems := checkEnvExampleDrift(tempDir); len(problems) != 0 {
+		t.Errorf("Expected no problems without .env.example, got %v", problems)
+	}
+
+	examplePath := filepath.Join(tempDir, ".env.example")
+	if err := os.WriteFile(examplePath, []byte("APP_NAME=Laravel\nSTRIPE_KEY=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// .env.example but no .env
+	if problems := checkEnvExampleDrift(tempDir); len(problems) != 1 {
+		t.Errorf("Expected missing .env problem, got %v", problems)
+	}
+
+	envPath := filepath.Join(tempDir, ".env")
+	if err := os.WriteFile(envPath, []byte("APP_NAME=Shop\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	problems := checkEnvExampleDrift(tempDir)
+	if len(problems) != 1 || !strings.Contains(problems[0], "STRIPE_KEY") {
+		t.Errorf("Expected STRIPE_KEY to be reported, got %v", problems)
+	}
+
+	if err := os.WriteFile(envPath, []byte("APP_NAME=Shop\nSTRIPE_KEY=sk_test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if problems := checkEnvExampleDrift(tempDir); len(problems) != 0 {
+		t.Errorf("Expected no drift, got %v", problems)
+	}
+}
+
+func TestCheckEnvExampleDriftIgnoresManagedPorts(t *testing.T) {
+	tempDir := t.TempDir()
+	compose := "services:\n  laravel.test:\n    image: sail-8.4/app\n  mysql:\n    image: mysql/mysql-server:8.0\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "compose.yaml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".env"), []byte("APP_NAME=Shop\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readEnvValues(filepath.Join(tempDir, ".env"))["FORWARD_REDIS_PORT"]; ok {
+		t.Fatal("Expected no Redis port without a redis service")
+	}
+	example := "APP_NAME=Laravel\nFORWARD_REDIS_PORT=6379\n"
+	if err := os.WriteFile(filepath.Join(tempDir, ".env.example"), []byte(example), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if problems := checkEnvExampleDrift(tempDir); len(problems) != 0 {
+		t.Errorf("Expected the managed Redis port not to count as drift, got %v", problems)
+	}
+}
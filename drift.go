@@ -0,0 +1,238 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// fingerprintEnvKeys are the .env keys sailinit manages besides the ports.
+var fingerprintEnvKeys = []string{
+	"APP_KEY", "APP_URL",
+	"DB_CONNECTION", "DB_HOST", "DB_PORT", "DB_DATABASE", "DB_USERNAME", "DB_PASSWORD",
+}
+
+// Fingerprint captures the sailinit-managed configuration of a project at
+// the time of the last apply. Env values and compose files are stored as
+// hashes so secrets never end up in the registry; env values are keyed
+// with the registry's FingerprintKey, so a short secret can't be found by
+// hashing guesses.
+type Fingerprint struct {
+	Suffix     int               `json:"suffix"`
+	PHPVersion string            `json:"php_version,omitempty"`
+	Env        map[string]string `json:"env"`
+	Compose    string            `json:"compose,omitempty"`
+	RecordedAt time.Time         `json:"recorded_at"`
+	// Keyed is false for fingerprints recorded before env hashes were
+	// keyed, which are compared unkeyed until the next apply
+	Keyed bool `json:"keyed,omitempty"`
+}
+
+// managedEnvKeys returns every .env key covered by the fingerprint,
+// including the port keys of both mail flavors.
+func managedEnvKeys() []string {
+	var keys []string
+	for _, b := range portBases() {
+		keys = append(keys, b.Name)
+	}
+	keys = append(keys, slices.Sorted(maps.Values(mailhogPortKeys))...)
+	return append(keys, fingerprintEnvKeys...)
+}
+
+// computeFingerprint reads the current managed configuration of projectDir,
+// running php, hashing env values with hashKey.
+func computeFingerprint(projectDir string, suffix int, php, hashKey string, now time.Time) Fingerprint {
+	fp := Fingerprint{
+		Suffix:     suffix,
+		PHPVersion: php,
+		Env:        make(map[string]string),
+		RecordedAt: now.UTC().Truncate(time.Second),
+		Keyed:      hashKey != "",
+	}
+
+	values := readEnvValues(filepath.Join(projectDir, ".env"))
+	for _, key := range managedEnvKeys() {
+		if val, ok := values[key]; ok {
+			fp.Env[key] = envValueHash(fp.Keyed, hashKey, val)
+		}
+	}
+
+	h := sha256.New()
+	found := false
+	for _, f := range composeFiles(projectDir) {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		found = true
+		fmt.Fprintf(h, "%s\x00", filepath.Base(f))
+		h.Write(data)
+	}
+	if found {
+		fp.Compose = hex.EncodeToString(h.Sum(nil))[:16]
+	}
+	return fp
+}
+
+func shortHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// envValueHash hashes an env value for a fingerprint: an HMAC under
+// hashKey when keyed, the plain hash of fingerprints from before keys
+// otherwise.
+func envValueHash(keyed bool, hashKey, value string) string {
+	if !keyed {
+		return shortHash([]byte(value))
+	}
+	mac := hmac.New(sha256.New, []byte(hashKey))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// fingerprintKey returns the key env hashes are made with, creating it on
+// first use.
+func (s *PortState) fingerprintKey() string {
+	if s.FingerprintKey == "" {
+		b := make([]byte, 32)
+		rand.Read(b)
+		s.FingerprintKey = hex.EncodeToString(b)
+	}
+	return s.FingerprintKey
+}
+
+// currentPHPVersion returns the PHP version projectDir runs now, to compare
+// with recorded, the version setup applied: recorded while the compose file
+// still has that runtime or names no runtime at all, else its primary one.
+func currentPHPVersion(projectDir, recorded string) string {
+	runtimes := detectPHPVersions(projectDir)
+	if len(runtimes) == 0 || slices.Contains(runtimes, recorded) {
+		return recorded
+	}
+	return runtimes[0]
+}
+
+// diffFingerprints describes what changed from recorded to current, one
+// line per piece of configuration.
+func diffFingerprints(recorded, current Fingerprint) []string {
+	var changes []string
+	if recorded.Suffix != current.Suffix {
+		changes = append(changes, fmt.Sprintf("port suffix: %d -> %d", recorded.Suffix, current.Suffix))
+	}
+	if recorded.PHPVersion != current.PHPVersion {
+		changes = append(changes, fmt.Sprintf("PHP version: %s -> %s", orNone(recorded.PHPVersion), orNone(current.PHPVersion)))
+	}
+	if recorded.Compose != current.Compose {
+		switch {
+		case recorded.Compose == "":
+			changes = append(changes, "compose file added")
+		case current.Compose == "":
+			changes = append(changes, "compose file removed")
+		default:
+			changes = append(changes, "compose file changed")
+		}
+	}
+	for _, key := range managedEnvKeys() {
+		before, hadBefore := recorded.Env[key]
+		after, hasNow := current.Env[key]
+		switch {
+		case hadBefore && !hasNow:
+			changes = append(changes, fmt.Sprintf("%s removed from .env", key))
+		case !hadBefore && hasNow:
+			changes = append(changes, fmt.Sprintf("%s added to .env", key))
+		case before != after:
+			changes = append(changes, fmt.Sprintf("%s changed in .env", key))
+		}
+	}
+	return changes
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// saveProjectFingerprint records the current fingerprint of projectDir,
+// applied with PHP version php.
+func saveProjectFingerprint(projectDir string, suffix int, php string) error {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+
+	return updatePortState(func(state *PortState) error {
+		if state.Fingerprints == nil {
+			state.Fingerprints = make(map[string]Fingerprint)
+		}
+		state.Fingerprints[absDir] = computeFingerprint(absDir, suffix, php, state.fingerprintKey(), time.Now())
+		return nil
+	})
+}
+
+// projectDrift compares projectDir against its recorded fingerprint. The
+// bool result is false when the project has never been applied.
+func projectDrift(projectDir string) (Fingerprint, []string, bool, error) {
+	state, _, err := loadPortState()
+	if err != nil {
+		return Fingerprint{}, nil, false, err
+	}
+
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return Fingerprint{}, nil, false, err
+	}
+
+	recorded, ok := state.Fingerprints[absDir]
+	if !ok {
+		return Fingerprint{}, nil, false, nil
+	}
+	suffix := -1
+	if e, registered := state.Projects[absDir]; registered {
+		suffix = e.Suffix
+	}
+	hashKey := ""
+	if recorded.Keyed {
+		hashKey = state.FingerprintKey
+	}
+	current := computeFingerprint(absDir, suffix, currentPHPVersion(absDir, recorded.PHPVersion), hashKey, time.Now())
+	return recorded, diffFingerprints(recorded, current), true, nil
+}
+
+func handleDrift(args []string) {
+	commandFlags("drift").Parse(args)
+	defer startPager()()
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+
+	recorded, changes, found, err := projectDrift(projectDir)
+	if err != nil {
+		exitWithError("Error checking drift", err)
+	}
+	if !found {
+		printError("No fingerprint recorded for this project (run sailinit or sailinit apply first).")
+		exit(ExitState)
+	}
+	if len(changes) == 0 {
+		printSuccess(fmt.Sprintf("No drift since %s.", recorded.RecordedAt.Local().Format("2006-01-02 15:04")))
+		return
+	}
+
+	slices.Sort(changes)
+	printWarning(fmt.Sprintf("%d change(s) since %s:", len(changes), recorded.RecordedAt.Local().Format("2006-01-02 15:04")))
+	for _, c := range changes {
+		printWarning(fmt.Sprintf("  %s", c))
+	}
+	exit(ExitGeneral)
+}
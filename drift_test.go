@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiffFingerprints(t *testing.T) {
+	recorded := Fingerprint{
+		Suffix:     51,
+		PHPVersion: "83",
+		Env:        map[string]string{"APP_PORT": "a", "DB_PASSWORD": "b", "APP_URL": "c"},
+		Compose:    "x",
+	}
+	if changes := diffFingerprints(recorded, recorded); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+
+	current := Fingerprint{
+		Suffix:     52,
+		PHPVersion: "84",
+		Env:        map[string]string{"APP_PORT": "z", "APP_URL": "c", "VITE_PORT": "v"},
+		Compose:    "y",
+	}
+	changes := diffFingerprints(recorded, current)
+	for _, want := range []string{
+		"port suffix: 51 -> 52",
+		"PHP version: 83 -> 84",
+		"compose file changed",
+		"APP_PORT changed in .env",
+		"DB_PASSWORD removed from .env",
+		"VITE_PORT added to .env",
+	} {
+		if !slices.Contains(changes, want) {
+			t.Errorf("Expected %q in %v", want, changes)
+		}
+	}
+	if len(changes) != 6 {
+		t.Errorf("Expected 6 changes, got %v", changes)
+	}
+}
+
+func TestComputeFingerprintHidesValues(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-drift-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	os.WriteFile(filepath.Join(tempDir, ".env"), []byte("DB_PASSWORD=secret\nUNRELATED=1\n"), 0644)
+	fp := computeFingerprint(tempDir, 51, "84", "registry-key", time.Now())
+
+	if fp.Env["DB_PASSWORD"] == "" || fp.Env["DB_PASSWORD"] == "secret" {
+		t.Errorf("Expected a hashed DB_PASSWORD, got %q", fp.Env["DB_PASSWORD"])
+	}
+	// Without the key the hash can't be matched against guesses
+	if fp.Env["DB_PASSWORD"] == shortHash([]byte("secret")) {
+		t.Error("Expected DB_PASSWORD hashed with the registry key")
+	}
+	if other := computeFingerprint(tempDir, 51, "84", "other-key", time.Now()); other.Env["DB_PASSWORD"] == fp.Env["DB_PASSWORD"] {
+		t.Error("Expected another key to give another hash")
+	}
+	if fp.PHPVersion != "84" || !fp.Keyed {
+		t.Errorf("Unexpected fingerprint %+v", fp)
+	}
+	if _, ok := fp.Env["UNRELATED"]; ok {
+		t.Error("Unmanaged keys should not be fingerprinted")
+	}
+	if fp.Compose != "" {
+		t.Errorf("Expected no compose hash without a compose file, got %q", fp.Compose)
+	}
+}
+
+func TestProjectDrift(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(projectDir, 0755)
+	envPath := filepath.Join(projectDir, ".env")
+	os.WriteFile(envPath, []byte("APP_PORT=8051\n"), 0644)
+	os.WriteFile(filepath.Join(projectDir, "compose.yaml"), []byte("services: {}\n"), 0644)
+
+	if _, _, found, err := projectDrift(projectDir); err != nil || found {
+		t.Fatalf("Expected no fingerprint yet, got found=%v err=%v", found, err)
+	}
+
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+	// The applied PHP version is recorded, even without a runtime in the
+	// compose file to detect it from
+	if err := saveProjectFingerprint(projectDir, 51, "84"); err != nil {
+		t.Fatal(err)
+	}
+	recorded, changes, found, err := projectDrift(projectDir)
+	if err != nil || !found || len(changes) != 0 {
+		t.Fatalf("Expected clean fingerprint, got changes=%v found=%v err=%v", changes, found, err)
+	}
+	if recorded.PHPVersion != "84" {
+		t.Errorf("Expected PHP 84 recorded, got %q", recorded.PHPVersion)
+	}
+
+	// A fingerprint from before keyed hashes still compares clean
+	state, _, _ := loadPortState()
+	legacy := computeFingerprint(projectDir, 51, "84", "", time.Now())
+	state.Fingerprints[projectDir] = legacy
+	state.save()
+	if _, changes, _, _ := projectDrift(projectDir); len(changes) != 0 {
+		t.Errorf("Expected an unkeyed fingerprint to compare clean, got %v", changes)
+	}
+	if err := saveProjectFingerprint(projectDir, 51, "84"); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(envPath, []byte("APP_PORT=9000\n"), 0644)
+	os.WriteFile(filepath.Join(projectDir, "compose.yaml"), []byte("services:\n  laravel.test:\n    image: sail-8.3/app\n"), 0644)
+	_, changes, _, _ = projectDrift(projectDir)
+	for _, want := range []string{"APP_PORT changed in .env", "compose file changed", "PHP version: 84 -> 83"} {
+		if !slices.Contains(changes, want) {
+			t.Errorf("Expected %q in %v", want, changes)
+		}
+	}
+
+	if err := RemoveProject(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, found, _ := projectDrift(projectDir); found {
+		t.Error("Expected fingerprint to be removed with the project")
+	}
+}
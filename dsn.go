@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// dsnSchemes maps DB_CONNECTION to the URL scheme GUI clients understand.
+var dsnSchemes = map[string]string{"mysql": "mysql", "mariadb": "mysql", "pgsql": "postgresql", "sqlsrv": "sqlserver"}
+
+// dsnURL builds a connection URL to 127.0.0.1, leaving out empty
+// credentials.
+func dsnURL(scheme, user, password, port, path string) string {
+	u := url.URL{Scheme: scheme, Host: "127.0.0.1:" + port, Path: path}
+	if user != "" {
+		u.User = url.User(user)
+		if password != "" {
+			u.User = url.UserPassword(user, password)
+		}
+	}
+	return u.String()
+}
+
+// forwardedPort returns the host port for key: the .env value, else the
+// port the registered suffix implies (suffix -1 when unknown), else def.
+func forwardedPort(env map[string]string, key string, suffix int, def string) string {
+	if v := env[key]; v != "" {
+		return v
+	}
+	if suffix >= 0 {
+		return strconv.Itoa(basePort(key) + suffix)
+	}
+	return def
+}
+
+// databaseDSN returns the host-side connection string for the database
+// configured in env.
+func databaseDSN(env map[string]string, suffix int) (string, error) {
+	connection := dbConnection(env)
+	scheme, ok := dsnSchemes[connection]
+	if !ok {
+		return "", fmt.Errorf("DB_CONNECTION=%s has no network connection string", connection)
+	}
+	def := "3306"
+	switch connection {
+	case "pgsql":
+		def = "5432"
+	case "sqlsrv":
+		def = "1433"
+	}
+	port := forwardedPort(env, "FORWARD_DB_PORT", suffix, def)
+	return dsnURL(scheme, env["DB_USERNAME"], env["DB_PASSWORD"], port, "/"+env["DB_DATABASE"]), nil
+}
+
+// redisDSN returns the host-side Redis URL. Laravel's "null" password
+// placeholder means no password.
+func redisDSN(env map[string]string, suffix int) string {
+	password := env["REDIS_PASSWORD"]
+	if password == "null" {
+		password = ""
+	}
+	port := forwardedPort(env, "FORWARD_REDIS_PORT", suffix, "6379")
+	u := url.URL{Scheme: "redis", Host: "127.0.0.1:" + port}
+	if password != "" {
+		u.User = url.UserPassword(env["REDIS_USERNAME"], password)
+	}
+	return u.String()
+}
+
+func handleDSN(args []string) {
+	if helpRequested("dsn", args) {
+		return
+	}
+	if len(args) > 1 || (len(args) == 1 && args[0] != "db" && args[0] != "redis") {
+		printError("Usage: sailinit dsn [db|redis]")
+		exit(ExitUsage)
+	}
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	suffix := -1
+	if s, registered, _, err := getSuggestedSuffix(projectDir); err == nil && registered {
+		suffix = s
+	}
+
+	if len(args) == 1 && args[0] == "redis" {
+		fmt.Println(redisDSN(env, suffix))
+		return
+	}
+
+	dsn, err := databaseDSN(env, suffix)
+	if len(args) == 1 {
+		if err != nil {
+			exitWithError("Error building connection string", usageError(err))
+		}
+		fmt.Println(dsn)
+		return
+	}
+
+	// Without an argument, print everything the project has
+	if err != nil {
+		printWarning(err.Error())
+	} else {
+		fmt.Println(dsn)
+	}
+	if project, err := loadCompose(projectDir); err == nil && project != nil && slices.ContainsFunc(project.ServiceNames(), func(s string) bool {
+		return s == "redis" || s == "valkey"
+	}) {
+		fmt.Println(redisDSN(env, suffix))
+	}
+}
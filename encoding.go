@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// decodeText converts UTF-16 input, as written by some Windows editors, to
+// UTF-8 and reports whether it did. UTF-8 input, including a UTF-8 byte
+// order mark, is returned unchanged.
+func decodeText(data []byte) ([]byte, bool) {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order, data = binary.LittleEndian, data[2:]
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order, data = binary.BigEndian, data[2:]
+	case looksLikeUTF16(data, 1):
+		// No BOM, but ASCII text with every other byte zero
+		order = binary.LittleEndian
+	case looksLikeUTF16(data, 0):
+		order = binary.BigEndian
+	default:
+		return data, false
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return []byte(string(utf16.Decode(units))), true
+}
+
+// looksLikeUTF16 reports whether data is BOM-less UTF-16 text in the ASCII
+// range: even length, and within the first few hundred bytes the byte at
+// zeroAt of every pair is zero while the other one is not.
+func looksLikeUTF16(data []byte, zeroAt int) bool {
+	if len(data) < 2 || len(data)%2 != 0 {
+		return false
+	}
+	for i := 0; i+1 < len(data) && i < 512; i += 2 {
+		if data[i+zeroAt] != 0 || data[i+1-zeroAt] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// readTextFile reads a file as UTF-8 text, converting UTF-16 and dropping
+// a UTF-8 byte order mark.
+func readTextFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, _ = decodeText(data)
+	return bytes.TrimPrefix(data, utf8BOM), nil
+}
+
+// textLayout remembers the byte order mark and line endings of a text file
+// so that a line-based rewrite can keep them.
+type textLayout struct {
+	BOM     bool
+	Newline string
+	// Converted is set when the file was UTF-16 and will be written as UTF-8
+	Converted bool
+}
+
+// splitTextFile decodes data and splits it into lines without terminators.
+func splitTextFile(data []byte) ([]string, textLayout) {
+	data, converted := decodeText(data)
+	layout := textLayout{Newline: "\n", Converted: converted}
+	if bytes.HasPrefix(data, utf8BOM) {
+		layout.BOM = true
+		data = data[len(utf8BOM):]
+	}
+	if i := bytes.IndexByte(data, '\n'); i > 0 && data[i-1] == '\r' {
+		layout.Newline = "\r\n"
+	}
+	return splitLines(string(data)), layout
+}
+
+// joinTextFile is the inverse of splitTextFile, always producing UTF-8 and
+// ending with a newline.
+func joinTextFile(lines []string, layout textLayout) []byte {
+	var buf bytes.Buffer
+	if layout.BOM {
+		buf.Write(utf8BOM)
+	}
+	buf.WriteString(strings.Join(lines, layout.Newline) + layout.Newline)
+	return buf.Bytes()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// utf16LE encodes s as UTF-16 little endian with a byte order mark.
+func utf16LE(s string) []byte {
+	out := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(u), byte(u>>8))
+	}
+	return out
+}
+
+func TestDecodeText(t *testing.T) {
+	got, converted := decodeText(utf16LE("APP_PORT=8051\r\n"))
+	if !converted || string(got) != "APP_PORT=8051\r\n" {
+		t.Errorf("Expected UTF-16LE to be converted, got %q (converted=%v)", got, converted)
+	}
+
+	be := []byte{0x00, 'A', 0x00, '=', 0x00, '1'}
+	if got, converted := decodeText(be); !converted || string(got) != "A=1" {
+		t.Errorf("Expected BOM-less UTF-16BE to be converted, got %q", got)
+	}
+
+	plain := append(append([]byte{}, utf8BOM...), "A=1\n"...)
+	if got, converted := decodeText(plain); converted || !bytes.Equal(got, plain) {
+		t.Errorf("Expected UTF-8 input to pass through, got %q", got)
+	}
+}
+
+func TestSplitJoinTextFileKeepsLayout(t *testing.T) {
+	input := "\uFEFFA=1\r\nB=2\r\n"
+	lines, layout := splitTextFile([]byte(input))
+	if len(lines) != 2 || lines[0] != "A=1" || !layout.BOM || layout.Newline != "\r\n" {
+		t.Fatalf("Unexpected split: %q %+v", lines, layout)
+	}
+	if got := string(joinTextFile(lines, layout)); got != input {
+		t.Errorf("Expected %q, got %q", input, got)
+	}
+}
+
+func TestExtractSuffixFromEnvEncodings(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-encoding-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	for name, data := range map[string][]byte{
+		"bom":    []byte("\uFEFFAPP_PORT=8051\r\n"),
+		"utf16":  utf16LE("APP_NAME=Shop\r\nAPP_PORT=8051\r\n"),
+		"crlf":   []byte("APP_PORT=8051\r\n"),
+		"nobom8": []byte("APP_PORT=8051"),
+	} {
+		os.WriteFile(envPath, data, 0644)
+		if suffix, ok := extractSuffixFromEnv(envPath); !ok || suffix != 51 {
+			t.Errorf("%s: expected suffix 51, got %d (found=%v)", name, suffix, ok)
+		}
+	}
+}
+
+func TestSetupEnvConvertsUTF16(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-encoding-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	os.WriteFile(envPath, utf16LE("APP_NAME=Shop\r\nAPP_PORT=8000\r\n"), 0644)
+	if err := setupEnv(tempDir, 52, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(envPath)
+	if _, converted := decodeText(data); converted {
+		t.Error("Expected .env to be written as UTF-8")
+	}
+	if !bytes.Contains(data, []byte("APP_NAME=Shop\r\n")) || !bytes.Contains(data, []byte("APP_PORT=8052\r\n")) {
+		t.Errorf("Expected CRLF lines with the new port, got %q", data)
+	}
+	if bytes.Count(data, []byte("APP_PORT=")) != 1 {
+		t.Errorf("Expected a single APP_PORT line, got %q", data)
+	}
+}
+
+func TestLoadComposeWithBOM(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-encoding-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	compose := "\uFEFFservices:\r\n  laravel.test:\r\n    build:\r\n      context: ./vendor/laravel/sail/runtimes/8.3\r\n"
+	os.WriteFile(filepath.Join(tempDir, "compose.yaml"), []byte(compose), 0644)
+	if got := detectPHPVersion(tempDir); got != "83" {
+		t.Errorf("Expected PHP 83 from a BOM/CRLF compose file, got %q", got)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "compose.yaml"), utf16LE(compose[3:]), 0644)
+	if got := detectPHPVersion(tempDir); got != "83" {
+		t.Errorf("Expected PHP 83 from a UTF-16 compose file, got %q", got)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// envBackupPrefix names the copies of .env taken before sailinit changes
+// it, e.g. .env.sailinit-bak-20250301-142501.
+const envBackupPrefix = ".env.sailinit-bak-"
+
+// maxEnvBackups is how many backups are kept per project; older ones are
+// removed.
+const maxEnvBackups = 5
+
+// envBackupIgnore keeps the backups, which hold the same secrets as .env,
+// out of git.
+const envBackupIgnore = "/" + envBackupPrefix + "*"
+
+// envBackedUp holds the .env files backed up by this command, so a setup
+// that writes .env several times keeps the version it started from.
+var envBackedUp = make(map[string]bool)
+
+// listEnvBackups returns the paths of projectDir's .env backups, oldest
+// first.
+func listEnvBackups(projectDir string) ([]string, error) {
+	backups, err := filepath.Glob(filepath.Join(projectDir, envBackupPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// backupEnvFile copies envPath next to itself before its first change in
+// this command, unless data is what it already holds. It returns the
+// backup's path, or "" when none was needed.
+func backupEnvFile(envPath string, data []byte, now time.Time) (string, error) {
+	if envBackedUp[envPath] {
+		return "", nil
+	}
+	current, err := os.ReadFile(envPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if bytes.Equal(current, data) {
+		return "", nil
+	}
+
+	dir := filepath.Dir(envPath)
+	backup := filepath.Join(dir, envBackupPrefix+now.Format("20060102-150405"))
+	for i := 2; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = filepath.Join(dir, fmt.Sprintf("%s%s-%d", envBackupPrefix, now.Format("20060102-150405"), i))
+	}
+	if err := os.WriteFile(backup, current, 0600); err != nil {
+		return "", err
+	}
+	envBackedUp[envPath] = true
+	ignoreEnvBackups(dir)
+
+	backups, err := listEnvBackups(dir)
+	if err != nil {
+		return backup, nil
+	}
+	for len(backups) > maxEnvBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return backup, nil
+}
+
+// ignoreEnvBackups adds the backups to the repository's local exclude
+// file, which unlike .gitignore is never committed. Projects that are not
+// git repositories are left alone.
+func ignoreEnvBackups(projectDir string) {
+	info, err := os.Stat(filepath.Join(projectDir, ".git", "info"))
+	if err != nil || !info.IsDir() {
+		return
+	}
+	path := filepath.Join(projectDir, ".git", "info", "exclude")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	if slices.Contains(strings.Split(string(data), "\n"), envBackupIgnore) {
+		return
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	os.WriteFile(path, append(data, envBackupIgnore+"\n"...), 0644)
+}
+
+// rollbackEnv restores .env from its newest backup and removes that
+// backup, so the next rollback goes one version further back.
+func rollbackEnv(projectDir, backup string) error {
+	data, err := os.ReadFile(backup)
+	if err != nil {
+		return err
+	}
+	if err := replaceFile(filepath.Join(projectDir, ".env"), data); err != nil {
+		return err
+	}
+	return os.Remove(backup)
+}
+
+func handleRollback(args []string) {
+	fset := commandFlags("rollback")
+	list := fset.Bool("list", false, "List the backups instead of restoring one")
+	yes := fset.Bool("yes", false, "Restore without asking")
+	fset.Parse(reorderArgs(args))
+	if fset.NArg() > 0 {
+		printError("Usage: sailinit rollback [--list] [--yes]")
+		exit(ExitUsage)
+	}
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	backups, err := listEnvBackups(projectDir)
+	if err != nil {
+		exitWithError("Error listing backups", err)
+	}
+	if len(backups) == 0 {
+		exitWithError("Nothing to roll back", usageError(errors.New("no .env backup in "+projectDir)))
+	}
+	if *list {
+		for _, b := range slices.Backward(backups) {
+			fmt.Println(filepath.Base(b))
+		}
+		return
+	}
+
+	backup := backups[len(backups)-1]
+	envPath := filepath.Join(projectDir, ".env")
+	current, err := os.ReadFile(envPath)
+	if err != nil && !os.IsNotExist(err) {
+		exitWithError("Error reading .env", envError(err))
+	}
+	previous, err := os.ReadFile(backup)
+	if err != nil {
+		exitWithError("Error reading backup", envError(err))
+	}
+	if diff := unifiedDiff(".env", string(current), string(previous)); diff != "" {
+		fmt.Print(colorDiff(diff))
+	}
+	if !*yes && !askYesNo(bufio.NewReader(os.Stdin), tr("rollback_confirm", filepath.Base(backup))) {
+		return
+	}
+
+	err = rollbackEnv(projectDir, backup)
+	recordHistory(projectDir, "rollback", filepath.Base(backup), err)
+	if err != nil {
+		exitWithError("Error restoring .env", envError(err))
+	}
+	printSuccess(fmt.Sprintf("Restored .env from %s.", filepath.Base(backup)))
+}
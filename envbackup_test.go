@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteEnvFileBacksUpOncePerCommand(t *testing.T) {
+	envBackedUp = make(map[string]bool)
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	os.WriteFile(envPath, []byte("APP_NAME=Shop\n"), 0644)
+
+	// Writing what is already there needs no backup
+	if err := writeEnvFile(envPath, []byte("APP_NAME=Shop\n")); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := listEnvBackups(dir); len(backups) != 0 {
+		t.Fatalf("Expected no backup for an unchanged .env, got %v", backups)
+	}
+
+	if err := setEnvValue(envPath, "APP_URL", "http://localhost:8051"); err != nil {
+		t.Fatal(err)
+	}
+	if err := setEnvValue(envPath, "APP_PORT", "8051"); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := listEnvBackups(dir)
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected one backup, got %v (err=%v)", backups, err)
+	}
+	data, _ := os.ReadFile(backups[0])
+	if string(data) != "APP_NAME=Shop\n" {
+		t.Errorf("Expected the backup to hold the original .env, got %q", data)
+	}
+	if info, _ := os.Stat(backups[0]); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the backup to be private, got %04o", info.Mode().Perm())
+	}
+}
+
+func TestBackupEnvFileKeepsNewest(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	start := time.Date(2025, 3, 1, 14, 0, 0, 0, time.UTC)
+	for i := range maxEnvBackups + 2 {
+		envBackedUp = make(map[string]bool)
+		os.WriteFile(envPath, []byte(fmt.Sprintf("V=%d\n", i)), 0644)
+		if _, err := backupEnvFile(envPath, []byte("changed\n"), start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, _ := listEnvBackups(dir)
+	if len(backups) != maxEnvBackups {
+		t.Fatalf("Expected %d backups, got %v", maxEnvBackups, backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "V=2\n" {
+		t.Errorf("Expected the oldest backups to be removed, oldest left is %q", data)
+	}
+}
+
+func TestBackupEnvFileIgnoredByGit(t *testing.T) {
+	envBackedUp = make(map[string]bool)
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".git", "info"), 0755)
+	exclude := filepath.Join(dir, ".git", "info", "exclude")
+	os.WriteFile(exclude, []byte("# git ls-files --others --exclude-from=.git/info/exclude"), 0644)
+	envPath := filepath.Join(dir, ".env")
+	os.WriteFile(envPath, []byte("A=1\n"), 0644)
+
+	now := time.Now()
+	backupEnvFile(envPath, []byte("A=2\n"), now)
+	envBackedUp = make(map[string]bool)
+	backupEnvFile(envPath, []byte("A=3\n"), now)
+
+	data, _ := os.ReadFile(exclude)
+	if strings.Count(string(data), envBackupIgnore) != 1 || !strings.HasSuffix(string(data), "\n"+envBackupIgnore+"\n") {
+		t.Errorf("Expected the backups to be excluded once, got %q", data)
+	}
+	if backups, _ := listEnvBackups(dir); len(backups) != 2 || !strings.HasSuffix(backups[1], "-2") {
+		t.Errorf("Expected backups in the same second to get distinct names, got %v", backups)
+	}
+}
+
+func TestRollbackEnv(t *testing.T) {
+	envBackedUp = make(map[string]bool)
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	os.WriteFile(envPath, []byte("DB_DATABASE=shop\n"), 0600)
+	if err := writeEnvFile(envPath, []byte("DB_DATABASE=laravel\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := listEnvBackups(dir)
+	if len(backups) != 1 {
+		t.Fatalf("Expected a backup, got %v", backups)
+	}
+	if err := rollbackEnv(dir, backups[0]); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(envPath); string(data) != "DB_DATABASE=shop\n" {
+		t.Errorf("Expected the original .env back, got %q", data)
+	}
+	if info, _ := os.Stat(envPath); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the mode of .env to be kept, got %04o", info.Mode().Perm())
+	}
+	if backups, _ := listEnvBackups(dir); len(backups) != 0 {
+		t.Errorf("Expected the restored backup to be removed, got %v", backups)
+	}
+}
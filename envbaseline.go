@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envBaseline is a company-standard .env.example kept at URL, a remote
+// address or a local path, and pinned by its SHA-256 checksum.
+type envBaseline struct {
+	URL    string `yaml:"url"`
+	SHA256 string `yaml:"sha256"`
+}
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// validate checks that a configured baseline is pinned by a checksum.
+func (b envBaseline) validate() error {
+	if b.URL == "" {
+		if b.SHA256 != "" {
+			return errors.New("env_baseline: sha256 is set but url is not")
+		}
+		return nil
+	}
+	sum := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(b.SHA256), "sha256:"))
+	if !sha256Pattern.MatchString(sum) {
+		return errors.New("env_baseline: sha256 must be the 64-digit hex checksum of the file at url")
+	}
+	return nil
+}
+
+// bootstrapEnvExample writes the configured baseline to path once its
+// checksum matches. Without a baseline it only warns, and the caller
+// starts from an empty .env.
+func bootstrapEnvExample(path string) error {
+	cfg, err := loadUserConfig()
+	if err != nil {
+		return err
+	}
+	baseline := cfg.EnvBaseline
+	if baseline.URL == "" {
+		printWarning(tr("env_empty"))
+		return nil
+	}
+	printInfo(tr("env_baseline_fetch", baseline.URL))
+	data, err := fetchSource(baseline.URL, "env_baseline")
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(bytes.NewReader(data), baseline.SHA256); err != nil {
+		return envError(fmt.Errorf("env baseline %s: %w (update env_baseline.sha256 if the baseline changed on purpose)", baseline.URL, err))
+	}
+	data, _ = decodeText(data)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	printSuccess(tr("env_baseline_written"))
+	return nil
+}
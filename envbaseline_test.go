@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testBaseline = "APP_NAME=Acme\nLOG_CHANNEL=stderr\n"
+
+func baselineServer(t *testing.T) (string, string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testBaseline)
+	}))
+	t.Cleanup(server.Close)
+	sum := sha256.Sum256([]byte(testBaseline))
+	return server.URL + "/env.example", hex.EncodeToString(sum[:])
+}
+
+func TestEnvBaselineValidate(t *testing.T) {
+	if err := (envBaseline{}).validate(); err != nil {
+		t.Errorf("Expected no baseline to be valid, got %v", err)
+	}
+	if err := (envBaseline{URL: "https://example.com/.env.example"}).validate(); err == nil {
+		t.Error("Expected a baseline without a checksum to be rejected")
+	}
+	sum := strings.Repeat("ab", 32)
+	if err := (envBaseline{URL: "https://example.com/.env.example", SHA256: "sha256:" + sum}).validate(); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestSetupEnvBootstrapsFromBaseline(t *testing.T) {
+	url, sum := baselineServer(t)
+	writeTestConfig(t, fmt.Sprintf("env_baseline:\n  url: %s\n  sha256: %s\n", url, sum))
+	dir := t.TempDir()
+
+	if err := setupEnv(dir, 51, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	example, _ := os.ReadFile(filepath.Join(dir, ".env.example"))
+	if string(example) != testBaseline {
+		t.Errorf("Expected the baseline as .env.example, got %q", example)
+	}
+	env := readEnvValues(filepath.Join(dir, ".env"))
+	if env["APP_NAME"] != "Acme" || env["APP_PORT"] != "8051" {
+		t.Errorf("Expected .env built from the baseline, got %v", env)
+	}
+}
+
+func TestSetupEnvRejectsBaselineChecksumMismatch(t *testing.T) {
+	url, _ := baselineServer(t)
+	writeTestConfig(t, fmt.Sprintf("env_baseline:\n  url: %s\n  sha256: %s\n", url, strings.Repeat("0", 64)))
+	dir := t.TempDir()
+
+	err := setupEnv(dir, 51, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Expected a checksum mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".env.example")); !os.IsNotExist(err) {
+		t.Error("Expected no .env.example from an unverified baseline")
+	}
+}
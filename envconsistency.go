@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// envIssue is an .env setting that does not fit the project's services.
+// Fix is the value that resolves it, or empty when there is no safe fix.
+type envIssue struct {
+	Key     string
+	Current string
+	Fix     string
+	Reason  string
+}
+
+// dbContainerPorts maps DB_CONNECTION to the port the database listens on
+// inside its container.
+var dbContainerPorts = map[string]int{
+	"mysql":   3306,
+	"mariadb": 3306,
+	"pgsql":   5432,
+	"sqlsrv":  1433,
+}
+
+// forwardedPortCheck describes a host/port pair that containers use to
+// reach a service, and the host port Sail forwards it to.
+type forwardedPortCheck struct {
+	HostKey    string
+	PortKey    string
+	ForwardKey string
+	// container returns the in-container port, or 0 when unknown
+	container func(env map[string]string) int
+}
+
+var forwardedPortChecks = []forwardedPortCheck{
+	{"DB_HOST", "DB_PORT", "FORWARD_DB_PORT", func(env map[string]string) int {
+		return dbContainerPorts[dbConnection(env)]
+	}},
+	{"REDIS_HOST", "REDIS_PORT", "FORWARD_REDIS_PORT", func(map[string]string) int { return 6379 }},
+}
+
+// driverChecks lists the env keys that select a backend, and the compose
+// service each backend needs.
+var driverChecks = []string{"CACHE_STORE", "CACHE_DRIVER", "SESSION_DRIVER", "QUEUE_CONNECTION"}
+
+var driverServices = map[string]string{
+	"redis":      "redis",
+	"memcached":  "memcached",
+	"beanstalkd": "beanstalkd",
+}
+
+func dbConnection(env map[string]string) string {
+	if c := env["DB_CONNECTION"]; c != "" {
+		return c
+	}
+	return "mysql"
+}
+
+func isLocalHost(host string) bool {
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// envConsistencyIssues checks env against the compose services (nil when
+// there is no compose file) and the forwarded host ports of suffix (-1 when
+// unknown). Sail containers reach services by name on their own ports; the
+// forwarded ports only work from the host.
+func envConsistencyIssues(env map[string]string, services []string, suffix int) []envIssue {
+	var issues []envIssue
+
+	for _, c := range forwardedPortChecks {
+		host := env[c.HostKey]
+		if host == "" || isLocalHost(host) {
+			continue
+		}
+		want := c.container(env)
+		port, err := strconv.Atoi(env[c.PortKey])
+		if want == 0 || err != nil || port == want {
+			continue
+		}
+		forwarded := []int{}
+		if suffix >= 0 {
+			forwarded = append(forwarded, basePort(c.ForwardKey)+suffix)
+		}
+		if p, err := strconv.Atoi(env[c.ForwardKey]); err == nil {
+			forwarded = append(forwarded, p)
+		}
+		if slices.Contains(forwarded, port) {
+			issues = append(issues, envIssue{
+				Key: c.PortKey, Current: env[c.PortKey], Fix: strconv.Itoa(want),
+				Reason: fmt.Sprintf("%s=%d is the forwarded host port; containers reach %s on %d", c.PortKey, port, host, want),
+			})
+		}
+	}
+
+	if services == nil {
+		return issues
+	}
+
+	// Hosts must name a compose service for containers to resolve them
+	hostServices := map[string]string{"REDIS_HOST": "redis"}
+	if c := dbConnection(env); dbContainerPorts[c] != 0 {
+		hostServices["DB_HOST"] = c
+	}
+	for _, key := range []string{"DB_HOST", "REDIS_HOST"} {
+		service, ok := hostServices[key]
+		host := env[key]
+		if !ok || host == "" {
+			continue
+		}
+		if isLocalHost(host) && slices.Contains(services, service) {
+			issues = append(issues, envIssue{
+				Key: key, Current: host, Fix: service,
+				Reason: fmt.Sprintf("%s=%s points inside the app container; the %s service is reachable as %q", key, host, service, service),
+			})
+		}
+	}
+
+	for _, key := range driverChecks {
+		driver := env[key]
+		service, ok := driverServices[driver]
+		if !ok || slices.Contains(services, service) {
+			continue
+		}
+		// An external Redis server is fine
+		if driver == "redis" && env["REDIS_HOST"] != "" && !isLocalHost(env["REDIS_HOST"]) && env["REDIS_HOST"] != "redis" {
+			continue
+		}
+		issues = append(issues, envIssue{
+			Key: key, Current: driver, Fix: "database",
+			Reason: fmt.Sprintf("%s=%s but compose has no %s service", key, driver, service),
+		})
+	}
+	return issues
+}
+
+// projectEnvIssues runs envConsistencyIssues for projectDir, using the
+// registered suffix when there is one.
+func projectEnvIssues(projectDir string, suffix int) []envIssue {
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	var services []string
+	if project, err := loadCompose(projectDir); err == nil && project != nil {
+		services = project.ServiceNames()
+	}
+	return envConsistencyIssues(env, services, suffix)
+}
+
+func checkEnvServices(projectDir string) []string {
+	suffix := -1
+	if s, registered, _, err := getSuggestedSuffix(projectDir); err == nil && registered {
+		suffix = s
+	}
+	var problems []string
+	for _, issue := range projectEnvIssues(projectDir, suffix) {
+		problem := issue.Reason
+		if issue.Fix != "" {
+			problem += fmt.Sprintf(" (fix: %s=%s)", issue.Key, issue.Fix)
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
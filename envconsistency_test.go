@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func issueKeys(issues []envIssue) string {
+	var parts []string
+	for _, i := range issues {
+		parts = append(parts, i.Key+"="+i.Fix)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestEnvConsistencyForwardedPorts(t *testing.T) {
+	cases := []struct {
+		name   string
+		env    map[string]string
+		suffix int
+		want   string
+	}{
+		{"forward port in DB_PORT", map[string]string{"DB_HOST": "mysql", "DB_PORT": "3355", "FORWARD_DB_PORT": "3355"}, -1, "DB_PORT=3306"},
+		{"suffix port without FORWARD_DB_PORT", map[string]string{"DB_HOST": "mysql", "DB_PORT": "3355"}, 55, "DB_PORT=3306"},
+		{"pgsql", map[string]string{"DB_CONNECTION": "pgsql", "DB_HOST": "pgsql", "DB_PORT": "3355"}, 55, "DB_PORT=5432"},
+		{"correct port", map[string]string{"DB_HOST": "mysql", "DB_PORT": "3306", "FORWARD_DB_PORT": "3355"}, -1, ""},
+		{"host access", map[string]string{"DB_HOST": "127.0.0.1", "DB_PORT": "3355", "FORWARD_DB_PORT": "3355"}, -1, ""},
+		{"unrelated custom port", map[string]string{"DB_HOST": "db", "DB_PORT": "3307", "FORWARD_DB_PORT": "3355"}, -1, ""},
+		{"sqlite", map[string]string{"DB_CONNECTION": "sqlite", "DB_HOST": "x", "DB_PORT": "3355"}, 55, ""},
+		{"redis", map[string]string{"REDIS_HOST": "redis", "REDIS_PORT": "6355"}, 55, "REDIS_PORT=6379"},
+	}
+	for _, c := range cases {
+		if got := issueKeys(envConsistencyIssues(c.env, nil, c.suffix)); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestEnvConsistencyServices(t *testing.T) {
+	services := []string{"laravel.test", "mysql", "redis"}
+
+	env := map[string]string{"DB_HOST": "127.0.0.1", "REDIS_HOST": "127.0.0.1", "CACHE_STORE": "redis"}
+	if got := issueKeys(envConsistencyIssues(env, services, -1)); got != "DB_HOST=mysql,REDIS_HOST=redis" {
+		t.Errorf("Expected host fixes, got %q", got)
+	}
+
+	env = map[string]string{"DB_HOST": "mysql", "CACHE_STORE": "redis", "SESSION_DRIVER": "memcached", "QUEUE_CONNECTION": "database"}
+	if got := issueKeys(envConsistencyIssues(env, []string{"laravel.test", "mysql"}, -1)); got != "CACHE_STORE=database,SESSION_DRIVER=database" {
+		t.Errorf("Expected driver fixes, got %q", got)
+	}
+
+	env = map[string]string{"REDIS_HOST": "cache.internal", "QUEUE_CONNECTION": "redis"}
+	if issues := envConsistencyIssues(env, []string{"laravel.test"}, -1); len(issues) != 0 {
+		t.Errorf("Expected external Redis to be accepted, got %v", issues)
+	}
+
+	// Without a compose file only the port checks apply
+	env = map[string]string{"DB_HOST": "127.0.0.1", "CACHE_STORE": "redis"}
+	if issues := envConsistencyIssues(env, nil, -1); len(issues) != 0 {
+		t.Errorf("Expected no service checks without compose, got %v", issues)
+	}
+}
+
+func TestCheckEnvServices(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(projectDir, 0755)
+	os.WriteFile(filepath.Join(projectDir, "compose.yaml"), []byte("services:\n  laravel.test:\n    image: sail\n  mysql:\n    image: mysql\n"), 0644)
+	os.WriteFile(filepath.Join(projectDir, ".env"), []byte("DB_HOST=mysql\nDB_PORT=3355\nSESSION_DRIVER=redis\n"), 0644)
+
+	problems := checkEnvServices(projectDir)
+	if len(problems) != 1 || !strings.Contains(problems[0], "SESSION_DRIVER=database") {
+		t.Errorf("Expected only the session driver problem before registering, got %v", problems)
+	}
+
+	if err := saveProjectSuffix(projectDir, 55); err != nil {
+		t.Fatal(err)
+	}
+	problems = checkEnvServices(projectDir)
+	if len(problems) != 2 || !strings.Contains(problems[0], "DB_PORT=3306") {
+		t.Errorf("Expected DB_PORT and session driver problems, got %v", problems)
+	}
+}
+
+func TestApplySetupPlanEnvFixes(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	sailDir := filepath.Join(projectDir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	os.WriteFile(filepath.Join(sailDir, "sail"), []byte("#!/bin/sh\nexit 0\n"), 0755)
+	envPath := filepath.Join(projectDir, ".env")
+	os.WriteFile(envPath, []byte("APP_KEY=base64:abc\nDB_HOST=mysql\nDB_PORT=3351\nREDIS_HOST=127.0.0.1\n"), 0644)
+
+	plan := &setupPlan{
+		Version: planVersion, ProjectDir: projectDir, PHPVersion: "84", Suffix: 51,
+		EnvFixes: []envEntry{{Key: "DB_PORT", Value: "3306"}, {Key: "REDIS_HOST", Value: "redis"}},
+	}
+	if err := applySetupPlan(nil, plan, false); err != nil {
+		t.Fatal(err)
+	}
+	values := readEnvValues(envPath)
+	if values["DB_PORT"] != "3306" || values["REDIS_HOST"] != "redis" {
+		t.Errorf("Expected fixes to be applied, got %v", values)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines surround each change in a diff.
+const diffContext = 3
+
+// errEnvDeclined is returned when the user declines the previewed .env
+// changes.
+var errEnvDeclined = errors.New("the .env changes were declined; .env was left unchanged")
+
+// diffLine is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffLine struct {
+	Op   byte
+	Text string
+}
+
+// diffLines returns the shortest edit script turning a into b, from their
+// longest common subsequence. Env files are small enough for the quadratic
+// table.
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var script []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			script = append(script, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			script = append(script, diffLine{'-', a[i]})
+			i++
+		default:
+			script = append(script, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		script = append(script, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		script = append(script, diffLine{'+', b[j]})
+	}
+	return script
+}
+
+// diffTextLines splits text into lines without their terminators.
+func diffTextLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// unifiedDiff renders the changes from before to after as a unified diff of
+// name, or "" when there are none.
+func unifiedDiff(name, before, after string) string {
+	script := diffLines(diffTextLines(before), diffTextLines(after))
+
+	var b strings.Builder
+	for start := 0; start < len(script); {
+		// Find the next change and extend the hunk while changes are
+		// close enough for their context to overlap
+		first := start
+		for first < len(script) && script[first].Op == ' ' {
+			first++
+		}
+		if first == len(script) {
+			break
+		}
+		from := max(first-diffContext, start)
+		to := first
+		for i := first; i < len(script); i++ {
+			if script[i].Op != ' ' {
+				to = i + 1
+			} else if i-to >= 2*diffContext {
+				break
+			}
+		}
+		to = min(to+diffContext, len(script))
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", name, name)
+		}
+		oldStart, newStart := 1, 1
+		for _, l := range script[:from] {
+			if l.Op != '+' {
+				oldStart++
+			}
+			if l.Op != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, l := range script[from:to] {
+			if l.Op != '+' {
+				oldCount++
+			}
+			if l.Op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, l := range script[from:to] {
+			fmt.Fprintf(&b, "%c%s\n", l.Op, l.Text)
+		}
+		start = to
+	}
+	return b.String()
+}
+
+// hunkRange formats a hunk's start and length the way diff -u does: an
+// empty range starts at the line before it.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// colorDiff colors removed lines red, added lines green and hunk headers
+// cyan.
+func colorDiff(diff string) string {
+	var b strings.Builder
+	for _, line := range diffTextLines(diff) {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			line = colorize(colorBold, line)
+		case strings.HasPrefix(line, "@@"):
+			line = colorize(colorCyan, line)
+		case strings.HasPrefix(line, "-"):
+			line = colorize(colorRed, line)
+		case strings.HasPrefix(line, "+"):
+			line = colorize(colorGreen, line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// confirmEnvChanges shows the changes about to be written to .env and asks
+// whether to write them. Without changes, or without a reader, it doesn't
+// ask. --yes confirms; under --no-input the diff is only shown, since
+// nobody can answer.
+func confirmEnvChanges(reader *bufio.Reader, before, after []byte) bool {
+	diff := unifiedDiff(".env", string(before), string(after))
+	if diff == "" || reader == nil {
+		return true
+	}
+	printInfo(tr("env_preview"))
+	fmt.Print(colorDiff(diff))
+	if noInput && !assumeYes {
+		return true
+	}
+	return askYesNo(reader, tr("env_write_confirm"))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "A=1\nB=2\nC=3\nD=4\nE=5\nF=6\nG=7\nH=8\nI=9\nJ=10\nK=11\n"
+	after := "A=1\nB=two\nC=3\nD=4\nE=5\nF=6\nG=7\nH=8\nI=9\nJ=10\nK=11\nL=12\n"
+	want := "--- .env\n+++ .env\n" +
+		"@@ -1,5 +1,5 @@\n A=1\n-B=2\n+B=two\n C=3\n D=4\n E=5\n" +
+		"@@ -9,3 +9,4 @@\n I=9\n J=10\n K=11\n+L=12\n"
+	if got := unifiedDiff(".env", before, after); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffMergesNearbyChanges(t *testing.T) {
+	got := unifiedDiff(".env", "A=1\nB=2\nC=3\nD=4\n", "A=one\nB=2\nC=3\nD=four\n")
+	if strings.Count(got, "@@ ") != 1 || !strings.Contains(got, "@@ -1,4 +1,4 @@") {
+		t.Errorf("Expected one hunk, got:\n%s", got)
+	}
+}
+
+func TestUnifiedDiffWithoutChanges(t *testing.T) {
+	if got := unifiedDiff(".env", "A=1\r\nB=2\r\n", "A=1\nB=2\n"); got != "" {
+		t.Errorf("Expected no diff, got:\n%s", got)
+	}
+	if got := unifiedDiff(".env", "", "A=1\n"); got != "--- .env\n+++ .env\n@@ -0,0 +1 @@\n+A=1\n" {
+		t.Errorf("Unexpected diff for an empty file:\n%s", got)
+	}
+}
+
+func TestSetupEnvAsksBeforeWriting(t *testing.T) {
+	tempDir := t.TempDir()
+	envPath := filepath.Join(tempDir, ".env")
+	initial := "APP_NAME=Shop\nDB_DATABASE=shop\n"
+	if err := os.WriteFile(envPath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := setupEnv(tempDir, 55, allDBResetGroups(), bufio.NewReader(strings.NewReader("n\n")))
+	if !errors.Is(err, errEnvDeclined) {
+		t.Fatalf("Expected the declined changes to be reported, got %v", err)
+	}
+	if data, _ := os.ReadFile(envPath); string(data) != initial {
+		t.Errorf("Expected .env to be left unchanged, got:\n%s", data)
+	}
+
+	if err := setupEnv(tempDir, 55, allDBResetGroups(), bufio.NewReader(strings.NewReader("y\n"))); err != nil {
+		t.Fatal(err)
+	}
+	if values := readEnvValues(envPath); values["DB_DATABASE"] != "laravel" || values["APP_PORT"] != "8055" {
+		t.Errorf("Expected the confirmed changes to be written, got %v", values)
+	}
+
+	// Nothing left to change: no question is asked
+	if err := setupEnv(tempDir, 55, nil, bufio.NewReader(strings.NewReader(""))); err != nil {
+		t.Errorf("Expected an unchanged .env to need no answer, got %v", err)
+	}
+}
+
+func TestConfirmEnvChangesUnderYes(t *testing.T) {
+	assumeYes = true
+	defer func() { assumeYes = false }()
+	if !confirmEnvChanges(bufio.NewReader(strings.NewReader("")), []byte("A=1\n"), []byte("A=2\n")) {
+		t.Error("Expected --yes to confirm the changes")
+	}
+}
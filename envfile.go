@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// envEntry is a single KEY=value assignment from an env file. Value is kept
+// exactly as written, including any surrounding quotes.
+type envEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// envLine is one logical line of an env file. Raw holds its exact text,
+// including the line terminator; a quoted value spanning several physical
+// lines is a single envLine. Key is empty for blanks, comments and lines
+// that are not assignments.
+type envLine struct {
+	Raw    string
+	Key    string
+	Value  string
+	Export bool
+}
+
+// envDocument is a parsed env file that serializes back byte for byte, so
+// edits touch only the lines they change.
+type envDocument struct {
+	BOM     bool
+	Newline string
+	Lines   []envLine
+	// Converted is set when the input was UTF-16
+	Converted bool
+}
+
+// parseEnvDocument parses env file contents. It never fails: anything it
+// does not understand is kept as an opaque line. UTF-16 input is converted
+// to UTF-8, which is what docker compose and Laravel read.
+func parseEnvDocument(data []byte) *envDocument {
+	doc := &envDocument{Newline: "\n"}
+	data, doc.Converted = decodeText(data)
+	if bytes.HasPrefix(data, utf8BOM) {
+		doc.BOM = true
+		data = data[len(utf8BOM):]
+	}
+	s := string(data)
+	if i := strings.IndexByte(s, '\n'); i > 0 && s[i-1] == '\r' {
+		doc.Newline = "\r\n"
+	}
+
+	for len(s) > 0 {
+		line, rest := cutPhysicalLine(s)
+		entry := parseEnvAssignment(line)
+		if quote, open := openQuote(entry.Value); open {
+			// Extend the value over following lines up to its closing quote
+			for scan := rest; len(scan) > 0; {
+				next, after := cutPhysicalLine(scan)
+				if closesQuote(next, quote) {
+					line = s[:len(s)-len(after)]
+					rest = after
+					entry = parseEnvAssignment(line)
+					break
+				}
+				scan = after
+			}
+		}
+		entry.Raw = line
+		doc.Lines = append(doc.Lines, entry)
+		s = rest
+	}
+	return doc
+}
+
+// cutPhysicalLine splits s after its first newline.
+func cutPhysicalLine(s string) (string, string) {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i+1], s[i+1:]
+	}
+	return s, ""
+}
+
+func parseEnvAssignment(raw string) envLine {
+	line := strings.TrimSpace(raw)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return envLine{}
+	}
+	export := false
+	if rest, ok := strings.CutPrefix(line, "export "); ok {
+		export = true
+		line = strings.TrimSpace(rest)
+	}
+	key, val, ok := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" || strings.ContainsAny(key, " \t\r\n") {
+		return envLine{}
+	}
+	return envLine{Key: key, Value: strings.TrimSpace(val), Export: export}
+}
+
+// openQuote reports whether val starts a quoted string that is not closed
+// on the same line, and which quote character it uses.
+func openQuote(val string) (byte, bool) {
+	if val == "" || (val[0] != '"' && val[0] != '\'') {
+		return 0, false
+	}
+	return val[0], !closesQuote(val[1:], val[0])
+}
+
+// closesQuote reports whether s contains an unescaped closing quote.
+func closesQuote(s string, quote byte) bool {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes serializes the document, reproducing the original input exactly
+// when nothing was changed.
+func (d *envDocument) Bytes() []byte {
+	var buf bytes.Buffer
+	if d.BOM {
+		buf.Write(utf8BOM)
+	}
+	for _, l := range d.Lines {
+		buf.WriteString(l.Raw)
+	}
+	return buf.Bytes()
+}
+
+// Entries returns the assignments in file order.
+func (d *envDocument) Entries() []envEntry {
+	var entries []envEntry
+	for _, l := range d.Lines {
+		if l.Key != "" {
+			entries = append(entries, envEntry{Key: l.Key, Value: l.Value})
+		}
+	}
+	return entries
+}
+
+// Get returns the value of the first assignment of key, as written.
+func (d *envDocument) Get(key string) (string, bool) {
+	for _, l := range d.Lines {
+		if l.Key == key {
+			return l.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the first assignment of key in place, keeping an export
+// prefix and the line ending, or inserts it above the port block.
+func (d *envDocument) Set(key, value string) {
+	for i, l := range d.Lines {
+		if l.Key != key {
+			continue
+		}
+		text := fmt.Sprintf("%s=%s", key, value)
+		if l.Export {
+			text = "export " + text
+		}
+		d.Lines[i] = envLine{Raw: text + lineEnding(l.Raw), Key: key, Value: value, Export: l.Export}
+		return
+	}
+	d.Insert([]string{fmt.Sprintf("%s=%s", key, value)})
+}
+
+// Comment turns every assignment of key into a comment, the way Laravel's
+// .env.example lists settings that are not in use. It returns false when
+// key is not assigned.
+func (d *envDocument) Comment(key string) bool {
+	found := false
+	for i, l := range d.Lines {
+		if l.Key == key {
+			d.Lines[i] = envLine{Raw: "# " + l.Raw}
+			found = true
+		}
+	}
+	return found
+}
+
+// Insert adds raw lines just above the sailinit-managed port block, or at
+// the end when there is none.
+func (d *envDocument) Insert(added []string) {
+	insertAt := len(d.Lines)
+	for i, l := range d.Lines {
+		if l.Key == "APP_PORT" {
+			insertAt = i
+			if i > 0 && strings.TrimSpace(d.Lines[i-1].Raw) == "" {
+				insertAt = i - 1
+			}
+			break
+		}
+	}
+	if insertAt == len(d.Lines) && insertAt > 0 && lineEnding(d.Lines[insertAt-1].Raw) == "" {
+		d.Lines[insertAt-1].Raw += d.Newline
+	}
+
+	d.Lines = slices.Insert(d.Lines, insertAt, d.newLines(added)...)
+}
+
+// InsertAfter adds raw lines right after the first assignment of anchor.
+// It returns false when anchor is not in the document.
+func (d *envDocument) InsertAfter(anchor string, added []string) bool {
+	for i, l := range d.Lines {
+		if l.Key != anchor {
+			continue
+		}
+		if lineEnding(l.Raw) == "" {
+			d.Lines[i].Raw += d.Newline
+		}
+		d.Lines = slices.Insert(d.Lines, i+1, d.newLines(added)...)
+		return true
+	}
+	return false
+}
+
+// InsertBefore adds raw lines right before the first assignment of anchor.
+// It returns false when anchor is not in the document.
+func (d *envDocument) InsertBefore(anchor string, added []string) bool {
+	i := slices.IndexFunc(d.Lines, func(l envLine) bool { return l.Key == anchor })
+	if i < 0 {
+		return false
+	}
+	d.Lines = slices.Insert(d.Lines, i, d.newLines(added)...)
+	return true
+}
+
+// AppendBlock adds raw lines at the end of the document, separated from
+// what comes before by a blank line.
+func (d *envDocument) AppendBlock(added []string) {
+	if n := len(d.Lines); n > 0 {
+		if lineEnding(d.Lines[n-1].Raw) == "" {
+			d.Lines[n-1].Raw += d.Newline
+		}
+		if strings.TrimSpace(d.Lines[n-1].Raw) != "" {
+			added = append([]string{""}, added...)
+		}
+	}
+	d.Lines = append(d.Lines, d.newLines(added)...)
+}
+
+// Remove deletes every assignment of key. It returns false when key is not
+// assigned.
+func (d *envDocument) Remove(key string) bool {
+	n := len(d.Lines)
+	d.Lines = slices.DeleteFunc(d.Lines, func(l envLine) bool { return l.Key == key })
+	return len(d.Lines) < n
+}
+
+// newLines parses raw lines for insertion, ending each with the document's
+// line ending.
+func (d *envDocument) newLines(added []string) []envLine {
+	var lines []envLine
+	for _, text := range added {
+		l := parseEnvAssignment(text)
+		l.Raw = text + d.Newline
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func lineEnding(raw string) string {
+	switch {
+	case strings.HasSuffix(raw, "\r\n"):
+		return "\r\n"
+	case strings.HasSuffix(raw, "\n"):
+		return "\n"
+	}
+	return ""
+}
+
+func readEnvDocument(envPath string) (*envDocument, error) {
+	data, err := os.ReadFile(envPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseEnvDocument(data), nil
+}
+
+func (d *envDocument) write(envPath string) error {
+	return writeEnvFile(envPath, d.Bytes())
+}
+
+// readEnvEntries returns the assignments of an env file in file order,
+// skipping comments and blank lines.
+func readEnvEntries(envPath string) ([]envEntry, error) {
+	doc, err := readEnvDocument(envPath)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Entries(), nil
+}
+
+// readEnvValues returns the KEY=value pairs of an env file, with surrounding
+// quotes stripped. A missing file yields an empty map.
+func readEnvValues(envPath string) map[string]string {
+	values := make(map[string]string)
+	entries, err := readEnvEntries(envPath)
+	if err != nil {
+		return values
+	}
+	for _, e := range entries {
+		values[e.Key] = unquoteEnvValue(e.Value)
+	}
+	return values
+}
+
+func unquoteEnvValue(val string) string {
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
+
+// missingExampleKeys returns the entries of .env.example whose keys are not
+// present in .env, in example order. Locked keys and the forwarded ports
+// sailinit manages are never reported, since setup only writes the ports
+// of the services the compose file runs; neither are the server settings
+// of a SQLite project.
+func missingExampleKeys(envPath, examplePath string) ([]envEntry, error) {
+	example, err := readEnvEntries(examplePath)
+	if err != nil {
+		return nil, err
+	}
+	present := readEnvValues(envPath)
+	locks := loadEnvLocks()
+	// A SQLite connection does without the server settings
+	sqlite := present["DB_CONNECTION"] == dbSQLite
+
+	var missing []envEntry
+	seen := make(map[string]bool)
+	for _, e := range example {
+		if _, ok := present[e.Key]; ok || seen[e.Key] || locks.locked(e.Key) || isManagedPortKey(e) {
+			continue
+		}
+		if sqlite && slices.Contains(sqliteUnusedKeys, e.Key) {
+			continue
+		}
+		seen[e.Key] = true
+		missing = append(missing, e)
+	}
+	return missing, nil
+}
+
+// appendEnvEntries adds entries to the env file, keeping them above the
+// sailinit-managed port block when one exists.
+func appendEnvEntries(envPath string, entries []envEntry) error {
+	return insertEnvLines(envPath, formatEnvEntries(entries))
+}
+
+func formatEnvEntries(entries []envEntry) []string {
+	var lines []string
+	for _, e := range entries {
+		lines = append(lines, fmt.Sprintf("%s=%s", e.Key, e.Value))
+	}
+	return lines
+}
+
+// insertEnvLines inserts raw lines into the env file just above the
+// sailinit-managed port block, or at the end when there is none.
+func insertEnvLines(envPath string, added []string) error {
+	doc, err := readEnvDocument(envPath)
+	if err != nil {
+		return err
+	}
+	doc.Insert(added)
+	return doc.write(envPath)
+}
+
+// setEnvValue sets key to value in the env file, replacing the existing
+// assignment in place or inserting it above the port block.
+func setEnvValue(envPath, key, value string) error {
+	doc, err := readEnvDocument(envPath)
+	if err != nil {
+		return err
+	}
+	current, _ := doc.Get(key)
+	if loadEnvLocks().skip(key, current, value) {
+		return nil
+	}
+	doc.Set(key, value)
+	return doc.write(envPath)
+}
+
+func handleEnv(args []string) {
+	if helpRequested("env", args) {
+		return
+	}
+	if len(args) == 0 || (args[0] != "sync-example" && args[0] != "tighten") {
+		printError("Usage: sailinit env <sync-example|tighten>")
+		exit(ExitUsage)
+	}
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+
+	if args[0] == "tighten" {
+		if err := tightenEnvPermissions(filepath.Join(projectDir, ".env")); err != nil {
+			exitWithError("Error changing .env permissions", envError(err))
+		}
+		printSuccess(".env is now only readable by its owner (0600).")
+		return
+	}
+
+	added, err := syncExampleKeys(projectDir, time.Now())
+	if err != nil {
+		exitWithError("Error syncing .env.example", envError(err))
+	}
+	if len(added) == 0 {
+		printSuccess(".env already contains every key from .env.example.")
+		return
+	}
+	for _, e := range added {
+		printInfo(fmt.Sprintf("  + %s=%s", e.Key, e.Value))
+	}
+	printSuccess(fmt.Sprintf("Added %d key(s) from .env.example.", len(added)))
+}
+
+// syncExampleKeys merges keys that exist only in .env.example into .env,
+// under a comment recording where and when they came from. Existing values
+// and the sailinit-managed port block are left untouched.
+func syncExampleKeys(projectDir string, now time.Time) ([]envEntry, error) {
+	envPath := filepath.Join(projectDir, ".env")
+	examplePath := filepath.Join(projectDir, ".env.example")
+
+	if _, err := os.Stat(envPath); err != nil {
+		return nil, fmt.Errorf(".env not found in %s", projectDir)
+	}
+
+	missing, err := missingExampleKeys(envPath, examplePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	lines := []string{"", fmt.Sprintf("# Added from .env.example by sailinit on %s", now.Format("2006-01-02"))}
+	lines = append(lines, formatEnvEntries(missing)...)
+	if err := insertEnvLines(envPath, lines); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// portEnvValues returns the .env values of the port keys of bases.
+func portEnvValues(projectDir string, bases []PortBase) map[string]string {
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	values := make(map[string]string, len(bases))
+	for _, b := range bases {
+		values[b.Name] = env[b.Name]
+	}
+	return values
+}
+
+// changedPortKeys returns the keys whose values differ between before and
+// after, sorted.
+func changedPortKeys(before, after map[string]string) []string {
+	var changed []string
+	for key, value := range after {
+		if before[key] != value {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// offerRestartForPorts follows a rewrite of the changed port keys: running
+// containers still publish the old ports until they are recreated, so it
+// warns and offers to run sail down && sail up.
+func offerRestartForPorts(reader *bufio.Reader, projectDir string, changed []string) error {
+	if len(changed) == 0 {
+		return nil
+	}
+	if running, err := countRunningContainers(projectDir); err != nil || running == 0 {
+		return nil
+	}
+	printWarning(tr("env_ports_running", strings.Join(changed, ", ")))
+	if !askYesNo(reader, tr("restart_stack_now")) {
+		return nil
+	}
+	if err := runSailDown(projectDir); err != nil {
+		return err
+	}
+	return runSailUp(projectDir)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeRunningSail installs a sail script that reports one running container
+// and logs every other invocation, which is returned.
+func fakeRunningSail(t *testing.T, projectDir string) string {
+	t.Helper()
+	dir := filepath.Join(projectDir, "vendor", "bin")
+	os.MkdirAll(dir, 0755)
+	logPath := filepath.Join(projectDir, "sail.log")
+	script := "#!/bin/sh\nif [ \"$1\" = ps ]; then echo running; else echo \"$1\" >> " + logPath + "; fi\n"
+	os.WriteFile(filepath.Join(dir, "sail"), []byte(script), 0755)
+	return logPath
+}
+
+func TestChangedPortKeys(t *testing.T) {
+	dir := t.TempDir()
+	bases := []PortBase{{"APP_PORT", 8000}, {"VITE_PORT", 5100}}
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("APP_PORT=8051\nVITE_PORT=5151\n"), 0644)
+	before := portEnvValues(dir, bases)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("APP_PORT=8052\nVITE_PORT=5151\n"), 0644)
+	if got := changedPortKeys(before, portEnvValues(dir, bases)); !slices.Equal(got, []string{"APP_PORT"}) {
+		t.Errorf("Expected only APP_PORT to change, got %v", got)
+	}
+}
+
+func TestOfferRestartForPorts(t *testing.T) {
+	dir := t.TempDir()
+	logPath := fakeRunningSail(t, dir)
+
+	if err := offerRestartForPorts(bufio.NewReader(strings.NewReader("n\n")), dir, []string{"APP_PORT"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(logPath); err == nil {
+		t.Error("Expected declining to leave the containers alone")
+	}
+
+	if err := offerRestartForPorts(bufio.NewReader(strings.NewReader("y\n")), dir, []string{"APP_PORT"}); err != nil {
+		t.Fatal(err)
+	}
+	calls, _ := os.ReadFile(logPath)
+	if string(calls) != "down\nup\n" {
+		t.Errorf("Expected sail down then sail up, got %q", calls)
+	}
+
+	os.Remove(logPath)
+	if err := offerRestartForPorts(bufio.NewReader(strings.NewReader("y\n")), dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(logPath); err == nil {
+		t.Error("Expected no restart without changed ports")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// writeEnvFile replaces an env file atomically. An existing file keeps its
+// mode and ownership, and a symlinked .env is written through to its target.
+// A project's .env is backed up before its first change in a command.
+func writeEnvFile(envPath string, data []byte) error {
+	if filepath.Base(envPath) == ".env" {
+		backup, err := backupEnvFile(envPath, data, time.Now())
+		if err != nil {
+			return fmt.Errorf("backing up .env: %w", err)
+		}
+		if backup != "" {
+			printInfo(tr("env_backed_up", filepath.Base(backup)))
+		}
+	}
+	return replaceFile(envPath, data)
+}
+
+// replaceFile does the replacing for writeEnvFile, without a backup.
+func replaceFile(envPath string, data []byte) error {
+	defer profileSpan("file: write .env")()
+	if resolved, err := filepath.EvalSymlinks(envPath); err == nil {
+		envPath = resolved
+	}
+
+	mode := os.FileMode(0644)
+	info, statErr := os.Stat(envPath)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(envPath), ".env.sailinit-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if statErr == nil {
+		// Best effort: only root can give a file away to another user
+		copyOwnership(tmp.Name(), info)
+	}
+	return os.Rename(tmp.Name(), envPath)
+}
+
+// secretEnvKeys returns the keys of entries that look like credentials and
+// have a value.
+func secretEnvKeys(entries []envEntry) []string {
+	var keys []string
+	for _, e := range entries {
+		if unquoteEnvValue(e.Value) == "" {
+			continue
+		}
+		upper := strings.ToUpper(e.Key)
+		if strings.Contains(upper, "PASSWORD") || strings.Contains(upper, "SECRET") ||
+			strings.Contains(upper, "TOKEN") || strings.HasSuffix(upper, "_KEY") {
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+// exposedEnvSecrets reports the secrets of an env file that other users on
+// the machine can read because the file is group or world readable.
+func exposedEnvSecrets(envPath string) (os.FileMode, []string, error) {
+	info, err := os.Stat(envPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	mode := info.Mode().Perm()
+	if mode&0044 == 0 {
+		return mode, nil, nil
+	}
+	entries, err := readEnvEntries(envPath)
+	if err != nil {
+		return mode, nil, err
+	}
+	return mode, secretEnvKeys(entries), nil
+}
+
+// tightenEnvPermissions restricts the env file to its owner.
+func tightenEnvPermissions(envPath string) error {
+	if resolved, err := filepath.EvalSymlinks(envPath); err == nil {
+		envPath = resolved
+	}
+	return os.Chmod(envPath, 0600)
+}
+
+func checkEnvPermissions(projectDir string) []string {
+	envPath := filepath.Join(projectDir, ".env")
+	mode, secrets, err := exposedEnvSecrets(envPath)
+	if err != nil || len(secrets) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf(".env is %04o and readable by other users but contains %s (fix with: sailinit env tighten)", mode, strings.Join(secrets, ", "))}
+}
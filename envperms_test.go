@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteEnvFilePreservesMode(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-perms-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	os.WriteFile(envPath, []byte("APP_NAME=Shop\n"), 0600)
+	if err := setEnvValue(envPath, "APP_URL", "http://localhost:8051"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 to be kept, got %04o", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(tempDir)
+	for _, e := range entries {
+		if e.Name() != ".env" && !strings.HasPrefix(e.Name(), envBackupPrefix) {
+			t.Errorf("Expected no leftover temp files, got %s", e.Name())
+		}
+	}
+}
+
+func TestWriteEnvFileFollowsSymlink(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-perms-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	target := filepath.Join(tempDir, "shared.env")
+	os.WriteFile(target, []byte("A=1\n"), 0640)
+	link := filepath.Join(tempDir, ".env")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported")
+	}
+
+	if err := writeEnvFile(link, []byte("A=2\n")); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Lstat(link); fi.Mode()&os.ModeSymlink == 0 {
+		t.Error("Expected .env to remain a symlink")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "A=2\n" {
+		t.Errorf("Expected target to be updated, got %q", data)
+	}
+}
+
+func TestSecretEnvKeys(t *testing.T) {
+	got := secretEnvKeys([]envEntry{
+		{Key: "APP_KEY", Value: "base64:abc"},
+		{Key: "APP_NAME", Value: "Shop"},
+		{Key: "DB_PASSWORD", Value: `""`},
+		{Key: "AWS_SECRET_ACCESS_KEY", Value: "xyz"},
+		{Key: "GITHUB_TOKEN", Value: "t"},
+	})
+	if strings.Join(got, ",") != "APP_KEY,AWS_SECRET_ACCESS_KEY,GITHUB_TOKEN" {
+		t.Errorf("Unexpected secret keys: %v", got)
+	}
+}
+
+func TestCheckEnvPermissions(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-perms-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	os.WriteFile(envPath, []byte("APP_KEY=base64:abc\n"), 0644)
+	os.Chmod(envPath, 0644)
+	if problems := checkEnvPermissions(tempDir); len(problems) != 1 || !strings.Contains(problems[0], "APP_KEY") {
+		t.Errorf("Expected exposed APP_KEY to be reported, got %v", problems)
+	}
+
+	if err := tightenEnvPermissions(envPath); err != nil {
+		t.Fatal(err)
+	}
+	if problems := checkEnvPermissions(tempDir); len(problems) != 0 {
+		t.Errorf("Expected no problems after tightening, got %v", problems)
+	}
+}
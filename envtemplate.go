@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// envTemplateData is what the values of the config's env map can refer to,
+// e.g. APP_URL: "http://{{.Name}}.localhost:{{.AppPort}}".
+type envTemplateData struct {
+	// Name is the project directory's name
+	Name       string
+	Path       string
+	Suffix     int
+	PHPVersion string
+	AppPort    int
+	VitePort   int
+	// Ports holds every forwarded port by its .env key, e.g.
+	// {{.Ports.FORWARD_DB_PORT}}
+	Ports map[string]int
+}
+
+// newEnvTemplateData describes plan's project for the templates.
+func newEnvTemplateData(plan *setupPlan) envTemplateData {
+	data := envTemplateData{
+		Name:       filepath.Base(plan.ProjectDir),
+		Path:       plan.ProjectDir,
+		Suffix:     plan.Suffix,
+		PHPVersion: plan.PHPVersion,
+		Ports:      make(map[string]int),
+	}
+	for _, b := range setupPortBases(plan.ProjectDir, plan.DB) {
+		data.Ports[b.Name] = b.Base + plan.Suffix
+	}
+	data.AppPort = data.Ports["APP_PORT"]
+	data.VitePort = data.Ports["VITE_PORT"]
+	return data
+}
+
+// renderEnvTemplates expands the templated values of env, in key order.
+// Referring to an unknown field or port is an error.
+func renderEnvTemplates(env map[string]string, data envTemplateData) ([]envEntry, error) {
+	var entries []envEntry
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(env[key])
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", key, err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("env %s: %w", key, err)
+		}
+		entries = append(entries, envEntry{Key: key, Value: b.String()})
+	}
+	return entries, nil
+}
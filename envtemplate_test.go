@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderEnvTemplates(t *testing.T) {
+	plan := &setupPlan{ProjectDir: filepath.Join(t.TempDir(), "shop"), Suffix: 51, PHPVersion: "84"}
+	data := newEnvTemplateData(plan)
+
+	entries, err := renderEnvTemplates(map[string]string{
+		"APP_URL":      "http://{{.Name}}.localhost:{{.AppPort}}",
+		"DB_URL":       "mysql://localhost:{{.Ports.FORWARD_DB_PORT}}",
+		"MAIL_MAILER":  "log",
+		"APP_PHP_INFO": "php{{.PHPVersion}}-{{.Suffix}}",
+	}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []envEntry{
+		{"APP_PHP_INFO", "php84-51"},
+		{"APP_URL", "http://shop.localhost:8051"},
+		{"DB_URL", "mysql://localhost:3351"},
+		{"MAIL_MAILER", "log"},
+	}
+	if !slices.Equal(entries, want) {
+		t.Errorf("Expected %v, got %v", want, entries)
+	}
+}
+
+func TestRenderEnvTemplatesErrors(t *testing.T) {
+	data := newEnvTemplateData(&setupPlan{ProjectDir: t.TempDir(), Suffix: 51})
+	for _, value := range []string{"{{.Nmae}}", "{{.Ports.FORWARD_NOPE_PORT}}", "{{.Name"} {
+		_, err := renderEnvTemplates(map[string]string{"APP_URL": value}, data)
+		if err == nil || !strings.Contains(err.Error(), "env APP_URL") {
+			t.Errorf("Expected an error for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestSQLiteTemplateHasNoDBPort(t *testing.T) {
+	data := newEnvTemplateData(&setupPlan{ProjectDir: t.TempDir(), Suffix: 51, DB: dbSQLite})
+	if _, ok := data.Ports["FORWARD_DB_PORT"]; ok {
+		t.Errorf("Expected no database port in SQLite mode, got %v", data.Ports)
+	}
+}
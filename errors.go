@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Exit codes returned by every command. Scripts can rely on these to tell
+// failure classes apart.
+const (
+	ExitOK      = 0
+	ExitGeneral = 1
+	ExitUsage   = 2
+	ExitState   = 3
+	ExitDocker  = 4
+	ExitSail    = 5
+	ExitEnv     = 6
+	ExitNetwork = 7
+)
+
+// ErrorCategory groups failures that share an exit code and remedy.
+type ErrorCategory int
+
+const (
+	CategoryGeneral ErrorCategory = iota
+	CategoryUsage
+	CategoryState
+	CategoryDocker
+	CategorySail
+	CategoryEnv
+	CategoryNetwork
+)
+
+// exitCodes maps each category to its exit code.
+var exitCodes = map[ErrorCategory]int{
+	CategoryGeneral: ExitGeneral,
+	CategoryUsage:   ExitUsage,
+	CategoryState:   ExitState,
+	CategoryDocker:  ExitDocker,
+	CategorySail:    ExitSail,
+	CategoryEnv:     ExitEnv,
+	CategoryNetwork: ExitNetwork,
+}
+
+// CLIError is an error with a category and a troubleshooting hint.
+type CLIError struct {
+	Category ErrorCategory
+	Err      error
+	Hint     string
+}
+
+func (e *CLIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CLIError) Unwrap() error {
+	return e.Err
+}
+
+// Sentinel errors; match them with errors.Is.
+var (
+	ErrDockerUnavailable = errors.New("docker is not available")
+	ErrSailNotFound      = errors.New("sail binary not found")
+)
+
+func dockerUnavailableError(err error) error {
+	return &CLIError{
+		Category: CategoryDocker,
+		Err:      fmt.Errorf("%w: %v", ErrDockerUnavailable, err),
+		Hint:     "Start Docker Desktop (or the docker daemon) and try again.",
+	}
+}
+
+func sailNotFoundError(sailPath string) error {
+	return &CLIError{
+		Category: CategorySail,
+		Err:      fmt.Errorf("%w at %s", ErrSailNotFound, sailPath),
+		Hint:     "Run sailinit in the project first to install composer dependencies (or use --fresh).",
+	}
+}
+
+func notReadyError(err error) error {
+	return &CLIError{
+		Category: CategorySail,
+		Err:      err,
+		Hint:     "Inspect the containers with: sail logs. Slow first starts may need a longer --ready-timeout.",
+	}
+}
+
+func usageError(err error) error {
+	return &CLIError{Category: CategoryUsage, Err: err}
+}
+
+func envError(err error) error {
+	return &CLIError{
+		Category: CategoryEnv,
+		Err:      err,
+		Hint:     "Check that .env (and .env.example) exist and are writable.",
+	}
+}
+
+func stateError(err error) error {
+	return &CLIError{
+		Category: CategoryState,
+		Err:      err,
+		Hint:     "Check ~/.laravel-sail-ports.json is readable; repair a broken one with: sailinit repair-state",
+	}
+}
+
+// dockerDaemonMessages are fragments docker prints when it can't reach the daemon.
+var dockerDaemonMessages = []string{
+	"Cannot connect to the Docker daemon",
+	"Is the docker daemon running",
+	"error during connect",
+	"docker daemon is not running",
+	"Docker is not running",
+}
+
+// classifyExecError turns the error of a docker/sail invocation into a
+// CLIError, using captured stderr to recognise an unreachable daemon and
+// refused image pulls.
+func classifyExecError(err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return dockerUnavailableError(err)
+	}
+	for _, msg := range dockerDaemonMessages {
+		if strings.Contains(stderr, msg) {
+			return dockerUnavailableError(err)
+		}
+	}
+	if pullErr := classifyPullError(err, stderr); pullErr != nil {
+		return pullErr
+	}
+	return &CLIError{
+		Category: CategoryDocker,
+		Err:      err,
+		Hint:     "See the docker output above for details.",
+	}
+}
+
+// exitCode returns the process exit code for err.
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var cliErr *CLIError
+	if errors.As(err, &cliErr) {
+		return exitCodes[cliErr.Category]
+	}
+	return ExitGeneral
+}
+
+// errorHint returns the troubleshooting hint attached to err, if any.
+func errorHint(err error) string {
+	var cliErr *CLIError
+	if errors.As(err, &cliErr) {
+		return cliErr.Hint
+	}
+	return ""
+}
+
+// exitWithError prints msg with err and its hint, then exits with the
+// code of err's category.
+func exitWithError(msg string, err error) {
+	printError(fmt.Sprintf("%s: %v", msg, err))
+	if hint := errorHint(err); hint != "" {
+		printInfo("Hint: " + hint)
+	}
+	exit(exitCode(err))
+}
+
+// runStreaming runs a docker or sail command with its output streamed to
+// the terminal, classifying failures from the captured stderr.
+func runStreaming(cmd *exec.Cmd) error {
+	_, err := runCapturingStderr(cmd)
+	return err
+}
+
+// runCapturingStderr is runStreaming that also returns what the command
+// wrote to stderr.
+func runCapturingStderr(cmd *exec.Cmd) (string, error) {
+	var stderr bytes.Buffer
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	err := commandRun(cmd)
+	return stderr.String(), classifyExecError(err, stderr.String())
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("plain"), ExitGeneral},
+		{usageError(errors.New("bad flag")), ExitUsage},
+		{stateError(errors.New("corrupt")), ExitState},
+		{envError(errors.New("read-only")), ExitEnv},
+		{sailNotFoundError("/x/vendor/bin/sail"), ExitSail},
+		{dockerUnavailableError(errors.New("no daemon")), ExitDocker},
+		{fmt.Errorf("wrapped: %w", stateError(errors.New("corrupt"))), ExitState},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	err := sailNotFoundError("/x/vendor/bin/sail")
+	if !errors.Is(err, ErrSailNotFound) {
+		t.Error("Expected sail not found error to match ErrSailNotFound")
+	}
+	if err.Error() != "sail binary not found at /x/vendor/bin/sail" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+	if errorHint(err) == "" {
+		t.Error("Expected a troubleshooting hint")
+	}
+	if errorHint(errors.New("plain")) != "" {
+		t.Error("Plain errors carry no hint")
+	}
+}
+
+func TestClassifyExecError(t *testing.T) {
+	if classifyExecError(nil, "") != nil {
+		t.Error("Expected nil for successful command")
+	}
+
+	err := classifyExecError(exec.ErrNotFound, "")
+	if !errors.Is(err, ErrDockerUnavailable) {
+		t.Errorf("Missing executable should be docker unavailable, got %v", err)
+	}
+
+	err = classifyExecError(errors.New("exit status 1"), "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?")
+	if !errors.Is(err, ErrDockerUnavailable) || exitCode(err) != ExitDocker {
+		t.Errorf("Daemon message should be docker unavailable, got %v", err)
+	}
+
+	err = classifyExecError(errors.New("exit status 2"), "composer failed")
+	if errors.Is(err, ErrDockerUnavailable) {
+		t.Error("Generic failures should not be classified as docker unavailable")
+	}
+	if exitCode(err) != ExitDocker {
+		t.Errorf("Expected docker exit code, got %d", exitCode(err))
+	}
+}
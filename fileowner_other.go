@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// copyOwnership is a no-op where files have no Unix owner.
+func copyOwnership(path string, info os.FileInfo) {}
+
+// fileOwner reports no owner where files have no Unix owner.
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) { return 0, 0, false }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// fixPermsDirs are the project directories containers commonly leave
+// root-owned files in.
+var fixPermsDirs = []string{"storage", "bootstrap/cache", "vendor"}
+
+// fixPermsImage is the small image the ownership repair runs in.
+const fixPermsImage = "alpine:3"
+
+// ownershipProblem counts the files under one directory that do not belong
+// to the expected user.
+type ownershipProblem struct {
+	Dir     string
+	Count   int
+	Example string
+}
+
+// foreignOwnedFiles walks fixPermsDirs and reports files not owned by uid.
+// Missing directories are skipped.
+func foreignOwnedFiles(projectDir string, uid int) ([]ownershipProblem, error) {
+	var problems []ownershipProblem
+	for _, dir := range fixPermsDirs {
+		root := filepath.Join(projectDir, filepath.FromSlash(dir))
+		problem := ownershipProblem{Dir: dir}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				// An unreadable directory is most likely root-owned itself
+				if errors.Is(err, fs.ErrPermission) {
+					problem.Count++
+					return nil
+				}
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if owner, _, ok := fileOwner(info); ok && owner != uid {
+				if problem.Count == 0 {
+					problem.Example, _ = filepath.Rel(projectDir, path)
+				}
+				problem.Count++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if problem.Count > 0 {
+			problems = append(problems, problem)
+		}
+	}
+	return problems, nil
+}
+
+// fixPermsOwner returns the uid:gid files should belong to: an explicit
+// mapping, otherwise the current user.
+func fixPermsOwner(spec string) string {
+	if _, _, found := strings.Cut(spec, ":"); found {
+		return spec
+	}
+	return fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+}
+
+// chownCommand builds the docker run that gives dirs to owner. Running as
+// root inside the container avoids needing sudo on the host.
+func chownCommand(projectDir, owner string, dirs []string) *exec.Cmd {
+	args := []string{"run", "--rm", "-v", fmt.Sprintf("%s:/app", projectDir), "-w", "/app", fixPermsImage, "chown", "-R", owner}
+	args = append(args, dirs...)
+	return exec.Command("docker", args...)
+}
+
+func handleFixPerms(args []string) {
+	fset := commandFlags("fix-perms")
+	dryRun := fset.Bool("dry-run", false, "Only list the affected directories")
+	user := fset.String("user", "", "uid:gid the files should belong to (default: the current user)")
+	fset.Parse(reorderArgs(args))
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+
+	spec := *user
+	if spec == "" {
+		if cfg, err := loadUserConfig(); err == nil {
+			spec = cfg.User
+		}
+	}
+	if spec, err = parseUserMapping(spec); err != nil {
+		exitWithError("Invalid --user", usageError(err))
+	}
+	owner := fixPermsOwner(spec)
+	uid, _ := strconv.Atoi(strings.SplitN(owner, ":", 2)[0])
+
+	problems, err := foreignOwnedFiles(projectDir, uid)
+	if err != nil {
+		exitWithError("Error scanning project files", err)
+	}
+	if len(problems) == 0 {
+		printSuccess(fmt.Sprintf("Every file in %s is owned by %s.", strings.Join(fixPermsDirs, ", "), owner))
+		return
+	}
+
+	var dirs []string
+	for _, p := range problems {
+		printWarning(fmt.Sprintf("%s: %d file(s) not owned by %s (e.g. %s)", p.Dir, p.Count, owner, p.Example))
+		dirs = append(dirs, p.Dir)
+	}
+	if *dryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would run: docker run --rm %s chown -R %s %s", fixPermsImage, owner, strings.Join(dirs, " ")))
+		return
+	}
+
+	printInfo(fmt.Sprintf("Repairing ownership via Docker (%s)...", fixPermsImage))
+	if err := runStreaming(chownCommand(projectDir, owner, dirs)); err != nil {
+		exitWithError("Error repairing ownership", err)
+	}
+	printSuccess(fmt.Sprintf("%s now belong to %s.", strings.Join(dirs, ", "), owner))
+}
+
+func checkFileOwnership(projectDir string) []string {
+	problems, err := foreignOwnedFiles(projectDir, os.Getuid())
+	if err != nil {
+		return []string{fmt.Sprintf("could not scan project files: %v", err)}
+	}
+	var out []string
+	for _, p := range problems {
+		out = append(out, fmt.Sprintf("%s: %d file(s) owned by another user, e.g. %s (fix with: sailinit fix-perms)", p.Dir, p.Count, p.Example))
+	}
+	return out
+}
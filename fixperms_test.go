@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestForeignOwnedFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("files have no Unix owner")
+	}
+	tempDir, err := os.MkdirTemp("", "sailinit-perms-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	os.MkdirAll(filepath.Join(tempDir, "storage", "logs"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "storage", "logs", "laravel.log"), []byte("log"), 0644)
+	os.MkdirAll(filepath.Join(tempDir, "bootstrap", "cache"), 0755)
+
+	problems, err := foreignOwnedFiles(tempDir, os.Getuid())
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Expected no problems for our own files, got %v, %v", problems, err)
+	}
+
+	// Everything belongs to someone else from another uid's point of view
+	problems, err = foreignOwnedFiles(tempDir, os.Getuid()+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 || problems[0].Dir != "storage" || problems[0].Count != 3 || problems[1].Dir != "bootstrap/cache" {
+		t.Errorf("Expected storage and bootstrap/cache to be reported, got %+v", problems)
+	}
+	if problems[0].Example != "storage" {
+		t.Errorf("Expected the first foreign path as example, got %q", problems[0].Example)
+	}
+}
+
+func TestFixPermsOwner(t *testing.T) {
+	if got := fixPermsOwner("1001:1001"); got != "1001:1001" {
+		t.Errorf("Expected the explicit mapping, got %q", got)
+	}
+	current := fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	for _, spec := range []string{"", "none"} {
+		if got := fixPermsOwner(spec); got != current {
+			t.Errorf("fixPermsOwner(%q) = %q, want %q", spec, got, current)
+		}
+	}
+}
+
+func TestChownCommand(t *testing.T) {
+	cmd := chownCommand("/srv/app", "1000:1000", []string{"storage", "vendor"})
+	got := strings.Join(cmd.Args, " ")
+	want := "docker run --rm -v /srv/app:/app -w /app " + fixPermsImage + " chown -R 1000:1000 storage vendor"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
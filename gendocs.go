@@ -0,0 +1,224 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// flagDoc is a setup flag as the man page and completions describe it.
+type flagDoc struct {
+	Name string
+	// Arg names the flag's value, empty for boolean flags
+	Arg   string
+	Usage string
+}
+
+// setupFlagDocs describes the flags defined on fs.
+func setupFlagDocs(fs *flag.FlagSet) []flagDoc {
+	var docs []flagDoc
+	fs.VisitAll(func(f *flag.Flag) {
+		arg, usage := flag.UnquoteUsage(f)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			arg = ""
+		}
+		docs = append(docs, flagDoc{Name: f.Name, Arg: arg, Usage: usage})
+	})
+	return docs
+}
+
+// exitCodeDocs lists the exit codes for the man page, as the README
+// describes them.
+var exitCodeDocs = [][2]string{
+	{"0", "Success"},
+	{"1", "General failure"},
+	{"2", "Invalid usage (bad flag or argument)"},
+	{"3", "Port registry could not be read or written"},
+	{"4", "Docker unavailable or a docker command failed"},
+	{"5", "vendor/bin/sail missing"},
+	{"6", ".env problem"},
+	{"7", "Network failure, including a registry's pull rate limit"},
+}
+
+var (
+	usageFlagPattern   = regexp.MustCompile(`--[a-z][a-z0-9-]*`)
+	usageChoicePattern = regexp.MustCompile(`[a-z][a-z0-9-]*(?:\|[a-z][a-z0-9-]*)+`)
+)
+
+// completionWords returns the words to complete after a subcommand: the
+// choices and flags of its usage, e.g. "install", "uninstall", "--print".
+func completionWords(usage string) []string {
+	var words []string
+	for _, choice := range usageChoicePattern.FindAllString(usage, -1) {
+		words = append(words, strings.Split(choice, "|")...)
+	}
+	words = append(words, usageFlagPattern.FindAllString(usage, -1)...)
+	slices.Sort(words)
+	return slices.Compact(words)
+}
+
+// roffEscape makes s safe as roff text.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// manPage renders sailinit(1).
+func manPage(flags []flagDoc, commands []subcommand) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH SAILINIT 1 \"\" \"sailinit %s\" \"User Commands\"\n", roffEscape(version))
+	b.WriteString(".SH NAME\nsailinit \\- set up Laravel Sail projects with collision\\-free ports\n")
+	b.WriteString(".SH SYNOPSIS\n.B sailinit\n[\\fIoptions\\fR] [\\fIphp_version\\fR]\n.br\n.B sailinit\n\\fIcommand\\fR [\\fIarguments\\fR]\n")
+	b.WriteString(".SH DESCRIPTION\n")
+	b.WriteString("Without a command, sailinit sets up the Laravel project in the current directory: it installs Sail's dependencies through Docker, assigns the project a port suffix that no other registered project uses and writes the resulting ports to .env before starting the containers.\n")
+	b.WriteString(".SH OPTIONS\n")
+	for _, f := range flags {
+		b.WriteString(".TP\n\\fB\\-\\-" + roffEscape(f.Name) + "\\fR")
+		if f.Arg != "" {
+			b.WriteString(" \\fI" + roffEscape(f.Arg) + "\\fR")
+		}
+		b.WriteString("\n" + roffEscape(f.Usage) + "\n")
+	}
+	b.WriteString(".SH COMMANDS\n")
+	for _, c := range commands {
+		b.WriteString(".TP\n\\fB" + roffEscape(c.Name) + "\\fR")
+		if c.Usage != "" {
+			b.WriteString(" " + roffEscape(c.Usage))
+		}
+		b.WriteString("\n" + roffEscape(c.Summary) + "\n")
+	}
+	b.WriteString(".SH EXIT STATUS\n")
+	for _, e := range exitCodeDocs {
+		b.WriteString(".TP\n.B " + e[0] + "\n" + roffEscape(e[1]) + "\n")
+	}
+	b.WriteString(".SH FILES\n")
+	b.WriteString(".TP\n.I ~/.laravel\\-sail\\-ports.json\nThe port registry.\n")
+	b.WriteString(".TP\n.I ~/.config/sailinit/config.yaml\nThe configuration file.\n")
+	return b.String()
+}
+
+// bashCompletion renders a bash completion script.
+func bashCompletion(flags []flagDoc, commands []subcommand) string {
+	var first []string
+	for _, c := range commands {
+		first = append(first, c.Name)
+	}
+	for _, f := range flags {
+		first = append(first, "--"+f.Name)
+	}
+	var b strings.Builder
+	b.WriteString("# bash completion for sailinit\n\n_sailinit() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return\n    fi\n", strings.Join(first, " "))
+	b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+	for _, c := range commands {
+		if words := completionWords(c.Usage); len(words) > 0 {
+			fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", c.Name, strings.Join(words, " "))
+		}
+	}
+	b.WriteString("        --*) COMPREPLY=($(compgen -f -- \"$cur\")) ;;\n")
+	b.WriteString("    esac\n}\n\ncomplete -F _sailinit sailinit\n")
+	return b.String()
+}
+
+// zshEntry quotes name and its description as a zsh _describe entry.
+func zshEntry(name, description string) string {
+	escape := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(s, "'", `'\''`), ":", `\:`)
+	}
+	return "'" + escape(name) + ":" + escape(description) + "'"
+}
+
+// zshCompletion renders a zsh completion function.
+func zshCompletion(flags []flagDoc, commands []subcommand) string {
+	var b strings.Builder
+	b.WriteString("#compdef sailinit\n\n_sailinit() {\n    local -a commands flags\n    commands=(\n")
+	for _, c := range commands {
+		b.WriteString("        " + zshEntry(c.Name, c.Summary) + "\n")
+	}
+	b.WriteString("    )\n    flags=(\n")
+	for _, f := range flags {
+		b.WriteString("        " + zshEntry("--"+f.Name, f.Usage) + "\n")
+	}
+	b.WriteString("    )\n    if (( CURRENT == 2 )); then\n")
+	b.WriteString("        _describe -t commands 'sailinit command' commands\n")
+	b.WriteString("        _describe -t flags 'sailinit option' flags\n        return\n    fi\n")
+	b.WriteString("    case $words[2] in\n")
+	for _, c := range commands {
+		if words := completionWords(c.Usage); len(words) > 0 {
+			fmt.Fprintf(&b, "        %s) compadd -- %s ;;\n", c.Name, strings.Join(words, " "))
+		}
+	}
+	b.WriteString("        --*) _files ;;\n    esac\n}\n\n_sailinit \"$@\"\n")
+	return b.String()
+}
+
+// fishQuote quotes s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
+
+// fishCompletion renders fish completions.
+func fishCompletion(flags []flagDoc, commands []subcommand) string {
+	var b strings.Builder
+	b.WriteString("# fish completion for sailinit\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "complete -c sailinit -n __fish_use_subcommand -f -a %s -d %s\n", c.Name, fishQuote(c.Summary))
+	}
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c sailinit -n __fish_use_subcommand -l %s -d %s", f.Name, fishQuote(f.Usage))
+		if f.Arg != "" {
+			line += " -r"
+		}
+		b.WriteString(line + "\n")
+	}
+	for _, c := range commands {
+		for _, w := range completionWords(c.Usage) {
+			cond := fishQuote("__fish_seen_subcommand_from " + c.Name)
+			if name, ok := strings.CutPrefix(w, "--"); ok {
+				fmt.Fprintf(&b, "complete -c sailinit -n %s -l %s\n", cond, name)
+			} else {
+				fmt.Fprintf(&b, "complete -c sailinit -n %s -f -a %s\n", cond, w)
+			}
+		}
+	}
+	return b.String()
+}
+
+// generatedDocs returns the files generate-docs writes, by name.
+func generatedDocs(flags []flagDoc, commands []subcommand) map[string]string {
+	return map[string]string{
+		"sailinit.1":    manPage(flags, commands),
+		"sailinit.bash": bashCompletion(flags, commands),
+		"_sailinit":     zshCompletion(flags, commands),
+		"sailinit.fish": fishCompletion(flags, commands),
+	}
+}
+
+func handleGenerateDocs(args []string) {
+	fset := commandFlags("generate-docs")
+	out := fset.String("out", "docs", "Directory to write the man page and completions to")
+	fset.Parse(reorderArgs(args))
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		exitWithError("Error creating "+*out, err)
+	}
+	files := generatedDocs(setupFlagDocs(legacyFlagSet(&setupOptions{}, &legacyFlags{})), subcommands())
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		path := filepath.Join(*out, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			exitWithError("Error writing "+path, err)
+		}
+		printSuccess("Wrote " + path)
+	}
+}
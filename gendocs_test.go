@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSubcommandsSortedAndUnique(t *testing.T) {
+	commands := subcommands()
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.Name
+		if c.Summary == "" || c.Run == nil {
+			t.Errorf("%s needs a summary and a handler", c.Name)
+		}
+	}
+	if !slices.IsSorted(names) || len(slices.Compact(slices.Clone(names))) != len(names) {
+		t.Errorf("Expected sorted, unique names, got %v", names)
+	}
+	if runCommand("83", nil) {
+		t.Error("Expected a PHP version not to be taken for a subcommand")
+	}
+}
+
+func TestCompletionWords(t *testing.T) {
+	got := completionWords("<install|uninstall> [--shell bash|zsh] [--rc <file>] [--print]")
+	want := []string{"--print", "--rc", "--shell", "bash", "install", "uninstall", "zsh"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := completionWords(""); len(got) != 0 {
+		t.Errorf("Expected no words, got %v", got)
+	}
+}
+
+func TestSetupFlagDocs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("dry-run", false, "Show what would happen")
+	fs.String("record", "", "Record the answers to `file`")
+	docs := setupFlagDocs(fs)
+	if len(docs) != 2 || docs[0] != (flagDoc{"dry-run", "", "Show what would happen"}) {
+		t.Errorf("Unexpected bool flag doc %+v", docs)
+	}
+	if docs[1] != (flagDoc{"record", "file", "Record the answers to file"}) {
+		t.Errorf("Unexpected string flag doc %+v", docs[1])
+	}
+}
+
+func TestGeneratedDocs(t *testing.T) {
+	flags := []flagDoc{{"dry-run", "", "Show what would happen"}, {"record", "file", "Record the answers to file"}}
+	commands := []subcommand{{Name: "alias", Usage: "<install|uninstall>", Summary: "Install the user's shortcuts: sail and si"}}
+	files := generatedDocs(flags, commands)
+
+	man := files["sailinit.1"]
+	for _, want := range []string{".TH SAILINIT 1", `\fB\-\-record\fR \fIfile\fR`, `\fBalias\fR <install|uninstall>`, ".SH EXIT STATUS"} {
+		if !strings.Contains(man, want) {
+			t.Errorf("Expected %q in the man page:\n%s", want, man)
+		}
+	}
+	if !strings.Contains(files["sailinit.bash"], `alias) COMPREPLY=($(compgen -W "install uninstall"`) {
+		t.Errorf("Expected alias choices in the bash completion:\n%s", files["sailinit.bash"])
+	}
+	if !strings.Contains(files["_sailinit"], `'alias:Install the user'\''s shortcuts\: sail and si'`) {
+		t.Errorf("Expected an escaped zsh description:\n%s", files["_sailinit"])
+	}
+	if !strings.Contains(files["sailinit.fish"], "-l record -d 'Record the answers to file' -r") {
+		t.Errorf("Expected the record flag to take a value in fish:\n%s", files["sailinit.fish"])
+	}
+}
+
+func TestRoffEscape(t *testing.T) {
+	if got := roffEscape(`.env at C:\dir -x`); got != `\&.env at C:\edir \-x` {
+		t.Errorf("Unexpected escaping %q", got)
+	}
+}
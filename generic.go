@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// parsePortMap parses "KEY=BASE,KEY=BASE" into port bases, keeping order.
+func parsePortMap(spec string) ([]PortBase, error) {
+	var bases []PortBase
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected KEY=BASE, got %q", pair)
+		}
+		base, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil || base < 1 || base > 65535 {
+			return nil, fmt.Errorf("invalid base port for %s: %q", key, val)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate key %s", key)
+		}
+		seen[key] = true
+		bases = append(bases, PortBase{Name: key, Base: base})
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("no ports given")
+	}
+	return bases, nil
+}
+
+// validateSuffixFor checks that every port of bases stays within the TCP range.
+func validateSuffixFor(bases []PortBase, suffix int) error {
+	if err := ValidateSuffix(suffix); err != nil {
+		return err
+	}
+	for _, b := range bases {
+		if b.Base+suffix > 65535 {
+			return fmt.Errorf("suffix %d too large: %s would be %d (max 65535)", suffix, b.Name, b.Base+suffix)
+		}
+	}
+	return nil
+}
+
+// runGenericSetup allocates a suffix for an arbitrary docker-compose project
+// and writes the resulting ports to .env, skipping composer and sail.
+func runGenericSetup(reader *bufio.Reader, projectDir string, bases []PortBase, dryRun bool, portOpts portCheckOptions) error {
+	printHeader("Starting generic port setup...")
+
+	suffix, err := chooseSuffix(reader, projectDir)
+	if err != nil {
+		return err
+	}
+	if err := validateSuffixFor(bases, suffix); err != nil {
+		return err
+	}
+	var ok bool
+	if suffix, ok = confirmPortsAvailable(reader, projectDir, bases, suffix, portOpts); !ok {
+		return nil
+	}
+
+	if dryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would save suffix %d for project %s", suffix, projectDir))
+		for _, b := range bases {
+			printInfo(fmt.Sprintf("[dry-run]   %s=%d", b.Name, b.Base+suffix))
+		}
+		return nil
+	}
+
+	if err := saveProjectSuffix(projectDir, suffix); err != nil {
+		return err
+	}
+	before := portEnvValues(projectDir, bases)
+	if err := writeGenericPorts(projectDir, bases, suffix); err != nil {
+		return err
+	}
+
+	printSuccess(fmt.Sprintf("\nPorts configured with suffix %d:", suffix))
+	for _, b := range bases {
+		printInfo(fmt.Sprintf("%s=%d", b.Name, b.Base+suffix))
+	}
+	return offerRestartForPorts(reader, projectDir, changedPortKeys(before, portEnvValues(projectDir, bases)))
+}
+
+// writeGenericPorts sets each port key in .env, creating the file from
+// .env.example (or empty) when needed. Other lines are left untouched.
+func writeGenericPorts(projectDir string, bases []PortBase, suffix int) error {
+	envPath := filepath.Join(projectDir, ".env")
+	if _, err := os.Stat(envPath); os.IsNotExist(err) {
+		data, err := os.ReadFile(filepath.Join(projectDir, ".env.example"))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		data, _ = decodeText(data)
+		if err := os.WriteFile(envPath, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range bases {
+		if err := setEnvValue(envPath, b.Name, strconv.Itoa(b.Base+suffix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
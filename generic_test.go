@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePortMap(t *testing.T) {
+	bases, err := parsePortMap("WEB_PORT=8000, PG_PORT=5400")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bases) != 2 || bases[0] != (PortBase{"WEB_PORT", 8000}) || bases[1] != (PortBase{"PG_PORT", 5400}) {
+		t.Errorf("Unexpected bases: %+v", bases)
+	}
+
+	for _, spec := range []string{"", "WEB_PORT", "WEB_PORT=abc", "WEB_PORT=70000", "A=1,A=2"} {
+		if _, err := parsePortMap(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestValidateSuffixFor(t *testing.T) {
+	bases := []PortBase{{"WEB_PORT", 60000}}
+	if err := validateSuffixFor(bases, 5535); err != nil {
+		t.Errorf("Expected 65535 to be valid, got %v", err)
+	}
+	if err := validateSuffixFor(bases, 5536); err == nil {
+		t.Error("Expected error when port exceeds 65535")
+	}
+}
+
+func TestWriteGenericPorts(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-generic-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// .env is created from .env.example
+	example := "# Web settings\nWEB_PORT=80\nOTHER=value\n"
+	if err := os.WriteFile(filepath.Join(tempDir, ".env.example"), []byte(example), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bases := []PortBase{{"WEB_PORT", 8000}, {"PG_PORT", 5400}}
+	if err := writeGenericPorts(tempDir, bases, 48); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, ".env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Web settings\nWEB_PORT=8048\nOTHER=value\nPG_PORT=5448\n"
+	if string(data) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, data)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// fieldName turns a port key into the field `sailinit get` knows it by:
+// APP_PORT is app_port, FORWARD_DB_PORT is db_port.
+func fieldName(key string) string {
+	return strings.ToLower(strings.TrimPrefix(key, "FORWARD_"))
+}
+
+// projectFields returns the values `sailinit get` can select for a
+// registered project. Values the project doesn't have are left out.
+func projectFields(p ProjectInfo) map[string]string {
+	env := readEnvValues(filepath.Join(p.Path, ".env"))
+	fields := map[string]string{
+		"name":   cmp.Or(p.Name, filepath.Base(p.Path)),
+		"path":   p.Path,
+		"suffix": strconv.Itoa(p.Suffix),
+	}
+	if len(p.PHPVersions) > 0 {
+		fields["php"] = p.PHPVersions[0]
+	}
+	for _, b := range projectPortBases(p.Path) {
+		fields[fieldName(b.Name)] = forwardedPort(env, b.Name, p.Suffix, "")
+	}
+	fields["url"] = "http://localhost:" + fields["app_port"]
+	if dsn, err := databaseDSN(env, p.Suffix); err == nil {
+		fields["db_dsn"] = dsn
+	}
+	fields["redis_dsn"] = redisDSN(env, p.Suffix)
+	return fields
+}
+
+// getSelector is a parsed `sailinit get` argument: a field of the current
+// project, or of every registered project, optionally filtered by another
+// field's value.
+type getSelector struct {
+	Field string
+	// All selects every registered project instead of the current one
+	All bool
+	// FilterField and FilterValue keep the projects whose field equals
+	// the value
+	FilterField string
+	FilterValue string
+}
+
+var (
+	projectsSelectorPattern = regexp.MustCompile(`^projects\[\]\.([a-z0-9_-]+)$`)
+	filterSelectorPattern   = regexp.MustCompile(`^projects\[\?([a-z0-9_-]+)\s*==\s*(?:'([^']*)'|"([^"]*)")\]\.([a-z0-9_-]+)$`)
+	fieldSelectorPattern    = regexp.MustCompile(`^[a-z0-9_-]+$`)
+)
+
+// parseSelector parses app_port (or app-port), projects[].app_port and
+// projects[?name=='shop'].app_port.
+func parseSelector(arg string) (getSelector, error) {
+	field := func(s string) string { return strings.ReplaceAll(s, "-", "_") }
+	arg = strings.TrimSpace(arg)
+	if m := filterSelectorPattern.FindStringSubmatch(arg); m != nil {
+		return getSelector{Field: field(m[4]), All: true, FilterField: field(m[1]), FilterValue: m[2] + m[3]}, nil
+	}
+	if m := projectsSelectorPattern.FindStringSubmatch(arg); m != nil {
+		return getSelector{Field: field(m[1]), All: true}, nil
+	}
+	if fieldSelectorPattern.MatchString(arg) {
+		return getSelector{Field: field(arg)}, nil
+	}
+	return getSelector{}, usageError(fmt.Errorf("invalid selector %q (use a field like app_port, projects[].app_port or projects[?name=='shop'].app_port)", arg))
+}
+
+// selectValues returns the selected field of each project, in order.
+// Unknown fields are reported with the fields there are.
+func selectValues(sel getSelector, projects []ProjectInfo) ([]string, error) {
+	var values []string
+	known := make(map[string]bool)
+	for _, p := range projects {
+		fields := projectFields(p)
+		for name := range fields {
+			known[name] = true
+		}
+		if sel.FilterField != "" && fields[sel.FilterField] != sel.FilterValue {
+			continue
+		}
+		if v, ok := fields[sel.Field]; ok {
+			values = append(values, v)
+		}
+	}
+	for _, name := range []string{sel.Field, sel.FilterField} {
+		if name != "" && !known[name] && len(projects) > 0 {
+			return nil, usageError(fmt.Errorf("unknown field %q (use one of %s)", name, strings.Join(slices.Sorted(maps.Keys(known)), ", ")))
+		}
+	}
+	return values, nil
+}
+
+func handleGet(args []string) {
+	fset := commandFlags("get")
+	var project string
+	fset.StringVar(&project, "p", "", "Project to read: a path, directory name or port suffix (default: the current one)")
+	fset.StringVar(&project, "project", "", "Same as -p")
+	fset.Parse(reorderArgs(args))
+	if fset.NArg() != 1 {
+		printError("Usage: sailinit get <selector> [-p <project>]")
+		exit(ExitUsage)
+	}
+	sel, err := parseSelector(fset.Arg(0))
+	if err != nil {
+		exitWithError("Error parsing selector", err)
+	}
+
+	projects, err := listRegisteredProjects()
+	if err != nil {
+		exitWithError("Error reading registry", err)
+	}
+	slices.SortFunc(projects, func(a, b ProjectInfo) int {
+		return cmp.Or(cmp.Compare(a.Suffix, b.Suffix), strings.Compare(a.Path, b.Path))
+	})
+	if !sel.All {
+		target := project
+		if target == "" {
+			if target, err = projectDirectory(); err != nil {
+				exitWithError("Error getting current directory", err)
+			}
+		}
+		if target, err = resolveSwitchTarget(projects, target); err != nil {
+			exitWithError("Error resolving project", err)
+		}
+		i := slices.IndexFunc(projects, func(p ProjectInfo) bool { return p.Path == target })
+		if i < 0 {
+			exitWithError("Error resolving project", usageError(fmt.Errorf("project not registered: %s", target)))
+		}
+		projects = projects[i : i+1]
+	}
+
+	values, err := selectValues(sel, projects)
+	if err != nil {
+		exitWithError("Error selecting values", err)
+	}
+	if len(values) == 0 {
+		exitWithError("Nothing selected", usageError(errors.New("no registered project has a value for "+fset.Arg(0))))
+	}
+	for _, v := range values {
+		fmt.Println(v)
+	}
+}
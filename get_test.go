@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	cases := map[string]getSelector{
+		"app-port":                            {Field: "app_port"},
+		"projects[].db_port":                  {Field: "db_port", All: true},
+		"projects[?name=='shop'].app_port":    {Field: "app_port", All: true, FilterField: "name", FilterValue: "shop"},
+		`projects[?suffix == "52"].vite-port`: {Field: "vite_port", All: true, FilterField: "suffix", FilterValue: "52"},
+	}
+	for arg, want := range cases {
+		if got, err := parseSelector(arg); err != nil || got != want {
+			t.Errorf("parseSelector(%q) = %+v (err=%v), want %+v", arg, got, err, want)
+		}
+	}
+	for _, bad := range []string{"projects[0].app_port", "APP_PORT", "projects[?name=shop].app_port"} {
+		if _, err := parseSelector(bad); err == nil || exitCode(err) != ExitUsage {
+			t.Errorf("Expected %q to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestSelectValues(t *testing.T) {
+	root := t.TempDir()
+	shop := filepath.Join(root, "shop")
+	blog := filepath.Join(root, "blog")
+	os.MkdirAll(shop, 0755)
+	os.MkdirAll(blog, 0755)
+	os.WriteFile(filepath.Join(shop, ".env"), []byte("APP_PORT=8080\n"), 0644)
+	projects := []ProjectInfo{{Path: blog, Suffix: 51}, {Path: shop, Suffix: 52}}
+
+	got, err := selectValues(getSelector{Field: "app_port", All: true}, projects)
+	if err != nil || !slices.Equal(got, []string{"8051", "8080"}) {
+		t.Errorf("Expected the app ports from the suffix and .env, got %v (err=%v)", got, err)
+	}
+	got, _ = selectValues(getSelector{Field: "db_port", All: true, FilterField: "name", FilterValue: "shop"}, projects)
+	if !slices.Equal(got, []string{"3352"}) {
+		t.Errorf("Expected shop's database port, got %v", got)
+	}
+	got, _ = selectValues(getSelector{Field: "url"}, projects[:1])
+	if !slices.Equal(got, []string{"http://localhost:8051"}) {
+		t.Errorf("Expected blog's URL, got %v", got)
+	}
+	if _, err := selectValues(getSelector{Field: "color"}, projects); err == nil || !strings.Contains(err.Error(), "app_port") {
+		t.Errorf("Expected an unknown field to list the known ones, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// healthCheck is one part of a project's health score.
+type healthCheck struct {
+	// Name is the short name shown for a failing check
+	Name string
+	OK   bool
+}
+
+// projectHealth is the composite health of a registered project: whether
+// it is registered, its directory exists, Sail is installed, its .env
+// agrees with its compose services and ports, and no running container is
+// failing. A stopped project's containers count as fine.
+type projectHealth []healthCheck
+
+// healthOf scores a project from its status and its .env issues.
+func healthOf(st ProjectStatus, envIssues int) projectHealth {
+	return projectHealth{
+		{"registered", st.Registered},
+		{"dir", st.Exists},
+		{"sail", st.Exists && st.Sail},
+		{"env", st.Exists && envIssues == 0},
+		{"containers", st.Exists && st.Error == "" && (st.Running == 0 || st.Healthy)},
+	}
+}
+
+// score returns how many checks pass out of how many there are.
+func (h projectHealth) score() (int, int) {
+	passed := 0
+	for _, c := range h {
+		if c.OK {
+			passed++
+		}
+	}
+	return passed, len(h)
+}
+
+// String renders the score compactly with the failing checks, e.g.
+// "3/5 sail,env", colored by how many checks pass.
+func (h projectHealth) String() string {
+	passed, total := h.score()
+	text := fmt.Sprintf("%d/%d", passed, total)
+	var failed []string
+	for _, c := range h {
+		if !c.OK {
+			failed = append(failed, c.Name)
+		}
+	}
+	switch {
+	case len(failed) == 0:
+		return colorize(colorGreen, text)
+	case passed >= total-1:
+		return colorize(colorYellow, text+" "+strings.Join(failed, ","))
+	}
+	return colorize(colorRed, text+" "+strings.Join(failed, ","))
+}
+
+// projectHealths scores the registered projects at paths, inspecting them
+// concurrently since each one asks docker for its containers.
+func projectHealths(paths []string) map[string]projectHealth {
+	state, _, err := loadPortState()
+	if err != nil {
+		state = &PortState{}
+	}
+	healths := make([]projectHealth, len(paths))
+	sem := make(chan struct{}, statConcurrency)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			st := state.status(path)
+			issues := 0
+			if st.Exists {
+				issues = len(projectEnvIssues(path, st.Suffix))
+			}
+			healths[i] = healthOf(st, issues)
+		})
+	}
+	wg.Wait()
+
+	result := make(map[string]projectHealth, len(paths))
+	for i, path := range paths {
+		result[path] = healths[i]
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHealthOf(t *testing.T) {
+	healthy := ProjectStatus{Registered: true, Exists: true, Sail: true, Running: 3, Healthy: true}
+	if passed, total := healthOf(healthy, 0).score(); passed != 5 || total != 5 {
+		t.Errorf("Expected 5/5, got %d/%d", passed, total)
+	}
+
+	stopped := ProjectStatus{Registered: true, Exists: true, Sail: true}
+	if passed, _ := healthOf(stopped, 0).score(); passed != 5 {
+		t.Errorf("Expected a stopped project to pass every check, got %d", passed)
+	}
+
+	failing := ProjectStatus{Registered: true, Exists: true, Running: 2}
+	got := healthOf(failing, 1).String()
+	if !strings.Contains(got, "2/5 sail,env,containers") {
+		t.Errorf("Expected the failing checks to be named, got %q", got)
+	}
+
+	missing := healthOf(ProjectStatus{Registered: true}, 0)
+	if passed, _ := missing.score(); passed != 1 {
+		t.Errorf("Expected only registered to pass for a missing project, got %d", passed)
+	}
+}
+
+func TestProjectHealths(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	if err := saveProjectSuffix(dir, 12); err != nil {
+		t.Fatal(err)
+	}
+	health := projectHealths([]string{dir})[dir]
+	if passed, total := health.score(); passed != 4 || total != 5 || !strings.Contains(health.String(), "sail") {
+		t.Errorf("Expected only the sail check to fail, got %s", health)
+	}
+}
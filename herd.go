@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// localServer describes a Valet-style server (Laravel Herd or Valet) that
+// serves parked and linked directories under a local TLD.
+type localServer struct {
+	Name      string
+	ConfigDir string
+}
+
+// localServers returns the Herd and Valet configuration locations for home.
+func localServers(home string) []localServer {
+	return []localServer{
+		{Name: "Herd", ConfigDir: filepath.Join(home, "Library", "Application Support", "Herd", "config", "valet")},
+		{Name: "Valet", ConfigDir: filepath.Join(home, ".config", "valet")},
+	}
+}
+
+type valetConfig struct {
+	TLD   string   `json:"tld"`
+	Paths []string `json:"paths"`
+}
+
+// valetDomain reports the domain under which server serves projectDir,
+// either because its parent directory is parked or because a link in one
+// of the served paths points at it.
+func (s localServer) valetDomain(projectDir string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(s.ConfigDir, "config.json"))
+	if err != nil {
+		return "", false
+	}
+	var cfg valetConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", false
+	}
+	if cfg.TLD == "" {
+		cfg.TLD = "test"
+	}
+
+	// Linked sites live in the Sites directory, which is always served
+	paths := append(cfg.Paths, filepath.Join(s.ConfigDir, "Sites"))
+
+	if slices.Contains(paths, filepath.Dir(projectDir)) {
+		return strings.ToLower(filepath.Base(projectDir)) + "." + cfg.TLD, true
+	}
+	resolved, err := filepath.EvalSymlinks(projectDir)
+	if err != nil {
+		resolved = projectDir
+	}
+	for _, dir := range paths {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			target, err := filepath.EvalSymlinks(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			if entry.Type()&os.ModeSymlink != 0 && target == resolved {
+				return strings.ToLower(entry.Name()) + "." + cfg.TLD, true
+			}
+		}
+	}
+	return "", false
+}
+
+// herdValetConflict describes a Herd/Valet site that serves the same project.
+type herdValetConflict struct {
+	Server string
+	Domain string
+	// AppURLUsesDomain is true when APP_URL points at the Herd/Valet domain
+	// rather than the Sail port.
+	AppURLUsesDomain bool
+}
+
+// detectHerdValet checks whether Herd or Valet serves projectDir and
+// whether APP_URL currently targets that domain.
+func detectHerdValet(projectDir, home string) (herdValetConflict, bool) {
+	for _, server := range localServers(home) {
+		domain, ok := server.valetDomain(projectDir)
+		if !ok {
+			continue
+		}
+		conflict := herdValetConflict{Server: server.Name, Domain: domain}
+		appURL := readEnvValues(filepath.Join(projectDir, ".env"))["APP_URL"]
+		if u, err := url.Parse(appURL); err == nil && u.Hostname() == domain {
+			conflict.AppURLUsesDomain = true
+		}
+		return conflict, true
+	}
+	return herdValetConflict{}, false
+}
+
+// chooseHerdOrSail asks whether Sail or the Herd/Valet server that also
+// serves the project should own APP_URL, and returns the APP_URL to set, or
+// "" to leave it as it is. Pressing Enter keeps the current choice.
+func chooseHerdOrSail(reader *bufio.Reader, conflict herdValetConflict, sailURL string) string {
+	localURL := "http://" + conflict.Domain
+	current := "1"
+	if conflict.AppURLUsesDomain {
+		current = "2"
+	}
+	for {
+		input := strings.ToLower(readAnswer(reader, tr("herd_choose", sailURL, conflict.Server, localURL, current)))
+		if input == "" {
+			input = current
+		}
+		switch {
+		case input == current:
+			return ""
+		case input == "1" || input == "sail":
+			return sailURL
+		case input == "2" || input == strings.ToLower(conflict.Server):
+			return localURL
+		}
+		printError(tr("herd_invalid_choice", conflict.Server))
+	}
+}
+
+// isPortListening reports whether something accepts TCP connections on the
+// local port. Unlike CheckPortAvailable it works for privileged ports.
+func isPortListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 300*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func checkHerdValet(projectDir string) []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	conflict, found := detectHerdValet(projectDir, home)
+	if !found {
+		return nil
+	}
+
+	problems := []string{fmt.Sprintf("%s also serves this project at http://%s", conflict.Server, conflict.Domain)}
+	if conflict.AppURLUsesDomain {
+		problems = append(problems, fmt.Sprintf("APP_URL points at the %s domain, so URLs generated inside Sail will not use the Sail port", conflict.Server))
+	}
+	for _, port := range []int{80, 443} {
+		if isPortListening(port) {
+			problems = append(problems, fmt.Sprintf("port %d is in use (likely %s)", port, conflict.Server))
+		}
+	}
+	return problems
+}
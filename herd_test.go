@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeValetConfig(t *testing.T, configDir string, cfg valetConfig) {
+	t.Helper()
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(cfg)
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectHerdValetParked(t *testing.T) {
+	home, err := os.MkdirTemp("", "sail-herd-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+
+	codeDir := filepath.Join(home, "code")
+	projectDir := filepath.Join(codeDir, "Shop")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := detectHerdValet(projectDir, home); found {
+		t.Fatal("Expected no conflict without Valet config")
+	}
+
+	writeValetConfig(t, filepath.Join(home, ".config", "valet"), valetConfig{TLD: "test", Paths: []string{codeDir}})
+	os.WriteFile(filepath.Join(projectDir, ".env"), []byte("APP_URL=http://shop.test\n"), 0644)
+
+	conflict, found := detectHerdValet(projectDir, home)
+	if !found {
+		t.Fatal("Expected Valet to be detected for parked directory")
+	}
+	if conflict.Server != "Valet" || conflict.Domain != "shop.test" {
+		t.Errorf("Expected Valet at shop.test, got %+v", conflict)
+	}
+	if !conflict.AppURLUsesDomain {
+		t.Error("Expected APP_URL to be detected as using the Valet domain")
+	}
+}
+
+func TestDetectHerdValetLinked(t *testing.T) {
+	home, err := os.MkdirTemp("", "sail-herd-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+
+	projectDir := filepath.Join(home, "elsewhere", "blog")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	herdDir := filepath.Join(home, "Library", "Application Support", "Herd", "config", "valet")
+	writeValetConfig(t, herdDir, valetConfig{TLD: "herd"})
+	sitesDir := filepath.Join(herdDir, "Sites")
+	if err := os.MkdirAll(sitesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(projectDir, filepath.Join(sitesDir, "my-blog")); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(projectDir, ".env"), []byte("APP_URL=http://localhost:8051\n"), 0644)
+
+	conflict, found := detectHerdValet(projectDir, home)
+	if !found {
+		t.Fatal("Expected Herd to be detected for linked site")
+	}
+	if conflict.Server != "Herd" || conflict.Domain != "my-blog.herd" {
+		t.Errorf("Expected Herd at my-blog.herd, got %+v", conflict)
+	}
+	if conflict.AppURLUsesDomain {
+		t.Error("APP_URL uses the Sail port and should not be flagged")
+	}
+}
+
+func TestChooseHerdOrSail(t *testing.T) {
+	conflict := herdValetConflict{Server: "Herd", Domain: "shop.test"}
+	sailURL := "http://localhost:8051"
+	cases := []struct {
+		input    string
+		inDomain bool
+		want     string
+	}{
+		{"\n", false, ""},
+		{"2\n", false, "http://shop.test"},
+		{"herd\n", false, "http://shop.test"},
+		{"\n", true, ""},
+		{"1\n", true, sailURL},
+		{"maybe\nsail\n", true, sailURL},
+	}
+	for _, c := range cases {
+		conflict.AppURLUsesDomain = c.inDomain
+		if got := chooseHerdOrSail(bufio.NewReader(strings.NewReader(c.input)), conflict, sailURL); got != c.want {
+			t.Errorf("chooseHerdOrSail(%q, domain=%v) = %q, want %q", c.input, c.inDomain, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// historyLimit is how many events are kept per project.
+const historyLimit = 100
+
+// historyEvent is one sailinit operation on a project.
+type historyEvent struct {
+	At     time.Time `json:"at"`
+	Op     string    `json:"op"`
+	Detail string    `json:"detail,omitempty"`
+	// Error is set when the operation failed
+	Error string `json:"error,omitempty"`
+}
+
+// appendHistory adds event to absDir's history, keeping the newest
+// historyLimit events.
+func (s *PortState) appendHistory(absDir string, event historyEvent) {
+	if s.History == nil {
+		s.History = make(map[string][]historyEvent)
+	}
+	events := append(s.History[absDir], event)
+	s.History[absDir] = events[max(0, len(events)-historyLimit):]
+}
+
+// recordHistory logs an operation and its outcome for a registered project.
+// History is best effort: read-only registries and unregistered projects
+// are skipped, and failures only print a message.
+func recordHistory(projectDir, op, detail string, opErr error) {
+	if stateReadOnlyReason() != "" {
+		return
+	}
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return
+	}
+	event := historyEvent{At: time.Now().UTC().Truncate(time.Second), Op: op, Detail: detail}
+	if opErr != nil {
+		event.Error = opErr.Error()
+	}
+	err = updatePortState(func(state *PortState) error {
+		if _, ok := state.Projects[absDir]; ok {
+			state.appendHistory(absDir, event)
+		}
+		return nil
+	})
+	if err != nil {
+		printError(fmt.Sprintf("Error recording history: %v", err))
+	}
+}
+
+// setupDetail summarizes the choices of a setup plan for the history.
+func setupDetail(plan *setupPlan) string {
+	parts := []string{fmt.Sprintf("suffix %d", plan.Suffix), "PHP " + plan.PHPVersion}
+	if len(plan.ResetDB) > 0 {
+		parts = append(parts, "reset-db "+strings.Join(plan.ResetDB, ","))
+	}
+	if plan.Fresh {
+		parts = append(parts, "fresh")
+	}
+	for _, e := range plan.EnvFixes {
+		parts = append(parts, e.Key+"="+e.Value)
+	}
+	if plan.AppURL != "" {
+		parts = append(parts, "APP_URL="+plan.AppURL)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resetDetail lists what a --reset-* run restored.
+func resetDetail(opts resetOptions) string {
+	var parts []string
+	if opts.Ports {
+		parts = append(parts, "ports")
+	}
+	if opts.Xdebug {
+		parts = append(parts, "xdebug")
+	}
+	if len(opts.DB) > 0 {
+		parts = append(parts, "db "+strings.Join(opts.DB, ","))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// phasesDetail lists the steps of a start and whether each ran or was
+// cached.
+func phasesDetail(phases []startPhase) string {
+	var parts []string
+	for _, p := range phases {
+		if p.Cached {
+			parts = append(parts, p.Name+" (cached)")
+		} else {
+			parts = append(parts, p.Name)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatHistory renders events oldest first, one per line.
+func formatHistory(events []historyEvent) string {
+	var b strings.Builder
+	for _, e := range events {
+		line := fmt.Sprintf("%s  %-8s %s", e.At.Local().Format("2006-01-02 15:04:05"), e.Op, e.Detail)
+		if e.Error != "" {
+			line += colorize(colorRed, " (failed: "+e.Error+")")
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return b.String()
+}
+
+func handleHistory(args []string) {
+	fset := commandFlags("history")
+	limit := fset.Int("n", 20, "Show at most this many of the newest events (0 for all)")
+	fset.Parse(reorderArgs(args))
+	defer startPager()()
+
+	target, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	if fset.NArg() > 0 {
+		projects, err := listRegisteredProjects()
+		if err != nil {
+			exitWithError("Error reading registry", err)
+		}
+		if target, err = resolveSwitchTarget(projects, fset.Arg(0)); err != nil {
+			exitWithError("Error resolving project", err)
+		}
+	}
+
+	state, _, err := loadPortState()
+	if err != nil {
+		exitWithError("Error reading registry", err)
+	}
+	if _, ok := state.Projects[target]; !ok {
+		exitWithError("No history", usageError(fmt.Errorf("project not registered: %s", target)))
+	}
+	events := state.History[target]
+	if len(events) == 0 {
+		printInfo("No recorded operations yet.")
+		return
+	}
+	if *limit > 0 && len(events) > *limit {
+		events = events[len(events)-*limit:]
+	}
+	fmt.Print(formatHistory(events))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordHistory(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(projectDir, 0755)
+	recordHistory(projectDir, "up", "", nil)
+	if state, _, _ := loadPortState(); len(state.History) != 0 {
+		t.Errorf("Expected unregistered projects to have no history, got %v", state.History)
+	}
+
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProjectSuffix(projectDir, 60); err != nil {
+		t.Fatal(err)
+	}
+	recordHistory(projectDir, "down", "", errors.New("docker is not running"))
+
+	state, _, err := loadPortState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := state.History[projectDir]
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", events)
+	}
+	if events[0].Detail != "registered with suffix 51" || events[1].Detail != "suffix changed from 51 to 60" {
+		t.Errorf("Unexpected suffix events %+v", events[:2])
+	}
+	if events[2].Op != "down" || events[2].Error != "docker is not running" {
+		t.Errorf("Expected a failed down event, got %+v", events[2])
+	}
+
+	out := formatHistory(events)
+	if !strings.Contains(out, "suffix changed from 51 to 60") || !strings.Contains(out, "failed: docker is not running") {
+		t.Errorf("Unexpected history output:\n%s", out)
+	}
+}
+
+func TestAppendHistoryLimit(t *testing.T) {
+	state := &PortState{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < historyLimit+5; i++ {
+		state.appendHistory("/srv/app", historyEvent{At: start.Add(time.Duration(i) * time.Minute), Op: "up"})
+	}
+	events := state.History["/srv/app"]
+	if len(events) != historyLimit {
+		t.Fatalf("Expected %d events kept, got %d", historyLimit, len(events))
+	}
+	if !events[0].At.Equal(start.Add(5 * time.Minute)) {
+		t.Errorf("Expected the oldest events to be dropped, first is %s", events[0].At)
+	}
+}
+
+func TestSetupDetail(t *testing.T) {
+	plan := &setupPlan{
+		Suffix:     51,
+		PHPVersion: "84",
+		ResetDB:    resetScope{"credentials"},
+		EnvFixes:   []envEntry{{Key: "DB_HOST", Value: "mysql"}},
+	}
+	if got := setupDetail(plan); got != "suffix 51, PHP 84, reset-db credentials, DB_HOST=mysql" {
+		t.Errorf("Unexpected detail %q", got)
+	}
+	if got := resetDetail(resetOptions{Ports: true, DB: resetScope{"connection"}}); got != "ports, db connection" {
+		t.Errorf("Unexpected reset detail %q", got)
+	}
+}
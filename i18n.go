@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// catalogs holds the translated CLI messages per language. English is the
+// fallback for any key a translation is missing.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"yes_no":                 "[y/N]",
+		"php_mismatch":           "Warning: Manually specified PHP version (%s) differs from detected version in compose file (%s).",
+		"continue_anyway":        "Continue anyway?",
+		"ports_next_free":        "Use suffix %d instead, where all ports are free?",
+		"php_detected":           "Detected PHP version: %s",
+		"php_pinned":             "Using PHP version %s pinned by %s",
+		"php_default":            "No PHP version detected. Using default: %s",
+		"php_multiple":           "Detected multiple PHP runtimes: %s",
+		"php_choose":             "Which runtime should run composer install? [%s]: ",
+		"php_invalid_choice":     "Invalid choice. Please enter one of: %s",
+		"setup_start":            "Starting Laravel Sail setup for PHP %s...",
+		"first_setup":            "First-ever setup detected.",
+		"start_suffix_prompt":    "Enter the starting port suffix for your projects [default 48]: ",
+		"suffix_not_number":      "Invalid suffix. Please enter a number.",
+		"suffix_invalid":         "Invalid suffix: %v",
+		"suffix_existing":        "Detected existing port suffix: %d",
+		"suffix_requested":       "Using port suffix %d from %s",
+		"env_empty":              "Neither .env nor .env.example exists and no env_baseline is configured; starting from an empty .env",
+		"env_baseline_fetch":     "Fetching the env baseline from %s ...",
+		"env_baseline_written":   "Checksum verified; wrote .env.example from the env baseline.",
+		"web_port_busy":          "Port %d is in use by %s. Sail does not use ports 80/443: each project gets its own port from its suffix, so this one is at %s",
+		"web_port_fix_url":       "APP_URL=%s would reach the server on port %d. Set APP_URL to %s?",
+		"web_port_pending":       "APP_URL=%s reaches a local server instead of Sail; the app is at %s.",
+		"suffix_prompt":          "Use suffix [%d]? (Press Enter to confirm, or type new suffix): ",
+		"suffix_taken":           "Error: Suffix %d is already in use by another project:\n%s",
+		"suffix_using":           "Using port suffix: %d",
+		"ports_busy":             "Warning: The following ports are already in use:",
+		"steal_prompt":           "Stop %s and continue?",
+		"setup_complete":         "\nSetup complete! Your application is running with the following ports:",
+		"label_app":              "Main App",
+		"label_mailpit":          "Mailpit Dashboard",
+		"label_mailhog":          "Mailhog Dashboard",
+		"label_minio_console":    "MinIO Console",
+		"label_database":         "Database",
+		"example_missing":        "Warning: %d key(s) from .env.example are missing in .env:",
+		"example_append":         "Append them with the example defaults?",
+		"herd_serves":            "Warning: %s also serves this project at http://%s",
+		"herd_choose":            "Configure this project for 1) Sail (APP_URL=%s) or 2) %s (APP_URL=%s)? [%s]: ",
+		"herd_invalid_choice":    "Please answer 1 (Sail) or 2 (%s).",
+		"herd_kept":              "APP_URL points at %s (http://%s).",
+		"ports_waiting":          "Waiting up to %s for %d busy port(s) to free up...",
+		"port_used_by":           "  %s: Port %d is used by %s",
+		"port_reserved":          "  %s: Port %d is reserved for %s in the config file",
+		"project_stopped":        "Stopped %s.",
+		"running_limit":          "%d other project(s) are running; max_running allows %d at a time.",
+		"running_limit_stop":     "Stop %s (least recently used)?",
+		"running_over_limit":     "Continuing above the limit of %d running project(s).",
+		"step_cached":            "%s: cached (inputs unchanged)",
+		"project_root":           "Using the project root %s",
+		"stack_running":          "Containers of %s are still running: %s",
+		"stack_down_prompt":      "Take them down first (sail down)?",
+		"stack_kept":             "Keeping %s in the registry while its containers run.",
+		"docker_login_retry":     "Run %s now and try again?",
+		"sail_fallback":          "vendor/bin/sail is missing; running docker compose %s directly",
+		"env_ports_running":      "Changed %s while the containers run: they publish the old ports until sail down && sail up.",
+		"restart_stack_now":      "Run sail down && sail up now?",
+		"env_preview":            "Changes to .env:",
+		"env_backed_up":          "Backed up .env to %s (undo with: sailinit rollback)",
+		"testing_synced":         "Synced test ports in %s",
+		"disk_space_low":         "Only %s free on %s (%s), less than %d GB. Image pulls and composer install may fail; docker system prune frees space.",
+		"rollback_confirm":       "Restore .env from %s?",
+		"env_write_confirm":      "Write these changes to .env?",
+		"docker_retry":           "%s failed with a transient error; retrying in %s (%d of %d)...",
+		"sail_outdated":          "laravel/sail %s is outdated for Laravel %s; %s or newer is recommended.",
+		"sail_update_prompt":     "Update laravel/sail now?",
+		"sail_install_prompt":    "Refresh the compose file with sail:install --with=%s? The current file is backed up first.",
+		"sqlite_db_service":      "The compose file still runs the %s service, so FORWARD_DB_PORT stays allocated; remove the service to use SQLite only.",
+		"db_engine_prompt":       "Switch %s from %s to %s?",
+		"db_engine_migrate":      "Switch %s from %s to %s? This dumps the database, then deletes the containers and volumes of %s and loads the dump into the new engine.",
+		"bench_cold_prompt":      "The cold run deletes the containers and volumes of %s, including its database. Continue?",
+		"app_key_generated":      "APP_KEY was empty and a new key has been generated.",
+		"example_keys_added":     "Added %d key(s) from .env.example to .env.",
+		"example_keys_pending":   "Some .env.example keys are still missing from .env (see sailinit doctor).",
+		"key_locked":             "%s is locked; leaving it unchanged.",
+		"env_issue":              "Warning: %s.",
+		"env_issue_fix":          "Set %s=%s?",
+		"env_issue_pending":      "%s still does not match the compose services (see sailinit doctor).",
+		"tz_compose":             "Added TZ to the compose environment of: %s",
+		"cache_stale":            "Cached configuration found (%s); it will be cleared after sail up.",
+		"cache_clear_failed":     "Could not clear cached configuration (%v); run: sail artisan optimize:clear",
+		"urls_copied":            "Service URLs copied to the clipboard.",
+		"clipboard_failed":       "Could not copy the URLs to the clipboard: %v",
+		"browser_failed":         "Could not open the browser: %v",
+		"ready_waiting":          "Waiting for containers and the app to become ready...",
+		"ready_ok":               "All containers are ready.",
+		"diagnostics_written":    "Diagnostics saved to %s (secrets masked); attach it when asking for help.",
+		"package_service":        "Warning: composer.json requires %s, which needs a %[2]s service the compose file does not define. Add it with: sail artisan sail:add %[2]s",
+		"package_port":           "Warning: composer.json requires %s, but no service publishes %[2]s. Publish \"${%[2]s:-8080}:8080\" on the app service and add %[2]s: %[3]d under its entry in the config file's services, so each project gets its own port.",
+		"composer_stale_removed": "Removing composer containers left by an aborted run: %s",
+		"composer_orphan_prompt": "Composer containers of an aborted sailinit are still running: %s. Remove them?",
+		"composer_interrupted":   "Interrupted (%s); the composer container was removed.",
+		"tracing_protocol":       "Tracing is off: OTEL_EXPORTER_OTLP_PROTOCOL=%s is not supported, only http/json",
+		"tracing_export_failed":  "Could not export the trace: %v",
+		"orphan_take_down":       "  Take %s down?",
+		"debug_clear_later":      "The containers are not running; after starting them run: sail artisan config:clear",
+		"debug_clearing":         "Clearing cached config (artisan config:clear)...",
+		"debug_clear_failed":     "Could not clear cached config (%v); run: sail artisan config:clear",
+		"debug_already_on":       "Debugging is already on.",
+		"debug_already_off":      "Debugging is already off.",
+		"debug_turned_on":        "Debugging turned on.",
+		"debug_turned_off":       "Debugging turned off.",
+		"env_exposed":            ".env is %04o and readable by other users but contains secrets (%s). Restrict it with: sailinit env tighten",
+	},
+	"hu": {
+		"yes_no":                 "[i/N]",
+		"php_mismatch":           "Figyelem: A megadott PHP verzió (%s) eltér a compose fájlban észlelt verziótól (%s).",
+		"continue_anyway":        "Folytatod mégis?",
+		"ports_next_free":        "Használjuk inkább a(z) %d suffixet, ahol minden port szabad?",
+		"php_detected":           "Észlelt PHP verzió: %s",
+		"php_pinned":             "A(z) %[2]s által rögzített %[1]s PHP verzió használata",
+		"php_default":            "Nem található PHP verzió. Alapértelmezett: %s",
+		"php_multiple":           "Több PHP futtatókörnyezet észlelve: %s",
+		"php_choose":             "Melyik futtatókörnyezet futtassa a composer install-t? [%s]: ",
+		"php_invalid_choice":     "Érvénytelen választás. Add meg valamelyiket: %s",
+		"setup_start":            "Laravel Sail beállítás indítása PHP %s verzióval...",
+		"first_setup":            "Első beállítás észlelve.",
+		"start_suffix_prompt":    "Add meg a projektek kezdő port-utótagját [alapértelmezett 48]: ",
+		"suffix_not_number":      "Érvénytelen utótag. Kérlek, adj meg egy számot.",
+		"suffix_invalid":         "Érvénytelen utótag: %v",
+		"suffix_existing":        "Meglévő port-utótag észlelve: %d",
+		"suffix_requested":       "A(z) %[2]s által megadott %[1]d port-utótag használata",
+		"env_empty":              "Nincs sem .env, sem .env.example, és env_baseline sincs beállítva; üres .env-ből indulunk",
+		"env_baseline_fetch":     "Az env-alap letöltése innen: %s ...",
+		"env_baseline_written":   "Az ellenőrzőösszeg egyezik; a .env.example az env-alapból készült.",
+		"web_port_busy":          "A %d-as portot ez használja: %s. A Sail nem a 80/443-as portot használja: minden projekt az utótagjából kap saját portot, ez a projekt itt érhető el: %s",
+		"web_port_fix_url":       "Az APP_URL=%s a %d-as porton futó szerverre mutat. Beállítsam az APP_URL-t erre: %s?",
+		"web_port_pending":       "Az APP_URL=%s egy helyi szerverre mutat a Sail helyett; az alkalmazás itt érhető el: %s.",
+		"suffix_prompt":          "Használjuk a(z) [%d] utótagot? (Enter a megerősítéshez, vagy írj be új utótagot): ",
+		"suffix_taken":           "Hiba: A(z) %d utótagot már egy másik projekt használja:\n%s",
+		"suffix_using":           "Használt port-utótag: %d",
+		"ports_busy":             "Figyelem: A következő portok már foglaltak:",
+		"steal_prompt":           "Leállítsuk a(z) %s projektet, és folytassuk?",
+		"setup_complete":         "\nA beállítás kész! Az alkalmazás a következő portokon fut:",
+		"label_app":              "Fő alkalmazás",
+		"label_mailpit":          "Mailpit felület",
+		"label_mailhog":          "Mailhog felület",
+		"label_minio_console":    "MinIO konzol",
+		"label_database":         "Adatbázis",
+		"example_missing":        "Figyelem: %d kulcs a .env.example fájlból hiányzik a .env fájlból:",
+		"example_append":         "Hozzáadjuk őket a példa alapértékeivel?",
+		"herd_serves":            "Figyelem: A(z) %s is kiszolgálja ezt a projektet: http://%s",
+		"herd_choose":            "Mire állítsuk be a projektet: 1) Sail (APP_URL=%s) vagy 2) %s (APP_URL=%s)? [%s]: ",
+		"herd_invalid_choice":    "Válaszolj 1-gyel (Sail) vagy 2-vel (%s).",
+		"herd_kept":              "Az APP_URL a(z) %s címére mutat (http://%s).",
+		"ports_waiting":          "Legfeljebb %s várakozás %d foglalt port felszabadulására...",
+		"port_used_by":           "  %s: A(z) %d portot használja: %s",
+		"port_reserved":          "  %s: A(z) %d port a konfigurációban fenn van tartva: %s",
+		"project_stopped":        "%s leállítva.",
+		"running_limit":          "%d másik projekt fut; a max_running legfeljebb %d egyidejű projektet enged.",
+		"running_limit_stop":     "Leállítsuk a(z) %s projektet (legrégebben használt)?",
+		"running_over_limit":     "Folytatás a(z) %d futó projektes korlát felett.",
+		"step_cached":            "%s: gyorsítótárból (a bemenetek nem változtak)",
+		"project_root":           "A projekt gyökerének használata: %s",
+		"stack_running":          "A(z) %s konténerei még futnak: %s",
+		"stack_down_prompt":      "Leállítsuk őket előbb (sail down)?",
+		"stack_kept":             "A(z) %s a nyilvántartásban marad, amíg a konténerei futnak.",
+		"docker_login_retry":     "Futtassuk most a(z) %s parancsot, és próbáljuk újra?",
+		"sail_fallback":          "A vendor/bin/sail hiányzik; a docker compose %s közvetlenül fut",
+		"env_ports_running":      "A(z) %s megváltozott, miközben a konténerek futnak: a sail down && sail up futtatásáig a régi portokat használják.",
+		"restart_stack_now":      "Futtassuk most a sail down && sail up parancsot?",
+		"env_preview":            "A .env változásai:",
+		"env_backed_up":          "A .env mentése: %s (visszaállítás: sailinit rollback)",
+		"testing_synced":         "Tesztportok frissítve: %s",
+		"disk_space_low":         "Csak %s szabad hely van ezen: %s (%s), kevesebb mint %d GB. A képek letöltése és a composer install meghiúsulhat; a docker system prune helyet szabadít fel.",
+		"rollback_confirm":       "Visszaállítsuk a .env fájlt ebből: %s?",
+		"env_write_confirm":      "Beírjuk ezeket a változásokat a .env fájlba?",
+		"docker_retry":           "A(z) %s átmeneti hibával leállt; újrapróbálás %s múlva (%d/%d)...",
+		"sail_outdated":          "A laravel/sail %s elavult a Laravel %s verzióhoz; legalább %s ajánlott.",
+		"sail_update_prompt":     "Frissítsük most a laravel/sail csomagot?",
+		"sail_install_prompt":    "Frissítsük a compose fájlt a sail:install --with=%s paranccsal? A jelenlegi fájlról előbb mentés készül.",
+		"sqlite_db_service":      "A compose fájl még futtatja a(z) %s szolgáltatást, ezért a FORWARD_DB_PORT foglalt marad; töröld a szolgáltatást, ha csak SQLite-ot használnál.",
+		"db_engine_prompt":       "Átállítsuk a(z) %s szolgáltatást %s helyett %s használatára?",
+		"db_engine_migrate":      "Átállítsuk a(z) %s szolgáltatást %s helyett %s használatára? Ez menti az adatbázist, törli a(z) %s konténereit és köteteit, majd betölti a mentést az új motorba.",
+		"bench_cold_prompt":      "A hideg futtatás törli a(z) %s konténereit és köteteit, az adatbázissal együtt. Folytatjuk?",
+		"app_key_generated":      "Az APP_KEY üres volt, új kulcs lett generálva.",
+		"example_keys_added":     "%d kulcs hozzáadva a .env.example fájlból a .env fájlhoz.",
+		"example_keys_pending":   "Néhány .env.example kulcs még hiányzik a .env fájlból (lásd: sailinit doctor).",
+		"key_locked":             "A(z) %s zárolva van, nem módosítjuk.",
+		"env_issue":              "Figyelem: %s.",
+		"env_issue_fix":          "Beállítsuk: %s=%s?",
+		"env_issue_pending":      "A(z) %s még mindig nem illik a compose szolgáltatásokhoz (lásd: sailinit doctor).",
+		"tz_compose":             "TZ hozzáadva a compose környezethez: %s",
+		"cache_stale":            "Gyorsítótárazott konfiguráció található (%s); a sail up után törlődik.",
+		"cache_clear_failed":     "Nem sikerült törölni a gyorsítótárazott konfigurációt (%v); futtasd: sail artisan optimize:clear",
+		"urls_copied":            "A szolgáltatások URL-jei a vágólapra másolva.",
+		"clipboard_failed":       "Nem sikerült az URL-eket a vágólapra másolni: %v",
+		"browser_failed":         "Nem sikerült megnyitni a böngészőt: %v",
+		"ready_waiting":          "Várakozás, amíg a konténerek és az alkalmazás elindulnak...",
+		"ready_ok":               "Minden konténer kész.",
+		"diagnostics_written":    "Diagnosztika mentve: %s (titkok kitakarva); csatold, ha segítséget kérsz.",
+		"package_service":        "Figyelem: a composer.json igényli a(z) %s csomagot, amelyhez %[2]s szolgáltatás kell, de a compose fájl nem definiálja. Hozzáadás: sail artisan sail:add %[2]s",
+		"package_port":           "Figyelem: a composer.json igényli a(z) %s csomagot, de egy szolgáltatás sem publikálja a(z) %[2]s portot. Publikáld a \"${%[2]s:-8080}:8080\" portot az alkalmazás szolgáltatásán, és add hozzá a %[2]s: %[3]d sort a bejegyzése alá a konfigurációs fájl services részében, hogy minden projekt saját portot kapjon.",
+		"composer_stale_removed": "Egy megszakított futás után maradt composer konténerek törlése: %s",
+		"composer_orphan_prompt": "Egy megszakított sailinit composer konténerei még futnak: %s. Töröljem őket?",
+		"composer_interrupted":   "Megszakítva (%s); a composer konténer törölve.",
+		"tracing_protocol":       "A nyomkövetés ki van kapcsolva: az OTEL_EXPORTER_OTLP_PROTOCOL=%s nem támogatott, csak a http/json",
+		"tracing_export_failed":  "Nem sikerült exportálni a nyomkövetést: %v",
+		"orphan_take_down":       "  Leállítsuk a(z) %s stacket (down)?",
+		"debug_clear_later":      "A konténerek nem futnak; indításuk után futtasd: sail artisan config:clear",
+		"debug_clearing":         "Gyorsítótárazott konfiguráció törlése (artisan config:clear)...",
+		"debug_clear_failed":     "Nem sikerült törölni a gyorsítótárazott konfigurációt (%v); futtasd: sail artisan config:clear",
+		"debug_already_on":       "A hibakeresés már be van kapcsolva.",
+		"debug_already_off":      "A hibakeresés már ki van kapcsolva.",
+		"debug_turned_on":        "Hibakeresés bekapcsolva.",
+		"debug_turned_off":       "Hibakeresés kikapcsolva.",
+		"env_exposed":            "A .env jogosultsága %04o, más felhasználók is olvashatják, pedig titkokat tartalmaz (%s). Szigorítás: sailinit env tighten",
+	},
+}
+
+// yesAnswers lists the accepted affirmative answers per language.
+var yesAnswers = map[string][]string{
+	"en": {"y", "yes"},
+	"hu": {"i", "igen", "y"},
+}
+
+// locale is the active language. It is detected from the environment at
+// startup and again by main once the config file can be read.
+var locale = detectLocale("")
+
+// detectLocale picks the message language from SAILINIT_LANG, then
+// configured (the config file's language), then the standard locale
+// variables, falling back to English.
+func detectLocale(configured string) string {
+	if lang := normalizeLocale(os.Getenv("SAILINIT_LANG")); lang != "" {
+		return lang
+	}
+	if lang := normalizeLocale(configured); lang != "" {
+		return lang
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := normalizeLocale(os.Getenv(name)); lang != "" {
+			return lang
+		}
+	}
+	return "en"
+}
+
+// configuredLocale applies the config file's language. A broken config
+// file is reported where it is loaded for the command.
+func configuredLocale() string {
+	cfg, err := loadUserConfig()
+	if err != nil {
+		return locale
+	}
+	return detectLocale(cfg.Language)
+}
+
+// normalizeLocale turns values like "hu_HU.UTF-8" into a supported language
+// code, or "" when the language has no catalog.
+func normalizeLocale(value string) string {
+	lang := strings.ToLower(value)
+	if i := strings.IndexAny(lang, "_.-@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return ""
+}
+
+// tr looks up key in the active catalog and formats it with args.
+func tr(key string, args ...any) string {
+	format, ok := catalogs[locale][key]
+	if !ok {
+		format, ok = catalogs["en"][key]
+	}
+	if !ok {
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// isYes reports whether answer is affirmative in the active language.
+func isYes(answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	answers, ok := yesAnswers[locale]
+	if !ok {
+		answers = yesAnswers["en"]
+	}
+	return slices.Contains(answers, answer)
+}
@@ -0,0 +1,106 @@
+package main
+
+import "testing"
+
+func TestNormalizeLocale(t *testing.T) {
+	cases := map[string]string{
+		"hu_HU.UTF-8": "hu",
+		"en_US":       "en",
+		"HU":          "hu",
+		"de_DE.UTF-8": "",
+		"C":           "",
+		"":            "",
+	}
+	for input, want := range cases {
+		if got := normalizeLocale(input); got != want {
+			t.Errorf("normalizeLocale(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestDetectLocale(t *testing.T) {
+	t.Setenv("SAILINIT_LANG", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "hu_HU.UTF-8")
+	if got := detectLocale(""); got != "hu" {
+		t.Errorf("Expected hu from LANG, got %q", got)
+	}
+
+	t.Setenv("SAILINIT_LANG", "en")
+	if got := detectLocale(""); got != "en" {
+		t.Errorf("Expected SAILINIT_LANG to win, got %q", got)
+	}
+
+	t.Setenv("SAILINIT_LANG", "")
+	t.Setenv("LANG", "de_DE.UTF-8")
+	if got := detectLocale(""); got != "en" {
+		t.Errorf("Expected fallback to en, got %q", got)
+	}
+}
+
+func TestConfiguredLocale(t *testing.T) {
+	t.Setenv("SAILINIT_LANG", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+	writeTestConfig(t, "language: hu\n")
+	if got := configuredLocale(); got != "hu" {
+		t.Errorf("Expected the config file to win over LANG, got %q", got)
+	}
+
+	t.Setenv("SAILINIT_LANG", "en")
+	if got := configuredLocale(); got != "en" {
+		t.Errorf("Expected SAILINIT_LANG to win over the config file, got %q", got)
+	}
+
+	writeTestConfig(t, "language: de\n")
+	if _, err := loadUserConfig(); err == nil {
+		t.Error("Expected an unsupported language to be rejected")
+	}
+}
+
+func TestTr(t *testing.T) {
+	old := locale
+	defer func() { locale = old }()
+
+	locale = "hu"
+	if got := tr("suffix_using", 51); got != "Használt port-utótag: 51" {
+		t.Errorf("Unexpected Hungarian message: %q", got)
+	}
+
+	delete(catalogs["hu"], "label_app")
+	defer func() { catalogs["hu"]["label_app"] = "Fő alkalmazás" }()
+	if got := tr("label_app"); got != "Main App" {
+		t.Errorf("Expected English fallback, got %q", got)
+	}
+
+	if got := tr("no_such_key"); got != "no_such_key" {
+		t.Errorf("Expected unknown key to be returned as-is, got %q", got)
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key := range catalogs["en"] {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("Catalog %q is missing key %q", lang, key)
+			}
+		}
+	}
+}
+
+func TestIsYes(t *testing.T) {
+	old := locale
+	defer func() { locale = old }()
+
+	locale = "en"
+	if !isYes("Y\n") || isYes("i") || isYes("") {
+		t.Error("Unexpected English yes handling")
+	}
+
+	locale = "hu"
+	if !isYes("igen\n") || !isYes("i") || isYes("n") {
+		t.Error("Unexpected Hungarian yes handling")
+	}
+}
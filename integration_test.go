@@ -0,0 +1,221 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moby/moby/client"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// The integration suite drives real Docker and needs network access for
+// composer and image pulls. Run it with:
+//
+//	go test -tags integration -run Integration -timeout 20m ./...
+
+const skeletonComposerJSON = `{
+    "name": "sailinit/integration-skeleton",
+    "type": "project",
+    "require": {},
+    "require-dev": {
+        "laravel/sail": "^1.26"
+    },
+    "config": {
+        "optimize-autoloader": true
+    }
+}
+`
+
+// skeletonCompose stands in for the Sail stack with a single, quick-to-pull
+// web container that honors APP_PORT like laravel.test does.
+const skeletonCompose = `services:
+    laravel.test:
+        image: nginx:alpine
+        ports:
+            - '${APP_PORT:-80}:80'
+`
+
+// requireDocker skips the test unless a Docker daemon answers and the
+// docker CLI that sail drives is installed.
+func requireDocker(t *testing.T) {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skipf("The docker CLI is not available: %v", err)
+	}
+}
+
+// removeComposeProject removes the containers, networks and volumes of
+// compose project name through the Docker API, whatever state the test
+// left them in.
+func removeComposeProject(t *testing.T, name string) {
+	t.Helper()
+	ctx := context.Background()
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		t.Logf("Could not clean up compose project %s: %v", name, err)
+		return
+	}
+	defer cli.Close()
+
+	filters := client.Filters{}.Add("label", "com.docker.compose.project="+name)
+	containers, err := cli.ContainerList(ctx, client.ContainerListOptions{All: true, Filters: filters})
+	if err != nil {
+		t.Logf("Could not list containers of %s: %v", name, err)
+		return
+	}
+	for _, c := range containers.Items {
+		if _, err := cli.ContainerRemove(ctx, c.ID, client.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			t.Logf("Could not remove container %s: %v", c.ID, err)
+		}
+	}
+	if networks, err := cli.NetworkList(ctx, client.NetworkListOptions{Filters: filters}); err == nil {
+		for _, n := range networks.Items {
+			cli.NetworkRemove(ctx, n.ID, client.NetworkRemoveOptions{})
+		}
+	}
+	if volumes, err := cli.VolumeList(ctx, client.VolumeListOptions{Filters: filters}); err == nil {
+		for _, v := range volumes.Items {
+			cli.VolumeRemove(ctx, v.Name, client.VolumeRemoveOptions{Force: true})
+		}
+	}
+}
+
+// newSkeletonProject creates a minimal Laravel-like project whose compose
+// project name is unique to the test.
+func newSkeletonProject(t *testing.T) string {
+	t.Helper()
+	projectDir := t.TempDir()
+
+	name := fmt.Sprintf("sailinit-it-%d", time.Now().UnixNano())
+	files := map[string]string{
+		"composer.json": skeletonComposerJSON,
+		"compose.yaml":  skeletonCompose,
+		".env.example":  "APP_NAME=Skeleton\nAPP_KEY=\nCOMPOSE_PROJECT_NAME=" + name + "\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(projectDir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Cleanup(func() { removeComposeProject(t, name) })
+	return projectDir
+}
+
+// freeSuffix finds a suffix whose ports are all free on this machine, far
+// above the range real projects use.
+func freeSuffix(t *testing.T) int {
+	t.Helper()
+	for suffix := 30000; suffix < 30500; suffix++ {
+		if len(CheckSuffixPortsAvailable(suffix)) == 0 {
+			return suffix
+		}
+	}
+	t.Fatal("No free port suffix found")
+	return 0
+}
+
+func TestIntegrationSetupLifecycle(t *testing.T) {
+	requireDocker(t)
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := newSkeletonProject(t)
+	suffix := freeSuffix(t)
+
+	t.Run("runSailInit installs sail", func(t *testing.T) {
+		if err := runSailInit("84", projectDir, false, composerUser("")); err != nil {
+			t.Fatalf("runSailInit failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(projectDir, "vendor", "bin", "sail")); err != nil {
+			t.Fatalf("Expected vendor/bin/sail after composer install: %v", err)
+		}
+	})
+
+	t.Run("status before up", func(t *testing.T) {
+		running, err := countRunningContainers(projectDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if running != 0 {
+			t.Errorf("Expected no running containers, got %d", running)
+		}
+	})
+
+	t.Run("apply starts the stack on the allocated port", func(t *testing.T) {
+		// Exercise the readiness phase against the real container; nginx
+		// answers /up with 404, which counts as serving
+		readyTimeout = time.Minute
+		defer func() { readyTimeout = 0 }()
+
+		plan := &setupPlan{Version: planVersion, ProjectDir: projectDir, PHPVersion: "84", Suffix: suffix}
+		if err := applySetupPlan(nil, plan, false); err != nil {
+			t.Fatalf("applySetupPlan failed: %v", err)
+		}
+
+		values := readEnvValues(filepath.Join(projectDir, ".env"))
+		if values["APP_PORT"] != fmt.Sprint(8000+suffix) {
+			t.Errorf("Expected APP_PORT=%d, got %q", 8000+suffix, values["APP_PORT"])
+		}
+		if !strings.HasPrefix(values["APP_KEY"], "base64:") {
+			t.Errorf("Expected generated APP_KEY, got %q", values["APP_KEY"])
+		}
+
+		url := fmt.Sprintf("http://localhost:%d", 8000+suffix)
+		var lastErr error
+		for deadline := time.Now().Add(30 * time.Second); time.Now().Before(deadline); time.Sleep(time.Second) {
+			resp, err := http.Get(url)
+			if err == nil {
+				resp.Body.Close()
+				lastErr = nil
+				break
+			}
+			lastErr = err
+		}
+		if lastErr != nil {
+			t.Errorf("Expected %s to respond: %v", url, lastErr)
+		}
+	})
+
+	t.Run("status while running", func(t *testing.T) {
+		running, err := countRunningContainers(projectDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if running != 1 {
+			t.Errorf("Expected 1 running container, got %d", running)
+		}
+		if status := getContainerStatus(projectDir); !strings.Contains(status, "1 running") {
+			t.Errorf("Expected status '1 running', got %q", status)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		if err := runSailStop(projectDir); err != nil {
+			t.Fatal(err)
+		}
+		if status := getContainerStatus(projectDir); !strings.Contains(status, "stopped") {
+			t.Errorf("Expected status 'stopped', got %q", status)
+		}
+	})
+
+	t.Run("no drift after apply", func(t *testing.T) {
+		_, changes, found, err := projectDrift(projectDir)
+		if err != nil || !found {
+			t.Fatalf("Expected a recorded fingerprint, got found=%v err=%v", found, err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("Expected no drift, got %v", changes)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// laravelSearchDepth is how deep below the current directory setup looks
+// for a Laravel project to suggest.
+const laravelSearchDepth = 2
+
+// laravelProjectProblem returns why dir does not look like a Laravel
+// project, or "" when it has an artisan file and requires laravel/framework.
+func laravelProjectProblem(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "artisan")); err != nil {
+		return "there is no artisan file"
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "composer.json"))
+	if err != nil {
+		return "there is no composer.json"
+	}
+	var composer struct {
+		Require map[string]string `json:"require"`
+	}
+	if err := json.Unmarshal(data, &composer); err != nil {
+		return fmt.Sprintf("composer.json is not valid JSON: %v", err)
+	}
+	if _, ok := composer.Require["laravel/framework"]; !ok {
+		return "composer.json does not require laravel/framework"
+	}
+	return ""
+}
+
+// findLaravelProjects returns Laravel projects below dir, at most depth
+// levels down. Hidden directories, vendor and node_modules are skipped.
+func findLaravelProjects(dir string, depth int) []string {
+	if depth <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var found []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" {
+			continue
+		}
+		sub := filepath.Join(dir, name)
+		if laravelProjectProblem(sub) == "" {
+			found = append(found, sub)
+			continue
+		}
+		found = append(found, findLaravelProjects(sub, depth-1)...)
+	}
+	return found
+}
+
+// checkLaravelProject refuses to set up dir unless it looks like a Laravel
+// project, pointing at projects found in its subdirectories. force turns
+// the refusal into a warning.
+func checkLaravelProject(dir string, force bool) error {
+	problem := laravelProjectProblem(dir)
+	if problem == "" {
+		return nil
+	}
+	if force {
+		printWarning(fmt.Sprintf("Warning: %s does not look like a Laravel project (%s); continuing because of --force.", shortenHome(dir), problem))
+		return nil
+	}
+
+	hint := "Run sailinit from the project root, pass --force to set up this directory anyway, or use --generic for non-Laravel projects."
+	if found := findLaravelProjects(dir, laravelSearchDepth); len(found) > 0 {
+		var rel []string
+		for _, f := range found {
+			if r, err := filepath.Rel(dir, f); err == nil {
+				f = "./" + r
+			}
+			rel = append(rel, f)
+		}
+		hint = fmt.Sprintf("Found a Laravel project in %s; cd there and run sailinit again, or pass --force to set up this directory anyway.", strings.Join(rel, ", "))
+	}
+	return &CLIError{
+		Category: CategoryUsage,
+		Err:      errors.New(shortenHome(dir) + " does not look like a Laravel project: " + problem),
+		Hint:     hint,
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeLaravelProject creates the files that identify a Laravel project.
+func makeLaravelProject(t *testing.T, dir string) {
+	t.Helper()
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "artisan"), []byte("#!/usr/bin/env php\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "composer.json"), []byte(`{"require": {"php": "^8.2", "laravel/framework": "^11.0"}}`), 0644)
+}
+
+func TestLaravelProjectProblem(t *testing.T) {
+	dir := t.TempDir()
+	if got := laravelProjectProblem(dir); !strings.Contains(got, "artisan") {
+		t.Errorf("Expected a missing artisan file, got %q", got)
+	}
+	os.WriteFile(filepath.Join(dir, "artisan"), nil, 0755)
+	if got := laravelProjectProblem(dir); !strings.Contains(got, "composer.json") {
+		t.Errorf("Expected a missing composer.json, got %q", got)
+	}
+	os.WriteFile(filepath.Join(dir, "composer.json"), []byte(`{"require": {"symfony/console": "^7"}}`), 0644)
+	if got := laravelProjectProblem(dir); !strings.Contains(got, "laravel/framework") {
+		t.Errorf("Expected laravel/framework to be missing, got %q", got)
+	}
+
+	makeLaravelProject(t, dir)
+	if got := laravelProjectProblem(dir); got != "" {
+		t.Errorf("Expected a Laravel project, got %q", got)
+	}
+}
+
+func TestCheckLaravelProject(t *testing.T) {
+	dir := t.TempDir()
+	makeLaravelProject(t, filepath.Join(dir, "backend"))
+	makeLaravelProject(t, filepath.Join(dir, "vendor", "ignored"))
+	makeLaravelProject(t, filepath.Join(dir, "apps", "admin"))
+
+	err := checkLaravelProject(dir, false)
+	if err == nil {
+		t.Fatal("Expected a directory without artisan to be refused")
+	}
+	if exitCode(err) != ExitUsage {
+		t.Errorf("Expected exit code %d, got %d", ExitUsage, exitCode(err))
+	}
+	hint := err.(*CLIError).Hint
+	if !strings.Contains(hint, "./backend") || !strings.Contains(hint, "./apps/admin") || strings.Contains(hint, "ignored") {
+		t.Errorf("Expected the hint to list the subdirectory projects, got %q", hint)
+	}
+
+	if err := checkLaravelProject(dir, true); err != nil {
+		t.Errorf("Expected --force to allow setup, got %v", err)
+	}
+	if err := checkLaravelProject(filepath.Join(dir, "backend"), false); err != nil {
+		t.Errorf("Expected a Laravel project to pass, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// lintConfig is the team conventions sailinit lint checks a project
+// against, from the config file and the project's .sailinit.yaml.
+type lintConfig struct {
+	// RequiredEnv lists keys .env.example must define
+	RequiredEnv []string `yaml:"required_env"`
+	// ForbiddenValues lists values .env.example must not hold, by key,
+	// such as DB_PASSWORD: [password]
+	ForbiddenValues map[string][]string `yaml:"forbidden_values"`
+	// Services lists the compose services a project may define besides
+	// its Sail PHP services; empty allows any
+	Services []string `yaml:"services"`
+	// PHPVersions lists the PHP versions a project may run, e.g. "8.3";
+	// empty allows any
+	PHPVersions []string `yaml:"php_versions"`
+}
+
+// merge adds other's conventions to c: a project extends the config
+// file's lists rather than replacing them.
+func (c *lintConfig) merge(other *lintConfig) {
+	if other == nil {
+		return
+	}
+	c.RequiredEnv = append(c.RequiredEnv, other.RequiredEnv...)
+	c.Services = append(c.Services, other.Services...)
+	c.PHPVersions = append(c.PHPVersions, other.PHPVersions...)
+	for key, values := range other.ForbiddenValues {
+		if c.ForbiddenValues == nil {
+			c.ForbiddenValues = make(map[string][]string)
+		}
+		c.ForbiddenValues[key] = append(c.ForbiddenValues[key], values...)
+	}
+}
+
+// empty reports whether c sets no convention at all.
+func (c *lintConfig) empty() bool {
+	return len(c.RequiredEnv) == 0 && len(c.ForbiddenValues) == 0 && len(c.Services) == 0 && len(c.PHPVersions) == 0
+}
+
+// lintViolation is a broken convention, at a file and line when it has
+// one.
+type lintViolation struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (v lintViolation) String() string {
+	if v.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", v.File, v.Line, v.Msg)
+	}
+	return fmt.Sprintf("%s: %s", v.File, v.Msg)
+}
+
+// envKeyLines returns the line each key of doc is first assigned on.
+func envKeyLines(doc *envDocument) map[string]int {
+	lines := make(map[string]int)
+	line := 1
+	for _, l := range doc.Lines {
+		if _, seen := lines[l.Key]; l.Key != "" && !seen {
+			lines[l.Key] = line
+		}
+		line += strings.Count(l.Raw, "\n")
+	}
+	return lines
+}
+
+// lintEnvExample checks .env.example, the env file the team commits, for
+// required keys and forbidden values.
+func lintEnvExample(projectDir string, cfg *lintConfig) []lintViolation {
+	if len(cfg.RequiredEnv) == 0 && len(cfg.ForbiddenValues) == 0 {
+		return nil
+	}
+	const file = ".env.example"
+	doc, err := readEnvDocument(filepath.Join(projectDir, file))
+	if os.IsNotExist(err) {
+		return []lintViolation{{File: file, Msg: "does not exist, but the conventions name keys it must define"}}
+	}
+	if err != nil {
+		return []lintViolation{{File: file, Msg: err.Error()}}
+	}
+	values := envValues(doc)
+	lines := envKeyLines(doc)
+
+	var violations []lintViolation
+	for _, key := range slices.Compact(slices.Sorted(slices.Values(cfg.RequiredEnv))) {
+		if _, ok := values[key]; !ok {
+			violations = append(violations, lintViolation{File: file, Msg: fmt.Sprintf("%s is required but not defined", key)})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(cfg.ForbiddenValues)) {
+		value, ok := values[key]
+		if ok && slices.Contains(cfg.ForbiddenValues[key], value) {
+			violations = append(violations, lintViolation{File: file, Line: lines[key], Msg: fmt.Sprintf("%s must not be %q", key, value)})
+		}
+	}
+	return violations
+}
+
+// lintCompose checks the compose file's services and PHP runtimes. Sail
+// PHP services are the app itself, so the services list never needs to
+// name them.
+func lintCompose(projectDir string, cfg *lintConfig, pinnedPHP string) []lintViolation {
+	if len(cfg.Services) == 0 && len(cfg.PHPVersions) == 0 {
+		return nil
+	}
+	file := "compose file"
+	for _, f := range composeFiles(projectDir) {
+		if _, err := os.Stat(f); err == nil {
+			file = filepath.Base(f)
+			break
+		}
+	}
+	project, err := loadCompose(projectDir)
+	if err != nil {
+		return []lintViolation{{File: file, Msg: err.Error()}}
+	}
+	if project == nil {
+		project = &composeProject{}
+	}
+
+	var violations []lintViolation
+	for _, svc := range project.Services {
+		if len(cfg.Services) > 0 && svc.phpVersion() == "" && !slices.Contains(cfg.Services, svc.Name) {
+			violations = append(violations, lintViolation{File: file, Msg: fmt.Sprintf("service %s is not allowed (allowed: %s)", svc.Name, strings.Join(cfg.Services, ", "))})
+		}
+	}
+	if len(cfg.PHPVersions) == 0 {
+		return violations
+	}
+	var allowed []string
+	for _, v := range cfg.PHPVersions {
+		allowed = append(allowed, strings.ReplaceAll(v, ".", ""))
+	}
+	for _, svc := range project.Services {
+		if v := svc.phpVersion(); v != "" && !slices.Contains(allowed, v) {
+			violations = append(violations, lintViolation{File: file, Msg: fmt.Sprintf("service %s runs PHP %s (allowed: %s)", svc.Name, dottedPHPVersion(v), strings.Join(cfg.PHPVersions, ", "))})
+		}
+	}
+	if pinnedPHP != "" && !slices.Contains(allowed, pinnedPHP) {
+		violations = append(violations, lintViolation{File: projectConfigFile, Msg: fmt.Sprintf("php pins PHP %s (allowed: %s)", dottedPHPVersion(pinnedPHP), strings.Join(cfg.PHPVersions, ", "))})
+	}
+	return violations
+}
+
+// dottedPHPVersion writes a runtime such as "83" as "8.3".
+func dottedPHPVersion(v string) string {
+	if len(v) == 2 {
+		return v[:1] + "." + v[1:]
+	}
+	return v
+}
+
+// runLint checks projectDir against cfg and returns the violations.
+func runLint(projectDir string, cfg *lintConfig, pinnedPHP string) []lintViolation {
+	return append(lintEnvExample(projectDir, cfg), lintCompose(projectDir, cfg, pinnedPHP)...)
+}
+
+// handleLint exits 0 when the project follows the conventions, 1 when it
+// breaks some and 2 when the conventions can't be read, so it can run as a
+// pre-commit hook.
+func handleLint(args []string) {
+	commandFlags("lint").Parse(args)
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	userCfg, err := loadUserConfig()
+	if err != nil {
+		exitWithError("Error reading config", err)
+	}
+	projectCfg, err := loadProjectConfig(projectDir)
+	if err != nil {
+		exitWithError("Error reading "+projectConfigFile, err)
+	}
+
+	cfg := &lintConfig{}
+	cfg.merge(userCfg.Lint)
+	pinnedPHP := ""
+	if projectCfg != nil {
+		cfg.merge(projectCfg.Lint)
+		pinnedPHP = projectCfg.PHP
+	}
+	if cfg.empty() {
+		printInfo("No conventions configured; add a lint section to " + projectConfigFile + " or the config file.")
+		return
+	}
+
+	violations := runLint(projectDir, cfg, pinnedPHP)
+	for _, v := range violations {
+		fmt.Println(v)
+	}
+	if len(violations) > 0 {
+		printError(fmt.Sprintf("%d violation(s) found.", len(violations)))
+		exit(ExitGeneral)
+	}
+	printSuccess("No violations found.")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunLint(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env.example"), []byte("APP_NAME=Laravel\n# Database\nDB_PASSWORD=\"password\"\nAPP_KEY=\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(`services:
+  laravel.test:
+    build:
+      context: ./vendor/laravel/sail/runtimes/8.2
+  mysql:
+    image: mysql/mysql-server:8.0
+  mongodb:
+    image: mongo:7
+`), 0644)
+
+	cfg := &lintConfig{
+		RequiredEnv:     []string{"APP_KEY", "MAIL_MAILER"},
+		ForbiddenValues: map[string][]string{"DB_PASSWORD": {"password"}, "APP_NAME": {"secret"}},
+		Services:        []string{"mysql", "redis"},
+		PHPVersions:     []string{"8.3", "8.4"},
+	}
+	var got []string
+	for _, v := range runLint(dir, cfg, "84") {
+		got = append(got, v.String())
+	}
+	want := []string{
+		".env.example: MAIL_MAILER is required but not defined",
+		`.env.example:3: DB_PASSWORD must not be "password"`,
+		"docker-compose.yml: service mongodb is not allowed (allowed: mysql, redis)",
+		"docker-compose.yml: service laravel.test runs PHP 8.2 (allowed: 8.3, 8.4)",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	if got := runLint(dir, &lintConfig{PHPVersions: []string{"8.2"}}, "81"); len(got) != 1 || got[0].File != projectConfigFile {
+		t.Errorf("Expected the pinned PHP version to break the policy, got %v", got)
+	}
+	if got := runLint(t.TempDir(), &lintConfig{RequiredEnv: []string{"APP_KEY"}}, ""); len(got) != 1 {
+		t.Errorf("Expected a missing .env.example to be reported, got %v", got)
+	}
+}
+
+func TestLintConfigMerge(t *testing.T) {
+	cfg := &lintConfig{}
+	if !cfg.empty() {
+		t.Error("Expected a fresh config to be empty")
+	}
+	cfg.merge(&lintConfig{Services: []string{"mysql"}, ForbiddenValues: map[string][]string{"DB_PASSWORD": {"password"}}})
+	cfg.merge(&lintConfig{Services: []string{"redis"}, ForbiddenValues: map[string][]string{"DB_PASSWORD": {"secret"}}})
+	cfg.merge(nil)
+	if !slices.Equal(cfg.Services, []string{"mysql", "redis"}) {
+		t.Errorf("Expected the project's services to add to the config file's, got %v", cfg.Services)
+	}
+	if !slices.Equal(cfg.ForbiddenValues["DB_PASSWORD"], []string{"password", "secret"}) {
+		t.Errorf("Expected forbidden values to add up, got %v", cfg.ForbiddenValues)
+	}
+}
+
+func TestLintConfigIsValidated(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectConfig(t, dir, "lint:\n  php_versions: [\"8.3\"]\n  forbidden_values:\n    DB_PASSWORD: [password]\n")
+	cfg, err := loadProjectConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Lint == nil || !slices.Equal(cfg.Lint.PHPVersions, []string{"8.3"}) {
+		t.Errorf("Expected the lint section to be read, got %+v", cfg.Lint)
+	}
+
+	writeProjectConfig(t, dir, "lint:\n  php_versions: [latest]\n")
+	if _, err := loadProjectConfig(dir); err == nil || exitCode(err) != ExitUsage {
+		t.Errorf("Expected an invalid PHP version to be a usage error, got %v", err)
+	}
+}
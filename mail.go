@@ -0,0 +1,22 @@
+package main
+
+// mailhogPortKeys maps Sail's Mailpit port keys to the ones its older
+// Mailhog service reads.
+var mailhogPortKeys = map[string]string{
+	"FORWARD_MAILPIT_DASHBOARD_PORT": "FORWARD_MAILHOG_DASHBOARD_PORT",
+	"FORWARD_MAILPIT_PORT":           "FORWARD_MAILHOG_PORT",
+}
+
+// mailFlavor returns the mail catcher projectDir runs: "mailhog" when its
+// compose file has a mailhog service and no mailpit one, otherwise
+// "mailpit", which is also assumed when there is no compose file.
+func mailFlavor(projectDir string) string {
+	project, err := loadCompose(projectDir)
+	if err != nil || project == nil {
+		return "mailpit"
+	}
+	if project.Service("mailhog") != nil && project.Service("mailpit") == nil {
+		return "mailhog"
+	}
+	return "mailpit"
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const mailhogCompose = `services:
+    laravel.test:
+        image: 'sail-8.1/app'
+    mailhog:
+        image: 'mailhog/mailhog:latest'
+        ports:
+            - '${FORWARD_MAILHOG_PORT:-1025}:1025'
+            - '${FORWARD_MAILHOG_DASHBOARD_PORT:-8025}:8025'
+`
+
+func TestMailFlavor(t *testing.T) {
+	tempDir := t.TempDir()
+	if got := mailFlavor(tempDir); got != "mailpit" {
+		t.Errorf("Expected mailpit without a compose file, got %q", got)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "docker-compose.yml"), []byte(mailhogCompose), 0644)
+	if got := mailFlavor(tempDir); got != "mailhog" {
+		t.Errorf("Expected mailhog, got %q", got)
+	}
+
+	bases := projectPortBases(tempDir)
+	if !slices.Contains(bases, PortBase{"FORWARD_MAILHOG_DASHBOARD_PORT", 18100}) || !slices.Contains(bases, PortBase{"FORWARD_MAILHOG_PORT", 1000}) {
+		t.Errorf("Expected Mailhog port keys, got %v", bases)
+	}
+	// The compose file runs no database, Redis or Meilisearch either
+	want := []string{"FORWARD_DB_PORT", "FORWARD_MAILPIT_DASHBOARD_PORT", "FORWARD_MAILPIT_PORT", "FORWARD_MEILISEARCH_PORT", "FORWARD_REDIS_PORT"}
+	if stale := stalePortKeys(bases); !slices.Equal(stale, want) {
+		t.Errorf("Expected the Mailpit keys and the unused services' keys to be stale, got %v", stale)
+	}
+	if !slices.Equal(sailPortBases[4:6], []PortBase{{"FORWARD_MAILPIT_DASHBOARD_PORT", 18100}, {"FORWARD_MAILPIT_PORT", 1000}}) {
+		t.Errorf("sailPortBases must not be modified, got %v", sailPortBases)
+	}
+}
+
+func TestSetupEnvMailhog(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "docker-compose.yml"), []byte(mailhogCompose), 0644)
+	envPath := filepath.Join(tempDir, ".env")
+	os.WriteFile(envPath, []byte("APP_NAME=Shop\nFORWARD_MAILPIT_PORT=1055\nFORWARD_MAILPIT_DASHBOARD_PORT=18155\n"), 0644)
+
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	values := readEnvValues(envPath)
+	if values["FORWARD_MAILHOG_PORT"] != "1055" || values["FORWARD_MAILHOG_DASHBOARD_PORT"] != "18155" {
+		t.Errorf("Expected Mailhog ports, got %v", values)
+	}
+	if _, ok := values["FORWARD_MAILPIT_PORT"]; ok {
+		t.Errorf("Expected the Mailpit keys to be removed, got %v", values)
+	}
+
+	summary := formatSummary(projectSummary(tempDir, nil))
+	if !strings.Contains(summary, "Mailhog Dashboard: http://localhost:18155") || strings.Contains(summary, "Mailpit") {
+		t.Errorf("Expected the Mailhog dashboard in the summary, got:\n%s", summary)
+	}
+}
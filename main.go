@@ -2,227 +2,406 @@ package main
 
 import (
 	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
 var version = "dev"
 
 func detectPHPVersion(projectDir string) string {
-	files := []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
-	for _, f := range files {
-		path := filepath.Join(projectDir, f)
-		data, err := os.ReadFile(path)
-		if err != nil {
-			continue
-		}
-
-		content := string(data)
-		// Look for vendor runtimes path
-		reRuntime := regexp.MustCompile(`runtimes/([0-9]+\.[0-9]+)`)
-		match := reRuntime.FindStringSubmatch(content)
-		if len(match) > 1 {
-			return strings.ReplaceAll(match[1], ".", "")
-		}
+	versions := detectPHPVersions(projectDir)
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[0]
+}
 
-		// Look for image name
-		reImage := regexp.MustCompile(`sail-([0-9]+\.[0-9]+)/app`)
-		match = reImage.FindStringSubmatch(content)
-		if len(match) > 1 {
-			return strings.ReplaceAll(match[1], ".", "")
-		}
+// detectPHPVersions returns every distinct PHP runtime used by the project's
+// compose services, e.g. an app on 8.3 and a worker on 8.2. The first entry
+// is the primary runtime.
+func detectPHPVersions(projectDir string) []string {
+	project, err := loadCompose(projectDir)
+	if err != nil {
+		printWarning(fmt.Sprintf("Warning: could not parse compose file: %v", err))
+		return nil
+	}
+	if project == nil {
+		return nil
+	}
 
-		// Look for docker context path (alternative)
-		reDocker := regexp.MustCompile(`context: \.?/docker/([0-9]+\.[0-9]+)`)
-		match = reDocker.FindStringSubmatch(content)
-		if len(match) > 1 {
-			return strings.ReplaceAll(match[1], ".", "")
+	var versions []string
+	for _, svc := range project.Services {
+		if v := svc.phpVersion(); v != "" && !slices.Contains(versions, v) {
+			versions = append(versions, v)
 		}
 	}
-	return ""
+	return versions
 }
 
 func main() {
-	versionFlag := flag.Bool("version", false, "Print version and exit")
-	listFlag := flag.Bool("list", false, "List all registered projects with their port suffixes")
-	statusFlag := flag.Bool("status", false, "Show status of all registered projects")
-	cleanFlag := flag.Bool("clean", false, "Remove entries for project directories that no longer exist")
-	removeFlag := flag.Bool("remove", false, "Remove the current project from port registry")
-	stopFlag := flag.Bool("stop", false, "Run sail stop in the current project")
-	downFlag := flag.Bool("down", false, "Run sail down in the current project")
-	freshFlag := flag.Bool("fresh", false, "Force re-run composer install even if vendor/bin/sail exists")
-	resetDbFlag := flag.Bool("reset-db", false, "Reset database settings to Sail defaults (mysql, laravel, sail/password)")
-	dryRunFlag := flag.Bool("dry-run", false, "Show what would happen without making changes")
-	newFlag := flag.String("new", "", "Create a new Laravel project with the given name (e.g. --new my-blog)")
-	flag.Parse()
-
-	// Handle --version flag
-	if *versionFlag {
-		fmt.Printf("sailinit %s\n", version)
-		os.Exit(0)
-	}
-
-	// Handle --list flag
-	if *listFlag {
-		handleList()
-		os.Exit(0)
-	}
-
-	// Handle --status flag
-	if *statusFlag {
-		if err := showProjectStatus(); err != nil {
-			printError(fmt.Sprintf("Error showing status: %v", err))
-			os.Exit(1)
-		}
-		os.Exit(0)
-	}
-
-	// Handle --clean flag
-	if *cleanFlag {
-		count, err := CleanOrphanedProjects()
-		if err != nil {
-			printError(fmt.Sprintf("Error cleaning orphaned projects: %v", err))
-			os.Exit(1)
+	os.Args = extractContextFlag(extractProfileFlag(extractReadOnlyFlag(os.Args)))
+	name, err := resolveContext()
+	if err != nil {
+		// A vanished context must not lock out the command that fixes it
+		if len(os.Args) < 2 || os.Args[1] != "context" {
+			exitWithError("Error selecting context", err)
 		}
-		printSuccess(fmt.Sprintf("Cleaned %d orphaned project(s)", count))
-		os.Exit(0)
+		printWarning(err.Error())
+		name = defaultContext
 	}
+	contextName = name
+	locale = configuredLocale()
 
-	// Handle --remove flag
-	if *removeFlag {
-		projectDir, err := os.Getwd()
-		if err != nil {
-			printError(fmt.Sprintf("Error getting current directory: %v", err))
-			os.Exit(1)
-		}
-		if err := RemoveProject(projectDir); err != nil {
-			printError(fmt.Sprintf("Error removing project: %v", err))
-			os.Exit(1)
+	command := "init"
+	if len(os.Args) > 1 {
+		if c, ok := findSubcommand(os.Args[1]); ok {
+			command = c.Name
 		}
-		printSuccess("Project removed from port registry.")
-		os.Exit(0)
 	}
+	startTracing(command)
 
-	// Handle --stop flag
-	if *stopFlag {
-		projectDir, err := os.Getwd()
-		if err != nil {
-			printError(fmt.Sprintf("Error getting current directory: %v", err))
-			os.Exit(1)
-		}
-		if err := runSailStop(projectDir); err != nil {
-			printError(fmt.Sprintf("Error stopping sail: %v", err))
-			os.Exit(1)
-		}
-		os.Exit(0)
+	if len(os.Args) > 1 && runCommand(os.Args[1], os.Args[2:]) {
+		exit(ExitOK)
 	}
+	runLegacy(os.Args[1:])
+	exit(ExitOK)
+}
 
-	// Handle --down flag
-	if *downFlag {
-		projectDir, err := os.Getwd()
+// setupOptions are the flags of `sailinit init`, which the flag interface
+// without a subcommand accepts as well.
+type setupOptions struct {
+	Force      bool
+	Fresh      bool
+	DryRun     bool
+	Steal      bool
+	Generic    bool
+	ClearCache bool
+	DB         string
+	User       string
+	PortMap    string
+	Answers    string
+	Record     string
+	ResetDB    resetScope
+	Wait       time.Duration
+}
+
+// register defines the setup flags on fs.
+func (o *setupOptions) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.Force, "force", false, "Set up a directory that doesn't look like a Laravel project")
+	fs.BoolVar(&o.Fresh, "fresh", false, "Force re-run composer install even if vendor/bin/sail exists")
+	fs.StringVar(&o.DB, "db", "", "Set the project up with `sqlite` instead of a database container")
+	fs.Var(&o.ResetDB, "reset-db", "Reset database settings to Sail defaults; optionally only `connection,credentials,database`")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "Show what would happen without making changes")
+	fs.BoolVar(&o.Steal, "steal", false, "Stop other registered projects whose running containers hold the needed ports")
+	fs.DurationVar(&o.Wait, "wait-for-ports", 0, "Wait up to this long for busy ports to free up (e.g. 30s)")
+	fs.BoolVar(&o.Generic, "generic", false, "Only allocate a suffix and write ports to .env (no composer or sail)")
+	fs.StringVar(&o.PortMap, "port-map", "", "Ports to write in --generic mode as KEY=BASE pairs (e.g. WEB_PORT=8000,PG_PORT=5400)")
+	fs.BoolVar(&o.ClearCache, "clear-cache", false, "Run artisan optimize:clear after sail up")
+	fs.StringVar(&o.User, "user", "", "uid:gid for the composer container and Sail's WWWUSER/WWWGROUP, or auto/none")
+	fs.DurationVar(&readyTimeout, "ready-timeout", readyTimeout, "How long to wait for containers and the app to become ready after sail up (0 to skip)")
+	fs.DurationVar(&composerTimeout, "composer-timeout", 0, "Remove the composer container and fail if composer install runs longer than this (0 for no limit)")
+	fs.StringVar(&composeFileOverride, "compose-file", "", "Compose file to use instead of the default (also honors COMPOSE_FILE)")
+	fs.StringVar(&o.Answers, "answers", "", "Replay the prompt answers recorded in `file`")
+	fs.StringVar(&o.Record, "record", "", "Record the prompt answers of this setup to `file`")
+	fs.Func("suffix", "Use this port `suffix` instead of asking; fails if another project has it", func(s string) error {
+		n, err := strconv.Atoi(s)
 		if err != nil {
-			printError(fmt.Sprintf("Error getting current directory: %v", err))
-			os.Exit(1)
+			return errors.New("not a number")
 		}
-		if err := runSailDown(projectDir); err != nil {
-			printError(fmt.Sprintf("Error running sail down: %v", err))
-			os.Exit(1)
+		requestedSuffix = n
+		return nil
+	})
+	fs.BoolVar(&assumeYes, "yes", false, "Answer yes to every question and take the default of every other prompt")
+	fs.BoolVar(&noInput, "no-input", false, "Never read from stdin: take every prompt's default and answer no to questions")
+}
+
+// validate checks the flag values and loads the answers to replay.
+func (o *setupOptions) validate() {
+	if _, err := parseDBMode(o.DB); err != nil {
+		exitWithError("Invalid --db", usageError(err))
+	}
+	if o.Answers != "" {
+		answers, err := loadAnswers(o.Answers)
+		if err != nil {
+			exitWithError("Error reading answers", err)
 		}
-		os.Exit(0)
+		replayAnswers = answers
+	}
+	if o.Record != "" {
+		recording = &answerFile{Version: answerFileVersion}
 	}
+}
 
-	// Handle --new flag: create a new Laravel project
-	if *newFlag != "" {
-		projectName := *newFlag
-		printHeader(fmt.Sprintf("Creating new Laravel project: %s", projectName))
+func handleInit(args []string) {
+	fs := commandFlags("init")
+	var opts setupOptions
+	opts.register(fs)
+	fs.Parse(reorderArgs(args))
+	if fs.NArg() > 1 {
+		exitWithError("Usage: sailinit init [php_version] [flags]", usageError(errors.New("expected at most one PHP version")))
+	}
+	opts.validate()
+	runSetup(&opts, fs.Arg(0))
+}
 
-		if *dryRunFlag {
-			printInfo(fmt.Sprintf("[dry-run] Would run: curl -s \"https://laravel.build/%s?with=mysql\" | bash", projectName))
-			printInfo(fmt.Sprintf("[dry-run] Would then set up ports in ./%s", projectName))
-			os.Exit(0)
-		}
+func handleNew(args []string) {
+	fs := commandFlags("new")
+	var opts setupOptions
+	opts.register(fs)
+	fs.Parse(reorderArgs(args))
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		exitWithError("Usage: sailinit new <name> [php_version] [flags]", usageError(errors.New("expected a project name")))
+	}
+	opts.validate()
+	newProject(fs.Arg(0), &opts)
+	runSetup(&opts, fs.Arg(1))
+}
 
-		if err := createNewProject(projectName); err != nil {
-			printError(fmt.Sprintf("Error creating project: %v", err))
-			os.Exit(1)
-		}
+// newProject creates a Laravel project called name with laravel.build and
+// changes into it, so the setup that follows configures its ports.
+func newProject(name string, opts *setupOptions) {
+	if !opts.DryRun {
+		requireWritableState()
+	}
+	printHeader(fmt.Sprintf("Creating new Laravel project: %s", name))
 
-		// Change into the new project directory for the rest of the setup
-		newDir := filepath.Join(".", projectName)
-		absDir, err := filepath.Abs(newDir)
-		if err != nil {
-			printError(fmt.Sprintf("Error resolving project path: %v", err))
-			os.Exit(1)
-		}
-		if err := os.Chdir(absDir); err != nil {
-			printError(fmt.Sprintf("Error changing to project directory: %v", err))
-			os.Exit(1)
-		}
+	if opts.DryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would run: curl -s \"%s\" | bash", newProjectURL(name, opts.DB)))
+		printInfo(fmt.Sprintf("[dry-run] Would then set up ports in ./%s", name))
+		exit(ExitOK)
+	}
 
-		printSuccess(fmt.Sprintf("Project created at %s", absDir))
-		printInfo("Configuring ports...")
+	if err := createNewProject(name, opts.DB); err != nil {
+		exitWithError("Error creating project", err)
+	}
 
-		// Stop containers started by laravel.build so we can reconfigure ports
-		sailPath := filepath.Join(absDir, "vendor", "bin", "sail")
-		if _, err := os.Stat(sailPath); err == nil {
-			stopCmd := exec.Command(sailPath, "down")
-			stopCmd.Stdout = os.Stdout
-			stopCmd.Stderr = os.Stderr
-			stopCmd.Run() // best-effort
+	// Change into the new project directory for the rest of the setup
+	absDir, err := filepath.Abs(filepath.Join(".", name))
+	if err != nil {
+		exitWithError("Error resolving project path", err)
+	}
+	if err := os.Chdir(absDir); err != nil {
+		exitWithError("Error changing to project directory", err)
+	}
+
+	printSuccess(fmt.Sprintf("Project created at %s", absDir))
+	printInfo("Configuring ports...")
+
+	// Stop containers started by laravel.build so we can reconfigure ports
+	if stopCmd, err := sailCommand(absDir, "down"); err == nil {
+		stopCmd.Stdout = os.Stdout
+		stopCmd.Stderr = os.Stderr
+		commandRun(stopCmd) // best-effort
+	}
+}
+
+// runSetup sets up the project in the current directory.
+func runSetup(opts *setupOptions, phpVersion string) {
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	if !opts.DryRun {
+		requireWritableState()
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	portOpts := portCheckOptions{Steal: opts.Steal, Wait: opts.Wait}
+
+	// Generic mode skips everything Laravel-specific
+	if opts.Generic {
+		bases := portBases()
+		if opts.PortMap != "" {
+			bases, err = parsePortMap(opts.PortMap)
+			if err != nil {
+				exitWithError("Invalid --port-map", usageError(err))
+			}
 		}
+		err := runGenericSetup(reader, projectDir, bases, opts.DryRun, portOpts)
+		saveRecordedAnswers(opts.Record)
+		if err != nil {
+			exitWithError("Error", err)
+		}
+		return
 	}
 
-	// Main setup flow
-	projectDir, err := os.Getwd()
+	if err := checkLaravelProject(projectDir, opts.Force); err != nil {
+		exitWithError("Refusing to set up", err)
+	}
+	startSetupPhase("plan")
+	plan, err := buildSetupPlan(reader, projectDir, planOptions{
+		PHPVersion:  phpVersion,
+		ResetDB:     opts.ResetDB,
+		Fresh:       opts.Fresh,
+		DB:          opts.DB,
+		User:        opts.User,
+		ClearCache:  opts.ClearCache,
+		Interactive: !opts.DryRun,
+		Ports:       portOpts,
+	})
 	if err != nil {
-		printError(fmt.Sprintf("Error getting current directory: %v", err))
-		os.Exit(1)
-	}
-
-	detectedVersion := detectPHPVersion(projectDir)
-	phpVersion := "84" // Default
-
-	// Check CLI arguments (positional args after flags)
-	args := flag.Args()
-	if len(args) > 0 {
-		phpVersion = args[0]
-		if detectedVersion != "" && phpVersion != detectedVersion {
-			printWarning(fmt.Sprintf("Warning: Manually specified PHP version (%s) differs from detected version in compose file (%s).", phpVersion, detectedVersion))
-			fmt.Print("Continue anyway? [y/N]: ")
-			var confirm string
-			fmt.Scanln(&confirm)
-			if strings.ToLower(confirm) != "y" {
-				os.Exit(0)
+		exitWithError("Error determining suffix", err)
+	}
+	saveRecordedAnswers(opts.Record)
+	if plan == nil {
+		return
+	}
+	if err := applySetupPlan(reader, plan, opts.DryRun); err != nil {
+		exitWithError("Error during setup", err)
+	}
+}
+
+func handleClean(args []string) {
+	fs := commandFlags("clean")
+	force := fs.Bool("force", false, "Drop projects whose containers still run")
+	fs.Parse(args)
+	cleanProjects(*force)
+}
+
+// cleanProjects drops the registry entries of projects whose directories
+// are gone. Those whose containers still run are kept unless taken down
+// or force is set.
+func cleanProjects(force bool) {
+	requireWritableState()
+	var keep []string
+	if projects, err := ListProjects(); err == nil {
+		reader := bufio.NewReader(os.Stdin)
+		for _, p := range projects {
+			if !p.Exists && !confirmStackDown(reader, p.Path, force) {
+				printWarning(tr("stack_kept", shortenHome(p.Path)))
+				keep = append(keep, p.Path)
 			}
 		}
-	} else if detectedVersion != "" {
-		phpVersion = detectedVersion
-		printInfo(fmt.Sprintf("Detected PHP version: %s", phpVersion))
-	} else {
-		printInfo(fmt.Sprintf("No PHP version detected. Using default: %s", phpVersion))
 	}
+	count, err := CleanOrphanedProjects(keep)
+	if err != nil {
+		exitWithError("Error cleaning orphaned projects", err)
+	}
+	printSuccess(fmt.Sprintf("Cleaned %d orphaned project(s)", count))
+}
 
-	printHeader(fmt.Sprintf("Starting Laravel Sail setup for PHP %s...", phpVersion))
-	suggested, existing, existed, err := getSuggestedSuffix(projectDir)
+func handleRemove(args []string) {
+	fs := commandFlags("remove")
+	force := fs.Bool("force", false, "Remove the project even when its containers still run")
+	fs.Parse(args)
+	removeProject(*force)
+}
+
+// removeProject drops the current project from the registry.
+func removeProject(force bool) {
+	requireWritableState()
+	projectDir, err := projectDirectory()
 	if err != nil {
-		printError(fmt.Sprintf("Error determining suffix: %v", err))
-		os.Exit(1)
+		exitWithError("Error getting current directory", err)
+	}
+	if !confirmStackDown(bufio.NewReader(os.Stdin), projectDir, force) {
+		exitWithError("Refusing to remove the project", usageError(errors.New("its containers are still running (use --force to remove it anyway)")))
+	}
+	if err := RemoveProject(projectDir); err != nil {
+		exitWithError("Error removing project", err)
+	}
+	printSuccess("Project removed from port registry.")
+}
+
+func handleStop(args []string) {
+	commandFlags("stop").Parse(args)
+	stopProject("stop", runSailStop)
+}
+
+func handleDown(args []string) {
+	commandFlags("down").Parse(args)
+	stopProject("down", runSailDown)
+}
+
+// stopProject runs `sail stop` or `sail down` through run in the current
+// project and records it as op.
+func stopProject(op string, run func(string) error) {
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	err = run(projectDir)
+	recordHistory(projectDir, op, "", err)
+	if err != nil {
+		exitWithError("Error running sail "+op, err)
+	}
+}
+
+func handleReset(args []string) {
+	fs := commandFlags("reset")
+	var opts resetOptions
+	fs.BoolVar(&opts.Ports, "ports", false, "Restore the port settings for the registered suffix")
+	fs.BoolVar(&opts.Xdebug, "xdebug", false, "Restore SAIL_XDEBUG_MODE")
+	fs.Var(&opts.DB, "db", "Restore the database settings; optionally only `connection,credentials,database`")
+	fs.Parse(args)
+	if !opts.Ports && !opts.Xdebug && len(opts.DB) == 0 {
+		exitWithError("Usage: sailinit reset [--ports] [--xdebug] [--db[=scopes]]", usageError(errors.New("nothing to reset")))
+	}
+	resetProject(opts)
+}
+
+// resetProject restores the selected .env defaults in place.
+func resetProject(opts resetOptions) {
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	bases := projectPortBases(projectDir)
+	before := portEnvValues(projectDir, bases)
+	err = resetEnvDefaults(projectDir, opts)
+	recordHistory(projectDir, "reset", resetDetail(opts), err)
+	if err != nil {
+		exitWithError("Error resetting .env", err)
+	}
+	printSuccess(".env defaults restored.")
+	changed := changedPortKeys(before, portEnvValues(projectDir, bases))
+	if err := offerRestartForPorts(bufio.NewReader(os.Stdin), projectDir, changed); err != nil {
+		exitWithError("Error restarting Sail", err)
+	}
+}
+
+func handleVersion(args []string) {
+	commandFlags("version").Parse(args)
+	fmt.Printf("sailinit %s\n", version)
+}
+
+// requestedSuffix is the suffix given with --suffix, or -1 to ask.
+var requestedSuffix = -1
+
+// chooseSuffix suggests a port suffix for projectDir and lets the user
+// confirm or override it, rejecting suffixes owned by other projects. A
+// suffix given with --suffix or pinned by .sailinit.yaml is claimed without
+// asking.
+func chooseSuffix(reader *bufio.Reader, projectDir string) (int, error) {
+	if requestedSuffix >= 0 {
+		return claimRequestedSuffix(projectDir, requestedSuffix, "--suffix")
+	}
+	if suffix, ok, err := pinnedSuffix(projectDir); err != nil {
+		return 0, err
+	} else if ok {
+		return claimRequestedSuffix(projectDir, suffix, projectConfigFile)
+	}
+	suggested, existing, existed, err := claimSuggestedSuffix(projectDir)
+	if err != nil {
+		return 0, err
 	}
 
-	reader := bufio.NewReader(os.Stdin)
 	if !existed && !existing {
-		printInfo("First-ever setup detected.")
+		printInfo(tr("first_setup"))
 		for {
-			fmt.Print("Enter the starting port suffix for your projects [default 48]: ")
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
+			input := readAnswer(reader, tr("start_suffix_prompt"))
 			if input == "" {
 				suggested = 48
 				break
@@ -230,47 +409,54 @@ func main() {
 			var startSuffix int
 			_, err := fmt.Sscanf(input, "%d", &startSuffix)
 			if err != nil {
-				printError("Invalid suffix. Please enter a number.")
+				printError(tr("suffix_not_number"))
 				continue
 			}
-			if err := ValidateSuffix(startSuffix); err != nil {
-				printError(fmt.Sprintf("Invalid suffix: %v", err))
+			if err := validateSuffixFor(portBases(), startSuffix); err != nil {
+				printError(tr("suffix_invalid", err))
 				continue
 			}
 			suggested = startSuffix
 			break
 		}
+		suggested = skipReservedSuffixes(suggested, reservedPorts())
 	}
 
 	suffix := suggested
 	if existing {
-		printInfo(fmt.Sprintf("Detected existing port suffix: %d", suffix))
+		printInfo(tr("suffix_existing", suffix))
 	}
 
 	for {
-		fmt.Printf("Use suffix [%d]? (Press Enter to confirm, or type new suffix): ", suffix)
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
+		input := readAnswer(reader, tr("suffix_prompt", suffix))
 
 		if input != "" {
 			var newSuffix int
 			_, err := fmt.Sscanf(input, "%d", &newSuffix)
 			if err != nil {
-				printError("Invalid suffix. Please enter a number.")
+				printError(tr("suffix_not_number"))
 				continue
 			}
-			if err := ValidateSuffix(newSuffix); err != nil {
-				printError(fmt.Sprintf("Invalid suffix: %v", err))
+			if err := validateSuffixFor(portBases(), newSuffix); err != nil {
+				printError(tr("suffix_invalid", err))
 				continue
 			}
 			suffix = newSuffix
 		}
 
-		// Validate against collisions
-		if otherPath, inUse := isSuffixInUseByOther(projectDir, suffix); inUse {
-			printError(fmt.Sprintf("Error: Suffix %d is already in use by another project:\n%s", suffix, otherPath))
-			// Reset suffix to suggested and retry loop but only if user didn't enter it
+		// Validate against collisions and reserve the suffix while the
+		// rest of the setup runs
+		otherPath, inUse, err := claimSuffix(projectDir, suffix)
+		if err != nil {
+			return 0, err
+		}
+		if inUse {
+			printError(tr("suffix_taken", suffix, otherPath))
+			// Suggest a fresh suffix unless the user entered this one
 			if input == "" {
+				if suggested, _, _, err = claimSuggestedSuffix(projectDir); err != nil {
+					return 0, err
+				}
 				suffix = suggested
 			}
 			continue
@@ -278,79 +464,159 @@ func main() {
 		break
 	}
 
-	// Check port availability
-	busyPorts := CheckSuffixPortsAvailable(suffix)
-	if len(busyPorts) > 0 {
-		printWarning("Warning: The following ports are already in use:")
-		for _, bp := range busyPorts {
-			printWarning(fmt.Sprintf("  %s: %d", bp.Name, bp.Port))
+	return suffix, nil
+}
+
+// portCheckOptions controls how confirmPortsAvailable resolves busy ports.
+type portCheckOptions struct {
+	// Steal stops other registered projects holding the ports without asking.
+	Steal bool
+	// Wait polls for busy ports to free up for at most this long.
+	Wait time.Duration
+}
+
+// suffixIsFixed reports whether projectDir's suffix was given with --suffix
+// or pinned by .sailinit.yaml, so setup must not pick another one.
+func suffixIsFixed(projectDir string) bool {
+	if requestedSuffix >= 0 {
+		return true
+	}
+	_, pinned, _ := pinnedSuffix(projectDir)
+	return pinned
+}
+
+// claimRequestedSuffix claims the suffix given with --suffix or pinned by
+// .sailinit.yaml (named by source), failing instead of asking when another
+// project owns it.
+func claimRequestedSuffix(projectDir string, suffix int, source string) (int, error) {
+	if err := validateSuffixFor(portBases(), suffix); err != nil {
+		return 0, usageError(err)
+	}
+	otherPath, inUse, err := claimSuffix(projectDir, suffix)
+	if err != nil {
+		return 0, err
+	}
+	if inUse {
+		return 0, usageError(fmt.Errorf("suffix %d from %s is already used by %s", suffix, source, shortenHome(otherPath)))
+	}
+	printInfo(tr("suffix_requested", suffix, source))
+	return suffix, nil
+}
+
+// confirmPortsAvailable warns about ports of suffix that are already in use
+// and asks whether to continue. When the ports belong to another registered
+// project's running stack it offers to stop that project first (or does so
+// straight away with opts.Steal). Unless the suffix was given with --suffix
+// or pinned by .sailinit.yaml, it then offers the next suffix whose ports
+// are all free. It returns the suffix to use, and true when its ports are
+// free or the user chose to continue anyway.
+func confirmPortsAvailable(reader *bufio.Reader, projectDir string, bases []PortBase, suffix int, opts portCheckOptions) (int, bool) {
+	busyPorts := CheckPortsAvailable(bases, suffix)
+	if len(busyPorts) > 0 && opts.Wait > 0 {
+		printInfo(tr("ports_waiting", opts.Wait, len(busyPorts)))
+		busyPorts = WaitForPorts(bases, suffix, opts.Wait, time.Second)
+	}
+	reserved := reservedPorts()
+	busyPorts = withReservedConflicts(busyPorts, bases, suffix, reserved)
+	containers := containerPorts(projectDir)
+	busyPorts = withContainerConflicts(busyPorts, bases, suffix, containers)
+	if len(busyPorts) == 0 || !warnPortBusy.warn(projectDir, tr("ports_busy")) {
+		return suffix, true
+	}
+	runningProjects := reportBusyPorts(busyPorts, reserved, containers, projectDir)
+	for _, other := range runningProjects {
+		if !opts.Steal && !askYesNo(reader, tr("steal_prompt", shortenHome(other))) {
+			continue
 		}
-		fmt.Print("Continue anyway? [y/N]: ")
-		var confirm string
-		fmt.Scanln(&confirm)
-		if strings.ToLower(confirm) != "y" {
-			os.Exit(0)
+		if err := runSailStop(other); err != nil {
+			printError(fmt.Sprintf("Error stopping %s: %v", other, err))
+			continue
 		}
+		printSuccess(tr("project_stopped", shortenHome(other)))
 	}
-
-	// Save the confirmed suffix
-	if *dryRunFlag {
-		printInfo(fmt.Sprintf("[dry-run] Would save suffix %d for project %s", suffix, projectDir))
-	} else {
-		if err := saveProjectSuffix(projectDir, suffix); err != nil {
-			printError(fmt.Sprintf("Error saving suffix: %v", err))
+	if len(runningProjects) > 0 {
+		busyPorts = withReservedConflicts(CheckPortsAvailable(bases, suffix), bases, suffix, reserved)
+		if len(withContainerConflicts(busyPorts, bases, suffix, containers)) == 0 {
+			return suffix, true
 		}
 	}
 
-	printInfo(fmt.Sprintf("Using port suffix: %d", suffix))
-
-	// 1. Setup .env
-	if *dryRunFlag {
-		printInfo(fmt.Sprintf("[dry-run] Would configure .env with suffix %d", suffix))
-		printInfo(fmt.Sprintf("[dry-run]   APP_PORT=%d", 8000+suffix))
-		printInfo(fmt.Sprintf("[dry-run]   FORWARD_DB_PORT=%d", 3300+suffix))
-		printInfo(fmt.Sprintf("[dry-run]   FORWARD_REDIS_PORT=%d", 6300+suffix))
-		printInfo(fmt.Sprintf("[dry-run]   FORWARD_MEILISEARCH_PORT=%d", 7700+suffix))
-		printInfo(fmt.Sprintf("[dry-run]   FORWARD_MAILPIT_DASHBOARD_PORT=%d", 18100+suffix))
-		printInfo(fmt.Sprintf("[dry-run]   FORWARD_MAILPIT_PORT=%d", 1000+suffix))
-		printInfo(fmt.Sprintf("[dry-run]   VITE_PORT=%d", 5100+suffix))
-	} else {
-		if err := setupEnv(projectDir, suffix, *resetDbFlag); err != nil {
-			printError(fmt.Sprintf("Error setting up .env: %v", err))
-			os.Exit(1)
+	if !suffixIsFixed(projectDir) {
+		if next, found := nextSuffixWithFreePorts(projectDir, bases, suffix, reserved, containers); found && askYesNo(reader, tr("ports_next_free", next)) {
+			otherPath, inUse, err := claimSuffix(projectDir, next)
+			switch {
+			case err != nil:
+				printError(fmt.Sprintf("Error reserving suffix %d: %v", next, err))
+			case inUse:
+				printError(tr("suffix_taken", next, otherPath))
+			default:
+				printInfo(tr("suffix_using", next))
+				return next, true
+			}
 		}
 	}
+	return suffix, askYesNo(reader, tr("continue_anyway"))
+}
 
-	// 2. Initial sailinit logic (Docker composer install)
-	if *dryRunFlag {
-		printInfo(fmt.Sprintf("[dry-run] Would run composer install via Docker (PHP %s)", phpVersion))
-	} else {
-		if err := runSailInit(phpVersion, projectDir, *freshFlag); err != nil {
-			printError(fmt.Sprintf("Error running sailinit: %v", err))
-			os.Exit(1)
+// reportBusyPorts prints what holds each of busy: a reserved service,
+// another registered project, a docker container, stopped ones included, or
+// a process. It returns the other projects whose running stacks hold some
+// of the ports.
+func reportBusyPorts(busy []BusyPort, reserved map[int]string, containers map[int]containerPort, projectDir string) []string {
+	explainer := newPortExplainer(containers)
+	var runningProjects []string
+	for _, bp := range busy {
+		if name, ok := reserved[bp.Port]; ok {
+			printWarning(tr("port_reserved", bp.Name, bp.Port, name))
+			continue
+		}
+		owner, found := explainer.owner(bp.Port, projectDir)
+		if !found {
+			printWarning(fmt.Sprintf("  %s: %d", bp.Name, bp.Port))
+			continue
+		}
+		printWarning(tr("port_used_by", bp.Name, bp.Port, owner.Description))
+		if owner.Running && !slices.Contains(runningProjects, owner.Project) {
+			runningProjects = append(runningProjects, owner.Project)
 		}
 	}
+	return runningProjects
+}
 
-	// 3. Run sail up -d
-	if *dryRunFlag {
-		printInfo("[dry-run] Would run sail up -d")
-	} else {
-		if err := runSailUp(projectDir); err != nil {
-			printError(fmt.Sprintf("Error running sail up: %v", err))
-			os.Exit(1)
+// choosePHPVersion asks which of several detected runtimes should drive
+// composer install. Pressing Enter picks the primary (first) runtime.
+func choosePHPVersion(reader *bufio.Reader, versions []string) string {
+	printInfo(tr("php_multiple", strings.Join(versions, ", ")))
+	for {
+		input := readAnswer(reader, tr("php_choose", versions[0]))
+		if input == "" {
+			return versions[0]
+		}
+		if slices.Contains(versions, input) {
+			return input
 		}
+		printError(tr("php_invalid_choice", strings.Join(versions, ", ")))
 	}
+}
 
-	printSuccess("\nSetup complete! Your application is running with the following ports:")
-	printInfo(fmt.Sprintf("Main App: http://localhost:%d", 8000+suffix))
-	printInfo(fmt.Sprintf("Mailpit Dashboard: http://localhost:%d", 18100+suffix))
+// askYesNo prints question with a [y/N] suffix and reports whether the
+// user answered yes.
+func askYesNo(reader *bufio.Reader, question string) bool {
+	auto := ""
+	if assumeYes {
+		auto = "y"
+	}
+	yes := isYes(promptAnswer(reader, fmt.Sprintf("%s %s: ", question, tr("yes_no")), auto))
+	recordYesNo(yes)
+	return yes
 }
 
-func handleList() {
+func handleList(args []string) {
+	commandFlags("list").Parse(args)
+	defer startPager()()
 	projects, err := ListProjects()
 	if err != nil {
-		printError(fmt.Sprintf("Error listing projects: %v", err))
-		os.Exit(1)
+		exitWithError("Error listing projects", err)
 	}
 	if len(projects) == 0 {
 		printInfo("No registered projects found.")
@@ -362,8 +628,14 @@ func handleList() {
 		return projects[i].Suffix < projects[j].Suffix
 	})
 
+	var paths []string
+	for _, p := range projects {
+		paths = append(paths, p.Path)
+	}
+	healths := projectHealths(paths)
+
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 		colorize(colorBold, "Project"),
 		colorize(colorBold, "Suffix"),
 		colorize(colorBold, "App Port"),
@@ -371,42 +643,59 @@ func handleList() {
 		colorize(colorBold, "Redis Port"),
 		colorize(colorBold, "Vite Port"),
 		colorize(colorBold, "Status"),
+		colorize(colorBold, "Health"),
+		colorize(colorBold, "Path"),
 	)
+	app, db, redis, vite := basePort("APP_PORT"), basePort("FORWARD_DB_PORT"), basePort("FORWARD_REDIS_PORT"), basePort("VITE_PORT")
 	for _, p := range projects {
 		status := colorize(colorGreen, "OK")
 		if !p.Exists {
 			status = colorize(colorRed, "[X] Missing")
 		}
-		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
-			p.Path,
-			p.Suffix,
-			8000+p.Suffix,
-			3300+p.Suffix,
-			6300+p.Suffix,
-			5100+p.Suffix,
+		suffix := strconv.Itoa(p.Suffix)
+		if p.DockerHost != "" {
+			suffix += " @" + p.DockerHost
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			p.Name,
+			suffix,
+			app+p.Suffix,
+			db+p.Suffix,
+			redis+p.Suffix,
+			vite+p.Suffix,
 			status,
+			healths[p.Path],
+			shortenHome(p.Path),
 		)
 	}
 	w.Flush()
 }
 
-func createNewProject(name string) error {
+// newProjectURL returns the laravel.build URL for a project named name. A
+// SQLite project gets no database service.
+func newProjectURL(name, db string) string {
+	with := "mysql"
+	if db == dbSQLite {
+		with = "mailpit"
+	}
+	return fmt.Sprintf("https://laravel.build/%s?with=%s", name, with)
+}
+
+func createNewProject(name, db string) error {
 	// Check the directory doesn't already exist
 	if _, err := os.Stat(name); err == nil {
 		return fmt.Errorf("directory %q already exists", name)
 	}
 
-	url := fmt.Sprintf("https://laravel.build/%s?with=mysql", name)
+	url := newProjectURL(name, db)
 	printInfo(fmt.Sprintf("Downloading from %s ...", url))
 
 	cmd := exec.Command("bash")
 	cmd.Stdin = strings.NewReader(fmt.Sprintf(`curl -s "%s" | bash`, url))
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return runStreaming(cmd)
 }
 
-func runSailInit(phpVersion, projectDir string, forceInstall bool) error {
+func runSailInit(phpVersion, projectDir string, forceInstall bool, user string) error {
 	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
 	if !forceInstall {
 		if _, err := os.Stat(sailPath); err == nil {
@@ -415,26 +704,58 @@ func runSailInit(phpVersion, projectDir string, forceInstall bool) error {
 		}
 	}
 
+	warnLowDiskSpace(projectDir)
+	if err := pullImage(composerImage(phpVersion)); err != nil {
+		return err
+	}
 	printInfo("Installing composer dependencies via Docker...")
+	return runComposerContainer(phpVersion, projectDir, user, "composer", "install", "--ignore-platform-reqs")
+}
 
-	currentUser := fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
-	dockerImage := fmt.Sprintf("laravelsail/php%s-composer:latest", phpVersion)
+// composerImage is Sail's composer image for phpVersion.
+func composerImage(phpVersion string) string {
+	return fmt.Sprintf("laravelsail/php%s-composer:latest", phpVersion)
+}
 
-	cmd := exec.Command("docker", "run", "--rm",
-		"-u", currentUser,
+// pullImage pulls image unless docker already has it, retrying transient
+// failures, so a flaky registry fails the pull rather than the command
+// that needs the image.
+func pullImage(image string) error {
+	if commandRun(exec.Command("docker", "image", "inspect", image)) == nil {
+		return nil
+	}
+	printInfo(fmt.Sprintf("Pulling %s...", image))
+	return runWithLoginRetry(func() *exec.Cmd {
+		return exec.Command("docker", "pull", image)
+	})
+}
+
+// composerContainerCommand runs command in Sail's composer image for
+// phpVersion with projectDir mounted, so it works before Sail is installed
+// or started. The container gets a generated name, recorded for
+// removeComposerContainers; run it with runComposerContainer.
+func composerContainerCommand(phpVersion, projectDir, user string, command ...string) *exec.Cmd {
+	dockerImage := composerImage(phpVersion)
+	name := newComposerContainerName()
+	trackComposerContainer(name)
+
+	args := []string{"run", "--rm", "--name", name,
+		"--label", composerLabel + "=" + projectDir,
+		"--label", fmt.Sprintf("%s=%d", composerPIDLabel, os.Getpid()),
+		"--label", composerHostLabel + "=" + composerHostname(),
+	}
+	if user != "" {
+		args = append(args, "-u", user)
+	}
+	args = append(args,
 		"-v", fmt.Sprintf("%s:/var/www/html", projectDir),
 		"-w", "/var/www/html",
 		dockerImage,
-		"composer", "install", "--ignore-platform-reqs",
 	)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	return exec.Command("docker", append(args, command...)...)
 }
 
-func setupEnv(projectDir string, suffix int, resetDb bool) error {
+func setupEnv(projectDir string, suffix int, resetDb resetScope, reader *bufio.Reader) error {
 	envPath := filepath.Join(projectDir, ".env")
 	envExamplePath := filepath.Join(projectDir, ".env.example")
 
@@ -442,11 +763,17 @@ func setupEnv(projectDir string, suffix int, resetDb bool) error {
 	if _, err := os.Stat(envPath); os.IsNotExist(err) {
 		envCreated = true
 		printInfo("Creating .env from .env.example...")
+		if _, err := os.Stat(envExamplePath); os.IsNotExist(err) {
+			if err := bootstrapEnvExample(envExamplePath); err != nil {
+				return err
+			}
+		}
 		if _, err := os.Stat(envExamplePath); err == nil {
 			data, err := os.ReadFile(envExamplePath)
 			if err != nil {
 				return err
 			}
+			data, _ = decodeText(data)
 			if err := os.WriteFile(envPath, data, 0644); err != nil {
 				return err
 			}
@@ -459,169 +786,256 @@ func setupEnv(projectDir string, suffix int, resetDb bool) error {
 
 	printInfo("Updating .env configuration...")
 
-	data, err := os.ReadFile(envPath)
+	// Settings are edited where they are, so comments, blank lines and the
+	// order of everything else survive
+	doc, err := readEnvDocument(envPath)
 	if err != nil {
 		return err
 	}
+	if doc.Converted {
+		printInfo("Converting .env from UTF-16 to UTF-8...")
+	}
+	before := doc.Bytes()
+	previousValues := envValues(doc)
+	locks := loadEnvLocks()
+
+	// Database settings - all of them when .env is newly created, otherwise
+	// only the groups selected with --reset-db. Missing ones go after the
+	// DB_ settings that precede them.
+	if envCreated {
+		resetDb = allDBResetGroups()
+	}
+	dbUpdates := resetDb.dbUpdates()
+	previous := ""
+	for _, key := range sailDBKeys {
+		value, selected := dbUpdates[key]
+		current, exists := doc.Get(key)
+		if selected && !locks.skip(key, current, value) {
+			if exists || previous == "" || !doc.InsertAfter(previous, []string{key + "=" + value}) {
+				setEnvLine(doc, key, value)
+			}
+		}
+		if _, ok := doc.Get(key); ok {
+			previous = key
+		}
+	}
 
-	lines := splitLines(string(data))
+	// Port keys follow the project's mail catcher (Mailpit or Mailhog).
+	// Missing ones join the existing ports; without any, the ports form a
+	// block at the end. Locked keys keep their line, value and position.
+	bases := projectPortBases(projectDir)
+	var leading, block []string
+	previous = ""
+	for _, b := range bases {
+		value := strconv.Itoa(b.Base + suffix)
+		current, exists := doc.Get(b.Name)
+		switch {
+		case locks.skip(b.Name, current, value):
+		case exists:
+			setEnvLine(doc, b.Name, value)
+		case previous != "":
+			doc.InsertAfter(previous, []string{b.Name + "=" + value})
+		default:
+			leading = append(leading, b.Name+"="+value)
+		}
+		if _, ok := doc.Get(b.Name); ok {
+			previous = b.Name
+		}
+	}
+	if len(leading) > 0 {
+		first := slices.IndexFunc(bases, func(b PortBase) bool {
+			_, ok := doc.Get(b.Name)
+			return ok
+		})
+		if first < 0 || !doc.InsertBefore(bases[first].Name, leading) {
+			block = leading
+		}
+	}
 
-	// Database settings - only apply when .env is newly created or --reset-db flag is used
-	applyDbSettings := envCreated || resetDb
-	coreUpdates := map[string]string{
-		"DB_CONNECTION": "mysql",
-		"DB_HOST":       "mysql",
-		"DB_PORT":       "3306",
-		"DB_DATABASE":   "laravel",
-		"DB_USERNAME":   "sail",
-		"DB_PASSWORD":   "password",
+	// Ports the project no longer uses are dropped unless locked
+	for _, key := range stalePortKeys(bases) {
+		if !locks.locked(key) {
+			doc.Remove(key)
+		}
 	}
 
-	portKeys := []string{
-		"APP_PORT",
-		"FORWARD_DB_PORT",
-		"FORWARD_REDIS_PORT",
-		"FORWARD_MEILISEARCH_PORT",
-		"FORWARD_MAILPIT_DASHBOARD_PORT",
-		"FORWARD_MAILPIT_PORT",
-		"VITE_PORT",
+	if len(block) > 0 {
+		doc.AppendBlock(block)
 	}
 
-	portValues := map[string]string{
-		"APP_PORT":                       fmt.Sprintf("%d", 8000+suffix),
-		"FORWARD_DB_PORT":                fmt.Sprintf("%d", 3300+suffix),
-		"FORWARD_REDIS_PORT":             fmt.Sprintf("%d", 6300+suffix),
-		"FORWARD_MEILISEARCH_PORT":       fmt.Sprintf("%d", 7700+suffix),
-		"FORWARD_MAILPIT_DASHBOARD_PORT": fmt.Sprintf("%d", 18100+suffix),
-		"FORWARD_MAILPIT_PORT":           fmt.Sprintf("%d", 1000+suffix),
-		"VITE_PORT":                      fmt.Sprintf("%d", 5100+suffix),
+	// Links Laravel generates follow the app to its new port
+	syncEnvURLs(doc, previousValues, locks)
+
+	// SAIL_XDEBUG_MODE stays where it is, or goes at the end
+	current, exists := doc.Get("SAIL_XDEBUG_MODE")
+	if !locks.skip("SAIL_XDEBUG_MODE", current, defaultXdebugMode) {
+		if exists {
+			setEnvLine(doc, "SAIL_XDEBUG_MODE", defaultXdebugMode)
+		} else {
+			doc.AppendBlock([]string{"SAIL_XDEBUG_MODE=" + defaultXdebugMode})
+		}
 	}
 
-	var newLines []string
-	seen := make(map[string]bool)
+	// An existing .env is only changed once the user has seen how
+	if !envCreated && !confirmEnvChanges(reader, before, doc.Bytes()) {
+		return errEnvDeclined
+	}
+	return doc.write(envPath)
+}
 
-	// First pass: update core variables (conditionally) and remove old port/debug variables
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
+// setEnvLine sets key in doc unless it already has value, so a line that
+// differs only in quoting or spacing is left as written.
+func setEnvLine(doc *envDocument, key, value string) {
+	if current, ok := doc.Get(key); ok && unquoteEnvValue(current) == value {
+		return
+	}
+	doc.Set(key, value)
+}
 
-		// Skip existing port or debug entries
-		isSkipKey := false
-		for _, pk := range portKeys {
-			if strings.HasPrefix(trimmedLine, pk+"=") {
-				isSkipKey = true
-				break
-			}
-		}
-		if strings.HasPrefix(trimmedLine, "SAIL_XDEBUG_MODE=") {
-			isSkipKey = true
-		}
-		if isSkipKey {
-			continue
-		}
+// generateAppKey returns a Laravel-compatible application key:
+// 32 random bytes, base64 encoded, with the "base64:" prefix.
+func generateAppKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "base64:" + base64.StdEncoding.EncodeToString(key), nil
+}
 
-		// Only update DB settings if applyDbSettings is true
-		updated := false
-		if applyDbSettings {
-			for key, val := range coreUpdates {
-				if strings.HasPrefix(trimmedLine, key+"=") {
-					newLines = append(newLines, fmt.Sprintf("%s=%s", key, val))
-					seen[key] = true
-					updated = true
-					break
-				}
-			}
+// ensureAppKey writes a freshly generated APP_KEY to the env file when the
+// key is missing or empty. It reports whether a key was generated.
+func ensureAppKey(envPath string) (bool, error) {
+	data, err := os.ReadFile(envPath)
+	if err != nil {
+		return false, err
+	}
+
+	lines, layout := splitTextFile(data)
+	keyLine := -1
+	insertAt := 0
+	for i, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmedLine, "APP_KEY=") {
+			keyLine = i
+			break
 		}
-		if !updated {
-			newLines = append(newLines, line)
+		if strings.HasPrefix(trimmedLine, "APP_ENV=") {
+			insertAt = i + 1
 		}
 	}
 
-	// Add missing core variables only if applyDbSettings is true
-	if applyDbSettings {
-		for key, val := range coreUpdates {
-			if !seen[key] {
-				newLines = append(newLines, fmt.Sprintf("%s=%s", key, val))
-			}
+	if keyLine >= 0 {
+		val := strings.Trim(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[keyLine]), "APP_KEY=")), `"'`)
+		if val != "" {
+			return false, nil
 		}
 	}
+	if loadEnvLocks().skip("APP_KEY", "", "generated") {
+		return false, nil
+	}
 
-	// Remove trailing empty lines
-	for len(newLines) > 0 && strings.TrimSpace(newLines[len(newLines)-1]) == "" {
-		newLines = newLines[:len(newLines)-1]
+	key, err := generateAppKey()
+	if err != nil {
+		return false, err
 	}
 
-	// Final Layout Construction
-	newLines = append(newLines, "") // 1. One empty line
+	if keyLine >= 0 {
+		lines[keyLine] = "APP_KEY=" + key
+	} else {
+		lines = append(lines[:insertAt], append([]string{"APP_KEY=" + key}, lines[insertAt:]...)...)
+	}
 
-	// 2. All port settings together
-	newLines = append(newLines, fmt.Sprintf("APP_PORT=%s", portValues["APP_PORT"]))
-	newLines = append(newLines, fmt.Sprintf("FORWARD_DB_PORT=%s", portValues["FORWARD_DB_PORT"]))
-	newLines = append(newLines, fmt.Sprintf("FORWARD_REDIS_PORT=%s", portValues["FORWARD_REDIS_PORT"]))
-	newLines = append(newLines, fmt.Sprintf("FORWARD_MEILISEARCH_PORT=%s", portValues["FORWARD_MEILISEARCH_PORT"]))
-	newLines = append(newLines, fmt.Sprintf("FORWARD_MAILPIT_DASHBOARD_PORT=%s", portValues["FORWARD_MAILPIT_DASHBOARD_PORT"]))
-	newLines = append(newLines, fmt.Sprintf("FORWARD_MAILPIT_PORT=%s", portValues["FORWARD_MAILPIT_PORT"]))
-	newLines = append(newLines, fmt.Sprintf("VITE_PORT=%s", portValues["VITE_PORT"]))
+	printInfo("Generated a new APP_KEY.")
+	return true, writeEnvFile(envPath, joinTextFile(lines, layout))
+}
 
-	newLines = append(newLines, "") // 3. One empty line
+// sailUpArgs are extra arguments for `sail up -d`, given after -- on the
+// command line. When nil, the config file's sail_up_args apply.
+var sailUpArgs []string
 
-	// 4. SAIL_XDEBUG_MODE at the end
-	newLines = append(newLines, "SAIL_XDEBUG_MODE=develop,debug,coverage")
+// splitPassthrough splits args at the first --, returning the arguments
+// before it and those after it.
+func splitPassthrough(args []string) ([]string, []string) {
+	if i := slices.Index(args, "--"); i >= 0 {
+		return args[:i], args[i+1:]
+	}
+	return args, nil
+}
 
-	return os.WriteFile(envPath, []byte(strings.Join(newLines, "\n")+"\n"), 0644)
+// sailUpCommand returns the sail arguments that start projectDir's stack,
+// restoring the replica counts recorded by `sailinit scale`.
+func sailUpCommand(projectDir string) []string {
+	args := append([]string{"up", "-d"}, scaleArgs(projectScales(projectDir))...)
+	if sailUpArgs != nil {
+		return append(args, sailUpArgs...)
+	}
+	if cfg, err := loadUserConfig(); err == nil {
+		return append(args, cfg.SailUpArgs...)
+	}
+	return args
 }
 
 func runSailUp(projectDir string) error {
-	printInfo("Starting Laravel Sail (sail up -d)...")
+	args := sailUpCommand(projectDir)
+	printInfo(fmt.Sprintf("Starting Laravel Sail (sail %s)...", strings.Join(args, " ")))
+	warnLowDiskSpace(projectDir)
 
-	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
-	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
-		return fmt.Errorf("sail binary not found at %s", sailPath)
+	if _, err := sailCommand(projectDir, args...); err != nil {
+		return err
 	}
 
-	cmd := exec.Command(sailPath, "up", "-d")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	err := runWithLoginRetry(func() *exec.Cmd {
+		cmd, _ := sailCommand(projectDir, args...)
+		return cmd
+	})
+	if err != nil {
+		return err
+	}
+	if readyTimeout <= 0 {
+		return nil
+	}
+	return waitForReady(projectDir, readyTimeout)
 }
 
 func runSailStop(projectDir string) error {
-	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
-	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
-		return fmt.Errorf("sail binary not found at %s", sailPath)
+	cmd, err := sailCommand(projectDir, "stop")
+	if err != nil {
+		return err
 	}
 
 	printInfo("Stopping Laravel Sail...")
-	cmd := exec.Command(sailPath, "stop")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return runStreaming(cmd)
 }
 
 func runSailDown(projectDir string) error {
-	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
-	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
-		return fmt.Errorf("sail binary not found at %s", sailPath)
+	cmd, err := sailCommand(projectDir, "down")
+	if err != nil {
+		return err
 	}
 
 	printInfo("Running sail down...")
-	cmd := exec.Command(sailPath, "down")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return runStreaming(cmd)
 }
 
 func getContainerStatus(projectDir string) string {
-	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
-	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
-		return "no sail"
+	st, err := Status(projectDir)
+	if err != nil {
+		return "unknown"
 	}
+	return containerSummary(st)
+}
 
-	cmd := exec.Command(sailPath, "ps", "--format", "{{.State}}")
-	cmd.Dir = projectDir
-	output, err := cmd.Output()
+// countRunningContainers returns how many of the project's Sail containers
+// are running. It returns ErrSailNotFound when vendor/bin/sail and the
+// compose file are missing.
+func countRunningContainers(projectDir string) (int, error) {
+	cmd, err := sailCommand(projectDir, "ps", "--format", "{{.State}}")
 	if err != nil {
-		return "unknown"
+		return 0, err
+	}
+	output, err := commandOutput(cmd)
+	if err != nil {
+		return 0, err
 	}
 
 	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
@@ -631,46 +1045,5 @@ func getContainerStatus(projectDir string) string {
 			running++
 		}
 	}
-
-	if running == 0 {
-		return colorize(colorDim, "stopped")
-	}
-	return colorize(colorGreen, fmt.Sprintf("%d running", running))
-}
-
-func showProjectStatus() error {
-	projects, err := ListProjects()
-	if err != nil {
-		return err
-	}
-	if len(projects) == 0 {
-		printInfo("No registered projects found.")
-		return nil
-	}
-
-	sort.Slice(projects, func(i, j int) bool {
-		return projects[i].Suffix < projects[j].Suffix
-	})
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-		colorize(colorBold, "Project"),
-		colorize(colorBold, "Suffix"),
-		colorize(colorBold, "App Port"),
-		colorize(colorBold, "Containers"),
-	)
-	for _, p := range projects {
-		containers := colorize(colorRed, "[X] Missing")
-		if p.Exists {
-			containers = getContainerStatus(p.Path)
-		}
-		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n",
-			p.Path,
-			p.Suffix,
-			8000+p.Suffix,
-			containers,
-		)
-	}
-	w.Flush()
-	return nil
+	return running, nil
 }
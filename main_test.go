@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bufio"
+	"encoding/base64"
 	"os"
 	"path/filepath"
 	"strings"
@@ -88,7 +90,7 @@ func TestRunSailInitSkipsWhenSailExists(t *testing.T) {
 	}
 
 	// With forceInstall=false, should skip and return nil
-	err = runSailInit("84", tempDir, false)
+	err = runSailInit("84", tempDir, false, "")
 	if err != nil {
 		t.Errorf("Expected nil error when sail exists and forceInstall=false, got: %v", err)
 	}
@@ -112,7 +114,7 @@ func TestRunSailInitRunsWithFreshFlag(t *testing.T) {
 	}
 
 	// With forceInstall=true, should attempt to run docker (which will fail in test env)
-	err = runSailInit("84", tempDir, true)
+	err = runSailInit("84", tempDir, true, "")
 
 	// We expect an error because docker won't run properly in tests,
 	// but the important thing is that it TRIED to run (didn't skip)
@@ -192,3 +194,167 @@ func TestGetContainerStatusNoSail(t *testing.T) {
 		t.Errorf("Expected %q, got %q", "no sail", status)
 	}
 }
+
+func TestEnsureAppKeyGeneratesWhenEmpty(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-key-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	if err := os.WriteFile(envPath, []byte("APP_NAME=Laravel\nAPP_KEY=\nAPP_DEBUG=true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := ensureAppKey(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !generated {
+		t.Error("Expected key to be generated for empty APP_KEY")
+	}
+
+	values := readEnvValues(envPath)
+	key := values["APP_KEY"]
+	if !strings.HasPrefix(key, "base64:") {
+		t.Fatalf("Expected base64: prefixed key, got %q", key)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(key, "base64:"))
+	if err != nil || len(raw) != 32 {
+		t.Errorf("Expected 32-byte key, got %d bytes (err=%v)", len(raw), err)
+	}
+	if values["APP_DEBUG"] != "true" {
+		t.Error("Other variables should be preserved")
+	}
+}
+
+func TestEnsureAppKeyKeepsExistingKey(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-key-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	content := "APP_KEY=base64:existing\n"
+	if err := os.WriteFile(envPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := ensureAppKey(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generated {
+		t.Error("Existing APP_KEY should not be replaced")
+	}
+
+	data, _ := os.ReadFile(envPath)
+	if string(data) != content {
+		t.Errorf("File should be unchanged, got %q", data)
+	}
+}
+
+func TestEnsureAppKeyInsertsMissingKey(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-key-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	if err := os.WriteFile(envPath, []byte("APP_NAME=Laravel\nAPP_ENV=local\nAPP_DEBUG=true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ensureAppKey(envPath); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(envPath)
+	lines := strings.Split(string(data), "\n")
+	if !strings.HasPrefix(lines[2], "APP_KEY=base64:") {
+		t.Errorf("Expected APP_KEY right after APP_ENV, got lines %q", lines)
+	}
+}
+
+func TestDetectPHPVersionsMultipleRuntimes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "php-detect-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	content := `services:
+  laravel.test:
+    build:
+      context: ./vendor/laravel/sail/runtimes/8.3
+    image: sail-8.3/app
+  worker:
+    build:
+      context: ./vendor/laravel/sail/runtimes/8.2
+    image: sail-8.2/app
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "compose.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	versions := detectPHPVersions(tempDir)
+	if strings.Join(versions, ",") != "83,82" {
+		t.Errorf("Expected [83 82], got %v", versions)
+	}
+	if got := detectPHPVersion(tempDir); got != "83" {
+		t.Errorf("Expected primary runtime 83, got %q", got)
+	}
+}
+
+func TestChoosePHPVersion(t *testing.T) {
+	versions := []string{"83", "82"}
+
+	if got := choosePHPVersion(bufio.NewReader(strings.NewReader("\n")), versions); got != "83" {
+		t.Errorf("Expected default 83, got %q", got)
+	}
+	if got := choosePHPVersion(bufio.NewReader(strings.NewReader("74\n82\n")), versions); got != "82" {
+		t.Errorf("Expected 82 after invalid input, got %q", got)
+	}
+}
+
+func TestSplitPassthrough(t *testing.T) {
+	own, rest := splitPassthrough([]string{"--no-cache", "--", "--build", "--remove-orphans"})
+	if strings.Join(own, " ") != "--no-cache" || strings.Join(rest, " ") != "--build --remove-orphans" {
+		t.Errorf("Unexpected split: %v / %v", own, rest)
+	}
+	if own, rest := splitPassthrough([]string{"shop"}); len(own) != 1 || rest != nil {
+		t.Errorf("Expected no passthrough without --, got %v / %v", own, rest)
+	}
+}
+
+func TestRunSailUpPassthrough(t *testing.T) {
+	projectDir := t.TempDir()
+	sailDir := filepath.Join(projectDir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	argsPath := filepath.Join(projectDir, "args.txt")
+	os.WriteFile(filepath.Join(sailDir, "sail"), []byte("#!/bin/sh\necho \"$@\" > "+argsPath+"\n"), 0755)
+	defer func() { sailUpArgs = nil }()
+
+	writeTestConfig(t, "sail_up_args: [--build]\n")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: "up -d --build"},
+		{args: []string{"--remove-orphans", "--scale", "queue=2"}, want: "up -d --remove-orphans --scale queue=2"},
+		{args: []string{}, want: "up -d"},
+	}
+	for _, tt := range tests {
+		sailUpArgs = tt.args
+		if err := runSailUp(projectDir); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := os.ReadFile(argsPath)
+		if strings.TrimSpace(string(got)) != tt.want {
+			t.Errorf("sailUpArgs %v: expected %q, got %q", tt.args, tt.want, strings.TrimSpace(string(got)))
+		}
+	}
+}
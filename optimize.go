@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+// cachedConfigPatterns match the files `artisan optimize` leaves in
+// bootstrap/cache. While they exist Laravel ignores changes to .env.
+var cachedConfigPatterns = []string{"config.php", "routes-*.php", "events.php"}
+
+// staleCacheFiles returns the cached config, route and event files of the
+// project, relative to projectDir.
+func staleCacheFiles(projectDir string) []string {
+	var files []string
+	for _, pattern := range cachedConfigPatterns {
+		matches, _ := filepath.Glob(filepath.Join(projectDir, "bootstrap", "cache", pattern))
+		for _, m := range matches {
+			rel, _ := filepath.Rel(projectDir, m)
+			files = append(files, filepath.ToSlash(rel))
+		}
+	}
+	return files
+}
+
+// runSailOptimizeClear clears Laravel's config, route, view and event caches
+// inside the running containers.
+func runSailOptimizeClear(projectDir string) error {
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	printInfo("Clearing cached config, routes and views (artisan optimize:clear)...")
+	cmd := exec.Command(sailPath, "artisan", "optimize:clear")
+	cmd.Dir = projectDir
+	cmd.Env = sailEnv(projectDir)
+	return runStreaming(cmd)
+}
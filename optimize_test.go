@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaleCacheFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sailinit-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if files := staleCacheFiles(tempDir); len(files) != 0 {
+		t.Errorf("Expected no cache files, got %v", files)
+	}
+
+	cacheDir := filepath.Join(tempDir, "bootstrap", "cache")
+	os.MkdirAll(cacheDir, 0755)
+	for _, name := range []string{"config.php", "routes-v7.php", "packages.php", ".gitignore"} {
+		os.WriteFile(filepath.Join(cacheDir, name), []byte("<?php"), 0644)
+	}
+	got := strings.Join(staleCacheFiles(tempDir), ",")
+	if got != "bootstrap/cache/config.php,bootstrap/cache/routes-v7.php" {
+		t.Errorf("Expected the config and route caches, got %q", got)
+	}
+}
+
+func TestApplySetupPlanClearCache(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	sailDir := filepath.Join(projectDir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	logPath := filepath.Join(tempDir, "sail.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\n"
+	os.WriteFile(filepath.Join(sailDir, "sail"), []byte(script), 0755)
+	os.WriteFile(filepath.Join(projectDir, ".env"), []byte("APP_KEY=base64:abc\n"), 0644)
+
+	plan := &setupPlan{Version: planVersion, ProjectDir: projectDir, PHPVersion: "84", Suffix: 54}
+	if err := applySetupPlan(nil, plan, false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(logPath)
+	if strings.Contains(string(data), "optimize:clear") {
+		t.Error("Expected no cache clearing unless the plan asks for it")
+	}
+
+	plan.ClearCache = true
+	if err := applySetupPlan(nil, plan, false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(logPath)
+	if !strings.Contains(string(data), "up -d\nartisan optimize:clear\n") {
+		t.Errorf("Expected optimize:clear after sail up, got:\n%s", data)
+	}
+}
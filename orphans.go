@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// composeStack is a docker compose project found among the machine's
+// containers.
+type composeStack struct {
+	Project    string
+	WorkingDir string
+	Services   []string
+	Running    int
+	Total      int
+	// Sail is set when the stack looks like Sail: a laravel.test service or
+	// an image built from Sail's runtimes
+	Sail bool
+}
+
+// parseComposeContainers groups `docker ps -a` lines of
+// "project\tworking dir\tservice\timage\tstate" into stacks, sorted by
+// project name. Containers not started by docker compose are skipped.
+func parseComposeContainers(output string) []composeStack {
+	byProject := make(map[string]*composeStack)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 || fields[0] == "" {
+			continue
+		}
+		project, dir, service, image, state := fields[0], fields[1], fields[2], fields[3], fields[4]
+		stack, ok := byProject[project]
+		if !ok {
+			stack = &composeStack{Project: project, WorkingDir: dir}
+			byProject[project] = stack
+		}
+		stack.Total++
+		if state == "running" {
+			stack.Running++
+		}
+		if !slices.Contains(stack.Services, service) {
+			stack.Services = append(stack.Services, service)
+		}
+		if service == "laravel.test" || strings.HasPrefix(image, "sail-") || strings.HasPrefix(image, "laravelsail/") {
+			stack.Sail = true
+		}
+	}
+
+	stacks := make([]composeStack, 0, len(byProject))
+	for _, stack := range byProject {
+		stacks = append(stacks, *stack)
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Project < stacks[j].Project })
+	return stacks
+}
+
+// unmanagedSailStacks returns the Sail stacks whose working directory is
+// not in the registry.
+func unmanagedSailStacks(stacks []composeStack, registered map[string]projectEntry) []composeStack {
+	var out []composeStack
+	for _, stack := range stacks {
+		if _, ok := registered[stack.WorkingDir]; stack.Sail && !ok {
+			out = append(out, stack)
+		}
+	}
+	return out
+}
+
+// listComposeStacks asks docker for every compose project's containers,
+// running or not.
+func listComposeStacks() ([]composeStack, error) {
+	out, err := commandOutput(exec.Command("docker", "ps", "-a", "--format",
+		`{{.Label "com.docker.compose.project"}}`+"\t"+
+			`{{.Label "com.docker.compose.project.working_dir"}}`+"\t"+
+			`{{.Label "com.docker.compose.service"}}`+"\t{{.Image}}\t{{.State}}",
+	))
+	if err != nil {
+		return nil, dockerUnavailableError(err)
+	}
+	return parseComposeContainers(string(out)), nil
+}
+
+// suffixFromEnv derives the suffix a project was set up with from the
+// APP_PORT in its .env.
+func suffixFromEnv(projectDir string) (int, error) {
+	value := readEnvValues(filepath.Join(projectDir, ".env"))["APP_PORT"]
+	if value == "" {
+		return 0, errors.New("no APP_PORT in .env")
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("APP_PORT %q is not a number", value)
+	}
+	suffix := port - basePort("APP_PORT")
+	if err := ValidateSuffix(suffix); err != nil {
+		return 0, fmt.Errorf("APP_PORT %d does not follow the suffix scheme: %w", port, err)
+	}
+	return suffix, nil
+}
+
+// adoptStack registers an unmanaged stack's directory under the suffix its
+// .env already uses, so its ports stay as they are.
+func adoptStack(stack composeStack) (int, error) {
+	if _, err := os.Stat(stack.WorkingDir); err != nil {
+		return 0, fmt.Errorf("directory %s is gone", stack.WorkingDir)
+	}
+	suffix, err := suffixFromEnv(stack.WorkingDir)
+	if err != nil {
+		return 0, fmt.Errorf("%w; run sailinit in %s instead", err, stack.WorkingDir)
+	}
+	owner, taken, err := claimSuffix(stack.WorkingDir, suffix)
+	if err != nil {
+		return 0, err
+	}
+	if taken {
+		return 0, fmt.Errorf("suffix %d already belongs to %s; run sailinit in %s to pick another", suffix, owner, stack.WorkingDir)
+	}
+	return suffix, saveProjectSuffix(stack.WorkingDir, suffix)
+}
+
+// describeStack renders a stack as one line of the orphans listing.
+func describeStack(stack composeStack) string {
+	dir := shortenHome(stack.WorkingDir)
+	if _, err := os.Stat(stack.WorkingDir); err != nil {
+		dir += ", directory missing"
+	}
+	return fmt.Sprintf("%s (%s): %d/%d running [%s]", stack.Project, dir, stack.Running, stack.Total, strings.Join(stack.Services, ", "))
+}
+
+func handleOrphans(args []string) {
+	fset := commandFlags("orphans")
+	containers := fset.Bool("containers", false, "List Sail stacks on this machine that are not in the registry")
+	adopt := fset.Bool("adopt", false, "With --containers, register each stack under the suffix its .env uses")
+	down := fset.Bool("down", false, "With --containers, offer to take each stack down")
+	fset.Parse(args)
+	if !*down {
+		defer startPager()()
+	}
+
+	if !*containers {
+		projects, err := ListProjects()
+		if err != nil {
+			exitWithError("Error reading registry", err)
+		}
+		missing := 0
+		for _, p := range projects {
+			if !p.Exists {
+				fmt.Printf("  %s (suffix %d)\n", shortenHome(p.Path), p.Suffix)
+				missing++
+			}
+		}
+		if missing == 0 {
+			printSuccess("Every registered project directory exists.")
+			return
+		}
+		printInfo("Remove them with: sailinit clean")
+		return
+	}
+	if *adopt {
+		requireWritableState()
+	}
+
+	stacks, err := listComposeStacks()
+	if err != nil {
+		exitWithError("Error listing containers", err)
+	}
+	state, _, err := loadPortState()
+	if err != nil {
+		exitWithError("Error reading registry", err)
+	}
+	orphans := unmanagedSailStacks(stacks, state.Projects)
+	if len(orphans) == 0 {
+		printSuccess("No unmanaged Sail stacks found.")
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for _, stack := range orphans {
+		fmt.Printf("  %s\n", describeStack(stack))
+		if *adopt {
+			if suffix, err := adoptStack(stack); err != nil {
+				printWarning(fmt.Sprintf("  Not adopted: %v", err))
+			} else {
+				printSuccess(fmt.Sprintf("  Registered with suffix %d.", suffix))
+				continue
+			}
+		}
+		if *down && askYesNo(reader, tr("orphan_take_down", stack.Project)) {
+			if err := takeStackDown(stack.WorkingDir, runningStack{Project: stack.Project}); err != nil {
+				printError(fmt.Sprintf("Error taking %s down: %v", stack.Project, err))
+			}
+		}
+	}
+	if !*adopt && !*down {
+		printInfo("Register them with --adopt, or remove them with --down.")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseComposeContainers(t *testing.T) {
+	output := strings.Join([]string{
+		"shop\t/code/shop\tlaravel.test\tsail-8.4/app\trunning",
+		"shop\t/code/shop\tmysql\tmysql/mysql-server:8.0\texited",
+		"blog\t/code/blog\tqueue\tsail-8.3/app\trunning",
+		"other\t/code/other\tweb\tnginx\trunning",
+		"\t\t\tpostgres\trunning",
+	}, "\n")
+
+	stacks := parseComposeContainers(output)
+	if len(stacks) != 3 {
+		t.Fatalf("Expected 3 compose stacks, got %+v", stacks)
+	}
+	blog, other, shop := stacks[0], stacks[1], stacks[2]
+	if shop.Project != "shop" || shop.Running != 1 || shop.Total != 2 || !shop.Sail {
+		t.Errorf("Unexpected shop stack %+v", shop)
+	}
+	if strings.Join(shop.Services, ",") != "laravel.test,mysql" {
+		t.Errorf("Unexpected shop services %v", shop.Services)
+	}
+	if !blog.Sail {
+		t.Error("Expected a Sail runtime image to mark the stack as Sail")
+	}
+	if other.Sail {
+		t.Error("Expected a plain nginx stack not to look like Sail")
+	}
+
+	unmanaged := unmanagedSailStacks(stacks, map[string]projectEntry{"/code/shop": {Suffix: 51}})
+	if len(unmanaged) != 1 || unmanaged[0].Project != "blog" {
+		t.Errorf("Expected only blog to be unmanaged, got %+v", unmanaged)
+	}
+}
+
+func TestAdoptStack(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	shop := filepath.Join(tempDir, "shop")
+	os.MkdirAll(shop, 0755)
+	os.WriteFile(filepath.Join(shop, ".env"), []byte("APP_PORT=8061\n"), 0644)
+	suffix, err := adoptStack(composeStack{Project: "shop", WorkingDir: shop})
+	if err != nil || suffix != 61 {
+		t.Fatalf("Expected suffix 61, got %d (err=%v)", suffix, err)
+	}
+	if projects, _ := ListProjects(); len(projects) != 1 || projects[0].Suffix != 61 {
+		t.Errorf("Expected shop to be registered, got %+v", projects)
+	}
+
+	clone := filepath.Join(tempDir, "clone")
+	os.MkdirAll(clone, 0755)
+	os.WriteFile(filepath.Join(clone, ".env"), []byte("APP_PORT=8061\n"), 0644)
+	if _, err := adoptStack(composeStack{Project: "clone", WorkingDir: clone}); err == nil || !strings.Contains(err.Error(), "already belongs to") {
+		t.Errorf("Expected a suffix conflict, got %v", err)
+	}
+
+	legacy := filepath.Join(tempDir, "legacy")
+	os.MkdirAll(legacy, 0755)
+	os.WriteFile(filepath.Join(legacy, ".env"), []byte("APP_PORT=80\n"), 0644)
+	if _, err := adoptStack(composeStack{Project: "legacy", WorkingDir: legacy}); err == nil || !strings.Contains(err.Error(), "run sailinit in") {
+		t.Errorf("Expected a port outside the scheme to be rejected, got %v", err)
+	}
+
+	if _, err := adoptStack(composeStack{Project: "gone", WorkingDir: filepath.Join(tempDir, "gone")}); err == nil {
+		t.Error("Expected a missing directory to be rejected")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// packageService names the Sail services a Composer package needs to work.
+type packageService struct {
+	Package string
+	// Services satisfy the package when the compose file defines any of
+	// them; the first is the one suggested
+	Services []string
+	// Port is the .env key of a port the app container must publish
+	// instead, for packages that serve from it
+	Port string
+}
+
+// packageServices lists the packages setup knows the needs of.
+var packageServices = []packageService{
+	{Package: "laravel/horizon", Services: []string{"redis"}},
+	{Package: "laravel/scout", Services: []string{"meilisearch", "typesense"}},
+	{Package: "laravel/telescope", Services: []string{"mysql", "pgsql", "mariadb"}},
+	{Package: "laravel/reverb", Port: "REVERB_PORT"},
+}
+
+// reverbPortBase is the base suggested for REVERB_PORT, 300 away from
+// APP_PORT's default base like the other ports of the scheme.
+const reverbPortBase = 8300
+
+// packageSuggestion is a service or port missing for a required package.
+type packageSuggestion struct {
+	Package string
+	// Service is the compose service to add, or "" when Port is missing
+	Service string
+	Port    string
+}
+
+// message explains s and how to fix it.
+func (s packageSuggestion) message() string {
+	if s.Service == "" {
+		return tr("package_port", s.Package, s.Port, reverbPortBase)
+	}
+	return tr("package_service", s.Package, s.Service)
+}
+
+// composerPackages returns the packages composer.json in dir requires,
+// including the development ones, or nil when it can't be read.
+func composerPackages(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, "composer.json"))
+	if err != nil {
+		return nil
+	}
+	var composer struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if json.Unmarshal(data, &composer) != nil {
+		return nil
+	}
+	var packages []string
+	for name := range composer.Require {
+		packages = append(packages, name)
+	}
+	for name := range composer.RequireDev {
+		packages = append(packages, name)
+	}
+	return packages
+}
+
+// packageSuggestions compares the packages projectDir requires with its
+// compose file and .env, and returns the services and ports they need
+// that are missing. Without a compose file there is nothing to compare.
+func packageSuggestions(projectDir string) []packageSuggestion {
+	compose, err := loadCompose(projectDir)
+	if err != nil || compose == nil {
+		return nil
+	}
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	if _, err := os.Stat(filepath.Join(projectDir, ".env")); err != nil {
+		env = readEnvValues(filepath.Join(projectDir, ".env.example"))
+	}
+	packages := composerPackages(projectDir)
+	names := compose.ServiceNames()
+
+	var suggestions []packageSuggestion
+	for _, p := range packageServices {
+		if !slices.Contains(packages, p.Package) {
+			continue
+		}
+		if p.Port != "" {
+			if !publishesEnvPort(compose, p.Port) {
+				suggestions = append(suggestions, packageSuggestion{Package: p.Package, Port: p.Port})
+			}
+			continue
+		}
+		services := packageServicesFor(p, env)
+		if len(services) == 0 || slices.ContainsFunc(services, func(s string) bool { return slices.Contains(names, s) }) {
+			continue
+		}
+		suggestions = append(suggestions, packageSuggestion{Package: p.Package, Service: services[0]})
+	}
+	return suggestions
+}
+
+// packageServicesFor narrows the services of p to those env calls for:
+// Scout's driver picks its search engine, and Telescope stores its entries
+// in SQLite without a database service.
+func packageServicesFor(p packageService, env map[string]string) []string {
+	switch p.Package {
+	case "laravel/scout":
+		switch driver := env["SCOUT_DRIVER"]; driver {
+		case "":
+			return p.Services
+		case "meilisearch", "typesense":
+			return []string{driver}
+		default:
+			// Algolia, database and collection need no service
+			return nil
+		}
+	case "laravel/telescope":
+		if dbConnection(env) == dbSQLite {
+			return nil
+		}
+	}
+	return p.Services
+}
+
+// publishesEnvPort reports whether a service of compose publishes the port
+// in the .env key.
+func publishesEnvPort(compose *composeProject, key string) bool {
+	for _, svc := range compose.Services {
+		for _, port := range svc.Ports {
+			if strings.Contains(port.Published, "${"+key+":") || strings.Contains(port.Published, "${"+key+"}") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// suggestPackageServices warns about the services and ports the packages
+// of projectDir need but its compose file lacks, unless projectDir
+// suppresses the warning.
+func suggestPackageServices(projectDir string) {
+	if warnPackageServices.suppressed(projectDir) {
+		return
+	}
+	for _, s := range packageSuggestions(projectDir) {
+		printWarning(warnPackageServices.tag(s.message()))
+	}
+}
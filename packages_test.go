@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackageSuggestions(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "composer.json"), []byte(`{
+  "require": {"laravel/framework": "^11.0", "laravel/horizon": "^5.0", "laravel/scout": "^10.0", "laravel/reverb": "^1.0"},
+  "require-dev": {"laravel/telescope": "^5.0"}
+}`), 0644)
+	compose := `services:
+  laravel.test:
+    image: sail-8.3/app
+    ports:
+      - '${APP_PORT:-80}:80'
+  mysql:
+    image: mysql/mysql-server:8.0
+`
+	os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644)
+
+	got := packageSuggestions(dir)
+	want := []packageSuggestion{
+		{Package: "laravel/horizon", Service: "redis"},
+		{Package: "laravel/scout", Service: "meilisearch"},
+		{Package: "laravel/reverb", Port: "REVERB_PORT"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want[i], got[i])
+		}
+	}
+
+	// Services and ports that are there, or that .env does not call for,
+	// are not suggested
+	compose = `services:
+  laravel.test:
+    ports:
+      - '${APP_PORT:-80}:80'
+      - '${REVERB_PORT:-8080}:8080'
+  mysql:
+    image: mysql/mysql-server:8.0
+  redis:
+    image: redis:alpine
+`
+	os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("SCOUT_DRIVER=database\n"), 0644)
+	if got := packageSuggestions(dir); len(got) != 0 {
+		t.Errorf("Expected no suggestions, got %v", got)
+	}
+}
+
+func TestPackageSuggestionsWithoutCompose(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "composer.json"), []byte(`{"require": {"laravel/horizon": "^5.0"}}`), 0644)
+	if got := packageSuggestions(dir); got != nil {
+		t.Errorf("Expected nothing to compare without a compose file, got %v", got)
+	}
+}
+
+func TestPackageServicesFor(t *testing.T) {
+	telescope := packageServices[2]
+	if got := packageServicesFor(telescope, map[string]string{"DB_CONNECTION": "sqlite"}); got != nil {
+		t.Errorf("Expected Telescope on SQLite to need no service, got %v", got)
+	}
+	scout := packageServices[1]
+	if got := packageServicesFor(scout, map[string]string{"SCOUT_DRIVER": "typesense"}); len(got) != 1 || got[0] != "typesense" {
+		t.Errorf("Expected typesense for its driver, got %v", got)
+	}
+}
+
+func TestPublishesEnvPort(t *testing.T) {
+	// Port lines as Sail's own stubs write them
+	compose, err := parseCompose([]byte(`services:
+  laravel.test:
+    ports:
+      - '${APP_PORT:-80}:80'
+      - '${VITE_PORT:-5173}:${VITE_PORT:-5173}'
+      - '${REVERB_PORT:-8080}:8080'
+  mailpit:
+    ports:
+      - '${FORWARD_MAILPIT_PORT:-1025}:1025'
+      - '${FORWARD_MAILPIT_DASHBOARD_PORT:-8025}:8025'
+  soketi:
+    ports:
+      - '${PUSHER_PORT}:6001'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"APP_PORT", "VITE_PORT", "REVERB_PORT", "FORWARD_MAILPIT_DASHBOARD_PORT", "PUSHER_PORT"} {
+		if !publishesEnvPort(compose, key) {
+			t.Errorf("Expected %s to be published", key)
+		}
+	}
+	for _, key := range []string{"MAILPIT_PORT", "FORWARD_REDIS_PORT", "PUSHER"} {
+		if publishesEnvPort(compose, key) {
+			t.Errorf("Expected %s not to be published", key)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// defaultPager is used when neither SAILINIT_PAGER, the config file nor
+// PAGER names one. -R passes colors through.
+const defaultPager = "less -R"
+
+// pagerStop ends the capture started by startPager; exit calls it so
+// output printed before an error still reaches the terminal.
+var pagerStop func()
+
+// pagerCommand returns the pager's command line: SAILINIT_PAGER, then the
+// config file's pager, then PAGER, then less. An empty one or cat turns
+// paging off, as with git.
+func pagerCommand() []string {
+	pager, ok := os.LookupEnv("SAILINIT_PAGER")
+	if !ok {
+		if cfg, err := loadUserConfig(); err == nil && cfg.Pager != nil {
+			pager, ok = *cfg.Pager, true
+		}
+	}
+	if !ok {
+		pager, ok = os.LookupEnv("PAGER")
+	}
+	if !ok {
+		pager = defaultPager
+	}
+	fields := strings.Fields(pager)
+	if len(fields) == 0 || fields[0] == "cat" {
+		return nil
+	}
+	return fields
+}
+
+// stdoutIsTerminal reports whether output goes to a terminal.
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalHeight returns the terminal's rows: LINES when set, otherwise
+// what the terminal reports, or 0 when unknown.
+func terminalHeight() int {
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		return n
+	}
+	return terminalRows(os.Stdout)
+}
+
+// startPager collects what the command prints until the returned stop is
+// called, then shows it through the pager when it is longer than the
+// terminal, or as it is otherwise. Output that isn't going to a terminal
+// is never collected. Use it as defer startPager()().
+func startPager() (stop func()) {
+	pager := pagerCommand()
+	if pager == nil || !stdoutIsTerminal() {
+		return func() {}
+	}
+	height := terminalHeight()
+	if height <= 0 {
+		return func() {}
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(done)
+	}()
+
+	pagerStop = func() {
+		os.Stdout = stdout
+		w.Close()
+		<-done
+		r.Close()
+		showPaged(stdout, buf.Bytes(), height, pager)
+	}
+	return stopPager
+}
+
+// stopPager shows the output collected by startPager, if any.
+func stopPager() {
+	if pagerStop != nil {
+		stop := pagerStop
+		pagerStop = nil
+		stop()
+	}
+}
+
+// showPaged writes output to stdout, through pager when it has more lines
+// than the terminal. LESS defaults to FRX, so less keeps colors and leaves
+// the output on screen. A pager that fails to start prints nothing, so the
+// output is written directly.
+func showPaged(stdout *os.File, output []byte, height int, pager []string) {
+	if bytes.Count(output, []byte("\n")) < height {
+		stdout.Write(output)
+		return
+	}
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		stdout.Write(output)
+		return
+	}
+	cmd.Wait()
+}
@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package main
+
+import "os"
+
+// terminalRows is unknown here; set LINES to page long output.
+func terminalRows(f *os.File) int {
+	return 0
+}
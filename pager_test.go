@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPagerCommand(t *testing.T) {
+	writeTestConfig(t, "")
+	t.Setenv("PAGER", "more")
+	os.Unsetenv("SAILINIT_PAGER")
+	if got := pagerCommand(); !slices.Equal(got, []string{"more"}) {
+		t.Errorf("Expected PAGER, got %q", got)
+	}
+
+	writeTestConfig(t, "pager: \"less -S\"\n")
+	if got := pagerCommand(); !slices.Equal(got, []string{"less", "-S"}) {
+		t.Errorf("Expected the config file's pager, got %q", got)
+	}
+	t.Setenv("SAILINIT_PAGER", "most")
+	if got := pagerCommand(); !slices.Equal(got, []string{"most"}) {
+		t.Errorf("Expected SAILINIT_PAGER, got %q", got)
+	}
+
+	for _, off := range []string{"", "cat"} {
+		t.Setenv("SAILINIT_PAGER", off)
+		if got := pagerCommand(); got != nil {
+			t.Errorf("Expected %q to turn paging off, got %q", off, got)
+		}
+	}
+	os.Unsetenv("SAILINIT_PAGER")
+	writeTestConfig(t, "pager: \"\"\n")
+	if got := pagerCommand(); got != nil {
+		t.Errorf("Expected an empty pager in the config file to turn paging off, got %q", got)
+	}
+}
+
+func TestShowPaged(t *testing.T) {
+	dir := t.TempDir()
+	show := func(output string, height int) string {
+		out, err := os.Create(filepath.Join(dir, "out"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		showPaged(out, []byte(output), height, []string{"sed", "s/^/> /"})
+		out.Close()
+		data, _ := os.ReadFile(out.Name())
+		return string(data)
+	}
+
+	if got := show("a\nb\n", 3); got != "a\nb\n" {
+		t.Errorf("Expected output that fits to be written as is, got %q", got)
+	}
+	if got := show("a\nb\nc\n", 3); got != "> a\n> b\n> c\n" {
+		t.Errorf("Expected longer output to go through the pager, got %q", got)
+	}
+
+	out, _ := os.Create(filepath.Join(dir, "missing"))
+	showPaged(out, []byte("a\nb\n"), 1, []string{"no-such-pager"})
+	out.Close()
+	if data, _ := os.ReadFile(out.Name()); string(data) != "a\nb\n" {
+		t.Errorf("Expected a missing pager to fall back to plain output, got %q", data)
+	}
+}
@@ -0,0 +1,611 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// planVersion is bumped whenever the plan file format changes incompatibly.
+const planVersion = 3
+
+// defaultPlanFile is where `sailinit plan` writes and `sailinit apply` reads
+// the plan when no path is given.
+const defaultPlanFile = "sailinit.plan.json"
+
+// setupPlan records every decision the interactive setup makes, so that
+// applying it needs no further input.
+type setupPlan struct {
+	Version     int        `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ProjectDir  string     `json:"project_dir"`
+	ComposeFile string     `json:"compose_file,omitempty"`
+	PHPVersion  string     `json:"php_version"`
+	Runtimes    []string   `json:"runtimes,omitempty"`
+	Suffix      int        `json:"suffix"`
+	ResetDB     resetScope `json:"reset_db,omitempty"`
+	Fresh       bool       `json:"fresh,omitempty"`
+	DB          string     `json:"db,omitempty"`
+	AppendKeys  []envEntry `json:"append_keys,omitempty"`
+	AppURL      string     `json:"app_url,omitempty"`
+	EnvFixes    []envEntry `json:"env_fixes,omitempty"`
+	ConfigEnv   []envEntry `json:"config_env,omitempty"`
+	Timezone    string     `json:"timezone,omitempty"`
+	User        string     `json:"user,omitempty"`
+	ClearCache  bool       `json:"clear_cache,omitempty"`
+	Notes       []string   `json:"notes,omitempty"`
+}
+
+// planOptions carries the command-line choices that shape a plan.
+type planOptions struct {
+	PHPVersion string
+	ResetDB    resetScope
+	Fresh      bool
+	// DB is the --db mode, "sqlite" or empty for the Sail database service
+	DB string
+	// User is the --user mapping; empty falls back to the config file
+	User string
+	// ClearCache runs optimize:clear after sail up even when nothing calls for it
+	ClearCache bool
+	// Interactive enables the optional .env prompts (example drift, Herd/Valet)
+	Interactive bool
+	Ports       portCheckOptions
+}
+
+// buildSetupPlan runs detection and every prompt of the setup flow for
+// projectDir without changing anything. It returns a nil plan when the user
+// aborts.
+func buildSetupPlan(reader *bufio.Reader, projectDir string, opts planOptions) (*setupPlan, error) {
+	plan := &setupPlan{
+		Version:     planVersion,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		ProjectDir:  projectDir,
+		ComposeFile: composeFileOverride,
+		Runtimes:    detectPHPVersions(projectDir),
+		ResetDB:     opts.ResetDB,
+		Fresh:       opts.Fresh,
+		DB:          opts.DB,
+	}
+
+	project, err := loadProjectConfig(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	detectedVersion := detectPHPVersion(projectDir)
+	plan.PHPVersion = "84" // Default
+	if opts.PHPVersion == "" && project != nil && project.PHP != "" {
+		plan.PHPVersion = project.PHP
+		printInfo(tr("php_pinned", plan.PHPVersion, projectConfigFile))
+	} else if opts.PHPVersion != "" {
+		plan.PHPVersion = opts.PHPVersion
+		if detectedVersion != "" && !slices.Contains(plan.Runtimes, plan.PHPVersion) &&
+			warnPHPMismatch.warn(projectDir, tr("php_mismatch", plan.PHPVersion, detectedVersion)) &&
+			!askYesNo(reader, tr("continue_anyway")) {
+			return nil, nil
+		}
+	} else if len(plan.Runtimes) > 1 {
+		plan.PHPVersion = choosePHPVersion(reader, plan.Runtimes)
+	} else if detectedVersion != "" {
+		plan.PHPVersion = detectedVersion
+		printInfo(tr("php_detected", plan.PHPVersion))
+	} else {
+		printInfo(tr("php_default", plan.PHPVersion))
+	}
+
+	printHeader(tr("setup_start", plan.PHPVersion))
+	suffix, err := chooseSuffix(reader, projectDir)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if suffix, ok = confirmPortsAvailable(reader, projectDir, setupPortBases(projectDir, plan.DB), suffix, opts.Ports); !ok {
+		return nil, nil
+	}
+	plan.Suffix = suffix
+	if service := composeDBService(projectDir); plan.DB == dbSQLite && service != "" {
+		plan.Notes = append(plan.Notes, tr("sqlite_db_service", service))
+	}
+
+	cfg, err := loadUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	confirmRunningLimit(reader, projectDir, cfg.MaxRunning)
+	if plan.Timezone, err = resolveTimezone(cfg.Timezone); err != nil {
+		return nil, usageError(err)
+	}
+	user := opts.User
+	if user == "" {
+		user = cfg.User
+	}
+	if plan.User, err = parseUserMapping(user); err != nil {
+		return nil, usageError(err)
+	}
+	if plan.ConfigEnv, err = renderEnvTemplates(cfg.Env, newEnvTemplateData(plan)); err != nil {
+		return nil, usageError(err)
+	}
+
+	// A fresh .env or cached config from a previous environment would leave
+	// the app running with stale ports and URLs
+	plan.ClearCache = opts.ClearCache
+	if _, err := os.Stat(filepath.Join(projectDir, ".env")); os.IsNotExist(err) {
+		plan.ClearCache = true
+	}
+	if stale := staleCacheFiles(projectDir); len(stale) > 0 {
+		printInfo(tr("cache_stale", strings.Join(stale, ", ")))
+		plan.ClearCache = true
+	}
+
+	if !opts.Interactive {
+		return plan, nil
+	}
+
+	// Catch keys a teammate added to .env.example but that are missing here.
+	// A missing .env is created from the example, so there is nothing to add.
+	envPath := filepath.Join(projectDir, ".env")
+	envExamplePath := filepath.Join(projectDir, ".env.example")
+	if _, err := os.Stat(envPath); err == nil {
+		if _, err := os.Stat(envExamplePath); err == nil {
+			missing, err := missingExampleKeys(envPath, envExamplePath)
+			if err != nil {
+				printError(fmt.Sprintf("Error comparing .env with .env.example: %v", err))
+			}
+			if len(missing) > 0 && warnEnvDrift.warn(projectDir, tr("example_missing", len(missing))) {
+				for _, e := range missing {
+					printWarning(fmt.Sprintf("  %s=%s", e.Key, e.Value))
+				}
+				if askYesNo(reader, tr("example_append")) {
+					plan.AppendKeys = missing
+				} else {
+					plan.Notes = append(plan.Notes, tr("example_keys_pending"))
+				}
+			}
+		}
+	}
+
+	// Catch hosts, ports and drivers that do not match the compose services,
+	// like DB_PORT set to the forwarded host port
+	envIssues := projectEnvIssues(projectDir, suffix)
+	if len(envIssues) > 0 && warnEnvMismatch.suppressed(projectDir) {
+		envIssues = nil
+	}
+	for _, issue := range envIssues {
+		printWarning(warnEnvMismatch.tag(tr("env_issue", issue.Reason)))
+		if issue.Fix != "" && askYesNo(reader, tr("env_issue_fix", issue.Key, issue.Fix)) {
+			plan.EnvFixes = append(plan.EnvFixes, envEntry{Key: issue.Key, Value: issue.Fix})
+		} else {
+			plan.Notes = append(plan.Notes, tr("env_issue_pending", issue.Key))
+		}
+	}
+
+	// Point out services the required packages need, like Redis for Horizon
+	suggestPackageServices(projectDir)
+
+	// Warn when Herd or Valet serves the same project
+	if home, err := os.UserHomeDir(); err == nil {
+		if conflict, found := detectHerdValet(projectDir, home); found && warnHerdValet.warn(projectDir, tr("herd_serves", conflict.Server, conflict.Domain)) {
+			appURL := chooseHerdOrSail(reader, conflict, sailAppURL(suffix))
+			plan.AppURL = appURL
+			// The app stays on the Herd/Valet domain
+			if appURL == "http://"+conflict.Domain || (appURL == "" && conflict.AppURLUsesDomain) {
+				plan.Notes = append(plan.Notes, tr("herd_kept", conflict.Server, conflict.Domain))
+			}
+		}
+	}
+
+	// Explain the suffix-based URL when a local server holds 80 or 443,
+	// where a portless APP_URL like http://localhost ends up
+	clashes := probeWebPorts(isPortListening, portProcess)
+	if len(clashes) > 0 && warnWebPort.suppressed(projectDir) {
+		clashes = nil
+	}
+	for _, c := range clashes {
+		printWarning(warnWebPort.tag(tr("web_port_busy", c.Port, c.owner(), sailAppURL(suffix))))
+	}
+	appURL := readEnvValues(envPath)["APP_URL"]
+	if _, err := os.Stat(envPath); err != nil {
+		appURL = readEnvValues(envExamplePath)["APP_URL"]
+	}
+	if clash, found := webPortClashFor(appURL, clashes); found && plan.AppURL == "" {
+		if askYesNo(reader, tr("web_port_fix_url", appURL, clash.Port, sailAppURL(suffix))) {
+			plan.AppURL = sailAppURL(suffix)
+		} else {
+			plan.Notes = append(plan.Notes, tr("web_port_pending", appURL, sailAppURL(suffix)))
+		}
+	}
+
+	return plan, nil
+}
+
+// isManagedPortKey reports whether e is one of the port keys setupEnv
+// writes, for either mail flavor.
+func isManagedPortKey(e envEntry) bool {
+	for _, key := range mailhogPortKeys {
+		if key == e.Key {
+			return true
+		}
+	}
+	return slices.ContainsFunc(portBases(), func(b PortBase) bool { return b.Name == e.Key })
+}
+
+// applySetupPlan executes a plan. The only question it asks, through
+// reader, is whether to write the previewed .env changes; a nil reader
+// writes them without asking. With dryRun it only prints what it would do.
+func applySetupPlan(reader *bufio.Reader, plan *setupPlan, dryRun bool) (err error) {
+	projectDir := plan.ProjectDir
+	if !dryRun {
+		defer func() { recordHistory(projectDir, "setup", setupDetail(plan), err) }()
+	}
+	defer func() { endSetupPhase(err) }()
+	suffix := plan.Suffix
+	envPath := filepath.Join(projectDir, ".env")
+
+	// Save the confirmed suffix
+	if dryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would save suffix %d for project %s", suffix, projectDir))
+	} else {
+		if err := saveProjectSuffix(projectDir, suffix); err != nil {
+			printError(fmt.Sprintf("Error saving suffix: %v", err))
+		}
+		if len(plan.Runtimes) > 0 {
+			if err := saveProjectRuntimes(projectDir, plan.Runtimes); err != nil {
+				printError(fmt.Sprintf("Error saving PHP runtimes: %v", err))
+			}
+		}
+		var services []string
+		if compose, err := loadCompose(projectDir); err == nil && compose != nil {
+			services = compose.ServiceNames()
+		}
+		if err := saveProjectRecord(projectDir, plan.PHPVersion, services); err != nil {
+			printError(fmt.Sprintf("Error saving project record: %v", err))
+		}
+	}
+
+	printInfo(tr("suffix_using", suffix))
+
+	// 1. Setup .env
+	startSetupPhase(".env")
+	if dryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would configure .env with suffix %d", suffix))
+		for _, b := range setupPortBases(projectDir, plan.DB) {
+			printInfo(fmt.Sprintf("[dry-run]   %s=%d", b.Name, b.Base+suffix))
+		}
+		if plan.DB == dbSQLite {
+			printInfo(fmt.Sprintf("[dry-run] Would set DB_CONNECTION=sqlite and create %s", sqliteDatabase))
+		}
+	} else {
+		if err := setupEnv(projectDir, suffix, plan.ResetDB, reader); err != nil {
+			if errors.Is(err, errEnvDeclined) {
+				return err
+			}
+			return envError(err)
+		}
+		if plan.DB == dbSQLite {
+			if err := configureSQLite(projectDir); err != nil {
+				return envError(err)
+			}
+		}
+	}
+
+	// Things worth pointing out once setup finishes
+	report := slices.Clone(plan.Notes)
+
+	// 1a. Keys from .env.example the user agreed to add
+	if len(plan.AppendKeys) > 0 {
+		if dryRun {
+			printInfo(fmt.Sprintf("[dry-run] Would add %d key(s) from .env.example", len(plan.AppendKeys)))
+		} else {
+			present := readEnvValues(envPath)
+			keys := slices.DeleteFunc(slices.Clone(plan.AppendKeys), func(e envEntry) bool {
+				_, ok := present[e.Key]
+				return ok
+			})
+			if len(keys) > 0 {
+				if err := appendEnvEntries(envPath, keys); err != nil {
+					return envError(err)
+				}
+				report = append(report, tr("example_keys_added", len(keys)))
+			}
+		}
+	}
+
+	// 1b. Point APP_URL away from Herd/Valet or a server on 80/443
+	if plan.AppURL != "" {
+		if dryRun {
+			printInfo(fmt.Sprintf("[dry-run] Would set APP_URL=%s", plan.AppURL))
+		} else if err := setEnvValue(envPath, "APP_URL", plan.AppURL); err != nil {
+			return envError(err)
+		}
+	}
+
+	// 1c. Make hosts, ports and drivers match the compose services
+	for _, fix := range plan.EnvFixes {
+		if dryRun {
+			printInfo(fmt.Sprintf("[dry-run] Would set %s=%s", fix.Key, fix.Value))
+		} else if err := setEnvValue(envPath, fix.Key, fix.Value); err != nil {
+			return envError(err)
+		}
+	}
+
+	// 1d. Keep the app and container clocks in the same timezone
+	if plan.Timezone != "" {
+		if dryRun {
+			printInfo(fmt.Sprintf("[dry-run] Would set APP_TIMEZONE and TZ to %s", plan.Timezone))
+		} else {
+			for _, key := range []string{"APP_TIMEZONE", "TZ"} {
+				if err := setEnvValue(envPath, key, plan.Timezone); err != nil {
+					return envError(err)
+				}
+			}
+			services, err := ensureComposeTZ(projectDir)
+			if err != nil {
+				return fmt.Errorf("adding TZ to compose: %w", err)
+			}
+			if len(services) > 0 {
+				printInfo(tr("tz_compose", strings.Join(services, ", ")))
+			}
+		}
+	}
+
+	// 1e. Run Sail's containers as the mapped user
+	for _, e := range wwwUserEntries(plan.User) {
+		if dryRun {
+			printInfo(fmt.Sprintf("[dry-run] Would set %s=%s", e.Key, e.Value))
+		} else if err := setEnvValue(envPath, e.Key, e.Value); err != nil {
+			return envError(err)
+		}
+	}
+
+	// 1f. Values from the config file, expanded for this project
+	for _, e := range plan.ConfigEnv {
+		if dryRun {
+			printInfo(fmt.Sprintf("[dry-run] Would set %s=%s", e.Key, e.Value))
+		} else if err := setEnvValue(envPath, e.Key, e.Value); err != nil {
+			return envError(err)
+		}
+	}
+
+	// 1g. Make sure the application has an encryption key
+	if dryRun {
+		printInfo("[dry-run] Would generate APP_KEY if it is empty")
+	} else {
+		generated, err := ensureAppKey(envPath)
+		if err != nil {
+			return envError(err)
+		}
+		if generated {
+			report = append(report, tr("app_key_generated"))
+		}
+		if mode, secrets, err := exposedEnvSecrets(envPath); err == nil && len(secrets) > 0 && !warnEnvPermissions.suppressed(projectDir) {
+			report = append(report, warnEnvPermissions.tag(tr("env_exposed", mode, strings.Join(secrets, ", "))))
+		}
+	}
+
+	// 1h. Point a test suite run from the host at the same ports
+	if syncTestingEnabled() {
+		if dryRun {
+			printInfo("[dry-run] Would sync the ports of .env.testing and phpunit.xml")
+		} else {
+			synced, err := syncTestingPorts(projectDir)
+			for _, line := range synced {
+				printInfo(tr("testing_synced", line))
+			}
+			if err != nil {
+				return envError(err)
+			}
+		}
+	}
+
+	// 2. Initial sailinit logic (Docker composer install)
+	startSetupPhase("composer install")
+	if dryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would run composer install via Docker (PHP %s)", plan.PHPVersion))
+	} else {
+		if err := runSailInit(plan.PHPVersion, projectDir, plan.Fresh, composerUser(plan.User)); err != nil {
+			return fmt.Errorf("running composer install: %w", err)
+		}
+	}
+
+	// 3. Run sail up -d
+	startSetupPhase("sail up")
+	if dryRun {
+		printInfo("[dry-run] Would run sail up -d")
+	} else {
+		if err := runSailUp(projectDir); err != nil {
+			if !errors.Is(err, ErrSailNotFound) {
+				if path, derr := writeDiagnostics(projectDir, err); derr == nil {
+					printWarning(tr("diagnostics_written", path))
+				}
+			}
+			return fmt.Errorf("running sail up: %w", err)
+		}
+		if err := recordProjectStarted(projectDir, time.Now()); err != nil {
+			printError(fmt.Sprintf("Error recording project start: %v", err))
+		}
+	}
+
+	// 4. Drop caches that still hold the previous configuration
+	startSetupPhase("clear caches")
+	if plan.ClearCache {
+		if dryRun {
+			printInfo("[dry-run] Would run sail artisan optimize:clear")
+		} else if err := runSailOptimizeClear(projectDir); err != nil {
+			report = append(report, tr("cache_clear_failed", err))
+		}
+	}
+
+	// Remember what was applied so `sailinit drift` can report changes
+	startSetupPhase("summary")
+	if !dryRun {
+		if err := saveProjectFingerprint(projectDir, suffix, plan.PHPVersion); err != nil {
+			printError(fmt.Sprintf("Error saving fingerprint: %v", err))
+		}
+	}
+
+	// List every service URL, from the ports .env now holds
+	var overrides map[string]string
+	if dryRun {
+		overrides = make(map[string]string)
+		for _, b := range setupPortBases(projectDir, plan.DB) {
+			overrides[b.Name] = strconv.Itoa(b.Base + suffix)
+		}
+	}
+	links := projectSummary(projectDir, overrides)
+	summary := formatSummary(links)
+
+	printSuccess(tr("setup_complete"))
+	fmt.Print(summary)
+	for _, note := range report {
+		printWarning(note)
+	}
+	if !dryRun {
+		if cfg, err := loadUserConfig(); err == nil {
+			finishDesktop(cfg, links[0].Value, summary)
+		}
+	}
+	return nil
+}
+
+// validatePlan re-checks a plan against the current registry, since other
+// projects may have been set up between plan and apply.
+func validatePlan(plan *setupPlan) error {
+	if plan.Version != planVersion {
+		return usageError(fmt.Errorf("unsupported plan version %d (expected %d)", plan.Version, planVersion))
+	}
+	if info, err := os.Stat(plan.ProjectDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("project directory %s does not exist", plan.ProjectDir)
+	}
+	if plan.PHPVersion == "" {
+		return usageError(fmt.Errorf("plan has no PHP version"))
+	}
+	if _, err := parseDBMode(plan.DB); err != nil {
+		return usageError(err)
+	}
+	if err := validateSuffixFor(portBases(), plan.Suffix); err != nil {
+		return usageError(err)
+	}
+	if otherPath, inUse := isSuffixInUseByOther(plan.ProjectDir, plan.Suffix); inUse {
+		return fmt.Errorf("suffix %d has since been taken by %s; run sailinit plan again", plan.Suffix, otherPath)
+	}
+	return nil
+}
+
+func writePlan(path string, plan *setupPlan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func readPlan(path string) (*setupPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan setupPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, usageError(fmt.Errorf("invalid plan file %s: %w", path, err))
+	}
+	return &plan, nil
+}
+
+func handlePlan(args []string) {
+	fs := commandFlags("plan")
+	out := fs.String("out", defaultPlanFile, "Where to write the plan")
+	var resetDb resetScope
+	fs.Var(&resetDb, "reset-db", "Reset database settings to Sail defaults when applying; optionally only `connection,credentials,database`")
+	fresh := fs.Bool("fresh", false, "Force re-run composer install when applying")
+	db := fs.String("db", "", "Set the project up with `sqlite` instead of a database container")
+	steal := fs.Bool("steal", false, "Stop other registered projects whose running containers hold the needed ports")
+	wait := fs.Duration("wait-for-ports", 0, "Wait up to this long for busy ports to free up (e.g. 30s)")
+	clearCache := fs.Bool("clear-cache", false, "Run artisan optimize:clear after sail up when applying")
+	user := fs.String("user", "", "uid:gid for the composer container and Sail's WWWUSER/WWWGROUP, or auto/none")
+	force := fs.Bool("force", false, "Plan a directory that doesn't look like a Laravel project")
+	fs.StringVar(&composeFileOverride, "compose-file", "", "Compose file to use instead of the default (also honors COMPOSE_FILE)")
+	fs.Parse(reorderArgs(args))
+	if fs.NArg() > 1 {
+		printError("Usage: sailinit plan [php_version] [--out <file>]")
+		exit(ExitUsage)
+	}
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	if err := checkLaravelProject(projectDir, *force); err != nil {
+		exitWithError("Refusing to plan", err)
+	}
+	if _, err := parseDBMode(*db); err != nil {
+		exitWithError("Invalid --db", usageError(err))
+	}
+
+	plan, err := buildSetupPlan(bufio.NewReader(os.Stdin), projectDir, planOptions{
+		PHPVersion:  fs.Arg(0),
+		ResetDB:     resetDb,
+		Fresh:       *fresh,
+		DB:          *db,
+		User:        *user,
+		ClearCache:  *clearCache,
+		Interactive: true,
+		Ports:       portCheckOptions{Steal: *steal, Wait: *wait},
+	})
+	if err != nil {
+		exitWithError("Error planning setup", err)
+	}
+	if plan == nil {
+		return
+	}
+	if err := writePlan(*out, plan); err != nil {
+		exitWithError("Error writing plan", err)
+	}
+	printSuccess(fmt.Sprintf("Plan written to %s. Review it, then run: sailinit apply %s", *out, *out))
+}
+
+func handleApply(args []string) {
+	fs := commandFlags("apply")
+	dryRun := fs.Bool("dry-run", false, "Show what would happen without making changes")
+	fs.DurationVar(&readyTimeout, "ready-timeout", readyTimeout, "How long to wait for containers and the app to become ready after sail up (0 to skip)")
+	fs.DurationVar(&composerTimeout, "composer-timeout", 0, "Remove the composer container and fail if composer install runs longer than this (0 for no limit)")
+	fs.Parse(reorderArgs(args))
+	if fs.NArg() > 1 {
+		printError("Usage: sailinit apply [plan_file] [--dry-run]")
+		exit(ExitUsage)
+	}
+	path := defaultPlanFile
+	if fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+
+	if !*dryRun {
+		requireWritableState()
+	}
+	plan, err := readPlan(path)
+	if err != nil {
+		exitWithError("Error reading plan", err)
+	}
+	if err := validatePlan(plan); err != nil {
+		exitWithError("Invalid plan", err)
+	}
+	composeFileOverride = plan.ComposeFile
+
+	bases := setupPortBases(plan.ProjectDir, plan.DB)
+	reserved := reservedPorts()
+	containers := containerPorts(plan.ProjectDir)
+	busy := withReservedConflicts(CheckPortsAvailable(bases, plan.Suffix), bases, plan.Suffix, reserved)
+	if busy = withContainerConflicts(busy, bases, plan.Suffix, containers); len(busy) > 0 {
+		printWarning(tr("ports_busy"))
+		reportBusyPorts(busy, reserved, containers, plan.ProjectDir)
+	}
+
+	if err := applySetupPlan(nil, plan, *dryRun); err != nil {
+		exitWithError("Error applying plan", err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteReadPlan(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-plan-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	plan := &setupPlan{
+		Version:    planVersion,
+		ProjectDir: tempDir,
+		PHPVersion: "83",
+		Suffix:     51,
+		AppendKeys: []envEntry{{Key: "NEW_KEY", Value: "value"}},
+		AppURL:     "http://localhost:8051",
+	}
+	path := filepath.Join(tempDir, defaultPlanFile)
+	if err := writePlan(path, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PHPVersion != "83" || got.Suffix != 51 || got.AppURL != plan.AppURL {
+		t.Errorf("Plan did not round-trip: %+v", got)
+	}
+	if len(got.AppendKeys) != 1 || got.AppendKeys[0].Key != "NEW_KEY" {
+		t.Errorf("Expected append keys to round-trip, got %+v", got.AppendKeys)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0644)
+	if _, err := readPlan(path); err == nil {
+		t.Error("Expected error for invalid plan file")
+	}
+}
+
+func TestValidatePlan(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	otherDir := filepath.Join(tempDir, "other")
+	os.MkdirAll(projectDir, 0755)
+	os.MkdirAll(otherDir, 0755)
+
+	plan := &setupPlan{Version: planVersion, ProjectDir: projectDir, PHPVersion: "84", Suffix: 51}
+	if err := validatePlan(plan); err != nil {
+		t.Errorf("Expected valid plan, got: %v", err)
+	}
+
+	if err := saveProjectSuffix(otherDir, 51); err != nil {
+		t.Fatal(err)
+	}
+	if err := validatePlan(plan); err == nil || !strings.Contains(err.Error(), "since been taken") {
+		t.Errorf("Expected suffix collision error, got: %v", err)
+	}
+
+	plan.Version = planVersion + 1
+	if err := validatePlan(plan); err == nil {
+		t.Error("Expected error for unsupported plan version")
+	}
+}
+
+func TestApplySetupPlan(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	sailDir := filepath.Join(projectDir, "vendor", "bin")
+	if err := os.MkdirAll(sailDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sailDir, "sail"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	envPath := filepath.Join(projectDir, ".env")
+	if err := os.WriteFile(envPath, []byte("APP_NAME=Laravel\nAPP_KEY=base64:abc\nAPP_URL=http://shop.test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &setupPlan{
+		Version:    planVersion,
+		ProjectDir: projectDir,
+		PHPVersion: "84",
+		Suffix:     52,
+		AppendKeys: []envEntry{{Key: "NEW_KEY", Value: "value"}, {Key: "APP_NAME", Value: "Other"}},
+		AppURL:     "http://localhost:8052",
+		ConfigEnv:  []envEntry{{Key: "VITE_APP_NAME", Value: "project-8052"}},
+	}
+	if err := applySetupPlan(nil, plan, false); err != nil {
+		t.Fatal(err)
+	}
+
+	values := readEnvValues(envPath)
+	if values["APP_PORT"] != "8052" {
+		t.Errorf("Expected APP_PORT=8052, got %q", values["APP_PORT"])
+	}
+	if values["NEW_KEY"] != "value" || values["APP_NAME"] != "Laravel" {
+		t.Errorf("Expected only missing keys to be added, got %v", values)
+	}
+	if values["APP_URL"] != "http://localhost:8052" {
+		t.Errorf("Expected APP_URL to be updated, got %q", values["APP_URL"])
+	}
+	if values["VITE_APP_NAME"] != "project-8052" {
+		t.Errorf("Expected the config value to be written, got %q", values["VITE_APP_NAME"])
+	}
+
+	suffix, _, existed, err := getSuggestedSuffix(projectDir)
+	if err != nil || !existed || suffix != 52 {
+		t.Errorf("Expected suffix 52 to be registered, got %d (existed=%v, err=%v)", suffix, existed, err)
+	}
+}
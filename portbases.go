@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// validatePortBases checks the port_bases of the config file: every key
+// must be one of Sail's port keys and every base a valid port.
+func validatePortBases(overrides map[string]int) error {
+	for key, base := range overrides {
+		if !slices.ContainsFunc(sailPortBases, func(b PortBase) bool { return b.Name == key }) {
+			var keys []string
+			for _, b := range sailPortBases {
+				keys = append(keys, b.Name)
+			}
+			return fmt.Errorf("port_bases: unknown key %s (use one of %s)", key, strings.Join(keys, ", "))
+		}
+		if base < 1 || base > 65535 {
+			return fmt.Errorf("port_bases: %s must be between 1 and 65535, got %d", key, base)
+		}
+	}
+	return nil
+}
+
+// withPortBases returns sailPortBases with the bases in overrides.
+func withPortBases(overrides map[string]int) []PortBase {
+	bases := slices.Clone(sailPortBases)
+	for i, b := range bases {
+		if base, ok := overrides[b.Name]; ok {
+			bases[i].Base = base
+		}
+	}
+	return bases
+}
+
+// portBases returns the base ports of the suffix scheme: Sail's defaults
+// with the port_bases of the config file applied, followed by the ports of
+// the services the config file adds. A broken config file is reported and
+// otherwise ignored.
+func portBases() []PortBase {
+	cfg := userConfigOrDefaults()
+	return append(withPortBases(cfg.PortBases), servicePorts(customServices(cfg.Services))...)
+}
+
+// baseOf returns the base of key in bases, or 0 when bases lack the key.
+func baseOf(bases []PortBase, key string) int {
+	if i := slices.IndexFunc(bases, func(b PortBase) bool { return b.Name == key }); i >= 0 {
+		return bases[i].Base
+	}
+	return 0
+}
+
+// basePort returns the configured base of a Sail port key, or 0 for a key
+// outside the scheme.
+func basePort(key string) int {
+	return baseOf(portBases(), key)
+}
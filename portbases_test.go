@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPortBasesFromConfig(t *testing.T) {
+	if got := basePort("APP_PORT"); got != 8000 {
+		t.Errorf("Expected the default app base without a config file, got %d", got)
+	}
+
+	writeTestConfig(t, "port_bases:\n  APP_PORT: 9000\n  VITE_PORT: 9100\n")
+	bases := portBases()
+	if baseOf(bases, "APP_PORT") != 9000 || baseOf(bases, "VITE_PORT") != 9100 || baseOf(bases, "FORWARD_DB_PORT") != 3300 {
+		t.Errorf("Unexpected bases %v", bases)
+	}
+	if sailPortBases[0].Base != 8000 {
+		t.Errorf("sailPortBases must not be modified, got %v", sailPortBases)
+	}
+	if got := baseOf(bases, "UNKNOWN_PORT"); got != 0 {
+		t.Errorf("Expected 0 for an unknown key, got %d", got)
+	}
+}
+
+func TestValidatePortBases(t *testing.T) {
+	if err := validatePortBases(map[string]int{"APP_PORT": 9000}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if err := validatePortBases(map[string]int{"WEB_PORT": 9000}); err == nil || !strings.Contains(err.Error(), "unknown key WEB_PORT") {
+		t.Errorf("Expected an unknown key error, got %v", err)
+	}
+	if err := validatePortBases(map[string]int{"APP_PORT": 70000}); err == nil {
+		t.Error("Expected an out of range base to be rejected")
+	}
+
+	writeTestConfig(t, "port_bases:\n  APP_PORT: 0\n")
+	if _, err := loadUserConfig(); err == nil {
+		t.Error("Expected loadUserConfig to reject an invalid base")
+	}
+}
+
+func TestProjectPortsFollowConfiguredBases(t *testing.T) {
+	writeTestConfig(t, "port_bases:\n  APP_PORT: 9000\n")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("APP_PORT=9051\n"), 0644)
+
+	if suffix, ok := extractSuffixFromEnv(filepath.Join(dir, ".env")); !ok || suffix != 51 {
+		t.Errorf("Expected suffix 51 from APP_PORT=9051, got %d (ok=%v)", suffix, ok)
+	}
+	if got := forwardedPorts(dir, 51, true)["APP_PORT"]; got != 9051 {
+		t.Errorf("Expected forwarded app port 9051, got %d", got)
+	}
+	if got := forwardedPort(map[string]string{}, "APP_PORT", 52, ""); got != "9052" {
+		t.Errorf("Expected 9052 from the configured base, got %s", got)
+	}
+}
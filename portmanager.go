@@ -3,28 +3,46 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
-// MaxPortSuffix is the highest valid suffix (65535 - 18100, the highest base port)
-const MaxPortSuffix = 47435
+// highestBase returns the port of bases with the highest base.
+func highestBase(bases []PortBase) PortBase {
+	return slices.MaxFunc(bases, func(a, b PortBase) int { return a.Base - b.Base })
+}
+
+// maxSuffixFor returns the suffix that puts the highest of bases on 65535.
+func maxSuffixFor(bases []PortBase) int {
+	return 65535 - highestBase(bases).Base
+}
+
+// MaxPortSuffix returns the highest valid suffix, the one that puts the
+// highest configured base port on 65535 (47435 with Sail's defaults).
+func MaxPortSuffix() int {
+	return maxSuffixFor(portBases())
+}
 
 // ValidateSuffix checks that a port suffix is within valid range.
 func ValidateSuffix(suffix int) error {
 	if suffix < 0 {
 		return fmt.Errorf("suffix must be non-negative, got %d", suffix)
 	}
-	if suffix > MaxPortSuffix {
-		return fmt.Errorf("suffix %d too large: highest port would be %d (max 65535)", suffix, 18100+suffix)
+	if top := highestBase(portBases()); top.Base+suffix > 65535 {
+		return fmt.Errorf("suffix %d too large: %s would be %d (max 65535)", suffix, top.Name, top.Base+suffix)
 	}
 	return nil
 }
 
 // CheckPortAvailable returns true if the given TCP port is not in use.
 func CheckPortAvailable(port int) bool {
+	defer profileSpan("port scan")()
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return false
@@ -39,65 +57,329 @@ type BusyPort struct {
 	Port int
 }
 
-// CheckSuffixPortsAvailable checks all 7 ports for a suffix and returns busy ones.
-func CheckSuffixPortsAvailable(suffix int) []BusyPort {
-	ports := []struct {
-		name string
-		port int
-	}{
-		{"APP_PORT", 8000 + suffix},
-		{"FORWARD_DB_PORT", 3300 + suffix},
-		{"FORWARD_REDIS_PORT", 6300 + suffix},
-		{"FORWARD_MEILISEARCH_PORT", 7700 + suffix},
-		{"FORWARD_MAILPIT_DASHBOARD_PORT", 18100 + suffix},
-		{"FORWARD_MAILPIT_PORT", 1000 + suffix},
-		{"VITE_PORT", 5100 + suffix},
+// PortBase is an env key whose value is Base + suffix.
+type PortBase struct {
+	Name string
+	Base int
+}
+
+// projectPortBases returns the forwarded ports projectDir needs, on the
+// configured bases: only those of the services its .sailinit.yaml lists or
+// else its compose file defines, the mail keys named for its mail catcher,
+// and no FORWARD_DB_PORT for a SQLite project without a database service.
+func projectPortBases(projectDir string) []PortBase {
+	bases := portBases()
+	cfg, err := loadProjectConfig(projectDir)
+	if err != nil {
+		printError(fmt.Sprintf("Error reading %s: %v", projectConfigFile, err))
+	}
+	custom := configuredServices()
+	if cfg != nil && len(cfg.Services) > 0 {
+		bases = withProjectServices(bases, cfg, custom)
+	} else {
+		bases = withComposeServices(bases, projectDir)
+	}
+	bases = withUsedServices(bases, custom, projectDir, cfg)
+	if mailFlavor(projectDir) == "mailhog" {
+		for i, b := range bases {
+			if key, ok := mailhogPortKeys[b.Name]; ok {
+				bases[i].Name = key
+			}
+		}
+	}
+	if projectUsesSQLite(projectDir) {
+		bases = withoutDBPort(bases)
+	}
+	return bases
+}
+
+// stalePortKeys returns the port keys sailinit may have written before
+// that are not in bases, such as the other mail flavor's keys or the
+// database port of a project that moved to SQLite.
+func stalePortKeys(bases []PortBase) []string {
+	known := slices.Collect(maps.Values(mailhogPortKeys))
+	for _, b := range portBases() {
+		known = append(known, b.Name)
+	}
+	var stale []string
+	for _, key := range known {
+		if !slices.ContainsFunc(bases, func(b PortBase) bool { return b.Name == key }) {
+			stale = append(stale, key)
+		}
 	}
+	slices.Sort(stale)
+	return stale
+}
+
+// CheckSuffixPortsAvailable checks every configured port for a suffix and
+// returns busy ones.
+func CheckSuffixPortsAvailable(suffix int) []BusyPort {
+	return CheckPortsAvailable(portBases(), suffix)
+}
 
+// CheckPortsAvailable checks the ports of bases for a suffix and returns busy ones.
+func CheckPortsAvailable(bases []PortBase, suffix int) []BusyPort {
 	var busy []BusyPort
-	for _, p := range ports {
-		if !CheckPortAvailable(p.port) {
-			busy = append(busy, BusyPort{Name: p.name, Port: p.port})
+	for _, p := range bases {
+		if !CheckPortAvailable(p.Base + suffix) {
+			busy = append(busy, BusyPort{Name: p.Name, Port: p.Base + suffix})
 		}
 	}
 	return busy
 }
 
-// RemoveProject removes a project from the port state file.
-func RemoveProject(projectDir string) error {
+// maxSuffixScan bounds how many suffixes nextSuffixWithFreePorts tries.
+const maxSuffixScan = 100
+
+// nextSuffixWithFreePorts returns the first suffix after suffix that no
+// other project has registered or reserved, whose ports avoid reserved and
+// the ports containers publish, and are all free right now.
+func nextSuffixWithFreePorts(projectDir string, bases []PortBase, suffix int, reserved map[int]string, containers map[int]containerPort) (int, bool) {
 	state, _, err := loadPortState()
 	if err != nil {
-		return err
+		return 0, false
+	}
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return 0, false
+	}
+	owners := state.suffixOwners(absDir, time.Now())
+	for next := suffix + 1; next <= suffix+maxSuffixScan; next++ {
+		if validateSuffixFor(bases, next) != nil {
+			break
+		}
+		if _, taken := owners[next]; taken || len(reservedConflicts(bases, next, reserved)) > 0 {
+			continue
+		}
+		if len(containerConflicts(bases, next, containers)) > 0 {
+			continue
+		}
+		if len(CheckPortsAvailable(bases, next)) == 0 {
+			return next, true
+		}
 	}
+	return 0, false
+}
 
+// WaitForPorts polls until every port of bases is free for suffix or the
+// timeout elapses, returning the ports that are still busy.
+func WaitForPorts(bases []PortBase, suffix int, timeout, interval time.Duration) []BusyPort {
+	deadline := time.Now().Add(timeout)
+	busy := CheckPortsAvailable(bases, suffix)
+	for len(busy) > 0 {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		time.Sleep(min(interval, remaining))
+		busy = CheckPortsAvailable(bases, suffix)
+	}
+	return busy
+}
+
+// RemoveProject removes a project from the port state file.
+func RemoveProject(projectDir string) error {
 	absDir, err := filepath.Abs(projectDir)
 	if err != nil {
 		return err
 	}
 
-	if _, ok := state.Projects[absDir]; !ok {
-		return fmt.Errorf("project not registered: %s", absDir)
+	return updatePortState(func(state *PortState) error {
+		if _, ok := state.Projects[absDir]; !ok {
+			return fmt.Errorf("project not registered: %s", absDir)
+		}
+		state.forget(absDir)
+		return nil
+	})
+}
+
+type PortState struct {
+	// MaxSuffix is the highest suffix registered on this machine's docker
+	MaxSuffix int `json:"max_suffix"`
+	// Projects holds each registered project's suffix and what its last
+	// setup recorded
+	Projects map[string]projectEntry `json:"projects"`
+	// Fingerprints holds each project's managed configuration as of its last apply
+	Fingerprints map[string]Fingerprint `json:"fingerprints,omitempty"`
+	// FingerprintKey keys the env value hashes of Fingerprints
+	FingerprintKey string `json:"fingerprint_key,omitempty"`
+	// Reservations holds suffixes suggested to setups that haven't finished
+	Reservations map[string]suffixReservation `json:"reservations,omitempty"`
+	// StepHashes holds, per project, the input hash of each `sailinit up`
+	// step as of its last successful run
+	StepHashes map[string]map[string]string `json:"step_hashes,omitempty"`
+	// Benchmarks holds each project's recent `sailinit bench` runs
+	Benchmarks map[string][]benchRun `json:"benchmarks,omitempty"`
+	// Scales holds the replica counts `sailinit scale` set per project
+	Scales map[string]map[string]int `json:"scales,omitempty"`
+	// History holds each project's recent sailinit operations
+	History map[string][]historyEvent `json:"history,omitempty"`
+	// Vite holds the dev servers `sailinit vite start` runs per project
+	Vite map[string]viteProcess `json:"vite,omitempty"`
+	// DockerHosts holds the remote docker host of each project that runs
+	// on one; projects on different hosts may share a suffix
+	DockerHosts map[string]string `json:"docker_hosts,omitempty"`
+	// HostMaxSuffix holds the highest suffix registered on each remote
+	// docker host, the MaxSuffix of projects that run there
+	HostMaxSuffix map[string]int `json:"host_max_suffix,omitempty"`
+}
+
+// suffixReservation keeps a suggested suffix from being offered to another
+// setup running at the same time.
+type suffixReservation struct {
+	Suffix  int       `json:"suffix"`
+	Expires time.Time `json:"expires"`
+	// DockerHost is the remote docker host the setup runs against
+	DockerHost string `json:"docker_host,omitempty"`
+}
+
+// reservationTTL is how long a suggested suffix stays reserved for a setup
+// that never finishes.
+const reservationTTL = time.Hour
+
+// forget drops everything recorded about a project.
+func (s *PortState) forget(absDir string) {
+	delete(s.Projects, absDir)
+	delete(s.Fingerprints, absDir)
+	delete(s.Reservations, absDir)
+	delete(s.StepHashes, absDir)
+	delete(s.Benchmarks, absDir)
+	delete(s.Scales, absDir)
+	delete(s.History, absDir)
+	delete(s.Vite, absDir)
+	delete(s.DockerHosts, absDir)
+}
+
+// suffixOwner returns the project other than absDir that has registered or
+// reserved suffix on the current docker host.
+func (s *PortState) suffixOwner(absDir string, suffix int, now time.Time) (string, bool) {
+	return s.ownerOn(dockerHost(), absDir, suffix, now)
+}
+
+// ownerOn returns the project other than absDir that has registered or
+// reserved suffix on docker host.
+func (s *PortState) ownerOn(host, absDir string, suffix int, now time.Time) (string, bool) {
+	for path, e := range s.Projects {
+		if e.Suffix == suffix && path != absDir && s.dockerHostOf(path) == host {
+			return path, true
+		}
+	}
+	for path, r := range s.Reservations {
+		if r.Suffix == suffix && path != absDir && now.Before(r.Expires) && r.DockerHost == host {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// suffixOwners indexes the suffixes registered or reserved on the current
+// docker host by projects other than absDir, so a search over many
+// suffixes checks each in O(1) instead of scanning the registry.
+func (s *PortState) suffixOwners(absDir string, now time.Time) map[int]string {
+	host := dockerHost()
+	owners := make(map[int]string, len(s.Projects)+len(s.Reservations))
+	for path, r := range s.Reservations {
+		if path != absDir && now.Before(r.Expires) && r.DockerHost == host {
+			owners[r.Suffix] = path
+		}
 	}
+	for path, e := range s.Projects {
+		if path != absDir && s.dockerHostOf(path) == host {
+			owners[e.Suffix] = path
+		}
+	}
+	return owners
+}
+
+// Values of suffix_strategy.
+const (
+	suffixFillGaps  = "fill-gaps"
+	suffixIncrement = "increment"
+)
 
-	delete(state.Projects, absDir)
-	return state.save()
+// fillSuffixGaps reports whether suggested suffixes reuse the gaps left by
+// removed projects, unless the config file sets suffix_strategy to
+// increment.
+func fillSuffixGaps() bool {
+	cfg, err := loadUserConfig()
+	return err != nil || cfg.SuffixStrategy != suffixIncrement
 }
 
-type PortState struct {
-	MaxSuffix int            `json:"max_suffix"`
-	Projects  map[string]int `json:"projects"`
+// nextFreeSuffix returns the lowest suffix that no other project has
+// registered or reserved and whose ports avoid reservedPorts. With
+// fillGaps the search starts at the lowest registered suffix, so suffixes
+// freed by removed projects are reused; otherwise above the highest
+// suffix ever registered on the current docker host. It fails when no
+// suffix up to MaxPortSuffix fits.
+func (s *PortState) nextFreeSuffix(absDir string, now time.Time, reservedPorts map[int]string, fillGaps bool) (int, error) {
+	bases := portBases()
+	owners := s.suffixOwners(absDir, now)
+	host := dockerHost()
+	suffix := s.maxSuffixOn(host) + 1
+	if fillGaps {
+		for path, e := range s.Projects {
+			if path != absDir && e.Suffix < suffix && s.dockerHostOf(path) == host {
+				suffix = e.Suffix
+			}
+		}
+	}
+	highest := maxSuffixFor(bases)
+	for ; suffix <= highest; suffix++ {
+		if _, taken := owners[suffix]; !taken && len(reservedConflicts(bases, suffix, reservedPorts)) == 0 {
+			return suffix, nil
+		}
+	}
+	hint := "Free suffixes with: sailinit clean, or sailinit remove in projects you no longer use."
+	if !fillGaps {
+		hint += " Setting suffix_strategy: fill-gaps in the config file also reuses lower suffixes."
+	}
+	return 0, &CLIError{Category: CategoryState, Err: fmt.Errorf("no free suffix up to %d", highest), Hint: hint}
+}
+
+// reserve records suffix as reserved by absDir, dropping expired
+// reservations on the way.
+func (s *PortState) reserve(absDir string, suffix int, now time.Time) {
+	if s.Reservations == nil {
+		s.Reservations = make(map[string]suffixReservation)
+	}
+	for path, r := range s.Reservations {
+		if !now.Before(r.Expires) {
+			delete(s.Reservations, path)
+		}
+	}
+	s.Reservations[absDir] = suffixReservation{Suffix: suffix, Expires: now.Add(reservationTTL), DockerHost: dockerHost()}
 }
 
 type ProjectInfo struct {
 	Path   string
 	Suffix int
-	Exists bool
+	// Exists is only set by ListProjects; listRegisteredProjects skips the
+	// check
+	Exists      bool
+	PHPVersions []string
+	// DockerHost is the remote docker host the project runs on, "" for
+	// this machine
+	DockerHost string
+	// Name is the friendly name from .sailinit.yaml or the directory name
+	Name     string
+	PHP      string
+	Services []string
+	Created  time.Time
+	// LastUsed is when the stack was last started, zero if never
+	LastUsed time.Time
 }
 
 // testStatePathOverride is used only for testing to override the state file path
 var testStatePathOverride string
 
 func getPortStatePath() (string, error) {
+	if contextName != defaultContext {
+		dir, err := contextDir(contextName)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "ports.json"), nil
+	}
 	if testStatePathOverride != "" {
 		return testStatePathOverride, nil
 	}
@@ -109,6 +391,7 @@ func getPortStatePath() (string, error) {
 }
 
 func loadPortState() (*PortState, bool, error) {
+	defer profileSpan("state: load")()
 	path, err := getPortStatePath()
 	if err != nil {
 		return nil, false, err
@@ -116,7 +399,7 @@ func loadPortState() (*PortState, bool, error) {
 
 	state := &PortState{
 		MaxSuffix: 0,
-		Projects:  make(map[string]int),
+		Projects:  make(map[string]projectEntry),
 	}
 
 	data, err := os.ReadFile(path)
@@ -124,17 +407,18 @@ func loadPortState() (*PortState, bool, error) {
 		if os.IsNotExist(err) {
 			return state, false, nil
 		}
-		return nil, false, err
+		return nil, false, stateError(err)
 	}
 
 	if err := json.Unmarshal(data, state); err != nil {
-		return nil, false, err
+		return nil, false, stateError(fmt.Errorf("invalid state file %s: %w", path, err))
 	}
 
 	return state, true, nil
 }
 
 func (s *PortState) save() error {
+	defer profileSpan("state: save")()
 	path, err := getPortStatePath()
 	if err != nil {
 		return err
@@ -145,7 +429,23 @@ func (s *PortState) save() error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	// Write a temp file and rename it, so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 func getSuggestedSuffix(projectDir string) (int, bool, bool, error) {
@@ -159,12 +459,19 @@ func getSuggestedSuffix(projectDir string) (int, bool, bool, error) {
 		return 0, false, false, err
 	}
 
-	// 1. Try to find in state by project directory
-	if suffix, ok := state.Projects[absDir]; ok {
-		return suffix, true, existed, nil
+	// 1. A suffix pinned by the committed project config wins over the
+	// local registry, so every teammate gets the same ports
+	if suffix, ok, err := pinnedSuffix(absDir); err != nil || ok {
+		return suffix, ok, existed, err
+	}
+
+	// 2. Try to find in state by project directory
+	if e, ok := state.Projects[absDir]; ok {
+		return e.Suffix, true, existed, nil
 	}
+	now := time.Now()
 
-	// 2. Try to find in .env if it exists
+	// 3. Try to find in .env if it exists
 	envPath := filepath.Join(projectDir, ".env")
 	if _, err := os.Stat(envPath); err == nil {
 		suffix, found := extractSuffixFromEnv(envPath)
@@ -173,27 +480,122 @@ func getSuggestedSuffix(projectDir string) (int, bool, bool, error) {
 		}
 	}
 
-	// 3. Suggest new allocation
-	return state.MaxSuffix + 1, false, existed, nil
+	// 4. Suggest new allocation, keeping a pending reservation of our own
+	if r, ok := state.Reservations[absDir]; ok && now.Before(r.Expires) {
+		return r.Suffix, false, existed, nil
+	}
+	suffix, err := state.nextFreeSuffix(absDir, now, reservedPorts(), fillSuffixGaps())
+	return suffix, false, existed, err
 }
 
-func saveProjectSuffix(projectDir string, suffix int) error {
+// claimSuggestedSuffix is getSuggestedSuffix for a setup that is about to
+// ask for confirmation: under the registry lock it also reserves the
+// suggestion, so a concurrent setup is offered a different suffix.
+func claimSuggestedSuffix(projectDir string) (int, bool, bool, error) {
+	// Read-only inspection (dry runs, plans) gets an unreserved suggestion
+	if stateReadOnlyReason() != "" {
+		return getSuggestedSuffix(projectDir)
+	}
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return 0, false, false, err
+	}
+	unlock, err := lockPortState()
+	if err != nil {
+		return 0, false, false, err
+	}
+	defer unlock()
+
+	suggested, existing, existed, err := getSuggestedSuffix(absDir)
+	if err != nil {
+		return 0, false, false, err
+	}
 	state, _, err := loadPortState()
 	if err != nil {
-		return err
+		return 0, false, false, err
+	}
+	if _, registered := state.Projects[absDir]; registered {
+		return suggested, existing, existed, nil
+	}
+	now := time.Now()
+	if _, taken := state.suffixOwner(absDir, suggested, now); taken {
+		// A suffix read from .env that another project owns by now
+		if suggested, err = state.nextFreeSuffix(absDir, now, reservedPorts(), fillSuffixGaps()); err != nil {
+			return 0, false, false, err
+		}
+		existing = false
 	}
+	state.reserve(absDir, suggested, now)
+	return suggested, existing, existed, state.save()
+}
 
+// claimSuffix reserves suffix for projectDir unless another project has
+// registered or reserved it, in which case that project is returned.
+func claimSuffix(projectDir string, suffix int) (string, bool, error) {
+	if stateReadOnlyReason() != "" {
+		owner, taken := isSuffixInUseByOther(projectDir, suffix)
+		return owner, taken, nil
+	}
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", false, err
+	}
+	var owner string
+	var taken bool
+	err = updatePortState(func(state *PortState) error {
+		now := time.Now()
+		if owner, taken = state.suffixOwner(absDir, suffix, now); taken {
+			return nil
+		}
+		if state.Projects[absDir].Suffix != suffix {
+			state.reserve(absDir, suffix, now)
+		}
+		return nil
+	})
+	return owner, taken, err
+}
+
+func saveProjectSuffix(projectDir string, suffix int) error {
 	absDir, err := filepath.Abs(projectDir)
 	if err != nil {
 		return err
 	}
 
-	state.Projects[absDir] = suffix
-	if suffix > state.MaxSuffix {
-		state.MaxSuffix = suffix
+	host := dockerHost()
+	name := projectName(absDir)
+	return updatePortState(func(state *PortState) error {
+		now := time.Now().UTC().Truncate(time.Second)
+		entry, registered := state.Projects[absDir]
+		if !registered || entry.Suffix != suffix {
+			detail := fmt.Sprintf("registered with suffix %d", suffix)
+			if registered {
+				detail = fmt.Sprintf("suffix changed from %d to %d", entry.Suffix, suffix)
+			}
+			state.appendHistory(absDir, historyEvent{At: now, Op: "suffix", Detail: detail})
+		}
+		if !registered {
+			entry = projectEntry{Name: name, Created: now}
+		}
+		entry.Suffix = suffix
+		state.Projects[absDir] = entry
+		state.setDockerHost(absDir, host)
+		state.raiseMaxSuffix(host, suffix)
+		delete(state.Reservations, absDir)
+		return nil
+	})
+}
+
+// saveProjectRuntimes records every PHP runtime a project's compose file uses.
+func saveProjectRuntimes(projectDir string, versions []string) error {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
 	}
 
-	return state.save()
+	return updatePortState(func(state *PortState) error {
+		state.updateEntry(absDir, func(e *projectEntry) { e.Runtimes = versions })
+		return nil
+	})
 }
 
 func isSuffixInUseByOther(projectDir string, suffix int) (string, bool) {
@@ -207,30 +609,22 @@ func isSuffixInUseByOther(projectDir string, suffix int) (string, bool) {
 		return "", false
 	}
 
-	for path, s := range state.Projects {
-		if s == suffix && path != absDir {
-			return path, true
-		}
-	}
-
-	return "", false
+	return state.suffixOwner(absDir, suffix, time.Now())
 }
 
 func extractSuffixFromEnv(envPath string) (int, bool) {
-	data, err := os.ReadFile(envPath)
+	data, err := readTextFile(envPath)
 	if err != nil {
 		return 0, false
 	}
 
-	content := string(data)
-	lines := splitLines(content)
-	for _, line := range lines {
+	for _, line := range splitLines(string(data)) {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "APP_PORT=") {
 			var p int
 			_, err := fmt.Sscanf(line, "APP_PORT=%d", &p)
-			if err == nil && p >= 8000 {
-				return p - 8000, true
+			if base := basePort("APP_PORT"); err == nil && p >= base {
+				return p - base, true
 			}
 		}
 	}
@@ -261,51 +655,97 @@ func splitLines(s string) []string {
 	return lines
 }
 
+// ListProjects returns the registered projects and whether their
+// directories still exist.
 func ListProjects() ([]ProjectInfo, error) {
-	state, _, err := loadPortState()
+	projects, err := listRegisteredProjects()
 	if err != nil {
 		return nil, err
 	}
-
-	var projects []ProjectInfo
-	for path, suffix := range state.Projects {
-		exists := true
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			exists = false
-		}
-		projects = append(projects, ProjectInfo{
-			Path:   path,
-			Suffix: suffix,
-			Exists: exists,
-		})
+	paths := make([]string, len(projects))
+	for i, p := range projects {
+		paths[i] = p.Path
+	}
+	exists := existingPaths(paths)
+	for i := range projects {
+		projects[i].Exists = exists[projects[i].Path]
 	}
-
 	return projects, nil
 }
 
-func CleanOrphanedProjects() (int, error) {
+// listRegisteredProjects returns the registered projects without checking
+// that their directories exist, for commands that only resolve names or
+// suffixes.
+func listRegisteredProjects() ([]ProjectInfo, error) {
 	state, _, err := loadPortState()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	var removed []string
-	for path := range state.Projects {
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			removed = append(removed, path)
-		}
+	projects := make([]ProjectInfo, 0, len(state.Projects))
+	for path, e := range state.Projects {
+		projects = append(projects, ProjectInfo{
+			Path:        path,
+			Suffix:      e.Suffix,
+			PHPVersions: e.Runtimes,
+			DockerHost:  state.dockerHostOf(path),
+			Name:        e.displayName(path),
+			PHP:         e.PHP,
+			Services:    e.Services,
+			Created:     e.Created,
+			LastUsed:    e.LastUsed,
+		})
+	}
+	return projects, nil
+}
+
+// statConcurrency bounds how many directories existingPaths checks at
+// once, which pays off for registries with projects on network mounts.
+const statConcurrency = 16
+
+// existingPaths reports which of paths exist, checking them concurrently.
+// A path that cannot be checked counts as existing.
+func existingPaths(paths []string) map[string]bool {
+	exists := make([]bool, len(paths))
+	sem := make(chan struct{}, statConcurrency)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			_, err := os.Stat(path)
+			exists[i] = !os.IsNotExist(err)
+		})
 	}
+	wg.Wait()
 
-	for _, path := range removed {
-		fmt.Printf("Removing orphaned project: %s (suffix %d)\n", path, state.Projects[path])
-		delete(state.Projects, path)
+	result := make(map[string]bool, len(paths))
+	for i, path := range paths {
+		result[path] = exists[i]
 	}
+	return result
+}
 
-	if len(removed) > 0 {
-		if err := state.save(); err != nil {
-			return 0, err
+// CleanOrphanedProjects drops projects whose directories no longer exist,
+// except those listed in keep.
+func CleanOrphanedProjects(keep []string) (int, error) {
+	removed := 0
+	err := updatePortState(func(state *PortState) error {
+		exists := existingPaths(slices.Collect(maps.Keys(state.Projects)))
+		for path := range state.Projects {
+			if slices.Contains(keep, path) {
+				continue
+			}
+			if !exists[path] {
+				fmt.Printf("Removing orphaned project: %s (suffix %d)\n", path, state.Projects[path].Suffix)
+				state.forget(path)
+				removed++
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-
-	return len(removed), nil
+	return removed, nil
 }
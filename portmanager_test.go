@@ -1,11 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestExtractSuffixFromEnv(t *testing.T) {
@@ -44,7 +46,7 @@ func TestSetupEnvFormatting(t *testing.T) {
 	}
 
 	// resetDb=false, .env already exists -> DB settings should be preserved
-	if err := setupEnv(tempDir, 55, false); err != nil {
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -82,6 +84,64 @@ func TestSetupEnvFormatting(t *testing.T) {
 	}
 }
 
+func TestSetupEnvEditsInPlace(t *testing.T) {
+	tempDir := t.TempDir()
+	envPath := filepath.Join(tempDir, ".env")
+	initial := "# App\nAPP_NAME=Shop\n\n# Ports\nAPP_PORT=8001\nFORWARD_DB_PORT=3301\n# Mail catcher\nFORWARD_MAILPIT_PORT=1001\n\nSAIL_XDEBUG_MODE=off\nLOG_CHANNEL=stack\n"
+	if err := os.WriteFile(envPath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# App\nAPP_NAME=Shop\n\n# Ports\nAPP_PORT=8055\nFORWARD_DB_PORT=3355\n" +
+		"FORWARD_REDIS_PORT=6355\nFORWARD_MEILISEARCH_PORT=7755\nFORWARD_MAILPIT_DASHBOARD_PORT=18155\n" +
+		"# Mail catcher\nFORWARD_MAILPIT_PORT=1055\nVITE_PORT=5155\n\n" +
+		"SAIL_XDEBUG_MODE=develop,debug,coverage\nLOG_CHANNEL=stack\n"
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// A second run changes nothing
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	again, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("second run changed .env:\n%s", again)
+	}
+}
+
+func TestSetupEnvInsertsMissingDbKeysInOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	envPath := filepath.Join(tempDir, ".env")
+	initial := "DB_CONNECTION=pgsql\n# keep me\nDB_DATABASE=shop\nDB_PASSWORD=\"se cret\"\n"
+	if err := os.WriteFile(envPath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setupEnv(tempDir, 55, resetScope{"connection"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	wantDB := "DB_CONNECTION=mysql\nDB_HOST=mysql\nDB_PORT=3306\n# keep me\nDB_DATABASE=shop\nDB_PASSWORD=\"se cret\"\n"
+	if !strings.HasPrefix(content, wantDB) {
+		t.Errorf("expected DB settings in place, got:\n%s", content)
+	}
+}
+
 func TestSetupEnvPreservesDbSettingsForExistingEnv(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "sail-test-*")
 	if err != nil {
@@ -97,7 +157,7 @@ func TestSetupEnvPreservesDbSettingsForExistingEnv(t *testing.T) {
 	}
 
 	// resetDb=false -> DB settings should be preserved
-	if err := setupEnv(tempDir, 55, false); err != nil {
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -146,7 +206,7 @@ func TestSetupEnvResetDbOverwritesSettings(t *testing.T) {
 	}
 
 	// resetDb=true -> DB settings should be overwritten to Sail defaults
-	if err := setupEnv(tempDir, 55, true); err != nil {
+	if err := setupEnv(tempDir, 55, allDBResetGroups(), nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -195,7 +255,7 @@ func TestSetupEnvNewEnvGetsDbSettings(t *testing.T) {
 	}
 
 	// resetDb=false but .env doesn't exist -> DB settings should be applied
-	if err := setupEnv(tempDir, 55, false); err != nil {
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -271,9 +331,9 @@ func TestListProjects(t *testing.T) {
 	// Save state with both projects
 	state := &PortState{
 		MaxSuffix: 52,
-		Projects: map[string]int{
-			existingDir: 51,
-			orphanedDir: 52,
+		Projects: map[string]projectEntry{
+			existingDir: {Suffix: 51},
+			orphanedDir: {Suffix: 52},
 		},
 	}
 	if err := state.save(); err != nil {
@@ -353,10 +413,10 @@ func TestCleanOrphanedProjects(t *testing.T) {
 	// Save state with all projects
 	state := &PortState{
 		MaxSuffix: 53,
-		Projects: map[string]int{
-			existingDir:  51,
-			orphanedDir1: 52,
-			orphanedDir2: 53,
+		Projects: map[string]projectEntry{
+			existingDir:  {Suffix: 51},
+			orphanedDir1: {Suffix: 52},
+			orphanedDir2: {Suffix: 53},
 		},
 	}
 	if err := state.save(); err != nil {
@@ -364,7 +424,7 @@ func TestCleanOrphanedProjects(t *testing.T) {
 	}
 
 	// Clean orphaned projects
-	count, err := CleanOrphanedProjects()
+	count, err := CleanOrphanedProjects(nil)
 	if err != nil {
 		t.Fatalf("CleanOrphanedProjects failed: %v", err)
 	}
@@ -405,9 +465,9 @@ func TestCleanOrphanedProjectsNoneToClean(t *testing.T) {
 	// Save state
 	state := &PortState{
 		MaxSuffix: 52,
-		Projects: map[string]int{
-			existingDir1: 51,
-			existingDir2: 52,
+		Projects: map[string]projectEntry{
+			existingDir1: {Suffix: 51},
+			existingDir2: {Suffix: 52},
 		},
 	}
 	if err := state.save(); err != nil {
@@ -415,7 +475,7 @@ func TestCleanOrphanedProjectsNoneToClean(t *testing.T) {
 	}
 
 	// Clean - should find nothing to clean
-	count, err := CleanOrphanedProjects()
+	count, err := CleanOrphanedProjects(nil)
 	if err != nil {
 		t.Fatalf("CleanOrphanedProjects failed: %v", err)
 	}
@@ -530,6 +590,24 @@ func TestValidateSuffix(t *testing.T) {
 	}
 }
 
+func TestMaxPortSuffixFollowsPortBases(t *testing.T) {
+	if got := MaxPortSuffix(); got != 47435 {
+		t.Errorf("Expected 47435 with Sail's bases, got %d", got)
+	}
+
+	writeTestConfig(t, "port_bases:\n  FORWARD_MAILPIT_DASHBOARD_PORT: 9100\nservices:\n  minio:\n    FORWARD_MINIO_PORT: 19000\n")
+	if got := MaxPortSuffix(); got != 65535-19000 {
+		t.Errorf("Expected the highest configured base to bound the suffix, got %d", got)
+	}
+	err := ValidateSuffix(65535 - 19000 + 1)
+	if err == nil || !strings.Contains(err.Error(), "FORWARD_MINIO_PORT would be 65536") {
+		t.Errorf("Expected the error to name the highest port, got %v", err)
+	}
+	if err := ValidateSuffix(65535 - 19000); err != nil {
+		t.Errorf("Expected the highest suffix to be valid, got %v", err)
+	}
+}
+
 func TestRemoveProject(t *testing.T) {
 	tempDir, cleanup := setupTestState(t)
 	defer cleanup()
@@ -616,7 +694,7 @@ func TestCheckSuffixPortsAvailable(t *testing.T) {
 	// If the occupied port happens to be 8000+suffix for some suffix, check it
 	if port > 8000 {
 		testSuffix := port - 8000
-		if testSuffix >= 0 && testSuffix <= MaxPortSuffix {
+		if testSuffix >= 0 && testSuffix <= MaxPortSuffix() {
 			busy = CheckSuffixPortsAvailable(testSuffix)
 			found := false
 			for _, bp := range busy {
@@ -631,3 +709,199 @@ func TestCheckSuffixPortsAvailable(t *testing.T) {
 		}
 	}
 }
+
+func TestSaveProjectRuntimes(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "matrix-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveProjectSuffix(projectDir, 48); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProjectRuntimes(projectDir, []string{"83", "82"}); err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := ListProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 {
+		t.Fatalf("Expected 1 project, got %d", len(projects))
+	}
+	if strings.Join(projects[0].PHPVersions, ",") != "83,82" {
+		t.Errorf("Expected runtimes [83 82], got %v", projects[0].PHPVersions)
+	}
+
+	// Runtimes of a removed project don't bring it back
+	if err := RemoveProject(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProjectRuntimes(projectDir, []string{"84"}); err != nil {
+		t.Fatal(err)
+	}
+	state, _, err := loadPortState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Projects[projectDir]; ok {
+		t.Error("Expected runtimes of an unregistered project to be ignored")
+	}
+}
+
+func TestWaitForPorts(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	bases := []PortBase{{"APP_PORT", port}}
+
+	// Still busy when the timeout elapses
+	busy := WaitForPorts(bases, 0, 50*time.Millisecond, 10*time.Millisecond)
+	if len(busy) != 1 || busy[0].Port != port {
+		t.Errorf("Expected port %d to remain busy, got %v", port, busy)
+	}
+
+	// Freed while waiting
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ln.Close()
+	}()
+	busy = WaitForPorts(bases, 0, 2*time.Second, 10*time.Millisecond)
+	if len(busy) != 0 {
+		t.Errorf("Expected port to free up, still busy: %v", busy)
+	}
+}
+
+func TestCleanOrphanedProjectsKeep(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	running := filepath.Join(tempDir, "deleted-but-running")
+	gone := filepath.Join(tempDir, "deleted")
+	state := &PortState{MaxSuffix: 52, Projects: map[string]projectEntry{running: {Suffix: 51}, gone: {Suffix: 52}}}
+	if err := state.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := CleanOrphanedProjects([]string{running})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 project cleaned, got %d", count)
+	}
+	projects, _ := ListProjects()
+	if len(projects) != 1 || projects[0].Path != running {
+		t.Errorf("Expected %s to be kept, got %v", running, projects)
+	}
+}
+
+func TestNextFreeSuffixLargeRegistry(t *testing.T) {
+	now := time.Now()
+	state := &PortState{MaxSuffix: 0, Projects: make(map[string]projectEntry)}
+	for i := 1; i <= 500; i++ {
+		state.Projects[fmt.Sprintf("/code/p%d", i)] = projectEntry{Suffix: i}
+	}
+	state.Reservations = map[string]suffixReservation{
+		"/code/pending": {Suffix: 501, Expires: now.Add(time.Hour)},
+		"/code/expired": {Suffix: 502, Expires: now.Add(-time.Hour)},
+	}
+	if got, _ := state.nextFreeSuffix("/code/new", now, nil, true); got != 502 {
+		t.Errorf("Expected 502 past the registered and reserved suffixes, got %d", got)
+	}
+	owners := state.suffixOwners("/code/p7", now)
+	if _, ok := owners[7]; ok {
+		t.Error("Expected the project's own suffix to be left out")
+	}
+	if owners[501] != "/code/pending" || owners[8] != "/code/p8" {
+		t.Errorf("Unexpected owners %v %v", owners[501], owners[8])
+	}
+}
+
+func TestListRegisteredProjectsSkipsStat(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	present := filepath.Join(tempDir, "present")
+	os.MkdirAll(present, 0755)
+	gone := filepath.Join(tempDir, "gone")
+	state := &PortState{MaxSuffix: 52, Projects: map[string]projectEntry{present: {Suffix: 51}, gone: {Suffix: 52}}}
+	if err := state.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	registered, err := listRegisteredProjects()
+	if err != nil || len(registered) != 2 {
+		t.Fatalf("Expected 2 projects, got %v (err=%v)", registered, err)
+	}
+	exists := existingPaths([]string{present, gone})
+	if !exists[present] || exists[gone] {
+		t.Errorf("Unexpected existence %v", exists)
+	}
+	projects, _ := ListProjects()
+	for _, p := range projects {
+		if p.Exists != (p.Path == present) {
+			t.Errorf("Unexpected Exists=%v for %s", p.Exists, p.Path)
+		}
+	}
+}
+
+func TestNextFreeSuffixFillsGaps(t *testing.T) {
+	now := time.Now()
+	state := &PortState{MaxSuffix: 55, Projects: map[string]projectEntry{"/code/a": {Suffix: 48}, "/code/b": {Suffix: 49}, "/code/d": {Suffix: 51}, "/code/e": {Suffix: 55}}}
+	if got, _ := state.nextFreeSuffix("/code/new", now, nil, true); got != 50 {
+		t.Errorf("Expected the gap at 50, got %d", got)
+	}
+	if got, _ := state.nextFreeSuffix("/code/new", now, nil, false); got != 56 {
+		t.Errorf("Expected 56 past MaxSuffix, got %d", got)
+	}
+
+	// A pending reservation keeps its gap
+	state.Reservations = map[string]suffixReservation{"/code/pending": {Suffix: 50, Expires: now.Add(time.Hour)}}
+	if got, _ := state.nextFreeSuffix("/code/new", now, nil, true); got != 52 {
+		t.Errorf("Expected 52 with 50 reserved, got %d", got)
+	}
+}
+
+func TestNextFreeSuffixStopsAtMaxSuffix(t *testing.T) {
+	now := time.Now()
+	state := &PortState{MaxSuffix: MaxPortSuffix(), Projects: map[string]projectEntry{"/code/last": {Suffix: MaxPortSuffix()}}}
+	if _, err := state.nextFreeSuffix("/code/new", now, nil, false); err == nil || !strings.Contains(err.Error(), "no free suffix up to 47435") {
+		t.Errorf("Expected no suffix past the highest valid one, got %v", err)
+	}
+
+	// A high configured base leaves suffixes 0-3, all of them taken
+	writeTestConfig(t, "services:\n  minio:\n    FORWARD_MINIO_PORT: 65532\n")
+	state = &PortState{MaxSuffix: 3, Projects: map[string]projectEntry{}}
+	for suffix := range 4 {
+		state.Projects[fmt.Sprintf("/code/p%d", suffix)] = projectEntry{Suffix: suffix}
+	}
+	if _, err := state.nextFreeSuffix("/code/new", now, nil, true); err == nil {
+		t.Error("Expected an error when every suffix is taken")
+	}
+	delete(state.Projects, "/code/p2")
+	if got, err := state.nextFreeSuffix("/code/new", now, nil, true); err != nil || got != 2 {
+		t.Errorf("Expected the freed suffix 2, got %d (err=%v)", got, err)
+	}
+}
+
+func TestFillSuffixGaps(t *testing.T) {
+	writeTestConfig(t, "")
+	if !fillSuffixGaps() {
+		t.Error("Expected gaps to be filled by default")
+	}
+	writeTestConfig(t, "suffix_strategy: increment\n")
+	if fillSuffixGaps() {
+		t.Error("Expected increment to keep the old behavior")
+	}
+	writeTestConfig(t, "suffix_strategy: random\n")
+	if _, err := loadUserConfig(); err == nil {
+		t.Error("Expected an unknown strategy to be rejected")
+	}
+}
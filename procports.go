@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procListenState is the st column of a listening socket in /proc/net/tcp.
+const procListenState = "0A"
+
+// procListenInodes returns the inodes of the sockets listening on port,
+// read from the tcp tables under root (normally /proc).
+func procListenInodes(root string, port int) map[string]bool {
+	inodes := make(map[string]bool)
+	for _, table := range []string{"tcp", "tcp6"} {
+		data, err := os.ReadFile(filepath.Join(root, "net", table))
+		if err != nil {
+			continue
+		}
+		for _, line := range splitLines(string(data)) {
+			fields := strings.Fields(line)
+			if len(fields) < 10 || fields[3] != procListenState {
+				continue
+			}
+			_, hexPort, ok := strings.Cut(fields[1], ":")
+			if !ok {
+				continue
+			}
+			if p, err := strconv.ParseUint(hexPort, 16, 16); err == nil && int(p) == port && fields[9] != "0" {
+				inodes[fields[9]] = true
+			}
+		}
+	}
+	return inodes
+}
+
+// procPortProcess names the process listening on port from /proc, for
+// systems without lsof or ss. Only processes whose file descriptors are
+// readable are found, so those of other users stay unknown unless sailinit
+// runs as root.
+func procPortProcess(root string, port int) string {
+	inodes := procListenInodes(root, port)
+	if len(inodes) == 0 {
+		return ""
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		fdDir := filepath.Join(root, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			inode, ok := strings.CutPrefix(target, "socket:[")
+			if !ok || !inodes[strings.TrimSuffix(inode, "]")] {
+				continue
+			}
+			comm, _ := os.ReadFile(filepath.Join(root, entry.Name(), "comm"))
+			name := strings.TrimSpace(string(comm))
+			if name == "" {
+				name = "unknown"
+			}
+			return fmt.Sprintf("%s (pid %s)", name, entry.Name())
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcPortProcess(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "net"), 0755)
+	tcp := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" +
+		"   0: 00000000:1F73 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 51234 1 0000000000000000 100 0 0 10 0\n" +
+		"   1: 0100007F:0D17 0100007F:A1B2 01 00000000:00000000 00:00000000 00000000  1000        0 51299 1 0000000000000000 20 4 30 10 -1\n"
+	os.WriteFile(filepath.Join(root, "net", "tcp"), []byte(tcp), 0644)
+
+	fdDir := filepath.Join(root, "4242", "fd")
+	os.MkdirAll(fdDir, 0755)
+	os.WriteFile(filepath.Join(root, "4242", "comm"), []byte("php\n"), 0644)
+	if err := os.Symlink("socket:[51234]", filepath.Join(fdDir, "5")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	os.Symlink("/dev/null", filepath.Join(fdDir, "0"))
+
+	if got := procPortProcess(root, 8051); got != "php (pid 4242)" {
+		t.Errorf("Expected php (pid 4242), got %q", got)
+	}
+	if got := procPortProcess(root, 3351); got != "" {
+		t.Errorf("Expected no owner for a connected, not listening, port, got %q", got)
+	}
+	if got := procPortProcess(t.TempDir(), 8051); got != "" {
+		t.Errorf("Expected no owner without /proc, got %q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// profileFlag is set by --profile, which reports where a command spent its
+// time when it exits.
+var profileFlag bool
+
+// profileStart is when the command started, for the report's total.
+var profileStart = time.Now()
+
+// profileStat sums the spans of one kind, such as "state: load".
+type profileStat struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+var (
+	profileMu    sync.Mutex
+	profileStats = make(map[string]*profileStat)
+)
+
+// extractProfileFlag removes --profile from args wherever it appears, so
+// it works with subcommands as well as with the flag interface.
+func extractProfileFlag(args []string) []string {
+	return slices.DeleteFunc(slices.Clone(args), func(a string) bool {
+		if a == "--profile" || a == "-profile" {
+			profileFlag = true
+			return true
+		}
+		return false
+	})
+}
+
+// profileSpan starts timing an operation of kind and returns the function
+// that ends it:
+//
+//	defer profileSpan("state: load")()
+func profileSpan(kind string) func() {
+	end := startSpan(kind, nil)
+	return func() { end(nil) }
+}
+
+// startSpan starts timing an operation of kind for the profile and, when
+// tracing, a span with attrs. The returned function ends both, marking the
+// span failed when err is not nil.
+func startSpan(kind string, attrs map[string]string) func(err error) {
+	t := activeTracer
+	if !profileFlag && t == nil {
+		return func(error) {}
+	}
+	start := time.Now()
+	var span *traceSpan
+	if t != nil {
+		span = t.begin(kind, attrs, start)
+	}
+	return func(err error) {
+		if span != nil {
+			t.end(span, err)
+		}
+		if !profileFlag {
+			return
+		}
+		d := time.Since(start)
+		profileMu.Lock()
+		defer profileMu.Unlock()
+		s := profileStats[kind]
+		if s == nil {
+			s = &profileStat{}
+			profileStats[kind] = s
+		}
+		s.Count++
+		s.Total += d
+		s.Max = max(s.Max, d)
+	}
+}
+
+// commandKind names an external command for the profile, e.g. "exec: docker".
+func commandKind(cmd *exec.Cmd) string {
+	name := filepath.Base(cmd.Path)
+	if len(cmd.Args) > 0 {
+		name = filepath.Base(cmd.Args[0])
+	}
+	return "exec: " + name
+}
+
+// commandOutput is cmd.Output, timed for the profile and the trace.
+func commandOutput(cmd *exec.Cmd) ([]byte, error) {
+	end := startSpan(commandKind(cmd), commandAttrs(cmd))
+	out, err := cmd.Output()
+	end(err)
+	return out, err
+}
+
+// commandCombinedOutput is cmd.CombinedOutput, timed for the profile and
+// the trace.
+func commandCombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	end := startSpan(commandKind(cmd), commandAttrs(cmd))
+	out, err := cmd.CombinedOutput()
+	end(err)
+	return out, err
+}
+
+// commandRun is cmd.Run, timed for the profile and the trace.
+func commandRun(cmd *exec.Cmd) error {
+	end := startSpan(commandKind(cmd), commandAttrs(cmd))
+	err := cmd.Run()
+	end(err)
+	return err
+}
+
+// formatProfile renders the collected spans, slowest kind first.
+func formatProfile(stats map[string]*profileStat, total time.Duration) string {
+	kinds := make([]string, 0, len(stats))
+	for kind := range stats {
+		kinds = append(kinds, kind)
+	}
+	slices.SortFunc(kinds, func(a, b string) int {
+		return cmp.Or(cmp.Compare(stats[b].Total, stats[a].Total), strings.Compare(a, b))
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "Profile (total %s):\n", total.Round(time.Microsecond))
+	for _, kind := range kinds {
+		s := stats[kind]
+		fmt.Fprintf(&b, "  %-22s %4dx  %10s  (max %s)\n", kind, s.Count, s.Total.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+	return b.String()
+}
+
+// exit ends the program with code, showing output held for the pager,
+// sending the trace when tracing and printing the profile first when
+// --profile is set.
+func exit(code int) {
+	stopPager()
+	finishTracing(code)
+	if profileFlag {
+		profileMu.Lock()
+		fmt.Fprint(os.Stderr, formatProfile(profileStats, time.Since(profileStart)))
+		profileMu.Unlock()
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withProfiling enables --profile with empty stats for the test.
+func withProfiling(t *testing.T) {
+	t.Helper()
+	profileFlag = true
+	profileStats = make(map[string]*profileStat)
+	t.Cleanup(func() {
+		profileFlag = false
+		profileStats = make(map[string]*profileStat)
+	})
+}
+
+func TestExtractProfileFlag(t *testing.T) {
+	t.Cleanup(func() { profileFlag = false })
+	args := extractProfileFlag([]string{"sailinit", "status", "--profile", "--json"})
+	if !profileFlag || !slices.Equal(args, []string{"sailinit", "status", "--json"}) {
+		t.Errorf("Expected --profile removed and enabled, got %v (%v)", args, profileFlag)
+	}
+}
+
+func TestProfileSpan(t *testing.T) {
+	profileSpan("state: load")()
+	if len(profileStats) != 0 {
+		t.Fatalf("Expected nothing recorded without --profile, got %v", profileStats)
+	}
+
+	withProfiling(t)
+	for range 3 {
+		profileSpan("state: load")()
+	}
+	if s := profileStats["state: load"]; s == nil || s.Count != 3 || s.Max > s.Total {
+		t.Errorf("Expected 3 spans, got %+v", s)
+	}
+}
+
+func TestCommandKind(t *testing.T) {
+	if got := commandKind(exec.Command("/project/vendor/bin/sail", "ps")); got != "exec: sail" {
+		t.Errorf("Expected exec: sail, got %q", got)
+	}
+}
+
+func TestFormatProfile(t *testing.T) {
+	got := formatProfile(map[string]*profileStat{
+		"port scan":   {Count: 84, Total: 11 * time.Millisecond, Max: time.Millisecond},
+		"exec: sail":  {Count: 2, Total: 2 * time.Second, Max: 1500 * time.Millisecond},
+		"state: save": {Count: 1, Total: time.Millisecond, Max: time.Millisecond},
+	}, 3*time.Second)
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "Profile (total 3s)") {
+		t.Fatalf("Unexpected profile:\n%s", got)
+	}
+	if !strings.Contains(lines[1], "exec: sail") || !strings.Contains(lines[3], "state: save") {
+		t.Errorf("Expected the slowest kind first:\n%s", got)
+	}
+	if !strings.Contains(lines[1], "2x") || !strings.Contains(lines[1], "(max 1.5s)") {
+		t.Errorf("Expected count and max for exec: sail, got %q", lines[1])
+	}
+}
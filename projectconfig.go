@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigFile is the per-project config committed next to artisan.
+const projectConfigFile = ".sailinit.yaml"
+
+// projectConfig is a project's .sailinit.yaml. It pins what every
+// teammate's setup has to agree on, and wins over the local registry.
+type projectConfig struct {
+	// Name is the friendly name listings show instead of the path
+	Name string `yaml:"name"`
+	// Suffix pins the port suffix; nil leaves it to the registry
+	Suffix *int `yaml:"suffix"`
+	// PHP pins the PHP version composer install runs with, e.g. "83"
+	PHP string `yaml:"php"`
+	// Services lists the Sail services the project runs. Only their
+	// forwarded ports (plus APP_PORT and VITE_PORT) are written to .env;
+	// empty means all of them.
+	Services []string `yaml:"services"`
+	// SuppressWarnings lists warning IDs or names the team has accepted
+	// for this project
+	SuppressWarnings []string `yaml:"suppress_warnings"`
+	// Lint adds conventions for sailinit lint to the config file's
+	Lint *lintConfig `yaml:"lint"`
+}
+
+// sailServices are the services Sail can add to a project.
+var sailServices = []string{"mailhog", "mailpit", "mariadb", "meilisearch", "memcached", "minio", "mongodb", "mysql", "pgsql", "redis", "selenium", "soketi", "typesense", "valkey"}
+
+// loadProjectConfig reads projectDir's .sailinit.yaml, or returns nil when
+// the project has none.
+func loadProjectConfig(projectDir string) (*projectConfig, error) {
+	path := filepath.Join(projectDir, projectConfigFile)
+	data, err := readTextFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := validateYAML(data, projectConfigSchema()); err != nil {
+		return nil, usageError(fmt.Errorf("invalid %s: %w", path, err))
+	}
+	cfg := &projectConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, usageError(fmt.Errorf("invalid %s: %w", path, err))
+	}
+	if err := cfg.validate(data); err != nil {
+		return nil, usageError(fmt.Errorf("invalid %s: %w", path, err))
+	}
+	return cfg, nil
+}
+
+// validate checks the pinned values, pointing errors at their line in
+// data, and normalizes the PHP version, so "8.3" pins the same runtime as
+// "83".
+func (c *projectConfig) validate(data []byte) error {
+	if c.Suffix != nil {
+		if err := validateSuffixFor(portBases(), *c.Suffix); err != nil {
+			return atKey(data, "suffix", err)
+		}
+	}
+	if c.PHP != "" {
+		if !looksLikePHPVersion(c.PHP) {
+			return atKey(data, "php", fmt.Errorf("php: %q is not a PHP version", c.PHP))
+		}
+		c.PHP = strings.ReplaceAll(c.PHP, ".", "")
+	}
+	known := slices.Clone(sailServices)
+	for _, s := range configuredServices() {
+		known = append(known, s.Name)
+	}
+	for _, s := range c.Services {
+		if !slices.Contains(known, s) {
+			return atKey(data, "services", fmt.Errorf("services: unknown service %q (use one of %s)", s, strings.Join(known, ", ")))
+		}
+	}
+	return nil
+}
+
+// pinnedSuffix returns the suffix projectDir's .sailinit.yaml pins.
+func pinnedSuffix(projectDir string) (int, bool, error) {
+	cfg, err := loadProjectConfig(projectDir)
+	if err != nil || cfg == nil || cfg.Suffix == nil {
+		return 0, false, err
+	}
+	return *cfg.Suffix, true, nil
+}
+
+// withProjectServices drops the forwarded ports of services the project
+// config leaves out, including services of the config file (custom).
+// APP_PORT and VITE_PORT always stay.
+func withProjectServices(bases []PortBase, cfg *projectConfig, custom []portService) []PortBase {
+	if cfg == nil || len(cfg.Services) == 0 {
+		return bases
+	}
+	keep := []string{"APP_PORT", "VITE_PORT"}
+	for _, s := range cfg.Services {
+		keep = append(keep, servicePortKeys[s]...)
+		if i := slices.IndexFunc(custom, func(c portService) bool { return c.Name == s }); i >= 0 {
+			for _, p := range custom[i].Ports {
+				keep = append(keep, p.Name)
+			}
+		}
+	}
+	return slices.DeleteFunc(bases, func(b PortBase) bool { return !slices.Contains(keep, b.Name) })
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeProjectConfig(t *testing.T, dir, yaml string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, projectConfigFile), []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	if cfg, err := loadProjectConfig(dir); cfg != nil || err != nil {
+		t.Errorf("Expected no config without the file, got %+v (err=%v)", cfg, err)
+	}
+
+	writeProjectConfig(t, dir, "suffix: 12\nphp: 8.3\nservices: [mysql, redis]\n")
+	cfg, err := loadProjectConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Suffix == nil || *cfg.Suffix != 12 || cfg.PHP != "83" || !slices.Equal(cfg.Services, []string{"mysql", "redis"}) {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+
+	for _, bad := range []string{"suffix: 70000\n", "php: latest\n", "services: [oracle]\n", "suffix: [1\n"} {
+		writeProjectConfig(t, dir, bad)
+		if _, err := loadProjectConfig(dir); err == nil || !strings.Contains(err.Error(), projectConfigFile) {
+			t.Errorf("Expected %q to be rejected naming the file, got %v", bad, err)
+		}
+	}
+}
+
+func TestPinnedSuffixWinsOverRegistry(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	if _, _, err := claimSuffix(dir, 5); err != nil {
+		t.Fatal(err)
+	}
+	writeProjectConfig(t, dir, "suffix: 7\n")
+	suffix, existing, _, err := getSuggestedSuffix(dir)
+	if err != nil || suffix != 7 || !existing {
+		t.Errorf("Expected the pinned suffix 7, got %d (existing=%v, err=%v)", suffix, existing, err)
+	}
+
+	got, err := chooseSuffix(bufio.NewReader(strings.NewReader("")), dir)
+	if err != nil || got != 7 {
+		t.Errorf("Expected chooseSuffix to claim the pin without asking, got %d (err=%v)", got, err)
+	}
+
+	other := t.TempDir()
+	writeProjectConfig(t, other, "suffix: 7\n")
+	if _, err := chooseSuffix(bufio.NewReader(strings.NewReader("")), other); err == nil || !strings.Contains(err.Error(), projectConfigFile) {
+		t.Errorf("Expected a clash with the pinned suffix to name the file, got %v", err)
+	}
+}
+
+func TestProjectServicesLimitForwardedPorts(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectConfig(t, dir, "services: [pgsql]\n")
+	var keys []string
+	for _, b := range projectPortBases(dir) {
+		keys = append(keys, b.Name)
+	}
+	if !slices.Equal(keys, []string{"APP_PORT", "FORWARD_DB_PORT", "VITE_PORT"}) {
+		t.Errorf("Expected only the app, Vite and database ports, got %v", keys)
+	}
+	if got := withProjectServices(slices.Clone(sailPortBases), nil, nil); len(got) != len(sailPortBases) {
+		t.Errorf("Expected every port without a project config, got %v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// findProjectRoot walks up from dir to the project it belongs to: the
+// nearest directory that is registered or has an artisan file. When none
+// is found, dir itself is returned.
+func findProjectRoot(dir string, registered map[string]projectEntry) string {
+	for d := dir; ; {
+		if _, ok := registered[d]; ok {
+			return d
+		}
+		if _, err := os.Stat(filepath.Join(d, "artisan")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// projectDirectory returns the root of the project containing the current
+// directory, so commands run from e.g. resources/js act on the project
+// instead of registering the subdirectory. The note goes to stderr to keep
+// machine-readable output clean.
+func projectDirectory() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	var registered map[string]projectEntry
+	if state, _, err := loadPortState(); err == nil {
+		registered = state.Projects
+	}
+	root := findProjectRoot(wd, registered)
+	if root != wd {
+		fmt.Fprintln(os.Stderr, colorize(colorCyan, tr("project_root", shortenHome(root))))
+	}
+	return root, nil
+}
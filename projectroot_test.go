@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectRoot(t *testing.T) {
+	dir := t.TempDir()
+	laravel := filepath.Join(dir, "shop")
+	makeLaravelProject(t, laravel)
+	nested := filepath.Join(laravel, "resources", "js", "components")
+	os.MkdirAll(nested, 0755)
+
+	if got := findProjectRoot(nested, nil); got != laravel {
+		t.Errorf("Expected the artisan directory %s, got %s", laravel, got)
+	}
+	if got := findProjectRoot(laravel, nil); got != laravel {
+		t.Errorf("Expected the root itself, got %s", got)
+	}
+
+	// A registered project without artisan (e.g. generic mode) is found too
+	generic := filepath.Join(dir, "api")
+	sub := filepath.Join(generic, "src", "handlers")
+	os.MkdirAll(sub, 0755)
+	if got := findProjectRoot(sub, map[string]projectEntry{generic: {Suffix: 51}}); got != generic {
+		t.Errorf("Expected the registered directory %s, got %s", generic, got)
+	}
+
+	// A registered subdirectory wins over an artisan file further up
+	if got := findProjectRoot(nested, map[string]projectEntry{filepath.Join(laravel, "resources"): {Suffix: 52}}); got != filepath.Join(laravel, "resources") {
+		t.Errorf("Expected the nearest registered directory, got %s", got)
+	}
+
+	lonely := filepath.Join(dir, "notes", "drafts")
+	os.MkdirAll(lonely, 0755)
+	if got := findProjectRoot(lonely, nil); got != lonely {
+		t.Errorf("Expected the directory itself without markers, got %s", got)
+	}
+}
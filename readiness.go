@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// readyTimeout bounds the readiness phase after sail up, set by
+// --ready-timeout. Zero skips the phase.
+var readyTimeout = 2 * time.Minute
+
+// readyInterval is the pause between readiness polls.
+var readyInterval = 2 * time.Second
+
+// containerState is one compose service's container as `sail ps` reports it.
+type containerState struct {
+	Service string
+	State   string
+	Health  string
+}
+
+// ready reports whether the container is running and, when it has a
+// healthcheck, healthy.
+func (c containerState) ready() bool {
+	return c.State == "running" && (c.Health == "" || c.Health == "healthy")
+}
+
+// status describes the state and health, e.g. "running, unhealthy".
+func (c containerState) status() string {
+	if c.Health == "" {
+		return c.State
+	}
+	return c.State + ", " + c.Health
+}
+
+// failed reports whether the container will not become ready by waiting.
+func (c containerState) failed() bool {
+	return c.State == "exited" || c.State == "dead" || c.Health == "unhealthy"
+}
+
+// containerStates lists the project's containers with their health.
+func containerStates(projectDir string) ([]containerState, error) {
+	cmd, err := sailCommand(projectDir, "ps", "--all", "--format", "{{.Service}}\t{{.State}}\t{{.Health}}")
+	if err != nil {
+		return nil, err
+	}
+	output, err := commandOutput(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var states []containerState
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		state := containerState{Service: fields[0], State: fields[1]}
+		if len(fields) > 2 {
+			state.Health = fields[2]
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// appHealthURL returns the URL of Laravel's /up health route when a compose
+// service publishes APP_PORT, or "" when there is nothing to probe.
+func appHealthURL(projectDir string) string {
+	project, err := loadCompose(projectDir)
+	if err != nil || project == nil {
+		return ""
+	}
+	for _, svc := range project.Services {
+		if slices.ContainsFunc(svc.Ports, func(p composePort) bool { return strings.Contains(p.Published, "APP_PORT") }) {
+			port := readEnvValues(filepath.Join(projectDir, ".env"))["APP_PORT"]
+			if port == "" {
+				port = "80"
+			}
+			return fmt.Sprintf("http://localhost:%s/up", port)
+		}
+	}
+	return ""
+}
+
+// appResponds reports whether the app answers. A 404 means the app predates
+// the /up route, which still proves it is serving requests.
+func appResponds(client *http.Client, url string) bool {
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 400 || resp.StatusCode == http.StatusNotFound
+}
+
+// waitForReady polls the containers and the app until everything is up or
+// timeout passes. On failure the error carries the failing containers' last
+// log lines.
+func waitForReady(projectDir string, timeout time.Duration) (err error) {
+	end := startSpan("sail up: wait ready", nil)
+	defer func() { end(err) }()
+	printInfo(tr("ready_waiting"))
+	healthURL := appHealthURL(projectDir)
+	client := &http.Client{Timeout: 5 * time.Second}
+	deadline := time.Now().Add(timeout)
+
+	var states, pending []containerState
+	appUp := healthURL == ""
+	for {
+		var err error
+		if states, err = containerStates(projectDir); err != nil {
+			return classifyExecError(err, "")
+		}
+		pending = slices.DeleteFunc(slices.Clone(states), containerState.ready)
+		if slices.ContainsFunc(pending, containerState.failed) {
+			break
+		}
+		if len(states) > 0 && len(pending) == 0 {
+			if !appUp {
+				appUp = appResponds(client, healthURL)
+			}
+			if appUp {
+				printSuccess(tr("ready_ok"))
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(readyInterval)
+	}
+
+	var details []string
+	for _, c := range pending {
+		details = append(details, fmt.Sprintf("%s (%s)%s", c.Service, c.status(), containerLogExcerpt(projectDir, c.Service)))
+	}
+	switch {
+	case len(states) == 0:
+		details = append(details, "no containers are running")
+	case len(details) == 0:
+		details = append(details, fmt.Sprintf("%s did not respond", healthURL))
+	}
+	return notReadyError(fmt.Errorf("project did not become ready within %s: %s", timeout, strings.Join(details, "; ")))
+}
+
+// containerLogExcerpt returns the last log lines of a service, indented for
+// the error message, or "" when they can't be read.
+func containerLogExcerpt(projectDir, service string) string {
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	cmd := exec.Command(sailPath, "logs", "--no-color", "--tail", "15", service)
+	cmd.Dir = projectDir
+	cmd.Env = sailEnv(projectDir)
+	output, err := commandCombinedOutput(cmd)
+	logs := strings.TrimSpace(string(output))
+	if err != nil || logs == "" {
+		return ""
+	}
+	return "\n      " + strings.ReplaceAll(logs, "\n", "\n      ")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readinessProject creates a project whose sail script reports the
+// container states in states.txt and prints a fixed log line.
+func readinessProject(t *testing.T, states string) string {
+	t.Helper()
+	projectDir := t.TempDir()
+	sailDir := filepath.Join(projectDir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	statesPath := filepath.Join(projectDir, "states.txt")
+	os.WriteFile(statesPath, []byte(states), 0644)
+	script := "#!/bin/sh\ncase \"$1\" in\nps) cat " + statesPath + " ;;\nlogs) echo \"[ERROR] InnoDB: cannot allocate memory\" ;;\nesac\n"
+	os.WriteFile(filepath.Join(sailDir, "sail"), []byte(script), 0755)
+
+	orig := readyInterval
+	readyInterval = 10 * time.Millisecond
+	t.Cleanup(func() { readyInterval = orig })
+	return projectDir
+}
+
+func TestWaitForReadyHealthy(t *testing.T) {
+	projectDir := readinessProject(t, "laravel.test\trunning\t\nmysql\trunning\thealthy\n")
+	if err := waitForReady(projectDir, time.Second); err != nil {
+		t.Errorf("Expected ready, got %v", err)
+	}
+}
+
+func TestWaitForReadyUnhealthy(t *testing.T) {
+	projectDir := readinessProject(t, "laravel.test\trunning\t\nmysql\trunning\tunhealthy\n")
+	err := waitForReady(projectDir, 5*time.Second)
+	if err == nil {
+		t.Fatal("Expected an unhealthy container to fail readiness")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "mysql (running, unhealthy)") || !strings.Contains(msg, "InnoDB: cannot allocate memory") {
+		t.Errorf("Expected the failing service and its logs, got %q", msg)
+	}
+	if strings.Contains(msg, "laravel.test") {
+		t.Errorf("Expected healthy services to be left out, got %q", msg)
+	}
+	if exitCode(err) != ExitSail {
+		t.Errorf("Expected exit code %d, got %d", ExitSail, exitCode(err))
+	}
+}
+
+func TestWaitForReadyNoContainers(t *testing.T) {
+	projectDir := readinessProject(t, "")
+	err := waitForReady(projectDir, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "no containers are running") {
+		t.Errorf("Expected a timeout without containers, got %v", err)
+	}
+}
+
+func TestWaitForReadyAppEndpoint(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path != "/up" || hits < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	projectDir := readinessProject(t, "laravel.test\trunning\t\n")
+	os.WriteFile(filepath.Join(projectDir, "compose.yaml"), []byte("services:\n  laravel.test:\n    ports:\n      - '${APP_PORT:-80}:80'\n"), 0644)
+	os.WriteFile(filepath.Join(projectDir, ".env"), []byte("APP_PORT="+u.Port()+"\n"), 0644)
+
+	if got := appHealthURL(projectDir); got != "http://localhost:"+u.Port()+"/up" {
+		t.Errorf("Unexpected health URL %q", got)
+	}
+	if err := waitForReady(projectDir, 5*time.Second); err != nil {
+		t.Errorf("Expected ready once /up answers, got %v", err)
+	}
+	if hits != 3 {
+		t.Errorf("Expected /up to be polled until it answered, got %d hits", hits)
+	}
+
+	server.Close()
+	err := waitForReady(projectDir, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "/up did not respond") {
+		t.Errorf("Expected the unreachable app to be reported, got %v", err)
+	}
+}
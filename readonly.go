@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// readOnlyFlag is set by --read-only or SAILINIT_READ_ONLY=1.
+var readOnlyFlag bool
+
+// extractReadOnlyFlag removes --read-only from args wherever it appears, so
+// it works in front of subcommands as well as with the flag interface.
+func extractReadOnlyFlag(args []string) []string {
+	return slices.DeleteFunc(slices.Clone(args), func(a string) bool {
+		if a == "--read-only" || a == "-read-only" {
+			readOnlyFlag = true
+			return true
+		}
+		return false
+	})
+}
+
+// stateReadOnlyReason explains why the registry must not be changed, or
+// returns "" when it may be. A state file (or, before the first run, its
+// directory) that can't be written enables read-only mode by itself.
+func stateReadOnlyReason() string {
+	if readOnlyFlag {
+		return "--read-only is set"
+	}
+	if os.Getenv("SAILINIT_READ_ONLY") == "1" {
+		return "SAILINIT_READ_ONLY=1 is set"
+	}
+	path, err := getPortStatePath()
+	if err != nil {
+		return ""
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err == nil {
+		f.Close()
+		return ""
+	}
+	if os.IsNotExist(err) {
+		probe, err := os.CreateTemp(filepath.Dir(path), ".sailinit-probe-*")
+		if err != nil {
+			return fmt.Sprintf("%s is not writable", filepath.Dir(path))
+		}
+		probe.Close()
+		os.Remove(probe.Name())
+		return ""
+	}
+	return fmt.Sprintf("%s is not writable", path)
+}
+
+// readOnlyError refuses a registry change.
+func readOnlyError(reason string) error {
+	return &CLIError{
+		Category: CategoryState,
+		Err:      fmt.Errorf("the port registry is read-only (%s)", reason),
+		Hint:     "Inspection commands still work. Drop --read-only or make the state file writable to change it.",
+	}
+}
+
+// requireWritableState exits before a command that changes the registry
+// starts, instead of failing halfway through.
+func requireWritableState() {
+	if reason := stateReadOnlyReason(); reason != "" {
+		exitWithError("Refusing to change the registry", readOnlyError(reason))
+	}
+}
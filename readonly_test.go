@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExtractReadOnlyFlag(t *testing.T) {
+	defer func() { readOnlyFlag = false }()
+
+	args := extractReadOnlyFlag([]string{"sailinit", "drift", "--read-only"})
+	if strings.Join(args, " ") != "sailinit drift" || !readOnlyFlag {
+		t.Errorf("Expected the flag to be stripped and set, got %v (%v)", args, readOnlyFlag)
+	}
+
+	readOnlyFlag = false
+	args = extractReadOnlyFlag([]string{"sailinit", "--list"})
+	if len(args) != 2 || readOnlyFlag {
+		t.Errorf("Expected args to stay untouched, got %v (%v)", args, readOnlyFlag)
+	}
+}
+
+func TestReadOnlyRefusesChanges(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+	saveProjectSuffix(filepath.Join(tempDir, "shop"), 60)
+
+	t.Setenv("SAILINIT_READ_ONLY", "1")
+	err := saveProjectSuffix(filepath.Join(tempDir, "blog"), 61)
+	if err == nil || exitCode(err) != ExitState || !strings.Contains(err.Error(), "SAILINIT_READ_ONLY=1") {
+		t.Fatalf("Expected a read-only state error, got %v", err)
+	}
+
+	// Inspection keeps working and suggestions are not reserved
+	if projects, err := ListProjects(); err != nil || len(projects) != 1 {
+		t.Errorf("Expected listing to work, got %v, %v", projects, err)
+	}
+	suffix, _, _, err := claimSuggestedSuffix(filepath.Join(tempDir, "blog"))
+	if err != nil || suffix != 61 {
+		t.Errorf("Expected suggestion 61, got %d, %v", suffix, err)
+	}
+	state, _, _ := loadPortState()
+	if len(state.Reservations) != 0 {
+		t.Errorf("Expected no reservation in read-only mode, got %v", state.Reservations)
+	}
+	if _, taken, err := claimSuffix(filepath.Join(tempDir, "blog"), 60); err != nil || !taken {
+		t.Errorf("Expected collisions to still be reported, got taken=%v err=%v", taken, err)
+	}
+}
+
+func TestReadOnlyWhenStateNotWritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Getuid() == 0 {
+		t.Skip("file permissions are not enforced")
+	}
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+	saveProjectSuffix(filepath.Join(tempDir, "shop"), 60)
+
+	os.Chmod(testStatePathOverride, 0444)
+	defer os.Chmod(testStatePathOverride, 0644)
+
+	if reason := stateReadOnlyReason(); !strings.Contains(reason, "is not writable") {
+		t.Errorf("Expected read-only mode for an unwritable file, got %q", reason)
+	}
+	if err := RemoveProject(filepath.Join(tempDir, "shop")); err == nil {
+		t.Error("Expected removing to be refused")
+	}
+}
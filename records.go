@@ -0,0 +1,136 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// projectEntry is what the registry knows about a project: its suffix, and
+// the name, PHP versions and services of its last setup, so listings can
+// show a name instead of a long path.
+type projectEntry struct {
+	Suffix int `json:"suffix"`
+	// Name is the name from .sailinit.yaml, or the directory name
+	Name string `json:"name,omitempty"`
+	// PHP is the PHP version of the last setup, e.g. "84"
+	PHP string `json:"php,omitempty"`
+	// Runtimes are every PHP runtime the compose file uses
+	Runtimes []string `json:"runtimes,omitempty"`
+	// Services are the compose services as of the last setup
+	Services []string  `json:"services,omitempty"`
+	Created  time.Time `json:"created,omitzero"`
+	// LastUsed is when the project's stack was last started
+	LastUsed time.Time `json:"last_used,omitzero"`
+}
+
+// UnmarshalJSON also reads the bare suffix older registries stored per
+// project.
+func (e *projectEntry) UnmarshalJSON(data []byte) error {
+	var suffix int
+	if json.Unmarshal(data, &suffix) == nil {
+		*e = projectEntry{Suffix: suffix}
+		return nil
+	}
+	type plain projectEntry
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// legacyProjectMaps are the per-project maps older registries kept next to
+// the suffixes, read only to migrate them into the entries.
+type legacyProjectMaps struct {
+	Runtimes map[string][]string     `json:"runtimes"`
+	LastUsed map[string]time.Time    `json:"last_used"`
+	Records  map[string]legacyRecord `json:"records"`
+}
+
+type legacyRecord struct {
+	Name     string    `json:"name"`
+	PHP      string    `json:"php"`
+	Created  time.Time `json:"created"`
+	Services []string  `json:"services"`
+}
+
+// UnmarshalJSON reads a registry, migrating the maps of older versions.
+func (s *PortState) UnmarshalJSON(data []byte) error {
+	type plain PortState
+	v := struct {
+		*plain
+		legacyProjectMaps
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	s.migrateLegacy(v.legacyProjectMaps)
+	return nil
+}
+
+// migrateLegacy moves what legacy holds about registered projects into
+// their entries. Entries of unregistered projects are not created.
+func (s *PortState) migrateLegacy(legacy legacyProjectMaps) {
+	for path, e := range s.Projects {
+		if r, ok := legacy.Records[path]; ok {
+			e.Name = cmp.Or(e.Name, r.Name)
+			e.PHP = cmp.Or(e.PHP, r.PHP)
+			if e.Created.IsZero() {
+				e.Created = r.Created
+			}
+			if e.Services == nil {
+				e.Services = r.Services
+			}
+		}
+		if runtimes, ok := legacy.Runtimes[path]; ok && e.Runtimes == nil {
+			e.Runtimes = runtimes
+		}
+		if t, ok := legacy.LastUsed[path]; ok && e.LastUsed.IsZero() {
+			e.LastUsed = t
+		}
+		s.Projects[path] = e
+	}
+}
+
+// updateEntry applies update to the entry of absDir and reports whether
+// the project is registered; unregistered projects are left out.
+func (s *PortState) updateEntry(absDir string, update func(*projectEntry)) bool {
+	e, ok := s.Projects[absDir]
+	if !ok {
+		return false
+	}
+	update(&e)
+	s.Projects[absDir] = e
+	return true
+}
+
+// projectName returns the friendly name of the project at absDir: the
+// name its .sailinit.yaml sets, or its directory name.
+func projectName(absDir string) string {
+	if cfg, err := loadProjectConfig(absDir); err == nil && cfg != nil && cfg.Name != "" {
+		return cfg.Name
+	}
+	return filepath.Base(absDir)
+}
+
+// displayName returns the name of the project at path. Projects registered
+// before names were recorded get their directory name.
+func (e projectEntry) displayName(path string) string {
+	return cmp.Or(e.Name, filepath.Base(path))
+}
+
+// saveProjectRecord refreshes the name, PHP version and services recorded
+// for a registered project after a setup.
+func saveProjectRecord(projectDir, php string, services []string) error {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+
+	name := projectName(absDir)
+	return updatePortState(func(state *PortState) error {
+		state.updateEntry(absDir, func(e *projectEntry) {
+			e.Name, e.PHP, e.Services = name, php, slices.Clone(services)
+		})
+		return nil
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProjectRecords(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "shop-backend")
+	os.MkdirAll(projectDir, 0755)
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+	state, _, _ := loadPortState()
+	created := state.Projects[projectDir].Created
+	if created.IsZero() || state.Projects[projectDir].Name != "shop-backend" {
+		t.Fatalf("Expected an entry named after the directory, got %+v", state.Projects[projectDir])
+	}
+
+	writeProjectConfig(t, projectDir, "name: Shop\n")
+	if err := saveProjectRecord(projectDir, "84", []string{"laravel.test", "mysql"}); err != nil {
+		t.Fatal(err)
+	}
+	// Registering again keeps the creation time
+	if err := saveProjectSuffix(projectDir, 52); err != nil {
+		t.Fatal(err)
+	}
+	projects, err := listRegisteredProjects()
+	if err != nil || len(projects) != 1 {
+		t.Fatalf("Expected one project, got %v (err=%v)", projects, err)
+	}
+	p := projects[0]
+	if p.Name != "Shop" || p.Suffix != 52 || p.PHP != "84" || !slices.Equal(p.Services, []string{"laravel.test", "mysql"}) || !p.Created.Equal(created) {
+		t.Errorf("Unexpected project %+v", p)
+	}
+
+	// Projects registered without a name still get one
+	if name := (projectEntry{Suffix: 60}).displayName("/code/legacy"); name != "legacy" {
+		t.Errorf("Expected the directory name, got %q", name)
+	}
+}
+
+func TestSaveProjectRecordUnregistered(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	if err := saveProjectRecord(tempDir, "84", nil); err != nil {
+		t.Fatal(err)
+	}
+	state, _, _ := loadPortState()
+	if len(state.Projects) != 0 {
+		t.Errorf("Expected no entry for an unregistered project, got %v", state.Projects)
+	}
+}
+
+func TestStatusCarriesEntry(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	state := &PortState{
+		Projects: map[string]projectEntry{"/code/gone": {Suffix: 51, Name: "Gone", PHP: "83", Created: created}},
+	}
+	st := state.status("/code/gone")
+	if st.Name != "Gone" || st.Suffix != 51 || st.PHP != "83" || st.Created == nil || !st.Created.Equal(created) || st.LastUsed != nil {
+		t.Errorf("Unexpected status %+v", st)
+	}
+}
+
+func TestLoadPortStateMigratesLegacyMaps(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	shop := filepath.Join(tempDir, "shop")
+	blog := filepath.Join(tempDir, "blog")
+	legacy := `{
+  "max_suffix": 52,
+  "projects": {"` + shop + `": 51, "` + blog + `": 52},
+  "runtimes": {"` + shop + `": ["84", "82"], "/code/removed": ["81"]},
+  "last_used": {"` + shop + `": "2026-10-02T08:00:00Z"},
+  "records": {"` + shop + `": {"name": "Shop", "php": "84", "created": "2026-09-01T10:00:00Z", "services": ["laravel.test", "redis"]}}
+}`
+	if err := os.WriteFile(testStatePathOverride, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, _, err := loadPortState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := projectEntry{
+		Suffix:   51,
+		Name:     "Shop",
+		PHP:      "84",
+		Runtimes: []string{"84", "82"},
+		Services: []string{"laravel.test", "redis"},
+		Created:  time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC),
+		LastUsed: time.Date(2026, 10, 2, 8, 0, 0, 0, time.UTC),
+	}
+	got := state.Projects[shop]
+	if got.Suffix != want.Suffix || got.Name != want.Name || got.PHP != want.PHP ||
+		!slices.Equal(got.Runtimes, want.Runtimes) || !slices.Equal(got.Services, want.Services) ||
+		!got.Created.Equal(want.Created) || !got.LastUsed.Equal(want.LastUsed) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if e := state.Projects[blog]; e.Suffix != 52 || e.Name != "" || !e.Created.IsZero() {
+		t.Errorf("Expected a bare entry for blog, got %+v", e)
+	}
+	if len(state.Projects) != 2 {
+		t.Errorf("Expected no entry for runtimes of an unregistered project, got %v", state.Projects)
+	}
+
+	// Saving writes entries only
+	if err := state.save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(testStatePathOverride)
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+	for _, name := range []string{"runtimes", "last_used", "records"} {
+		if _, ok := fields[name]; ok {
+			t.Errorf("Expected %s to be dropped, got %s", name, data)
+		}
+	}
+	if reloaded, _, _ := loadPortState(); !reloaded.Projects[shop].LastUsed.Equal(want.LastUsed) || reloaded.Projects[shop].Name != "Shop" {
+		t.Errorf("Expected the entry to survive a round trip, got %+v", reloaded.Projects[shop])
+	}
+}
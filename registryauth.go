@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Sentinel errors for image pulls the registry refused; match them with
+// errors.Is.
+var (
+	ErrRegistryAuth = errors.New("the registry requires authentication")
+	ErrRateLimited  = errors.New("the registry's pull rate limit was reached")
+)
+
+// registryAuthMessages are fragments docker prints when a pull needs
+// credentials.
+var registryAuthMessages = []string{
+	"pull access denied",
+	"unauthorized: authentication required",
+	"unauthorized: incorrect username or password",
+	"requested access to the resource is denied",
+	"no basic auth credentials",
+}
+
+// rateLimitMessages are fragments docker prints when a registry throttles
+// pulls.
+var rateLimitMessages = []string{
+	"toomanyrequests",
+	"You have reached your pull rate limit",
+	"429 Too Many Requests",
+}
+
+// registryHostPattern finds the registry in URLs like
+// https://ghcr.io/v2/owner/image/manifests/latest.
+var registryHostPattern = regexp.MustCompile(`https://([^/\s"]+)/v2/`)
+
+// pullRegistry returns the registry named in docker's pull error output,
+// or "" for Docker Hub.
+func pullRegistry(stderr string) string {
+	m := registryHostPattern.FindStringSubmatch(stderr)
+	if m == nil {
+		return ""
+	}
+	switch m[1] {
+	case "registry-1.docker.io", "index.docker.io", "auth.docker.io":
+		return ""
+	}
+	return m[1]
+}
+
+// loginCommand returns the docker login invocation for registry.
+func loginCommand(registry string) string {
+	return strings.TrimSpace("docker login " + registry)
+}
+
+// classifyPullError recognises refused image pulls in stderr, returning
+// nil when the failure is something else.
+func classifyPullError(err error, stderr string) error {
+	for _, msg := range rateLimitMessages {
+		if strings.Contains(stderr, msg) {
+			return &CLIError{
+				Category: CategoryNetwork,
+				Err:      fmt.Errorf("%w: %v", ErrRateLimited, err),
+				Hint:     "Anonymous pulls from Docker Hub are rate limited. Log in with `docker login` for a higher limit, configure a registry mirror (registry-mirrors in Docker's daemon.json), or wait a few hours and try again.",
+			}
+		}
+	}
+	for _, msg := range registryAuthMessages {
+		if strings.Contains(stderr, msg) {
+			return &CLIError{
+				Category: CategoryDocker,
+				Err:      fmt.Errorf("%w: %v", ErrRegistryAuth, err),
+				Hint:     fmt.Sprintf("Log in with `%s`. The same error appears when the image name or tag is misspelled.", loginCommand(pullRegistry(stderr))),
+			}
+		}
+	}
+	return nil
+}
+
+// runWithLoginRetry runs the command built by newCmd, retrying transient
+// failures. When a pull is refused for missing credentials or a rate limit
+// and stdin is a terminal, it offers to run docker login and retries once.
+func runWithLoginRetry(newCmd func() *exec.Cmd) error {
+	stderr, err := runWithRetry(newCmd)
+	if !errors.Is(err, ErrRegistryAuth) && !errors.Is(err, ErrRateLimited) || !stdinIsTerminal() {
+		return err
+	}
+	printWarning(err.Error())
+	registry := pullRegistry(stderr)
+	if !askYesNo(bufio.NewReader(os.Stdin), tr("docker_login_retry", loginCommand(registry))) {
+		return err
+	}
+	login := exec.Command("docker", strings.Fields(loginCommand(registry))[1:]...)
+	login.Stdin = os.Stdin
+	login.Stdout = os.Stdout
+	login.Stderr = os.Stderr
+	if loginErr := commandRun(login); loginErr != nil {
+		return fmt.Errorf("docker login failed: %w", loginErr)
+	}
+	_, err = runWithRetry(newCmd)
+	return err
+}
+
+// stdinIsTerminal reports whether sailinit can prompt on stdin.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
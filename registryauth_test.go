@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyPullErrors(t *testing.T) {
+	status := errors.New("exit status 1")
+
+	err := classifyExecError(status, "Error response from daemon: toomanyrequests: You have reached your pull rate limit.")
+	if !errors.Is(err, ErrRateLimited) || exitCode(err) != ExitNetwork {
+		t.Errorf("Expected a rate limit error, got %v", err)
+	}
+	if !strings.Contains(errorHint(err), "registry mirror") {
+		t.Errorf("Expected mirror guidance, got %q", errorHint(err))
+	}
+
+	err = classifyExecError(status, `Error response from daemon: Head "https://ghcr.io/v2/acme/app/manifests/latest": unauthorized: authentication required`)
+	if !errors.Is(err, ErrRegistryAuth) || exitCode(err) != ExitDocker {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+	if !strings.Contains(errorHint(err), "docker login ghcr.io") {
+		t.Errorf("Expected a login hint for ghcr.io, got %q", errorHint(err))
+	}
+
+	err = classifyExecError(status, "Error response from daemon: pull access denied for sail-8.4/app, repository does not exist or may require 'docker login'")
+	if !errors.Is(err, ErrRegistryAuth) || !strings.Contains(errorHint(err), "`docker login`") {
+		t.Errorf("Expected a Docker Hub login hint, got %v (%q)", err, errorHint(err))
+	}
+
+	if err := classifyPullError(status, "composer failed"); err != nil {
+		t.Errorf("Expected other failures to be left alone, got %v", err)
+	}
+}
+
+func TestPullRegistry(t *testing.T) {
+	cases := map[string]string{
+		`Head "https://registry-1.docker.io/v2/library/mysql/manifests/8.4": unauthorized`: "",
+		`Get "https://ghcr.io/v2/": denied`:                                                "ghcr.io",
+		"pull access denied for foo":                                                       "",
+	}
+	for stderr, want := range cases {
+		if got := pullRegistry(stderr); got != want {
+			t.Errorf("pullRegistry(%q) = %q, want %q", stderr, got, want)
+		}
+	}
+}
+
+func TestRunWithLoginRetryWithoutTerminal(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "pull")
+	os.WriteFile(script, []byte("#!/bin/sh\necho 'toomanyrequests: rate limit' >&2\nexit 1\n"), 0755)
+
+	runs := 0
+	err := runWithLoginRetry(func() *exec.Cmd {
+		runs++
+		return exec.Command(script)
+	})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected the rate limit error, got %v", err)
+	}
+	if runs != 1 {
+		t.Errorf("Expected no retry without a terminal, got %d runs", runs)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reSalvageEntry finds "path": suffix pairs in a registry too broken to
+// parse as JSON.
+var reSalvageEntry = regexp.MustCompile(`"((?:[^"\\]|\\.)+)"\s*:\s*"?(-?\d+)"?`)
+
+// decodeRegistryLeniently decodes what it can of a registry file. Fields
+// with the wrong type are skipped instead of failing the whole file, and
+// a file that isn't JSON at all is scanned for project entries.
+func decodeRegistryLeniently(data []byte) (*PortState, map[string]json.RawMessage, []string) {
+	state := &PortState{Projects: make(map[string]projectEntry)}
+	var notes []string
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		notes = append(notes, fmt.Sprintf("file is not valid JSON (%v); salvaged project entries", err))
+		for _, m := range reSalvageEntry.FindAllSubmatch(data, -1) {
+			path, _ := strconv.Unquote(`"` + string(m[1]) + `"`)
+			suffix, _ := strconv.Atoi(string(m[2]))
+			if path != "" && path != "max_suffix" {
+				state.Projects[path] = projectEntry{Suffix: suffix}
+			}
+		}
+		return state, nil, notes
+	}
+
+	var projects map[string]json.RawMessage
+	if raw, ok := fields["projects"]; ok {
+		if err := json.Unmarshal(raw, &projects); err != nil {
+			notes = append(notes, "projects is not an object; dropped it")
+		}
+	}
+	for path, raw := range projects {
+		var entry projectEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			// Accept a suffix written as a string
+			var text string
+			if json.Unmarshal(raw, &text) != nil {
+				notes = append(notes, fmt.Sprintf("removed %s: suffix %s is not a number", path, raw))
+				continue
+			}
+			if entry.Suffix, err = strconv.Atoi(strings.TrimSpace(text)); err != nil {
+				notes = append(notes, fmt.Sprintf("removed %s: suffix %q is not a number", path, text))
+				continue
+			}
+		}
+		state.Projects[path] = entry
+	}
+	var legacy legacyProjectMaps
+	for name, target := range map[string]any{
+		"runtimes":        &legacy.Runtimes,
+		"fingerprints":    &state.Fingerprints,
+		"fingerprint_key": &state.FingerprintKey,
+		"reservations":    &state.Reservations,
+		"last_used":       &legacy.LastUsed,
+		"step_hashes":     &state.StepHashes,
+		"benchmarks":      &state.Benchmarks,
+		"scales":          &state.Scales,
+		"history":         &state.History,
+		"docker_hosts":    &state.DockerHosts,
+		"records":         &legacy.Records,
+	} {
+		if raw, ok := fields[name]; ok && json.Unmarshal(raw, target) != nil {
+			notes = append(notes, fmt.Sprintf("%s is malformed; dropped it", name))
+		}
+	}
+	state.migrateLegacy(legacy)
+	for path := range legacy.Runtimes {
+		if _, ok := state.Projects[path]; !ok {
+			notes = append(notes, fmt.Sprintf("dropped runtimes of unregistered %s", path))
+		}
+	}
+	return state, fields, notes
+}
+
+// repairPortState rebuilds a consistent registry from data: entries with
+// invalid suffixes or relative paths are dropped, paths are normalized and
+// deduplicated, a suffix claimed twice keeps its first owner (existing
+// directories first), side maps are pruned to known projects and MaxSuffix
+// is recomputed per docker host. The notes describe every change.
+func repairPortState(data []byte, now time.Time) (*PortState, []string) {
+	raw, fields, notes := decodeRegistryLeniently(data)
+	state := &PortState{Projects: make(map[string]projectEntry)}
+
+	// Existing directories win suffix conflicts, then alphabetical order
+	paths := slices.Collect(maps.Keys(raw.Projects))
+	exists := func(p string) bool { _, err := os.Stat(p); return err == nil }
+	slices.SortFunc(paths, func(a, b string) int {
+		if ea, eb := exists(a), exists(b); ea != eb {
+			if ea {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	// Keyed fingerprints are only comparable with their key
+	state.FingerprintKey = raw.FingerprintKey
+
+	// A suffix may be used once per docker host
+	type hostSuffix struct {
+		host   string
+		suffix int
+	}
+	owners := make(map[hostSuffix]string)
+	for _, path := range paths {
+		entry := raw.Projects[path]
+		suffix := entry.Suffix
+		if !filepath.IsAbs(path) {
+			notes = append(notes, fmt.Sprintf("removed %s: path is not absolute", path))
+			continue
+		}
+		if err := ValidateSuffix(suffix); err != nil {
+			notes = append(notes, fmt.Sprintf("removed %s: %v", path, err))
+			continue
+		}
+		clean := filepath.Clean(path)
+		if existing, dup := state.Projects[clean]; dup {
+			notes = append(notes, fmt.Sprintf("removed %s: duplicate of %s (suffix %d)", path, clean, existing.Suffix))
+			continue
+		}
+		key := hostSuffix{raw.dockerHostOf(path), suffix}
+		if owner, taken := owners[key]; taken {
+			notes = append(notes, fmt.Sprintf("removed %s: suffix %d already belongs to %s; run sailinit there again", path, suffix, owner))
+			continue
+		}
+		if clean != path {
+			notes = append(notes, fmt.Sprintf("normalized %s to %s", path, clean))
+		}
+		state.Projects[clean] = entry
+		owners[key] = clean
+		state.setDockerHost(clean, key.host)
+		if fp, ok := raw.Fingerprints[path]; ok {
+			if state.Fingerprints == nil {
+				state.Fingerprints = make(map[string]Fingerprint)
+			}
+			state.Fingerprints[clean] = fp
+		}
+		if hashes, ok := raw.StepHashes[path]; ok {
+			if state.StepHashes == nil {
+				state.StepHashes = make(map[string]map[string]string)
+			}
+			state.StepHashes[clean] = hashes
+		}
+		if runs, ok := raw.Benchmarks[path]; ok {
+			if state.Benchmarks == nil {
+				state.Benchmarks = make(map[string][]benchRun)
+			}
+			state.Benchmarks[clean] = runs
+		}
+		if scales, ok := raw.Scales[path]; ok {
+			if state.Scales == nil {
+				state.Scales = make(map[string]map[string]int)
+			}
+			state.Scales[clean] = scales
+		}
+		if events, ok := raw.History[path]; ok {
+			if state.History == nil {
+				state.History = make(map[string][]historyEvent)
+			}
+			state.History[clean] = events
+		}
+	}
+
+	for path := range raw.Fingerprints {
+		if _, ok := state.Projects[filepath.Clean(path)]; !ok {
+			notes = append(notes, fmt.Sprintf("dropped fingerprint of unregistered %s", path))
+		}
+	}
+	for path, r := range raw.Reservations {
+		if now.Before(r.Expires) && filepath.IsAbs(path) && ValidateSuffix(r.Suffix) == nil {
+			if _, taken := state.ownerOn(r.DockerHost, path, r.Suffix, now); !taken {
+				if state.Reservations == nil {
+					state.Reservations = make(map[string]suffixReservation)
+				}
+				state.Reservations[path] = r
+				continue
+			}
+		}
+		notes = append(notes, fmt.Sprintf("dropped reservation of suffix %d for %s", r.Suffix, path))
+	}
+
+	for path, e := range state.Projects {
+		state.raiseMaxSuffix(state.dockerHostOf(path), e.Suffix)
+	}
+	var recorded int
+	if fields != nil {
+		json.Unmarshal(fields["max_suffix"], &recorded)
+	}
+	if recorded != state.MaxSuffix {
+		notes = append(notes, fmt.Sprintf("max_suffix %d recomputed as %d", recorded, state.MaxSuffix))
+	}
+	return state, notes
+}
+
+func handleRepairState(args []string) {
+	fs := commandFlags("repair-state")
+	dryRun := fs.Bool("dry-run", false, "Only report what would be repaired")
+	fs.Parse(args)
+
+	path, err := getPortStatePath()
+	if err != nil {
+		exitWithError("Error locating registry", err)
+	}
+	unlock := func() {}
+	if !*dryRun {
+		if unlock, err = lockPortState(); err != nil {
+			exitWithError("Error locking registry", err)
+		}
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		printSuccess("No registry yet; nothing to repair.")
+		return
+	}
+	if err != nil {
+		unlock()
+		exitWithError("Error reading registry", stateError(err))
+	}
+
+	state, notes := repairPortState(data, time.Now())
+	if len(notes) == 0 {
+		printSuccess(fmt.Sprintf("%s is consistent (%d project(s)).", path, len(state.Projects)))
+		return
+	}
+	for _, note := range notes {
+		printWarning("  " + note)
+	}
+	if *dryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would repair %s (%d change(s)).", path, len(notes)))
+		return
+	}
+
+	backup := fmt.Sprintf("%s.bak-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		unlock()
+		exitWithError("Error backing up registry", stateError(err))
+	}
+	if err := state.save(); err != nil {
+		unlock()
+		exitWithError("Error writing registry", stateError(err))
+	}
+	printSuccess(fmt.Sprintf("Repaired %s (%d change(s)); the original is at %s.", path, len(notes), backup))
+}
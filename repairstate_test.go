@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepairPortState(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sailinit-repair-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+	live := filepath.Join(tempDir, "live")
+	os.MkdirAll(live, 0755)
+	gone := filepath.Join(tempDir, "gone")
+
+	data := `{
+  "max_suffix": 900,
+  "projects": {
+    "` + live + `": 50,
+    "` + gone + `": 50,
+    "` + live + `/": 51,
+    "relative/app": 52,
+    "/srv/negative": -3,
+    "/srv/huge": 99999,
+    "/srv/text": "53",
+    "/srv/bad": [1]
+  },
+  "runtimes": {"` + live + `": ["84"], "/srv/other": ["82"]}
+}`
+	state, notes := repairPortState([]byte(data), time.Now())
+
+	want := map[string]int{live: 50, "/srv/text": 53}
+	if len(state.Projects) != len(want) {
+		t.Errorf("Expected %v, got %v", want, state.Projects)
+	}
+	for path, suffix := range want {
+		if state.Projects[path].Suffix != suffix {
+			t.Errorf("Expected %s=%d, got %v", path, suffix, state.Projects)
+		}
+	}
+	if state.MaxSuffix != 53 {
+		t.Errorf("Expected max_suffix 53, got %d", state.MaxSuffix)
+	}
+	if runtimes := state.Projects[live].Runtimes; len(runtimes) != 1 || runtimes[0] != "84" {
+		t.Errorf("Expected the live project's runtimes migrated into its entry, got %v", runtimes)
+	}
+
+	joined := strings.Join(notes, "\n")
+	for _, fragment := range []string{
+		"suffix 50 already belongs to " + live,
+		"duplicate of " + live,
+		"relative/app: path is not absolute",
+		"/srv/negative: suffix must be non-negative",
+		"/srv/huge: suffix 99999 too large",
+		"/srv/bad: suffix [1] is not a number",
+		"dropped runtimes of unregistered /srv/other",
+		"max_suffix 900 recomputed as 53",
+	} {
+		if !strings.Contains(joined, fragment) {
+			t.Errorf("Expected a note containing %q, got:\n%s", fragment, joined)
+		}
+	}
+
+	// A consistent registry needs no changes
+	clean, notes := repairPortState([]byte(`{"max_suffix": 50, "projects": {"`+live+`": 50}}`), time.Now())
+	if len(notes) != 0 || clean.MaxSuffix != 50 {
+		t.Errorf("Expected no notes for a consistent registry, got %v", notes)
+	}
+}
+
+func TestRepairPortStateKeepsEntries(t *testing.T) {
+	data := `{"max_suffix": 51, "projects": {"/srv/shop/": {"suffix": 51, "name": "Shop", "php": "84", "created": "2026-09-01T10:00:00Z"}}}`
+	state, notes := repairPortState([]byte(data), time.Now())
+	e, ok := state.Projects["/srv/shop"]
+	if !ok || e.Suffix != 51 || e.Name != "Shop" || e.PHP != "84" || e.Created.IsZero() {
+		t.Errorf("Expected the entry kept under the normalized path, got %v (%v)", state.Projects, notes)
+	}
+}
+
+func TestRepairPortStateSalvage(t *testing.T) {
+	data := `{"max_suffix": 61, "projects": {"/srv/shop": 60, "/srv/blog": 61,` // truncated
+	state, notes := repairPortState([]byte(data), time.Now())
+	if len(state.Projects) != 2 || state.Projects["/srv/shop"].Suffix != 60 || state.Projects["/srv/blog"].Suffix != 61 {
+		t.Errorf("Expected both entries to be salvaged, got %v", state.Projects)
+	}
+	if state.MaxSuffix != 61 || !strings.Contains(notes[0], "not valid JSON") {
+		t.Errorf("Unexpected result: max %d, notes %v", state.MaxSuffix, notes)
+	}
+}
+
+func TestRepairPortStateReservations(t *testing.T) {
+	now := time.Now()
+	data := `{"max_suffix": 5, "projects": {"/srv/a": 5}, "reservations": {
+		"/srv/b": {"suffix": 6, "expires": "` + now.Add(time.Hour).Format(time.RFC3339) + `"},
+		"/srv/c": {"suffix": 7, "expires": "` + now.Add(-time.Hour).Format(time.RFC3339) + `"},
+		"/srv/d": {"suffix": 5, "expires": "` + now.Add(time.Hour).Format(time.RFC3339) + `"}
+	}}`
+	state, _ := repairPortState([]byte(data), now)
+	if len(state.Reservations) != 1 || state.Reservations["/srv/b"].Suffix != 6 {
+		t.Errorf("Expected only the live, unclaimed reservation, got %v", state.Reservations)
+	}
+}
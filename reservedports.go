@@ -0,0 +1,51 @@
+package main
+
+import "slices"
+
+// reservedPorts returns the ports the config file reserves for services
+// running outside the registry, mapped to their names. A broken config
+// file is reported and otherwise ignored.
+func reservedPorts() map[int]string {
+	cfg := userConfigOrDefaults()
+	reserved := make(map[int]string, len(cfg.ReservedPorts))
+	for name, port := range cfg.ReservedPorts {
+		// Keep the result stable when two names share a port
+		if other, ok := reserved[port]; !ok || name < other {
+			reserved[port] = name
+		}
+	}
+	return reserved
+}
+
+// reservedConflicts returns the ports of bases for suffix that land on a
+// reserved port.
+func reservedConflicts(bases []PortBase, suffix int, reserved map[int]string) []BusyPort {
+	var busy []BusyPort
+	for _, b := range bases {
+		if _, ok := reserved[b.Base+suffix]; ok {
+			busy = append(busy, BusyPort{Name: b.Name, Port: b.Base + suffix})
+		}
+	}
+	return busy
+}
+
+// skipReservedSuffixes returns the lowest suffix from suffix on whose
+// ports avoid reserved.
+func skipReservedSuffixes(suffix int, reserved map[int]string) int {
+	bases := portBases()
+	for len(reservedConflicts(bases, suffix, reserved)) > 0 {
+		suffix++
+	}
+	return suffix
+}
+
+// withReservedConflicts adds the reserved ports of bases for suffix to
+// busy, which holds the ports found in use.
+func withReservedConflicts(busy []BusyPort, bases []PortBase, suffix int, reserved map[int]string) []BusyPort {
+	for _, bp := range reservedConflicts(bases, suffix, reserved) {
+		if !slices.Contains(busy, bp) {
+			busy = append(busy, bp)
+		}
+	}
+	return busy
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReservedPortsFromConfig(t *testing.T) {
+	writeTestConfig(t, "reserved_ports:\n  postgres: 5432\n  grafana: 3000\n  pg: 5432\n")
+	reserved := reservedPorts()
+	if len(reserved) != 2 || reserved[3000] != "grafana" || reserved[5432] != "pg" {
+		t.Errorf("Expected grafana and the alphabetically first name for 5432, got %v", reserved)
+	}
+}
+
+func TestReservedConflicts(t *testing.T) {
+	reserved := map[int]string{5173: "vite", 3306: "mysql"}
+	busy := reservedConflicts(sailPortBases, 73, reserved)
+	if len(busy) != 1 || busy[0] != (BusyPort{"VITE_PORT", 5173}) {
+		t.Errorf("Expected VITE_PORT on 5173, got %v", busy)
+	}
+	if busy := reservedConflicts(sailPortBases, 74, reserved); len(busy) != 0 {
+		t.Errorf("Expected no conflicts for suffix 74, got %v", busy)
+	}
+
+	merged := withReservedConflicts([]BusyPort{{"VITE_PORT", 5173}}, sailPortBases, 6, reserved)
+	if len(merged) != 2 || merged[1] != (BusyPort{"FORWARD_DB_PORT", 3306}) {
+		t.Errorf("Expected the reserved DB port added once, got %v", merged)
+	}
+	if got := skipReservedSuffixes(6, reserved); got != 7 {
+		t.Errorf("Expected 7, got %d", got)
+	}
+}
+
+func TestNextFreeSuffixSkipsReservedPorts(t *testing.T) {
+	state := &PortState{MaxSuffix: 71, Projects: map[string]projectEntry{"/other": {Suffix: 72}}}
+	got, _ := state.nextFreeSuffix("/project", time.Now(), map[int]string{5173: "vite"}, true)
+	if got != 74 {
+		t.Errorf("Expected 74 (72 is taken, 73 puts VITE_PORT on 5173), got %d", got)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// sailDBDefaults are the database settings of a fresh Sail install.
+var sailDBDefaults = map[string]string{
+	"DB_CONNECTION": "mysql",
+	"DB_HOST":       "mysql",
+	"DB_PORT":       "3306",
+	"DB_DATABASE":   "laravel",
+	"DB_USERNAME":   "sail",
+	"DB_PASSWORD":   "password",
+}
+
+// sailDBKeys are the keys of sailDBDefaults in the order Laravel's
+// .env.example lists them.
+var sailDBKeys = []string{"DB_CONNECTION", "DB_HOST", "DB_PORT", "DB_DATABASE", "DB_USERNAME", "DB_PASSWORD"}
+
+// defaultXdebugMode is the SAIL_XDEBUG_MODE sailinit writes.
+const defaultXdebugMode = "develop,debug,coverage"
+
+// dbResetGroups maps the scopes accepted by --reset-db to their keys.
+var dbResetGroups = map[string][]string{
+	"connection":  {"DB_CONNECTION", "DB_HOST", "DB_PORT"},
+	"credentials": {"DB_USERNAME", "DB_PASSWORD"},
+	"database":    {"DB_DATABASE"},
+}
+
+// resetScope is the value of --reset-db: a list of groups from
+// dbResetGroups. The bare flag selects every group.
+type resetScope []string
+
+func allDBResetGroups() resetScope {
+	groups := make(resetScope, 0, len(dbResetGroups))
+	for g := range dbResetGroups {
+		groups = append(groups, g)
+	}
+	slices.Sort(groups)
+	return groups
+}
+
+func (s *resetScope) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *resetScope) Set(value string) error {
+	switch value {
+	case "true", "all":
+		*s = allDBResetGroups()
+		return nil
+	case "false", "":
+		*s = nil
+		return nil
+	}
+
+	var groups resetScope
+	for _, g := range strings.Split(value, ",") {
+		g = strings.TrimSpace(g)
+		if _, ok := dbResetGroups[g]; !ok {
+			return fmt.Errorf("unknown scope %q (use connection, credentials or database)", g)
+		}
+		if !slices.Contains(groups, g) {
+			groups = append(groups, g)
+		}
+	}
+	*s = groups
+	return nil
+}
+
+// IsBoolFlag lets --reset-db be used without a value.
+func (s *resetScope) IsBoolFlag() bool { return true }
+
+// dbUpdates returns the Sail defaults for the keys in scope.
+func (s resetScope) dbUpdates() map[string]string {
+	updates := make(map[string]string)
+	for _, g := range s {
+		for _, key := range dbResetGroups[g] {
+			updates[key] = sailDBDefaults[key]
+		}
+	}
+	return updates
+}
+
+// resetOptions selects the defaults restored by resetEnvDefaults.
+type resetOptions struct {
+	DB     resetScope
+	Ports  bool
+	Xdebug bool
+}
+
+// resetEnvDefaults restores the selected defaults in the project's .env in
+// place, without running the rest of the setup. Ports are derived from the
+// project's registered suffix.
+func resetEnvDefaults(projectDir string, opts resetOptions) error {
+	envPath := filepath.Join(projectDir, ".env")
+	if _, err := os.Stat(envPath); err != nil {
+		return envError(fmt.Errorf(".env not found in %s", projectDir))
+	}
+
+	updates := opts.DB.dbUpdates()
+	var order []string
+	for key := range updates {
+		order = append(order, key)
+	}
+	slices.Sort(order)
+
+	if opts.Ports {
+		suffix, registered, _, err := getSuggestedSuffix(projectDir)
+		if err != nil {
+			return err
+		}
+		if !registered {
+			return fmt.Errorf("no port suffix known for %s (run sailinit first)", projectDir)
+		}
+		for _, b := range projectPortBases(projectDir) {
+			updates[b.Name] = fmt.Sprint(b.Base + suffix)
+			order = append(order, b.Name)
+		}
+	}
+	if opts.Xdebug {
+		updates["SAIL_XDEBUG_MODE"] = defaultXdebugMode
+		order = append(order, "SAIL_XDEBUG_MODE")
+	}
+
+	doc, err := readEnvDocument(envPath)
+	if err != nil {
+		return envError(err)
+	}
+	previousValues := envValues(doc)
+	locks := loadEnvLocks()
+	previous := ""
+	for _, key := range order {
+		current, exists := doc.Get(key)
+		if !locks.skip(key, current, updates[key]) {
+			line := fmt.Sprintf("%s=%s", key, updates[key])
+			// Keep a missing port next to the other ports
+			if exists || previous == "" || !doc.InsertAfter(previous, []string{line}) {
+				doc.Set(key, updates[key])
+			}
+			printInfo(line)
+		}
+		if _, present := doc.Get(key); present && isManagedPortKey(envEntry{Key: key}) {
+			previous = key
+		}
+	}
+	if opts.Ports {
+		for _, line := range syncEnvURLs(doc, previousValues, locks) {
+			printInfo(line)
+		}
+	}
+	if err := doc.write(envPath); err != nil {
+		return envError(err)
+	}
+	if opts.Ports && syncTestingEnabled() {
+		synced, err := syncTestingPorts(projectDir)
+		for _, line := range synced {
+			printInfo(tr("testing_synced", line))
+		}
+		if err != nil {
+			return envError(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResetScopeFlag(t *testing.T) {
+	parse := func(args ...string) (resetScope, error) {
+		var scope resetScope
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(new(strings.Builder))
+		fs.Var(&scope, "reset-db", "")
+		err := fs.Parse(args)
+		return scope, err
+	}
+
+	if scope, err := parse("--reset-db"); err != nil || scope.String() != "connection,credentials,database" {
+		t.Errorf("Expected bare flag to select all groups, got %q (err=%v)", scope.String(), err)
+	}
+	if scope, err := parse("--reset-db=credentials,database"); err != nil || scope.String() != "credentials,database" {
+		t.Errorf("Expected two groups, got %q (err=%v)", scope.String(), err)
+	}
+	if _, err := parse("--reset-db=passwords"); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+}
+
+func TestSetupEnvScopedReset(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sail-reset-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	envPath := filepath.Join(tempDir, ".env")
+	os.WriteFile(envPath, []byte("DB_CONNECTION=pgsql\nDB_HOST=pgsql\nDB_DATABASE=shop\nDB_USERNAME=root\nDB_PASSWORD=secret\n"), 0644)
+
+	if err := setupEnv(tempDir, 55, resetScope{"credentials"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	values := readEnvValues(envPath)
+	if values["DB_USERNAME"] != "sail" || values["DB_PASSWORD"] != "password" {
+		t.Errorf("Expected credentials to be reset, got %v", values)
+	}
+	if values["DB_CONNECTION"] != "pgsql" || values["DB_DATABASE"] != "shop" {
+		t.Errorf("Expected connection and database to be kept, got %v", values)
+	}
+}
+
+func TestResetEnvDefaults(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(projectDir, 0755)
+	envPath := filepath.Join(projectDir, ".env")
+	os.WriteFile(envPath, []byte("APP_NAME=Shop\n"), 0644)
+	if err := resetEnvDefaults(projectDir, resetOptions{Ports: true}); err == nil {
+		t.Error("Expected error for a project without a known suffix")
+	}
+
+	original := "APP_NAME=Shop\nDB_DATABASE=shop\n\nAPP_PORT=9999\nVITE_PORT=1\n\nSAIL_XDEBUG_MODE=off\n"
+	os.WriteFile(envPath, []byte(original), 0644)
+	if err := saveProjectSuffix(projectDir, 57); err != nil {
+		t.Fatal(err)
+	}
+	if err := resetEnvDefaults(projectDir, resetOptions{Ports: true, Xdebug: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(envPath)
+	content := string(data)
+	want := "APP_NAME=Shop\nDB_DATABASE=shop\n\nAPP_PORT=8057\nFORWARD_DB_PORT=3357\nFORWARD_REDIS_PORT=6357\n" +
+		"FORWARD_MEILISEARCH_PORT=7757\nFORWARD_MAILPIT_DASHBOARD_PORT=18157\nFORWARD_MAILPIT_PORT=1057\nVITE_PORT=5157\n\n" +
+		"SAIL_XDEBUG_MODE=develop,debug,coverage\n"
+	if content != want {
+		t.Errorf("Expected ports restored in place:\n%s\ngot:\n%s", want, content)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultDockerRetries is how often a transient docker failure is retried
+// when the config file doesn't set docker_retries.
+const defaultDockerRetries = 2
+
+// maxDockerRetries bounds docker_retries, so a typo can't keep a setup
+// retrying for hours.
+const maxDockerRetries = 10
+
+// retryBaseDelay is the wait before the first retry; it doubles with every
+// further attempt up to retryMaxDelay.
+var (
+	retryBaseDelay = 2 * time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// retrySleep waits between attempts; tests replace it.
+var retrySleep = time.Sleep
+
+// transientDockerMessages are fragments docker, the registry or composer
+// print for failures that tend to pass on their own: a daemon still
+// starting up, a dropped connection or an overloaded registry.
+var transientDockerMessages = []string{
+	"Cannot connect to the Docker daemon",
+	"Is the docker daemon running",
+	"error during connect",
+	"TLS handshake timeout",
+	"i/o timeout",
+	"connection reset by peer",
+	"connection refused",
+	"unexpected EOF",
+	"net/http: request canceled",
+	"Temporary failure in name resolution",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Time-out",
+	"Client.Timeout exceeded",
+	"Could not resolve host",
+	"Operation timed out",
+	"Connection timed out",
+}
+
+// isTransientDockerError reports whether a failed docker or sail command
+// is worth retrying, judged by what it wrote to stderr. Missing binaries,
+// refused pulls and rate limits are not: retrying seconds later fails the
+// same way.
+func isTransientDockerError(stderr string) bool {
+	for _, msg := range slices.Concat(rateLimitMessages, registryAuthMessages) {
+		if strings.Contains(stderr, msg) {
+			return false
+		}
+	}
+	for _, msg := range transientDockerMessages {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay returns the wait before retry number attempt (from 1).
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay << (attempt - 1)
+	if delay > retryMaxDelay || delay <= 0 {
+		return retryMaxDelay
+	}
+	return delay
+}
+
+// dockerRetries returns docker_retries from the config file, or the
+// default. A broken config file is reported and otherwise ignored.
+func dockerRetries() int {
+	if retries := userConfigOrDefaults().DockerRetries; retries != nil {
+		return *retries
+	}
+	return defaultDockerRetries
+}
+
+// validateDockerRetries checks the docker_retries of the config file.
+func validateDockerRetries(retries *int) error {
+	if retries != nil && (*retries < 0 || *retries > maxDockerRetries) {
+		return fmt.Errorf("docker_retries must be between 0 and %d, got %d", maxDockerRetries, *retries)
+	}
+	return nil
+}
+
+// runWithRetry runs the command built by newCmd with its output streamed,
+// and runs a fresh one after a growing delay while it fails transiently,
+// up to docker_retries times. It returns the last attempt's stderr.
+func runWithRetry(newCmd func() *exec.Cmd) (string, error) {
+	retries := dockerRetries()
+	for attempt := 1; ; attempt++ {
+		cmd := newCmd()
+		stderr, err := runCapturingStderr(cmd)
+		if err == nil || attempt > retries || !isTransientDockerError(stderr) {
+			return stderr, err
+		}
+		delay := retryDelay(attempt)
+		printWarning(tr("docker_retry", strings.TrimPrefix(commandKind(cmd), "exec: "), delay, attempt, retries))
+		retrySleep(delay)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsTransientDockerError(t *testing.T) {
+	cases := map[string]bool{
+		"Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?": true,
+		`Get "https://registry-1.docker.io/v2/": net/http: TLS handshake timeout`:                           true,
+		"read tcp 10.0.0.2:5123->104.18.1.1:443: read: connection reset by peer":                            true,
+		"toomanyrequests: You have reached your pull rate limit":                                            false,
+		"pull access denied for sail-8.4/app":                                                               false,
+		"Your requirements could not be resolved to an installable set of packages.":                        false,
+	}
+	for stderr, want := range cases {
+		if got := isTransientDockerError(stderr); got != want {
+			t.Errorf("isTransientDockerError(%q) = %v, want %v", stderr, got, want)
+		}
+	}
+}
+
+func TestRetryDelayBacksOff(t *testing.T) {
+	if retryDelay(1) != 2*time.Second || retryDelay(2) != 4*time.Second || retryDelay(3) != 8*time.Second {
+		t.Errorf("Expected the delay to double, got %v %v %v", retryDelay(1), retryDelay(2), retryDelay(3))
+	}
+	if retryDelay(10) != retryMaxDelay || retryDelay(100) != retryMaxDelay {
+		t.Errorf("Expected the delay to be capped at %v, got %v", retryMaxDelay, retryDelay(10))
+	}
+}
+
+// flakyScript fails with a daemon error until it has run failures times.
+func flakyScript(t *testing.T, failures int) (string, func() int) {
+	t.Helper()
+	dir := t.TempDir()
+	count := filepath.Join(dir, "runs")
+	script := filepath.Join(dir, "docker")
+	os.WriteFile(script, []byte(`#!/bin/sh
+echo x >> "`+count+`"
+if [ "$(wc -l < "`+count+`")" -le `+string(rune('0'+failures))+` ]; then
+  echo "Cannot connect to the Docker daemon" >&2
+  exit 1
+fi
+`), 0755)
+	return script, func() int {
+		data, _ := os.ReadFile(count)
+		return len(data) / 2
+	}
+}
+
+func TestRunWithRetryRecoversFromTransientFailures(t *testing.T) {
+	script, runs := flakyScript(t, 2)
+	if _, err := runWithRetry(func() *exec.Cmd { return exec.Command(script) }); err != nil {
+		t.Errorf("Expected the third attempt to succeed, got %v", err)
+	}
+	if runs() != 3 {
+		t.Errorf("Expected 3 runs, got %d", runs())
+	}
+}
+
+func TestRunWithRetryHonorsConfiguredRetries(t *testing.T) {
+	writeTestConfig(t, "docker_retries: 0\n")
+	script, runs := flakyScript(t, 1)
+	if _, err := runWithRetry(func() *exec.Cmd { return exec.Command(script) }); err == nil {
+		t.Error("Expected the failure without retries")
+	}
+	if runs() != 1 {
+		t.Errorf("Expected a single run, got %d", runs())
+	}
+
+	writeTestConfig(t, "docker_retries: 50\n")
+	if _, err := loadUserConfig(); err == nil {
+		t.Error("Expected an out of range docker_retries to be rejected")
+	}
+}
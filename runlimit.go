@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// recordProjectStarted notes when projectDir's stack was last started, so
+// the running limit stops the least recently used project first.
+func recordProjectStarted(projectDir string, now time.Time) error {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+
+	return updatePortState(func(state *PortState) error {
+		state.updateEntry(absDir, func(e *projectEntry) { e.LastUsed = now.UTC().Truncate(time.Second) })
+		return nil
+	})
+}
+
+// leastRecentlyUsed orders projects by when they were last started, oldest
+// first. Projects never started through sailinit come first.
+func leastRecentlyUsed(projects []string, lastUsed map[string]time.Time) []string {
+	sorted := slices.Clone(projects)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		return lastUsed[a].Compare(lastUsed[b])
+	})
+	return sorted
+}
+
+// projectsOverLimit returns the running projects to stop so that starting
+// one more keeps at most limit stacks running. A limit of 0 disables it.
+func projectsOverLimit(running []string, lastUsed map[string]time.Time, limit int) []string {
+	if limit <= 0 || len(running) < limit {
+		return nil
+	}
+	return leastRecentlyUsed(running, lastUsed)[:len(running)-limit+1]
+}
+
+// confirmRunningLimit enforces the max_running setting before projectDir is
+// started: it offers to stop the least recently used running projects until
+// the new stack fits. Declining only prints a warning.
+func confirmRunningLimit(reader *bufio.Reader, projectDir string, limit int) {
+	if limit <= 0 {
+		return
+	}
+	projects, err := ListProjects()
+	if err != nil {
+		return
+	}
+	running := stacksToStop(projects, projectDir, "", false)
+	lastUsed := make(map[string]time.Time, len(projects))
+	for _, p := range projects {
+		lastUsed[p.Path] = p.LastUsed
+	}
+
+	over := projectsOverLimit(running, lastUsed, limit)
+	if len(over) == 0 {
+		return
+	}
+	printWarning(tr("running_limit", len(running), limit))
+	for _, other := range over {
+		if !askYesNo(reader, tr("running_limit_stop", shortenHome(other))) {
+			printWarning(tr("running_over_limit", limit))
+			return
+		}
+		if err := runSailStop(other); err != nil {
+			printError(fmt.Sprintf("Error stopping %s: %v", other, err))
+			return
+		}
+		printSuccess(tr("project_stopped", shortenHome(other)))
+	}
+}
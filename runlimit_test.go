@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProjectsOverLimit(t *testing.T) {
+	now := time.Now()
+	lastUsed := map[string]time.Time{
+		"/srv/recent": now,
+		"/srv/old":    now.Add(-time.Hour),
+	}
+	running := []string{"/srv/recent", "/srv/old", "/srv/never"}
+
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{limit: 0, want: nil},
+		{limit: 4, want: nil},
+		{limit: 3, want: []string{"/srv/never"}},
+		{limit: 2, want: []string{"/srv/never", "/srv/old"}},
+		{limit: 1, want: []string{"/srv/never", "/srv/old", "/srv/recent"}},
+	}
+	for _, tt := range tests {
+		got := projectsOverLimit(running, lastUsed, tt.limit)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("projectsOverLimit(limit %d) = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+	if running[0] != "/srv/recent" {
+		t.Errorf("Expected the running list to be left unsorted, got %v", running)
+	}
+}
+
+func TestRecordProjectStarted(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(projectDir, 0755)
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC)
+	if err := recordProjectStarted(projectDir, started); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordProjectStarted(filepath.Join(tempDir, "unregistered"), started); err != nil {
+		t.Fatal(err)
+	}
+
+	state, _, err := loadPortState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Projects[projectDir].LastUsed.Equal(started) {
+		t.Errorf("Expected %s, got %s", started, state.Projects[projectDir].LastUsed)
+	}
+	if len(state.Projects) != 1 {
+		t.Errorf("Expected unregistered projects to be ignored, got %v", state.Projects)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// sailComposeCommands are the sail subcommands that pass straight through
+// to docker compose, so they work without Sail installed.
+var sailComposeCommands = []string{"up", "down", "stop", "ps"}
+
+// sailFallbackNoticed is set once the user has been told sail commands run
+// as docker compose.
+var sailFallbackNoticed bool
+
+// hasComposeFile reports whether projectDir has a compose file docker
+// compose would pick up.
+func hasComposeFile(projectDir string) bool {
+	for _, path := range composeFiles(projectDir) {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// sailCommand returns the command running `sail args...` in projectDir.
+// Without vendor/bin/sail, as before composer install completes or with a
+// trimmed vendor directory, the subcommands in sailComposeCommands run as
+// `docker compose` with the environment Sail would set up, provided the
+// project has a compose file. Anything else fails with ErrSailNotFound.
+func sailCommand(projectDir string, args ...string) (*exec.Cmd, error) {
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	if _, err := os.Stat(sailPath); err == nil {
+		cmd := exec.Command(sailPath, args...)
+		cmd.Dir = projectDir
+		cmd.Env = sailEnv(projectDir)
+		return cmd, nil
+	}
+	if len(args) == 0 || !slices.Contains(sailComposeCommands, args[0]) || !hasComposeFile(projectDir) {
+		return nil, sailNotFoundError(sailPath)
+	}
+	if args[0] != "ps" && !sailFallbackNoticed {
+		sailFallbackNoticed = true
+		printInfo(tr("sail_fallback", args[0]))
+	}
+	cmd := exec.Command("docker", append([]string{"compose"}, args...)...)
+	cmd.Dir = projectDir
+	cmd.Env = composeFallbackEnv(projectDir)
+	return cmd, nil
+}
+
+// composeFallbackEnv returns the environment of a docker compose command
+// standing in for sail. Like Sail, it defaults WWWUSER and WWWGROUP to the
+// calling user unless the environment or .env sets them; docker compose
+// reads the rest of .env itself.
+func composeFallbackEnv(projectDir string) []string {
+	env := sailEnv(projectDir)
+	if env == nil {
+		env = os.Environ()
+	}
+	dotenv := readEnvValues(filepath.Join(projectDir, ".env"))
+	for key, id := range map[string]int{"WWWUSER": os.Getuid(), "WWWGROUP": os.Getgid()} {
+		if id < 0 || os.Getenv(key) != "" || dotenv[key] != "" {
+			continue
+		}
+		env = append(env, key+"="+strconv.Itoa(id))
+	}
+	return env
+}
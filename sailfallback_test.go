@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSailCommandFallsBackToCompose(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := sailCommand(dir, "stop"); !errors.Is(err, ErrSailNotFound) {
+		t.Errorf("Expected ErrSailNotFound without sail or a compose file, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services: {}\n"), 0644)
+	if _, err := sailCommand(dir, "artisan", "migrate"); !errors.Is(err, ErrSailNotFound) {
+		t.Errorf("Expected ErrSailNotFound for a command compose can't run, got %v", err)
+	}
+
+	logPath := fakeDocker(t, "")
+	if err := runSailStop(dir); err != nil {
+		t.Fatal(err)
+	}
+	calls, _ := os.ReadFile(logPath)
+	if strings.TrimSpace(string(calls)) != "compose stop" {
+		t.Errorf("Expected docker compose stop, got %q", calls)
+	}
+
+	sailDir := filepath.Join(dir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	os.WriteFile(filepath.Join(sailDir, "sail"), []byte("#!/bin/sh\n"), 0755)
+	cmd, err := sailCommand(dir, "stop")
+	if err != nil || filepath.Base(cmd.Path) != "sail" {
+		t.Errorf("Expected sail itself once installed, got %v (err=%v)", cmd, err)
+	}
+}
+
+func TestComposeFallbackEnvDefaultsWWWUser(t *testing.T) {
+	if os.Getuid() < 0 {
+		t.Skip("no numeric user ids on this platform")
+	}
+	t.Setenv("WWWUSER", "")
+	t.Setenv("WWWGROUP", "")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("WWWGROUP=2000\n"), 0644)
+
+	env := composeFallbackEnv(dir)
+	if !slices.ContainsFunc(env, func(e string) bool { return strings.HasPrefix(e, "WWWUSER=") && e != "WWWUSER=" }) {
+		t.Error("Expected WWWUSER to default to the calling user")
+	}
+	if slices.ContainsFunc(env, func(e string) bool { return strings.HasPrefix(e, "WWWGROUP=") && e != "WWWGROUP=" }) {
+		t.Error("Expected WWWGROUP from .env to be left to docker compose")
+	}
+}
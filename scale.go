@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseScaleArgs parses service=replicas arguments.
+func parseScaleArgs(args []string) (map[string]int, error) {
+	scales := make(map[string]int)
+	for _, arg := range args {
+		name, count, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected service=replicas, got %q", arg)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("replicas for %s must be a non-negative number, got %q", name, count)
+		}
+		scales[name] = n
+	}
+	return scales, nil
+}
+
+// checkScales validates scales against the project's compose services.
+// Scaling a service that publishes a fixed host port beyond one replica is
+// rejected, since every replica would try to bind the same port.
+func checkScales(project *composeProject, scales map[string]int) error {
+	for _, name := range slices.Sorted(maps.Keys(scales)) {
+		svc := project.Service(name)
+		if svc == nil {
+			return fmt.Errorf("no service %q in the compose file (services: %s)", name, strings.Join(project.ServiceNames(), ", "))
+		}
+		if scales[name] <= 1 {
+			continue
+		}
+		for _, p := range svc.Ports {
+			if p.Published != "" {
+				return fmt.Errorf("%s publishes host port %s, so it can't run more than one replica", name, p.Published)
+			}
+		}
+	}
+	return nil
+}
+
+// scaleArgs turns scales into sorted --scale arguments for sail up.
+func scaleArgs(scales map[string]int) []string {
+	var args []string
+	for _, name := range slices.Sorted(maps.Keys(scales)) {
+		args = append(args, "--scale", fmt.Sprintf("%s=%d", name, scales[name]))
+	}
+	return args
+}
+
+// projectScales returns the replica counts recorded for projectDir.
+func projectScales(projectDir string) map[string]int {
+	state, _, err := loadPortState()
+	if err != nil {
+		return nil
+	}
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil
+	}
+	return state.Scales[absDir]
+}
+
+// saveProjectScales merges scales into projectDir's recorded replica
+// counts. A count of 1 is the compose default and is not kept.
+func saveProjectScales(projectDir string, scales map[string]int) error {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+
+	return updatePortState(func(state *PortState) error {
+		if _, ok := state.Projects[absDir]; !ok {
+			return fmt.Errorf("project not registered: %s", absDir)
+		}
+		if state.Scales == nil {
+			state.Scales = make(map[string]map[string]int)
+		}
+		recorded := state.Scales[absDir]
+		if recorded == nil {
+			recorded = make(map[string]int)
+		}
+		for name, n := range scales {
+			if n == 1 {
+				delete(recorded, name)
+			} else {
+				recorded[name] = n
+			}
+		}
+		if len(recorded) == 0 {
+			delete(state.Scales, absDir)
+		} else {
+			state.Scales[absDir] = recorded
+		}
+		return nil
+	})
+}
+
+func handleScale(args []string) {
+	if helpRequested("scale", args) {
+		return
+	}
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+
+	if len(args) == 0 {
+		recorded := projectScales(projectDir)
+		if len(recorded) == 0 {
+			printInfo("No services are scaled; every service runs one replica.")
+			return
+		}
+		for _, name := range slices.Sorted(maps.Keys(recorded)) {
+			fmt.Printf("%s=%d\n", name, recorded[name])
+		}
+		return
+	}
+
+	scales, err := parseScaleArgs(args)
+	if err != nil {
+		exitWithError("Usage: sailinit scale <service=replicas>...", usageError(err))
+	}
+	project, err := loadCompose(projectDir)
+	if err != nil {
+		exitWithError("Error reading compose file", err)
+	}
+	if project == nil {
+		exitWithError("Error reading compose file", usageError(errors.New("no compose file found")))
+	}
+	if err := checkScales(project, scales); err != nil {
+		exitWithError("Cannot scale", usageError(err))
+	}
+	requireWritableState()
+
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
+		exitWithError("Error scaling", sailNotFoundError(sailPath))
+	}
+	cmd := exec.Command(sailPath, append([]string{"up", "-d", "--no-recreate"}, scaleArgs(scales)...)...)
+	cmd.Dir = projectDir
+	cmd.Env = sailEnv(projectDir)
+	err = runStreaming(cmd)
+	recordHistory(projectDir, "scale", strings.Join(args, " "), err)
+	if err != nil {
+		exitWithError("Error scaling", err)
+	}
+
+	if err := saveProjectScales(projectDir, scales); err != nil {
+		exitWithError("Error recording the scale", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(scales)) {
+		printSuccess(fmt.Sprintf("%s scaled to %d replica(s).", name, scales[name]))
+	}
+}
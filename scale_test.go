@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseScaleArgs(t *testing.T) {
+	scales, err := parseScaleArgs([]string{"horizon=3", "queue=0"})
+	if err != nil || scales["horizon"] != 3 || scales["queue"] != 0 || len(scales) != 2 {
+		t.Errorf("Unexpected result %v (err=%v)", scales, err)
+	}
+	for _, bad := range []string{"horizon", "=3", "queue=-1", "queue=two"} {
+		if _, err := parseScaleArgs([]string{bad}); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestCheckScales(t *testing.T) {
+	project, err := parseCompose([]byte(`services:
+  laravel.test:
+    image: sail-8.4/app
+    ports:
+      - '${APP_PORT:-80}:80'
+  queue:
+    image: sail-8.4/app
+  horizon:
+    image: sail-8.4/app
+    ports:
+      - '8080'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkScales(project, map[string]int{"queue": 3, "horizon": 2, "laravel.test": 1}); err != nil {
+		t.Errorf("Expected valid scales, got %v", err)
+	}
+	if err := checkScales(project, map[string]int{"laravel.test": 2}); err == nil || !strings.Contains(err.Error(), "APP_PORT") {
+		t.Errorf("Expected a fixed host port to block scaling, got %v", err)
+	}
+	if err := checkScales(project, map[string]int{"worker": 2}); err == nil || !strings.Contains(err.Error(), "queue") {
+		t.Errorf("Expected an unknown service error listing the services, got %v", err)
+	}
+}
+
+func TestProjectScales(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(projectDir, 0755)
+	if err := saveProjectScales(projectDir, map[string]int{"queue": 2}); err == nil {
+		t.Error("Expected unregistered projects to be rejected")
+	}
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveProjectScales(projectDir, map[string]int{"queue": 2, "horizon": 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProjectScales(projectDir, map[string]int{"horizon": 1}); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(scaleArgs(projectScales(projectDir)), " ")
+	if got != "--scale queue=2" {
+		t.Errorf("Expected only queue to stay scaled, got %q", got)
+	}
+	if args := strings.Join(sailUpCommand(projectDir), " "); args != "up -d --scale queue=2" {
+		t.Errorf("Expected sail up to restore the scale, got %q", args)
+	}
+
+	if err := saveProjectScales(projectDir, map[string]int{"queue": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if state, _, _ := loadPortState(); len(state.Scales) != 0 {
+		t.Errorf("Expected default replica counts to be dropped, got %v", state.Scales)
+	}
+}
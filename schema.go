@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonSchema is the subset of JSON Schema the config files are described
+// with. The same schema is published for editors and checked on load.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	// AdditionalProperties is false for a closed object, or the schema of
+	// a map's values
+	AdditionalProperties any         `json:"additionalProperties,omitempty"`
+	PropertyNames        *jsonSchema `json:"propertyNames,omitempty"`
+	Items                *jsonSchema `json:"items,omitempty"`
+	Enum                 []string    `json:"enum,omitempty"`
+	Pattern              string      `json:"pattern,omitempty"`
+	Minimum              *int        `json:"minimum,omitempty"`
+	Maximum              *int        `json:"maximum,omitempty"`
+}
+
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+func schemaObject(description string, properties map[string]*jsonSchema) *jsonSchema {
+	return &jsonSchema{Type: "object", Description: description, Properties: properties, AdditionalProperties: false}
+}
+
+func schemaMap(description string, keys, values *jsonSchema) *jsonSchema {
+	return &jsonSchema{Type: "object", Description: description, PropertyNames: keys, AdditionalProperties: values}
+}
+
+func schemaString(description, pattern string) *jsonSchema {
+	return &jsonSchema{Type: "string", Description: description, Pattern: pattern}
+}
+
+func schemaInteger(description string, minimum, maximum int) *jsonSchema {
+	return &jsonSchema{Type: "integer", Description: description, Minimum: &minimum, Maximum: &maximum}
+}
+
+func schemaPort(description string) *jsonSchema {
+	return schemaInteger(description, 1, 65535)
+}
+
+// warningsSchema describes suppress_warnings.
+func warningsSchema() *jsonSchema {
+	return &jsonSchema{Type: "array", Description: "Warnings never shown, by ID (W001) or name (port-busy)", Items: &jsonSchema{Type: "string", Enum: warningSelectors()}}
+}
+
+// envKeySchema matches the .env keys the config files name.
+var envKeySchema = schemaString("", portKeyPattern.String())
+
+// lintSchema describes the conventions of sailinit lint.
+func lintSchema() *jsonSchema {
+	return schemaObject("Team conventions sailinit lint checks", map[string]*jsonSchema{
+		"required_env":     {Type: "array", Description: "Keys .env.example must define", Items: envKeySchema},
+		"forbidden_values": schemaMap("Values .env.example must not hold, by key", envKeySchema, &jsonSchema{Type: "array", Items: &jsonSchema{Type: "string"}}),
+		"services":         {Type: "array", Description: "Compose services allowed besides the Sail PHP services", Items: &jsonSchema{Type: "string"}},
+		"php_versions":     {Type: "array", Description: `PHP versions projects may run, e.g. "8.3"`, Items: schemaString("", `^[0-9]\.?[0-9]$`)},
+	})
+}
+
+// userConfigSchema describes the config file and a context's config.yaml.
+func userConfigSchema() *jsonSchema {
+	var sailKeys []string
+	for _, b := range sailPortBases {
+		sailKeys = append(sailKeys, b.Name)
+	}
+	s := schemaObject("sailinit config file", map[string]*jsonSchema{
+		"locked_keys":  {Type: "array", Description: ".env keys sailinit never adds, changes or moves", Items: envKeySchema},
+		"timezone":     schemaString(`Timezone for APP_TIMEZONE and the containers' TZ; "host" uses the host's`, ""),
+		"user":         schemaString("uid:gid mapping for the composer container and Sail: auto, none or uid:gid", `^(auto|none|[0-9]+:[0-9]+)$`),
+		"open_browser": {Type: "boolean", Description: "Open the app URL once setup completes"},
+		"copy_urls":    {Type: "boolean", Description: "Copy the app and Mailpit URLs to the clipboard once setup completes"},
+		"max_running":  {Type: "integer", Description: "How many projects may run at once; 0 means no limit", Minimum: new(int)},
+		"sail_up_args": {Type: "array", Description: "Arguments passed to every sail up -d", Items: &jsonSchema{Type: "string"}},
+		"env":          schemaMap("Values written to every project's .env, as Go templates", envKeySchema, &jsonSchema{Type: "string"}),
+		"reserved_ports": schemaMap("Ports used outside the registry, by name; suggested suffixes avoid them",
+			nil, schemaPort("")),
+		"port_bases": schemaMap("Base ports of the suffix scheme by Sail port key",
+			&jsonSchema{Type: "string", Enum: sailKeys}, schemaPort("")),
+		"env_baseline": schemaObject("The .env.example fetched for projects that have neither .env nor .env.example", map[string]*jsonSchema{
+			"url":    schemaString("Where to download the baseline from", ""),
+			"sha256": schemaString("SHA-256 checksum of the file at url", `^(sha256:)?[0-9a-fA-F]{64}$`),
+		}),
+		"services": schemaMap("Services added to the suffix scheme: .env key to base port",
+			schemaString("", serviceNamePattern.String()), schemaMap("", envKeySchema, schemaPort(""))),
+		"docker_retries":     schemaInteger("How often docker commands are retried after a transient failure", 0, maxDockerRetries),
+		"min_free_disk_gb":   {Type: "integer", Description: "Free space in GB below which composer install and sail up warn; 0 turns the check off", Minimum: new(int)},
+		"language":           {Type: "string", Description: "Language of prompts and messages; SAILINIT_LANG wins", Enum: slices.Sorted(maps.Keys(catalogs))},
+		"pager":              schemaString(`Command long output is shown through, e.g. "less -R"; empty or "cat" turns paging off`, ""),
+		"suppress_warnings":  warningsSchema(),
+		"suffix_strategy":    {Type: "string", Description: "How new suffixes are suggested: fill-gaps reuses the lowest free suffix, increment goes past the highest ever used", Enum: []string{suffixFillGaps, suffixIncrement}},
+		"sync_testing_ports": {Type: "boolean", Description: "Write the project's ports to .env.testing and phpunit.xml too"},
+		"lint":               lintSchema(),
+		"tracing": schemaObject("Where the spans of every command go, as OTLP/HTTP JSON; the OTEL_EXPORTER_OTLP_* variables win", map[string]*jsonSchema{
+			"endpoint": schemaString("Base URL of the collector, e.g. http://localhost:4318", `^https?://`),
+			"headers":  schemaMap("Headers sent with every export, e.g. an API key", nil, &jsonSchema{Type: "string"}),
+		}),
+	})
+	s.Schema = schemaDraft
+	s.Title = "sailinit config"
+	return s
+}
+
+// projectConfigSchema describes .sailinit.yaml. Its services are Sail's
+// and those of the config file.
+func projectConfigSchema() *jsonSchema {
+	services := slices.Clone(sailServices)
+	for _, s := range configuredServices() {
+		services = append(services, s.Name)
+	}
+	s := schemaObject("Per-project sailinit config, committed next to artisan", map[string]*jsonSchema{
+		"name":              schemaString("Name --list and --status show instead of the path", `\S`),
+		"suffix":            schemaInteger("Port suffix every teammate's setup uses", 0, MaxPortSuffix()),
+		"php":               schemaString(`PHP version composer install runs with, e.g. "8.3"`, `^[0-9]\.?[0-9]$`),
+		"services":          {Type: "array", Description: "Sail services the project runs; only their ports are written to .env", Items: &jsonSchema{Type: "string", Enum: services}},
+		"suppress_warnings": warningsSchema(),
+		"lint":              lintSchema(),
+	})
+	s.Schema = schemaDraft
+	s.Title = projectConfigFile
+	return s
+}
+
+// schemaError is a config value that doesn't match its schema.
+type schemaError struct {
+	Line int
+	// Key is the dotted path of the value, e.g. services.minio
+	Key string
+	Msg string
+}
+
+func (e *schemaError) Error() string {
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Key, e.Msg)
+}
+
+// validateYAML checks YAML config contents against s, returning the first
+// mismatch with its line and key.
+func validateYAML(data []byte, s *jsonSchema) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	return s.check(doc.Content[0], "")
+}
+
+// check validates node, found at key, against s.
+func (s *jsonSchema) check(node *yaml.Node, key string) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	fail := func(format string, args ...any) error {
+		name := key
+		if name == "" {
+			name = "(top level)"
+		}
+		return &schemaError{Line: node.Line, Key: name, Msg: fmt.Sprintf(format, args...)}
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+
+	switch s.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			return fail("must be a mapping")
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i].Value, node.Content[i+1]
+			child := joinKey(key, name)
+			if prop, ok := s.Properties[name]; ok {
+				if err := prop.check(value, child); err != nil {
+					return err
+				}
+				continue
+			}
+			values, isMap := s.AdditionalProperties.(*jsonSchema)
+			if !isMap {
+				return &schemaError{Line: node.Content[i].Line, Key: child, Msg: "unknown key (use one of " + strings.Join(slices.Sorted(maps.Keys(s.Properties)), ", ") + ")"}
+			}
+			if s.PropertyNames != nil {
+				if err := s.PropertyNames.check(node.Content[i], child); err != nil {
+					return err
+				}
+			}
+			if err := values.check(value, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			return fail("must be a list")
+		}
+		for i, item := range node.Content {
+			if err := s.Items.check(item, fmt.Sprintf("%s[%d]", key, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return fail("must be a single value")
+	}
+	switch s.Type {
+	case "integer":
+		var n int
+		if node.Tag != "!!int" || node.Decode(&n) != nil {
+			return fail("must be a whole number, got %q", node.Value)
+		}
+		if s.Maximum != nil && (n < *s.Minimum || n > *s.Maximum) {
+			return fail("must be between %d and %d, got %d", *s.Minimum, *s.Maximum, n)
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			return fail("must be at least %d, got %d", *s.Minimum, n)
+		}
+	case "boolean":
+		if node.Tag != "!!bool" {
+			return fail("must be true or false, got %q", node.Value)
+		}
+	case "string":
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, node.Value) {
+			return fail("%q is not one of %s", node.Value, strings.Join(s.Enum, ", "))
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(node.Value) {
+			return fail("%q does not match %s", node.Value, s.Pattern)
+		}
+	}
+	return nil
+}
+
+func joinKey(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// keyLine returns the line of a top-level key in YAML config contents, or
+// 0 when it isn't there.
+func keyLine(data []byte, key string) int {
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return 0
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			return root.Content[i].Line
+		}
+	}
+	return 0
+}
+
+// atKey points err, found by checking the value of a top-level key, at
+// that key's line.
+func atKey(data []byte, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if line := keyLine(data, key); line > 0 {
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	return err
+}
+
+func handleConfig(args []string) {
+	fset := commandFlags("config")
+	project := fset.Bool("project", false, "Print the schema of .sailinit.yaml instead of the config file")
+	fset.Parse(reorderArgs(args))
+	if fset.NArg() != 1 || fset.Arg(0) != "schema" {
+		printError("Usage: sailinit config schema [--project]")
+		exit(ExitUsage)
+	}
+	schema := userConfigSchema()
+	if *project {
+		schema = projectConfigSchema()
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		exitWithError("Error encoding schema", err)
+	}
+	fmt.Println(string(data))
+}
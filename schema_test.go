@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateYAMLAcceptsValidConfig(t *testing.T) {
+	config := `locked_keys: [APP_KEY]
+timezone: host
+user: "1000:1000"
+open_browser: true
+max_running: 2
+sail_up_args: [--build]
+env:
+  APP_URL: "http://{{.Name}}.localhost"
+reserved_ports:
+  postgres: 5432
+port_bases:
+  APP_PORT: 9000
+services:
+  minio:
+    FORWARD_MINIO_PORT: 9100
+docker_retries: 3
+`
+	if err := validateYAML([]byte(config), userConfigSchema()); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+	if err := validateYAML([]byte(""), userConfigSchema()); err != nil {
+		t.Errorf("Expected an empty config to be valid, got %v", err)
+	}
+}
+
+func TestValidateYAMLPointsAtLines(t *testing.T) {
+	tests := []struct {
+		config string
+		want   string
+	}{
+		{"timezone: UTC\nmax_running: many\n", `line 2: max_running: must be a whole number, got "many"`},
+		{"open_browsr: true\n", "line 1: open_browsr: unknown key"},
+		{"services:\n  minio:\n    FORWARD_MINIO_PORT: 70000\n", "line 3: services.minio.FORWARD_MINIO_PORT: must be between 1 and 65535, got 70000"},
+		{"port_bases:\n  WEB_PORT: 9000\n", `line 2: port_bases.WEB_PORT: "WEB_PORT" is not one of APP_PORT`},
+		{"locked_keys:\n  - APP_KEY\n  - app key\n", `line 3: locked_keys[1]: "app key" does not match`},
+		{"env_baseline: https://example.com\n", "line 1: env_baseline: must be a mapping"},
+		{"copy_urls: yes please\n", `line 1: copy_urls: must be true or false`},
+	}
+	for _, tt := range tests {
+		err := validateYAML([]byte(tt.config), userConfigSchema())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("For %q expected %q, got %v", tt.config, tt.want, err)
+		}
+	}
+}
+
+func TestLoadUserConfigReportsLines(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	writeTestConfig(t, "timezone: UTC\nmax_running: -1\n")
+	if _, err := loadUserConfig(); err == nil || !strings.Contains(err.Error(), "line 2: max_running: must be at least 0") {
+		t.Errorf("Expected the schema error with its line, got %v", err)
+	}
+
+	writeTestConfig(t, "timezone: UTC\nenv_baseline:\n  url: https://example.com/.env.example\n")
+	if _, err := loadUserConfig(); err == nil || !strings.Contains(err.Error(), "line 2: env_baseline: sha256") {
+		t.Errorf("Expected the baseline error at its key, got %v", err)
+	}
+}
+
+func TestLoadProjectConfigReportsLines(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	writeProjectConfig(t, dir, "php: \"8.3\"\nservices:\n  - mysql\n  - mysqll\n")
+	if _, err := loadProjectConfig(dir); err == nil || !strings.Contains(err.Error(), `line 4: services[1]: "mysqll" is not one of`) {
+		t.Errorf("Expected the unknown service with its line, got %v", err)
+	}
+
+	writeProjectConfig(t, dir, "php: \"8.3\"\nsuffix: 99999\n")
+	if _, err := loadProjectConfig(dir); err == nil || !strings.Contains(err.Error(), "line 2: suffix: must be between 0 and 47435") {
+		t.Errorf("Expected the suffix range with its line, got %v", err)
+	}
+}
+
+func TestConfigSchemaJSON(t *testing.T) {
+	data, err := json.Marshal(userConfigSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["$schema"] != schemaDraft || schema["additionalProperties"] != false {
+		t.Errorf("Expected a closed draft-07 schema, got %v", schema)
+	}
+	properties := schema["properties"].(map[string]any)
+	if _, ok := properties["docker_retries"]; !ok {
+		t.Errorf("Expected every config key, got %v", properties)
+	}
+	services := properties["services"].(map[string]any)
+	if _, ok := services["additionalProperties"].(map[string]any); !ok {
+		t.Errorf("Expected services to be a map, got %v", services)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+)
+
+// portService is a service of the suffix scheme with the forwarded ports it
+// needs, each an .env key whose value is Base + suffix.
+type portService struct {
+	Name  string
+	Ports []PortBase
+}
+
+// builtinServices are the services of Sail's default stack. Their ports are
+// allocated for every project; a project's .env only gets those of the
+// services it runs.
+var builtinServices = []portService{
+	{"app", []PortBase{{"APP_PORT", 8000}}},
+	{"database", []PortBase{{"FORWARD_DB_PORT", 3300}}},
+	{"redis", []PortBase{{"FORWARD_REDIS_PORT", 6300}}},
+	{"meilisearch", []PortBase{{"FORWARD_MEILISEARCH_PORT", 7700}}},
+	{"mailpit", []PortBase{{"FORWARD_MAILPIT_DASHBOARD_PORT", 18100}, {"FORWARD_MAILPIT_PORT", 1000}}},
+	{"vite", []PortBase{{"VITE_PORT", 5100}}},
+}
+
+// sailPortBases are the forwarded ports Sail reads from .env.
+var sailPortBases = servicePorts(builtinServices)
+
+// servicePorts lists the ports of services in order.
+func servicePorts(services []portService) []PortBase {
+	var ports []PortBase
+	for _, s := range services {
+		ports = append(ports, s.Ports...)
+	}
+	return ports
+}
+
+// servicePortKeys maps compose services to the forwarded ports of Sail's
+// stack they need. APP_PORT and VITE_PORT belong to the app itself.
+var servicePortKeys = map[string][]string{
+	"mysql":       {"FORWARD_DB_PORT"},
+	"mariadb":     {"FORWARD_DB_PORT"},
+	"pgsql":       {"FORWARD_DB_PORT"},
+	"redis":       {"FORWARD_REDIS_PORT"},
+	"meilisearch": {"FORWARD_MEILISEARCH_PORT"},
+	"mailpit":     {"FORWARD_MAILPIT_DASHBOARD_PORT", "FORWARD_MAILPIT_PORT"},
+	"mailhog":     {"FORWARD_MAILPIT_DASHBOARD_PORT", "FORWARD_MAILPIT_PORT"},
+}
+
+var (
+	serviceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+	portKeyPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// validateServices checks the services of the config file: names a compose
+// service could have, .env keys that no other service uses and valid base
+// ports.
+func validateServices(services map[string]map[string]int) error {
+	seen := make(map[string]string)
+	for _, b := range sailPortBases {
+		seen[b.Name] = "Sail"
+	}
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		if !serviceNamePattern.MatchString(name) {
+			return fmt.Errorf("services: invalid service name %q", name)
+		}
+		if len(services[name]) == 0 {
+			return fmt.Errorf("services: %s has no ports", name)
+		}
+		for _, key := range slices.Sorted(maps.Keys(services[name])) {
+			if !portKeyPattern.MatchString(key) {
+				return fmt.Errorf("services: %s: invalid .env key %q", name, key)
+			}
+			if owner, ok := seen[key]; ok {
+				return fmt.Errorf("services: %s: %s is already a port of %s", name, key, owner)
+			}
+			seen[key] = name
+			if base := services[name][key]; base < 1 || base > 65535 {
+				return fmt.Errorf("services: %s: %s must be between 1 and 65535, got %d", name, key, base)
+			}
+		}
+	}
+	return nil
+}
+
+// customServices returns the services of the config file, sorted by name
+// with their ports sorted by key.
+func customServices(services map[string]map[string]int) []portService {
+	var custom []portService
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		s := portService{Name: name}
+		for _, key := range slices.Sorted(maps.Keys(services[name])) {
+			s.Ports = append(s.Ports, PortBase{key, services[name][key]})
+		}
+		custom = append(custom, s)
+	}
+	return custom
+}
+
+// configuredServices returns the services of the config file. A broken
+// config file is reported and otherwise ignored.
+func configuredServices() []portService {
+	return customServices(userConfigOrDefaults().Services)
+}
+
+// withUsedServices drops the ports of configured services projectDir
+// doesn't run: a service counts when .sailinit.yaml lists it or, without
+// such a list, when the compose file defines it.
+func withUsedServices(bases []PortBase, custom []portService, projectDir string, project *projectConfig) []PortBase {
+	used := func(name string) bool {
+		if project != nil && len(project.Services) > 0 {
+			return slices.Contains(project.Services, name)
+		}
+		compose, err := loadCompose(projectDir)
+		return err == nil && compose != nil && slices.Contains(compose.ServiceNames(), name)
+	}
+	for _, s := range custom {
+		if used(s.Name) {
+			continue
+		}
+		bases = slices.DeleteFunc(bases, func(b PortBase) bool {
+			return slices.ContainsFunc(s.Ports, func(p PortBase) bool { return p.Name == b.Name })
+		})
+	}
+	return bases
+}
+
+// withComposeServices drops the forwarded ports of Sail's services that the
+// compose file doesn't define, so .env only names ports the project
+// publishes. Without a readable compose file every port stays.
+func withComposeServices(bases []PortBase, projectDir string) []PortBase {
+	compose, err := loadCompose(projectDir)
+	if err != nil || compose == nil {
+		return bases
+	}
+	names := compose.ServiceNames()
+	needed := make(map[string]bool)
+	for service, keys := range servicePortKeys {
+		for _, key := range keys {
+			needed[key] = needed[key] || slices.Contains(names, service)
+		}
+	}
+	return slices.DeleteFunc(bases, func(b PortBase) bool {
+		wanted, optional := needed[b.Name]
+		return optional && !wanted
+	})
+}
+
+// customServiceLinks lists the ports of configured services that the
+// completion summary doesn't know, as host:port lines.
+func customServiceLinks(env map[string]string, custom []portService, services []string) []serviceLink {
+	var links []serviceLink
+	for _, s := range custom {
+		if !slices.Contains(services, s.Name) {
+			continue
+		}
+		for _, p := range s.Ports {
+			known := slices.ContainsFunc(serviceURLs, func(u serviceURL) bool { return u.PortKey == p.Name })
+			if known || env[p.Name] == "" {
+				continue
+			}
+			label := s.Name
+			if len(s.Ports) > 1 {
+				label += " " + p.Name
+			}
+			links = append(links, serviceLink{label, "localhost:" + env[p.Name]})
+		}
+	}
+	return links
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateServices(t *testing.T) {
+	ok := map[string]map[string]int{"minio": {"FORWARD_MINIO_PORT": 9100, "FORWARD_MINIO_CONSOLE_PORT": 8900}}
+	if err := validateServices(ok); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	cases := map[string]map[string]map[string]int{
+		"already a port of Sail":  {"db": {"FORWARD_DB_PORT": 3400}},
+		"already a port of minio": {"minio": {"FORWARD_MINIO_PORT": 9100}, "s3": {"FORWARD_MINIO_PORT": 9200}},
+		"invalid .env key":        {"reverb": {"reverb-port": 8080}},
+		"must be between 1":       {"reverb": {"REVERB_PORT": 0}},
+		"has no ports":            {"reverb": {}},
+		"invalid service name":    {"Reverb Server": {"REVERB_PORT": 8080}},
+	}
+	for want, services := range cases {
+		if err := validateServices(services); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q for %v, got %v", want, services, err)
+		}
+	}
+}
+
+func TestConfiguredServicesGetPorts(t *testing.T) {
+	writeTestConfig(t, "services:\n  typesense:\n    FORWARD_TYPESENSE_PORT: 8200\n  reverb:\n    FORWARD_REVERB_PORT: 8300\n")
+	bases := portBases()
+	if baseOf(bases, "FORWARD_REVERB_PORT") != 8300 || baseOf(bases, "FORWARD_TYPESENSE_PORT") != 8200 || baseOf(bases, "APP_PORT") != 8000 {
+		t.Errorf("Expected Sail's and the configured ports, got %v", bases)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services:\n  laravel.test:\n    image: sail-8.4/app\n  reverb:\n    image: reverb\n"), 0644)
+	keys := func() []string {
+		var keys []string
+		for _, b := range projectPortBases(dir) {
+			keys = append(keys, b.Name)
+		}
+		return keys
+	}
+	if got := keys(); !slices.Contains(got, "FORWARD_REVERB_PORT") || slices.Contains(got, "FORWARD_TYPESENSE_PORT") {
+		t.Errorf("Expected only the ports of services in the compose file, got %v", got)
+	}
+
+	writeProjectConfig(t, dir, "services: [typesense]\n")
+	if got := keys(); slices.Contains(got, "FORWARD_REVERB_PORT") || !slices.Contains(got, "FORWARD_TYPESENSE_PORT") {
+		t.Errorf("Expected .sailinit.yaml to pick the services, got %v", got)
+	}
+}
+
+func TestCustomServiceLinks(t *testing.T) {
+	custom := customServices(map[string]map[string]int{
+		"minio":  {"FORWARD_MINIO_PORT": 9100},
+		"reverb": {"FORWARD_REVERB_PORT": 8300},
+	})
+	env := map[string]string{"FORWARD_MINIO_PORT": "9112", "FORWARD_REVERB_PORT": "8312"}
+	links := customServiceLinks(env, custom, []string{"laravel.test", "minio", "reverb"})
+	if len(links) != 1 || links[0] != (serviceLink{"reverb", "localhost:8312"}) {
+		t.Errorf("Expected only the port the summary doesn't know, got %v", links)
+	}
+}
+
+func TestComposeServicesLimitSailPorts(t *testing.T) {
+	dir := t.TempDir()
+	if got := withComposeServices(slices.Clone(sailPortBases), dir); len(got) != len(sailPortBases) {
+		t.Errorf("Expected every port without a compose file, got %v", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services:\n  laravel.test:\n    image: sail-8.4/app\n  pgsql:\n    image: postgres\n  mailpit:\n    image: axllent/mailpit\n"), 0644)
+	var keys []string
+	for _, b := range withComposeServices(slices.Clone(sailPortBases), dir) {
+		keys = append(keys, b.Name)
+	}
+	want := []string{"APP_PORT", "FORWARD_DB_PORT", "FORWARD_MAILPIT_DASHBOARD_PORT", "FORWARD_MAILPIT_PORT", "VITE_PORT"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("Expected no Redis or Meilisearch ports, got %v", keys)
+	}
+}
+
+func TestSetupEnvDropsPortsOfMissingServices(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services:\n  laravel.test:\n    image: sail-8.4/app\n  mysql:\n    image: mysql\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("APP_NAME=Shop\nFORWARD_REDIS_PORT=6355\n"), 0644)
+	if err := setupEnv(dir, 55, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ".env"))
+	env := string(data)
+	if strings.Contains(env, "FORWARD_REDIS_PORT") || strings.Contains(env, "FORWARD_MEILISEARCH_PORT") {
+		t.Errorf("Expected no ports of services the compose file lacks, got:\n%s", env)
+	}
+	if !strings.Contains(env, "FORWARD_DB_PORT=3355") || !strings.Contains(env, "APP_PORT=8055") {
+		t.Errorf("Expected the app and database ports, got:\n%s", env)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// dbSQLite is the --db value that sets a project up with SQLite instead of
+// a database container.
+const dbSQLite = "sqlite"
+
+// sqliteDatabase is the file Laravel's sqlite connection uses when
+// DB_DATABASE is not set.
+const sqliteDatabase = "database/database.sqlite"
+
+// sqliteUnusedKeys are the server settings a SQLite connection does not
+// need. DB_DATABASE has to go as well, since it would name the file.
+var sqliteUnusedKeys = []string{"DB_HOST", "DB_PORT", "DB_DATABASE", "DB_USERNAME", "DB_PASSWORD"}
+
+// serverDBServices are the compose services that run a database server.
+var serverDBServices = []string{"mysql", "mariadb", "pgsql", "sqlsrv"}
+
+// parseDBMode validates the --db flag.
+func parseDBMode(value string) (string, error) {
+	switch value {
+	case "", dbSQLite:
+		return value, nil
+	}
+	return "", fmt.Errorf("unsupported --db %q (only sqlite is supported; leave it out for the Sail database service)", value)
+}
+
+// usesSQLite reports whether env selects SQLite and services (nil when
+// there is no compose file) run no database server, so there is no
+// database port to forward.
+func usesSQLite(env map[string]string, services []string) bool {
+	if env["DB_CONNECTION"] != dbSQLite {
+		return false
+	}
+	return !slices.ContainsFunc(services, func(s string) bool { return slices.Contains(serverDBServices, s) })
+}
+
+// projectUsesSQLite runs usesSQLite on projectDir's .env and compose file.
+func projectUsesSQLite(projectDir string) bool {
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	var services []string
+	if project, err := loadCompose(projectDir); err == nil && project != nil {
+		services = project.ServiceNames()
+	}
+	return usesSQLite(env, services)
+}
+
+// withoutDBPort drops FORWARD_DB_PORT from bases.
+func withoutDBPort(bases []PortBase) []PortBase {
+	return slices.DeleteFunc(slices.Clone(bases), func(b PortBase) bool { return b.Name == "FORWARD_DB_PORT" })
+}
+
+// setupPortBases returns the ports setup allocates for projectDir with the
+// --db mode db. A database service left in the compose file keeps its port,
+// since it would otherwise publish Sail's default one.
+func setupPortBases(projectDir, db string) []PortBase {
+	bases := projectPortBases(projectDir)
+	if db == dbSQLite && composeDBService(projectDir) == "" {
+		bases = withoutDBPort(bases)
+	}
+	return bases
+}
+
+// configureSQLite switches projectDir's .env to SQLite: DB_CONNECTION is
+// set, the server settings are commented out, and the database file is
+// created when missing. FORWARD_DB_PORT goes too unless the compose file
+// still runs a database service. Locked keys are left alone.
+func configureSQLite(projectDir string) error {
+	envPath := filepath.Join(projectDir, ".env")
+	doc, err := readEnvDocument(envPath)
+	if err != nil {
+		return err
+	}
+	locks := loadEnvLocks()
+	current, _ := doc.Get("DB_CONNECTION")
+	if !locks.skip("DB_CONNECTION", current, dbSQLite) {
+		doc.Set("DB_CONNECTION", dbSQLite)
+	}
+	unused := slices.Clone(sqliteUnusedKeys)
+	if composeDBService(projectDir) == "" {
+		unused = append(unused, "FORWARD_DB_PORT")
+	}
+	for _, key := range unused {
+		if current, ok := doc.Get(key); ok && !locks.skip(key, current, "") {
+			doc.Comment(key)
+		}
+	}
+	if err := doc.write(envPath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(projectDir, sqliteDatabase)
+	if _, err := os.Stat(dbPath); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return err
+	}
+	printInfo(fmt.Sprintf("Creating %s...", sqliteDatabase))
+	f, err := os.OpenFile(dbPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// composeDBService returns the database server service in projectDir's
+// compose file, or "" when it runs none.
+func composeDBService(projectDir string) string {
+	project, err := loadCompose(projectDir)
+	if err != nil || project == nil {
+		return ""
+	}
+	for _, name := range serverDBServices {
+		if project.Service(name) != nil {
+			return name
+		}
+	}
+	return ""
+}
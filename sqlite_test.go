@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDBMode(t *testing.T) {
+	for _, ok := range []string{"", "sqlite"} {
+		if _, err := parseDBMode(ok); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", ok, err)
+		}
+	}
+	if _, err := parseDBMode("mysql"); err == nil {
+		t.Error("Expected mysql to be rejected")
+	}
+}
+
+func TestUsesSQLite(t *testing.T) {
+	env := map[string]string{"DB_CONNECTION": "sqlite"}
+	if !usesSQLite(env, nil) || !usesSQLite(env, []string{"laravel.test", "mailpit"}) {
+		t.Error("Expected SQLite without a database service")
+	}
+	if usesSQLite(env, []string{"laravel.test", "mysql"}) {
+		t.Error("Expected a mysql service to keep the database port")
+	}
+	if usesSQLite(map[string]string{"DB_CONNECTION": "mysql"}, nil) {
+		t.Error("Expected mysql not to be SQLite")
+	}
+}
+
+func TestConfigureSQLite(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+	tempDir := t.TempDir()
+	envPath := filepath.Join(tempDir, ".env")
+	os.WriteFile(envPath, []byte("APP_NAME=Shop\nDB_CONNECTION=mysql\nDB_HOST=mysql\nDB_PORT=3306\nDB_DATABASE=laravel\nDB_USERNAME=sail\nDB_PASSWORD=password\n\nAPP_PORT=8055\nFORWARD_DB_PORT=3355\n"), 0644)
+
+	if err := configureSQLite(tempDir); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(envPath)
+	want := "APP_NAME=Shop\nDB_CONNECTION=sqlite\n# DB_HOST=mysql\n# DB_PORT=3306\n# DB_DATABASE=laravel\n# DB_USERNAME=sail\n# DB_PASSWORD=password\n\nAPP_PORT=8055\n# FORWARD_DB_PORT=3355\n"
+	if string(data) != want {
+		t.Errorf("Unexpected .env:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, sqliteDatabase)); err != nil {
+		t.Errorf("Expected the database file to be created: %v", err)
+	}
+	if !projectUsesSQLite(tempDir) {
+		t.Error("Expected the project to use SQLite")
+	}
+	if slices.ContainsFunc(projectPortBases(tempDir), func(b PortBase) bool { return b.Name == "FORWARD_DB_PORT" }) {
+		t.Error("Expected no database port for a SQLite project")
+	}
+
+	// Running setup again neither brings the port back nor complains
+	if err := setupEnv(tempDir, 55, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if values := readEnvValues(envPath); values["FORWARD_DB_PORT"] != "" || values["DB_CONNECTION"] != "sqlite" {
+		t.Errorf("Expected SQLite settings to survive setup, got %v", values)
+	}
+}
+
+func TestSetupPortBasesKeepsComposeDatabase(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "docker-compose.yml"), []byte(mysqlCompose), 0644)
+
+	if !slices.ContainsFunc(setupPortBases(tempDir, dbSQLite), func(b PortBase) bool { return b.Name == "FORWARD_DB_PORT" }) {
+		t.Error("Expected the mysql service to keep its port")
+	}
+	if composeDBService(tempDir) != "mysql" {
+		t.Errorf("Expected the mysql service, got %q", composeDBService(tempDir))
+	}
+	if len(setupPortBases(t.TempDir(), dbSQLite)) != len(sailPortBases)-1 {
+		t.Error("Expected SQLite mode to skip the database port")
+	}
+}
+
+func TestMissingExampleKeysSQLite(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+	tempDir := t.TempDir()
+	envPath := filepath.Join(tempDir, ".env")
+	examplePath := filepath.Join(tempDir, ".env.example")
+	os.WriteFile(examplePath, []byte("DB_CONNECTION=mysql\nDB_HOST=127.0.0.1\nDB_DATABASE=laravel\nMAIL_MAILER=log\n"), 0644)
+	os.WriteFile(envPath, []byte("DB_CONNECTION=sqlite\n"), 0644)
+
+	missing, err := missingExampleKeys(envPath, examplePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0].Key != "MAIL_MAILER" {
+		t.Errorf("Expected only MAIL_MAILER to be missing, got %v", missing)
+	}
+}
+
+func TestCompletionSummarySQLite(t *testing.T) {
+	links := completionSummary(map[string]string{"DB_CONNECTION": "sqlite"}, []string{"laravel.test"})
+	if last := links[len(links)-1]; last.Value != "sqlite:database/database.sqlite" {
+		t.Errorf("Expected the SQLite file, got %v", last)
+	}
+}
+
+func TestNewProjectURL(t *testing.T) {
+	if url := newProjectURL("blog", ""); !strings.HasSuffix(url, "/blog?with=mysql") {
+		t.Errorf("Unexpected URL %s", url)
+	}
+	if url := newProjectURL("blog", dbSQLite); !strings.HasSuffix(url, "/blog?with=mailpit") {
+		t.Errorf("Unexpected URL %s", url)
+	}
+}
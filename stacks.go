@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// composeWorkingDirLabel is set by docker compose on every container it
+// creates, so a project's containers can be found even after its
+// directory is gone.
+const composeWorkingDirLabel = "com.docker.compose.project.working_dir"
+
+// runningStack is what docker reports running for a project directory.
+type runningStack struct {
+	// Project is the compose project name, used to take the stack down
+	// without its compose file
+	Project    string
+	Containers []string
+}
+
+// parseRunningStack parses `docker ps` lines of "project\tname".
+func parseRunningStack(output string) runningStack {
+	var stack runningStack
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		project, name, ok := strings.Cut(line, "\t")
+		if !ok || name == "" {
+			continue
+		}
+		stack.Project = project
+		stack.Containers = append(stack.Containers, name)
+	}
+	return stack
+}
+
+// findRunningStack returns the containers docker compose started from
+// projectDir that are still running. Docker being unavailable counts as
+// nothing running.
+func findRunningStack(projectDir string) runningStack {
+	out, err := commandOutput(exec.Command("docker", "ps",
+		"--filter", "label="+composeWorkingDirLabel+"="+projectDir,
+		"--format", `{{.Label "com.docker.compose.project"}}`+"\t{{.Names}}",
+	))
+	if err != nil {
+		return runningStack{}
+	}
+	return parseRunningStack(string(out))
+}
+
+// takeStackDown removes a running stack: through sail when the project is
+// still installed, otherwise by compose project name.
+func takeStackDown(projectDir string, stack runningStack) error {
+	if _, err := os.Stat(filepath.Join(projectDir, "vendor", "bin", "sail")); err == nil {
+		return runSailDown(projectDir)
+	}
+	printInfo(fmt.Sprintf("Running docker compose -p %s down...", stack.Project))
+	return runStreaming(exec.Command("docker", "compose", "-p", stack.Project, "down"))
+}
+
+// confirmStackDown makes sure projectDir has no running containers before
+// its registry entry is dropped, offering to take them down. It returns
+// false when the entry should be kept; force drops it regardless.
+func confirmStackDown(reader *bufio.Reader, projectDir string, force bool) bool {
+	stack := findRunningStack(projectDir)
+	if len(stack.Containers) == 0 {
+		return true
+	}
+	printWarning(tr("stack_running", shortenHome(projectDir), strings.Join(stack.Containers, ", ")))
+	if force {
+		return true
+	}
+	if !askYesNo(reader, tr("stack_down_prompt")) {
+		return false
+	}
+	if err := takeStackDown(projectDir, stack); err != nil {
+		printError(fmt.Sprintf("Error taking %s down: %v", projectDir, err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRunningStack(t *testing.T) {
+	stack := parseRunningStack("shop\tshop-laravel.test-1\nshop\tshop-mysql-1\n")
+	if stack.Project != "shop" || strings.Join(stack.Containers, ",") != "shop-laravel.test-1,shop-mysql-1" {
+		t.Errorf("Unexpected stack %+v", stack)
+	}
+	if stack := parseRunningStack(""); len(stack.Containers) != 0 {
+		t.Errorf("Expected no containers, got %+v", stack)
+	}
+}
+
+// fakeDocker puts a docker script on PATH that prints psOutput for ps and
+// appends every other invocation to a log, which is returned.
+func fakeDocker(t *testing.T, psOutput string) string {
+	t.Helper()
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, "calls.txt")
+	os.WriteFile(filepath.Join(binDir, "ps.txt"), []byte(psOutput), 0644)
+	script := "#!/bin/sh\nif [ \"$1\" = ps ]; then cat " + filepath.Join(binDir, "ps.txt") + "; else echo \"$@\" >> " + logPath + "; fi\n"
+	os.WriteFile(filepath.Join(binDir, "docker"), []byte(script), 0755)
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+func TestConfirmStackDown(t *testing.T) {
+	projectDir := filepath.Join(t.TempDir(), "deleted")
+
+	fakeDocker(t, "")
+	if !confirmStackDown(bufio.NewReader(strings.NewReader("")), projectDir, false) {
+		t.Error("Expected a stopped project to be droppable")
+	}
+
+	logPath := fakeDocker(t, "shop\tshop-mysql-1\n")
+	if confirmStackDown(bufio.NewReader(strings.NewReader("n\n")), projectDir, false) {
+		t.Error("Expected declining to keep the project")
+	}
+	if !confirmStackDown(bufio.NewReader(strings.NewReader("")), projectDir, true) {
+		t.Error("Expected --force to drop the project")
+	}
+	if _, err := os.Stat(logPath); err == nil {
+		t.Error("Expected nothing to be taken down so far")
+	}
+
+	if !confirmStackDown(bufio.NewReader(strings.NewReader("y\n")), projectDir, false) {
+		t.Error("Expected the project to be droppable once taken down")
+	}
+	calls, _ := os.ReadFile(logPath)
+	if strings.TrimSpace(string(calls)) != "compose -p shop down" {
+		t.Errorf("Expected the stack to be taken down by project name, got %q", calls)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errLockBusy is returned by tryLock when another process holds the lock.
+var errLockBusy = errors.New("lock is held by another process")
+
+// stateLockTimeout bounds how long a command waits for another sailinit
+// to finish updating the registry.
+var stateLockTimeout = 30 * time.Second
+
+// lockPortState takes the registry lock, waiting while another sailinit
+// holds it. The returned function releases it.
+func lockPortState() (func(), error) {
+	if reason := stateReadOnlyReason(); reason != "" {
+		return nil, readOnlyError(reason)
+	}
+	path, err := getPortStatePath()
+	if err != nil {
+		return nil, err
+	}
+	defer profileSpan("state: lock wait")()
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(stateLockTimeout)
+	for {
+		unlock, err := tryLock(lockPath)
+		if err == nil {
+			return unlock, nil
+		}
+		if !errors.Is(err, errLockBusy) {
+			return nil, stateError(err)
+		}
+		if time.Now().After(deadline) {
+			return nil, stateError(fmt.Errorf("timed out waiting for %s; is another sailinit running?", lockPath))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// updatePortState loads the registry, lets fn change it and saves it, all
+// under the registry lock so concurrent commands can't lose each other's
+// updates. Nothing is saved when fn fails.
+func updatePortState(fn func(state *PortState) error) error {
+	unlock, err := lockPortState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, _, err := loadPortState()
+	if err != nil {
+		return err
+	}
+	if err := fn(state); err != nil {
+		return err
+	}
+	return state.save()
+}
@@ -0,0 +1,28 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// staleLockAge is when a lock file left behind by a crashed process is
+// considered abandoned.
+const staleLockAge = 2 * time.Minute
+
+// tryLock creates path exclusively where flock is unavailable.
+func tryLock(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+				os.Remove(path)
+			}
+			return nil, errLockBusy
+		}
+		return nil, err
+	}
+	f.Close()
+	return func() { os.Remove(path) }, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdatePortStateConcurrent(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := saveProjectSuffix(filepath.Join(tempDir, fmt.Sprintf("p%d", i)), i+1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	state, _, err := loadPortState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Projects) != 20 || state.MaxSuffix != 20 {
+		t.Errorf("Expected every concurrent registration to survive, got %d projects, max %d", len(state.Projects), state.MaxSuffix)
+	}
+}
+
+func TestClaimSuggestedSuffixConcurrent(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+	saveProjectSuffix(filepath.Join(tempDir, "existing"), 10)
+
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			suffix, _, _, err := claimSuggestedSuffix(filepath.Join(tempDir, fmt.Sprintf("new%d", i)))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[suffix] {
+				t.Errorf("Suffix %d was suggested twice", suffix)
+			}
+			seen[suffix] = true
+		}()
+	}
+	wg.Wait()
+	for suffix := 11; suffix <= 20; suffix++ {
+		if !seen[suffix] {
+			t.Errorf("Expected suffix %d to be suggested, got %v", suffix, seen)
+		}
+	}
+
+	// The same project keeps its reservation
+	first, _, _, _ := claimSuggestedSuffix(filepath.Join(tempDir, "new0"))
+	again, _, _, _ := claimSuggestedSuffix(filepath.Join(tempDir, "new0"))
+	if first != again {
+		t.Errorf("Expected a stable suggestion, got %d then %d", first, again)
+	}
+}
+
+func TestClaimSuffix(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+	a := filepath.Join(tempDir, "a")
+	b := filepath.Join(tempDir, "b")
+
+	if _, taken, err := claimSuffix(a, 30); err != nil || taken {
+		t.Fatalf("Expected to reserve 30, got taken=%v err=%v", taken, err)
+	}
+	owner, taken, err := claimSuffix(b, 30)
+	if err != nil || !taken || owner != a {
+		t.Errorf("Expected 30 to be reserved by %s, got %q taken=%v err=%v", a, owner, taken, err)
+	}
+	if _, inUse := isSuffixInUseByOther(b, 30); !inUse {
+		t.Error("Expected a reservation to count as in use")
+	}
+
+	// Registering turns the reservation into a registration
+	saveProjectSuffix(a, 30)
+	state, _, _ := loadPortState()
+	if _, ok := state.Reservations[a]; ok {
+		t.Error("Expected the reservation to be dropped once registered")
+	}
+
+	// Expired reservations no longer block
+	state.Reservations = map[string]suffixReservation{a: {Suffix: 31, Expires: time.Now().Add(-time.Minute)}}
+	state.save()
+	if _, taken, _ := claimSuffix(b, 31); taken {
+		t.Error("Expected an expired reservation to be ignored")
+	}
+}
+
+func TestChooseSuffixRequested(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+	a := filepath.Join(tempDir, "a")
+	b := filepath.Join(tempDir, "b")
+	saveProjectSuffix(a, 40)
+	t.Cleanup(func() { requestedSuffix = -1 })
+
+	requestedSuffix = 41
+	// No input: a prompt would read an empty answer and keep the suggestion
+	reader := bufio.NewReader(strings.NewReader(""))
+	if got, err := chooseSuffix(reader, b); err != nil || got != 41 {
+		t.Errorf("Expected --suffix 41 to be claimed, got %d (err=%v)", got, err)
+	}
+	requestedSuffix = 40
+	if _, err := chooseSuffix(reader, b); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Errorf("Expected a taken --suffix to fail, got %v", err)
+	}
+}
+
+func TestLockPortStateTimeout(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	unlock, err := lockPortState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	orig := stateLockTimeout
+	stateLockTimeout = 100 * time.Millisecond
+	defer func() { stateLockTimeout = orig }()
+
+	err = updatePortState(func(*PortState) error { return nil })
+	if err == nil || exitCode(err) != ExitState {
+		t.Errorf("Expected a state error while the lock is held, got %v", err)
+	}
+}
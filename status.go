@@ -0,0 +1,295 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ProjectStatus is the state of one project: its registration, forwarded
+// ports, containers and URLs. It backs --status and `sailinit status`,
+// whose --json output is meant for editor plugins and other tools.
+type ProjectStatus struct {
+	// Name is the friendly name from .sailinit.yaml or the directory name
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Registered bool   `json:"registered"`
+	Suffix     int    `json:"suffix"`
+	Exists     bool   `json:"exists"`
+	// PHP is the PHP version of the last setup
+	PHP string `json:"php,omitempty"`
+	// Created and LastUsed are when the project was registered and its
+	// stack last started, if known
+	Created  *time.Time `json:"created,omitempty"`
+	LastUsed *time.Time `json:"last_used,omitempty"`
+	// Sail reports whether vendor/bin/sail is installed
+	Sail bool `json:"sail"`
+	// Ports are the forwarded ports from .env, keyed by variable
+	Ports    map[string]int  `json:"ports"`
+	Services []ServiceStatus `json:"services"`
+	Running  int             `json:"running"`
+	// Healthy is set when at least one container runs and all of them are
+	// running and, where they have a healthcheck, healthy
+	Healthy bool         `json:"healthy"`
+	URLs    []ServiceURL `json:"urls"`
+	// Vite is the dev server started with `sailinit vite start`, if running
+	Vite *ViteStatus `json:"vite,omitempty"`
+	// Error explains why the containers could not be inspected
+	Error string `json:"error,omitempty"`
+}
+
+// ServiceStatus is one compose service's container.
+type ServiceStatus struct {
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Health string `json:"health,omitempty"`
+}
+
+// ServiceURL is an address the project exposes.
+type ServiceURL struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// Status inspects the project at projectPath. Container problems are
+// reported in the result's Error field; an error is returned only when the
+// path or the registry cannot be read.
+func Status(projectPath string) (ProjectStatus, error) {
+	absDir, err := filepath.Abs(projectPath)
+	if err != nil {
+		return ProjectStatus{}, err
+	}
+	state, _, err := loadPortState()
+	if err != nil {
+		return ProjectStatus{}, err
+	}
+	return state.status(absDir), nil
+}
+
+// status inspects the project at absDir against an already loaded
+// registry.
+func (s *PortState) status(absDir string) ProjectStatus {
+	st := ProjectStatus{Path: absDir, Ports: make(map[string]int)}
+	entry, registered := s.Projects[absDir]
+	st.Suffix, st.Registered = entry.Suffix, registered
+	st.Name, st.PHP = entry.displayName(absDir), entry.PHP
+	if !entry.Created.IsZero() {
+		st.Created = &entry.Created
+	}
+	if !entry.LastUsed.IsZero() {
+		st.LastUsed = &entry.LastUsed
+	}
+	if _, err := os.Stat(absDir); err != nil {
+		return st
+	}
+	st.Exists = true
+	if p, ok := runningVite(s, absDir); ok {
+		st.Vite = &ViteStatus{PID: p.PID, Port: p.Port}
+	}
+
+	st.Ports = forwardedPorts(absDir, st.Suffix, st.Registered)
+	for _, link := range projectSummary(absDir, nil) {
+		st.URLs = append(st.URLs, ServiceURL{Label: link.Label, URL: link.Value})
+	}
+
+	if _, err := os.Stat(filepath.Join(absDir, "vendor", "bin", "sail")); err == nil {
+		st.Sail = true
+	} else if !hasComposeFile(absDir) {
+		return st
+	}
+	states, err := containerStates(absDir)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.Healthy = len(states) > 0
+	for _, c := range states {
+		st.Services = append(st.Services, ServiceStatus{Name: c.Service, State: c.State, Health: c.Health})
+		if c.State == "running" {
+			st.Running++
+		}
+		if !c.ready() {
+			st.Healthy = false
+		}
+	}
+	return st
+}
+
+// forwardedPorts returns the ports projectDir forwards, keyed by variable:
+// the .env value, or for a registered project the suffix-based default.
+func forwardedPorts(projectDir string, suffix int, registered bool) map[string]int {
+	ports := make(map[string]int)
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	for _, b := range projectPortBases(projectDir) {
+		if port, err := strconv.Atoi(env[b.Name]); err == nil {
+			ports[b.Name] = port
+		} else if registered {
+			ports[b.Name] = b.Base + suffix
+		}
+	}
+	return ports
+}
+
+// containerSummary describes a project's containers in one short phrase.
+func containerSummary(st ProjectStatus) string {
+	switch {
+	case !st.Exists:
+		return colorize(colorRed, "[X] Missing")
+	case !st.Sail && st.Running == 0:
+		return "no sail"
+	case st.Error != "":
+		return "unknown"
+	case st.Running == 0:
+		return colorize(colorDim, "stopped")
+	}
+	return colorize(colorGreen, fmt.Sprintf("%d running", st.Running))
+}
+
+// viteSummary describes a project's managed Vite dev server.
+func viteSummary(st ProjectStatus) string {
+	if st.Vite == nil {
+		return colorize(colorDim, "stopped")
+	}
+	return colorize(colorGreen, fmt.Sprintf("running on %d", st.Vite.Port))
+}
+
+// registeredStatuses returns the status of every registered project,
+// ordered by suffix.
+func registeredStatuses() ([]ProjectStatus, error) {
+	state, _, err := loadPortState()
+	if err != nil {
+		return nil, err
+	}
+	paths := slices.Collect(maps.Keys(state.Projects))
+	slices.SortFunc(paths, func(a, b string) int {
+		return cmp.Or(cmp.Compare(state.Projects[a].Suffix, state.Projects[b].Suffix), strings.Compare(a, b))
+	})
+	statuses := make([]ProjectStatus, 0, len(paths))
+	for _, path := range paths {
+		statuses = append(statuses, state.status(path))
+	}
+	return statuses, nil
+}
+
+func showProjectStatus() error {
+	statuses, err := registeredStatuses()
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		printInfo("No registered projects found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		colorize(colorBold, "Project"),
+		colorize(colorBold, "Suffix"),
+		colorize(colorBold, "App Port"),
+		colorize(colorBold, "Containers"),
+		colorize(colorBold, "Vite"),
+		colorize(colorBold, "Path"),
+	)
+	app := basePort("APP_PORT")
+	for _, st := range statuses {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n",
+			st.Name,
+			st.Suffix,
+			app+st.Suffix,
+			containerSummary(st),
+			viteSummary(st),
+			shortenHome(st.Path),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+// showProjectDetail prints one project's containers and URLs.
+func showProjectDetail(st ProjectStatus) {
+	printHeader(fmt.Sprintf("%s (%s)", st.Name, shortenHome(st.Path)))
+	if st.Registered {
+		fmt.Printf("Suffix:     %d\n", st.Suffix)
+	} else {
+		fmt.Println("Suffix:     not registered")
+	}
+	if st.PHP != "" {
+		fmt.Printf("PHP:        %s\n", st.PHP)
+	}
+	if st.Created != nil {
+		fmt.Printf("Registered: %s\n", st.Created.Local().Format(time.DateTime))
+	}
+	if st.LastUsed != nil {
+		fmt.Printf("Last used:  %s\n", st.LastUsed.Local().Format(time.DateTime))
+	}
+	fmt.Printf("Containers: %s\n", containerSummary(st))
+	fmt.Printf("Vite:       %s\n", viteSummary(st))
+	if st.Error != "" {
+		printWarning(st.Error)
+	}
+	for _, s := range st.Services {
+		c := containerState{Service: s.Name, State: s.State, Health: s.Health}
+		fmt.Printf("  %s: %s\n", s.Name, c.status())
+	}
+	var links []serviceLink
+	for _, u := range st.URLs {
+		links = append(links, serviceLink{u.Label, u.URL})
+	}
+	fmt.Print(formatSummary(links))
+}
+
+func handleStatus(args []string) {
+	fset := commandFlags("status")
+	asJSON := fset.Bool("json", false, "Print the status as JSON")
+	fset.Parse(reorderArgs(args))
+	if !*asJSON {
+		defer startPager()()
+	}
+
+	var result any
+	if fset.NArg() > 0 {
+		projects, err := listRegisteredProjects()
+		if err != nil {
+			exitWithError("Error reading registry", err)
+		}
+		target, err := resolveSwitchTarget(projects, fset.Arg(0))
+		if err != nil {
+			exitWithError("Error resolving project", err)
+		}
+		st, err := Status(target)
+		if err != nil {
+			exitWithError("Error reading status", err)
+		}
+		if !*asJSON {
+			showProjectDetail(st)
+			return
+		}
+		result = st
+	} else {
+		if !*asJSON {
+			if err := showProjectStatus(); err != nil {
+				exitWithError("Error showing status", err)
+			}
+			return
+		}
+		statuses, err := registeredStatuses()
+		if err != nil {
+			exitWithError("Error reading status", err)
+		}
+		result = statuses
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		exitWithError("Error encoding status", err)
+	}
+	fmt.Println(string(data))
+}
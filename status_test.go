@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := readinessProject(t, "laravel.test\trunning\t\nmysql\trunning\tstarting\nredis\texited\t\n")
+	os.WriteFile(filepath.Join(projectDir, ".env"), []byte("APP_PORT=8051\nFORWARD_DB_PORT=3351\n"), 0644)
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := Status(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Registered || st.Suffix != 51 || !st.Exists || !st.Sail {
+		t.Errorf("Unexpected registration fields %+v", st)
+	}
+	if st.Running != 2 || len(st.Services) != 3 || st.Healthy {
+		t.Errorf("Expected 2 of 3 running and not healthy, got %+v", st)
+	}
+	if st.Services[1].Name != "mysql" || st.Services[1].Health != "starting" {
+		t.Errorf("Unexpected mysql status %+v", st.Services[1])
+	}
+	if st.Ports["APP_PORT"] != 8051 || st.Ports["VITE_PORT"] != 5151 {
+		t.Errorf("Expected .env ports with suffix fallbacks, got %v", st.Ports)
+	}
+	if len(st.URLs) == 0 || st.URLs[0].URL != "http://localhost:8051" {
+		t.Errorf("Expected the app URL first, got %v", st.URLs)
+	}
+	if got := containerSummary(st); !strings.Contains(got, "2 running") {
+		t.Errorf("Expected %q to mention 2 running", got)
+	}
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"path"`, `"services"`, `"healthy":false`, `"url":"http://localhost:8051"`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("Expected %s in %s", key, data)
+		}
+	}
+}
+
+func TestStatusMissingProject(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	st, err := Status(filepath.Join(t.TempDir(), "gone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Exists || st.Registered || st.Sail {
+		t.Errorf("Expected a missing, unregistered project, got %+v", st)
+	}
+	if got := containerSummary(st); !strings.Contains(got, "Missing") {
+		t.Errorf("Expected the project to be reported missing, got %q", got)
+	}
+}
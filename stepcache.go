@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Steps of `sailinit up` whose inputs are hashed so unchanged ones can be
+// skipped on the next run.
+const (
+	stepComposer = "composer install"
+	stepSailUp   = "sail up"
+	stepNPM      = "npm ci"
+)
+
+// stepInputs returns the files whose contents decide whether step must run
+// again.
+func stepInputs(projectDir, step string) []string {
+	switch step {
+	case stepComposer:
+		return []string{filepath.Join(projectDir, "composer.json"), filepath.Join(projectDir, "composer.lock")}
+	case stepNPM:
+		return []string{filepath.Join(projectDir, "package.json"), filepath.Join(projectDir, "package-lock.json")}
+	case stepSailUp:
+		// .env carries the forwarded ports the compose files interpolate
+		return append(composeFiles(projectDir), filepath.Join(projectDir, ".env"))
+	}
+	return nil
+}
+
+// hashInputs hashes the names and contents of files. Missing files count
+// too, so creating or deleting one changes the hash.
+func hashInputs(files []string) string {
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s\x00", f)
+		data, err := os.ReadFile(f)
+		if err != nil {
+			h.Write([]byte("-\x00"))
+			continue
+		}
+		fmt.Fprintf(h, "%d\x00", len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordedStepHash returns the input hash saved after step last succeeded
+// in projectDir, or "" when there is none.
+func recordedStepHash(projectDir, step string) string {
+	state, _, err := loadPortState()
+	if err != nil {
+		return ""
+	}
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return ""
+	}
+	return state.StepHashes[absDir][step]
+}
+
+// recordStepHash saves the input hash of a step that just succeeded.
+// Unregistered projects are not tracked.
+func recordStepHash(projectDir, step, hash string) error {
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+
+	return updatePortState(func(state *PortState) error {
+		if _, ok := state.Projects[absDir]; !ok {
+			return nil
+		}
+		if state.StepHashes == nil {
+			state.StepHashes = make(map[string]map[string]string)
+		}
+		if state.StepHashes[absDir] == nil {
+			state.StepHashes[absDir] = make(map[string]string)
+		}
+		state.StepHashes[absDir][step] = hash
+		return nil
+	})
+}
+
+// runCachedStep runs step unless its inputs are unchanged since its last
+// successful run, in which case a "cached" marker is printed instead and
+// true is returned. force runs it regardless, e.g. when its output has gone
+// missing.
+func runCachedStep(projectDir, step string, force bool, run func() error) (bool, error) {
+	hash := hashInputs(stepInputs(projectDir, step))
+	if !force && recordedStepHash(projectDir, step) == hash {
+		printInfo(tr("step_cached", step))
+		return true, nil
+	}
+	if err := run(); err != nil {
+		return false, err
+	}
+	if err := recordStepHash(projectDir, step, hash); err != nil {
+		printError(fmt.Sprintf("Error caching %s: %v", step, err))
+	}
+	return false, nil
+}
+
+// startPhase is the timing of one step of a project start.
+type startPhase struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Cached   bool          `json:"cached,omitempty"`
+}
+
+// timedStep runs a cached step and appends its timing to phases.
+func timedStep(phases *[]startPhase, projectDir, step string, force bool, run func() error) error {
+	began := time.Now()
+	cached, err := runCachedStep(projectDir, step, force, run)
+	*phases = append(*phases, startPhase{Name: step, Duration: time.Since(began), Cached: cached})
+	return err
+}
+
+// stackRunning reports whether every container of the project is running
+// and healthy, so an unchanged `sail up` can be skipped.
+func stackRunning(projectDir string) bool {
+	states, err := containerStates(projectDir)
+	if err != nil || len(states) == 0 {
+		return false
+	}
+	return !slices.ContainsFunc(states, func(s containerState) bool { return !s.ready() })
+}
+
+// runSailNPMCI installs the frontend dependencies inside the app container.
+func runSailNPMCI(projectDir string) error {
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	if _, err := os.Stat(sailPath); os.IsNotExist(err) {
+		return sailNotFoundError(sailPath)
+	}
+
+	printInfo("Installing npm dependencies (sail npm ci)...")
+	cmd := exec.Command(sailPath, "npm", "ci")
+	cmd.Dir = projectDir
+	cmd.Env = sailEnv(projectDir)
+	return runStreaming(cmd)
+}
+
+// fileMissing reports whether path does not exist.
+func fileMissing(path string) bool {
+	_, err := os.Stat(path)
+	return os.IsNotExist(err)
+}
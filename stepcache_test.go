@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashInputs(t *testing.T) {
+	dir := t.TempDir()
+	lock := filepath.Join(dir, "composer.lock")
+	files := []string{filepath.Join(dir, "composer.json"), lock}
+
+	missing := hashInputs(files)
+	os.WriteFile(lock, []byte(`{"packages":[]}`), 0644)
+	written := hashInputs(files)
+	if written == missing {
+		t.Error("Expected creating an input to change the hash")
+	}
+	if hashInputs(files) != written {
+		t.Error("Expected the hash to be stable")
+	}
+	os.WriteFile(lock, []byte(`{"packages":[{}]}`), 0644)
+	if hashInputs(files) == written {
+		t.Error("Expected editing an input to change the hash")
+	}
+}
+
+func TestRunCachedStep(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(projectDir, 0755)
+	os.WriteFile(filepath.Join(projectDir, "composer.lock"), []byte("v1"), 0644)
+	if err := saveProjectSuffix(projectDir, 51); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := 0
+	step := func() error { runs++; return nil }
+	for i, want := range []bool{false, true} {
+		cached, err := runCachedStep(projectDir, stepComposer, false, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached != want {
+			t.Errorf("Run %d: expected cached=%v, got %v", i, want, cached)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("Expected the unchanged step to be cached, ran %d times", runs)
+	}
+
+	if _, err := runCachedStep(projectDir, stepComposer, true, step); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(projectDir, "composer.lock"), []byte("v2"), 0644)
+	if _, err := runCachedStep(projectDir, stepComposer, false, step); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 3 {
+		t.Errorf("Expected forced and changed steps to run, ran %d times", runs)
+	}
+
+	// A failed run must not be cached
+	os.WriteFile(filepath.Join(projectDir, "composer.lock"), []byte("v3"), 0644)
+	failing := func() error { return errors.New("boom") }
+	if _, err := runCachedStep(projectDir, stepComposer, false, failing); err == nil {
+		t.Fatal("Expected the step's error")
+	}
+	if _, err := runCachedStep(projectDir, stepComposer, false, step); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 4 {
+		t.Errorf("Expected the step to run again after a failure, ran %d times", runs)
+	}
+
+	if err := RemoveProject(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	if state, _, _ := loadPortState(); len(state.StepHashes) != 0 {
+		t.Errorf("Expected removal to forget step hashes, got %v", state.StepHashes)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// serviceLink is one line of the completion summary.
+type serviceLink struct {
+	Label string
+	Value string
+}
+
+// serviceURL describes how a compose service is reached from the host: the
+// .env key holding its forwarded port, Sail's default, and the URL format.
+type serviceURL struct {
+	Service string
+	Label   string
+	PortKey string
+	Default string
+	Format  string
+}
+
+// serviceURLs lists the services the summary knows about, in display order.
+// Labels starting with "label_" are translated.
+var serviceURLs = []serviceURL{
+	{"mailpit", "label_mailpit", "FORWARD_MAILPIT_DASHBOARD_PORT", "8025", "http://localhost:%s"},
+	{"mailpit", "Mailpit SMTP", "FORWARD_MAILPIT_PORT", "1025", "smtp://localhost:%s"},
+	{"mailhog", "label_mailhog", "FORWARD_MAILHOG_DASHBOARD_PORT", "8025", "http://localhost:%s"},
+	{"mailhog", "Mailhog SMTP", "FORWARD_MAILHOG_PORT", "1025", "smtp://localhost:%s"},
+	{"meilisearch", "Meilisearch", "FORWARD_MEILISEARCH_PORT", "7700", "http://localhost:%s"},
+	{"typesense", "Typesense", "FORWARD_TYPESENSE_PORT", "8108", "http://localhost:%s"},
+	{"minio", "MinIO API", "FORWARD_MINIO_PORT", "9000", "http://localhost:%s"},
+	{"minio", "label_minio_console", "FORWARD_MINIO_CONSOLE_PORT", "8900", "http://localhost:%s"},
+	{"soketi", "Soketi", "FORWARD_SOKETI_PORT", "6001", "ws://localhost:%s"},
+	{"redis", "Redis", "FORWARD_REDIS_PORT", "6379", "redis://localhost:%s"},
+	{"valkey", "Valkey", "FORWARD_VALKEY_PORT", "6379", "redis://localhost:%s"},
+	{"memcached", "Memcached", "FORWARD_MEMCACHED_PORT", "11211", "localhost:%s"},
+}
+
+// completionSummary lists every URL the project exposes on the host, based
+// on its env and compose services (nil when there is no compose file, in
+// which case the Sail defaults of app and Mailpit are assumed).
+func completionSummary(env map[string]string, services []string) []serviceLink {
+	port := func(key, def string) string {
+		if v := env[key]; v != "" {
+			return v
+		}
+		return def
+	}
+
+	links := []serviceLink{{tr("label_app"), "http://localhost:" + port("APP_PORT", "80")}}
+	if services == nil {
+		services = []string{"mailpit"}
+	}
+	if env["VITE_PORT"] != "" {
+		links = append(links, serviceLink{"Vite", "http://localhost:" + env["VITE_PORT"]})
+	}
+	for _, s := range serviceURLs {
+		if !slices.Contains(services, s.Service) {
+			continue
+		}
+		label := s.Label
+		if strings.HasPrefix(label, "label_") {
+			label = tr(label)
+		}
+		links = append(links, serviceLink{label, fmt.Sprintf(s.Format, port(s.PortKey, s.Default))})
+	}
+
+	if env["DB_CONNECTION"] == dbSQLite {
+		return append(links, serviceLink{tr("label_database"), "sqlite:" + orDefault(env["DB_DATABASE"], sqliteDatabase)})
+	}
+	for _, name := range []string{"mysql", "mariadb", "pgsql"} {
+		if !slices.Contains(services, name) {
+			continue
+		}
+		def := "3306"
+		if name == "pgsql" {
+			def = "5432"
+		}
+		dsn := dsnURL(dsnSchemes[name], env["DB_USERNAME"], env["DB_PASSWORD"], port("FORWARD_DB_PORT", def), "/"+env["DB_DATABASE"])
+		links = append(links, serviceLink{tr("label_database"), dsn})
+		break
+	}
+	return links
+}
+
+// formatSummary aligns the links into a block that can be copied as is.
+func formatSummary(links []serviceLink) string {
+	width := 0
+	for _, l := range links {
+		width = max(width, len([]rune(l.Label)))
+	}
+	var b strings.Builder
+	for _, l := range links {
+		fmt.Fprintf(&b, "%s:%s %s\n", l.Label, strings.Repeat(" ", width-len([]rune(l.Label))), l.Value)
+	}
+	return b.String()
+}
+
+// projectSummary builds the summary for projectDir after setup. overrides
+// replace .env values, so a dry run can show the ports it would write.
+func projectSummary(projectDir string, overrides map[string]string) []serviceLink {
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	for k, v := range overrides {
+		env[k] = v
+	}
+	var services []string
+	if project, err := loadCompose(projectDir); err == nil && project != nil {
+		services = project.ServiceNames()
+	}
+	return append(completionSummary(env, services), customServiceLinks(env, configuredServices(), services)...)
+}
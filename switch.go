@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resolveSwitchTarget finds the registered project arg refers to: a path,
+// a project or directory name or a port suffix. Paths need not be registered yet.
+func resolveSwitchTarget(projects []ProjectInfo, arg string) (string, error) {
+	if info, err := os.Stat(arg); err == nil && info.IsDir() {
+		return filepath.Abs(arg)
+	}
+
+	var matches []string
+	for _, p := range projects {
+		if p.Name == arg || filepath.Base(p.Path) == arg || strconv.Itoa(p.Suffix) == arg {
+			matches = append(matches, p.Path)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", usageError(fmt.Errorf("no registered project matches %q", arg))
+	case 1:
+		return matches[0], nil
+	}
+	for i, m := range matches {
+		matches[i] = shortenHome(m)
+	}
+	return "", usageError(fmt.Errorf("%q matches several projects: %s (pass the path instead)", arg, strings.Join(matches, ", ")))
+}
+
+// stacksToStop lists the projects whose containers should be stopped before
+// switching to target. With onlyCurrent just the project in currentDir is
+// considered; otherwise every registered project that has running containers.
+func stacksToStop(projects []ProjectInfo, target, currentDir string, onlyCurrent bool) []string {
+	var stop []string
+	for _, p := range projects {
+		if p.Path == target || !p.Exists {
+			continue
+		}
+		if onlyCurrent && p.Path != currentDir {
+			continue
+		}
+		if n, err := countRunningContainers(p.Path); err == nil && n > 0 {
+			stop = append(stop, p.Path)
+		}
+	}
+	return stop
+}
+
+// startProject starts projectDir's stack within the max_running limit and
+// records the start. composer install, sail up and npm ci are skipped when
+// their inputs are unchanged since their last run, unless noCache is set. A
+// failed start leaves a diagnostics bundle behind. It returns the timing of
+// each step that was reached.
+func startProject(reader *bufio.Reader, projectDir string, noCache bool) (phases []startPhase, err error) {
+	cfg, err := loadUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	confirmRunningLimit(reader, projectDir, cfg.MaxRunning)
+	defer func() { recordHistory(projectDir, "up", phasesDetail(phases), err) }()
+
+	vendorMissing := fileMissing(filepath.Join(projectDir, "vendor", "autoload.php"))
+	err = timedStep(&phases, projectDir, stepComposer, noCache || vendorMissing, func() error {
+		user, err := parseUserMapping(cfg.User)
+		if err != nil {
+			return usageError(err)
+		}
+		phpVersion := detectPHPVersion(projectDir)
+		if phpVersion == "" {
+			phpVersion = "84"
+		}
+		return runSailInit(phpVersion, projectDir, true, composerUser(user))
+	})
+	if err != nil {
+		return phases, fmt.Errorf("running composer install: %w", err)
+	}
+
+	// Passed-through sail up arguments always warrant a fresh run
+	force := noCache || len(sailUpArgs) > 0 || !stackRunning(projectDir)
+	err = timedStep(&phases, projectDir, stepSailUp, force, func() error {
+		err := runSailUp(projectDir)
+		if err != nil && !errors.Is(err, ErrSailNotFound) {
+			if path, derr := writeDiagnostics(projectDir, err); derr == nil {
+				printWarning(tr("diagnostics_written", path))
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return phases, err
+	}
+	if err := recordProjectStarted(projectDir, time.Now()); err != nil {
+		printError(fmt.Sprintf("Error recording project start: %v", err))
+	}
+
+	if fileMissing(filepath.Join(projectDir, "package-lock.json")) {
+		return phases, nil
+	}
+	nodeModulesMissing := fileMissing(filepath.Join(projectDir, "node_modules"))
+	err = timedStep(&phases, projectDir, stepNPM, noCache || nodeModulesMissing, func() error {
+		return runSailNPMCI(projectDir)
+	})
+	if err != nil {
+		return phases, fmt.Errorf("running npm ci: %w", err)
+	}
+	return phases, nil
+}
+
+// handleUp starts the project in the current directory.
+func handleUp(args []string) {
+	fset := commandFlags("up")
+	noCache := fset.Bool("no-cache", false, "Run every step even when its inputs are unchanged")
+	args, sailUpArgs = splitPassthrough(args)
+	fset.Parse(args)
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	if _, err := startProject(bufio.NewReader(os.Stdin), projectDir, *noCache); err != nil {
+		exitWithError("Error starting Sail", err)
+	}
+	links := projectSummary(projectDir, nil)
+	summary := formatSummary(links)
+	fmt.Print(summary)
+	if cfg, err := loadUserConfig(); err == nil {
+		finishDesktop(cfg, links[0].Value, summary)
+	}
+}
+
+func handleSwitch(args []string) {
+	fset := commandFlags("switch")
+	onlyCurrent := fset.Bool("only-current", false, "Stop only the project in the current directory")
+	noBrowser := fset.Bool("no-browser", false, "Do not open the app in the browser")
+	dryRun := fset.Bool("dry-run", false, "Only show which stacks would be stopped and started")
+	args, sailUpArgs = splitPassthrough(args)
+	fset.Parse(reorderArgs(args))
+
+	if fset.NArg() != 1 {
+		exitWithError("Usage: sailinit switch <project> [--only-current] [--no-browser] [--dry-run] [-- <sail up args>]", usageError(errors.New("expected one project")))
+	}
+
+	projects, err := ListProjects()
+	if err != nil {
+		exitWithError("Error reading registry", err)
+	}
+	target, err := resolveSwitchTarget(projects, fset.Arg(0))
+	if err != nil {
+		exitWithError("Error resolving project", err)
+	}
+	currentDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+
+	for _, other := range stacksToStop(projects, target, currentDir, *onlyCurrent) {
+		if *dryRun {
+			printInfo(fmt.Sprintf("[dry-run] Would stop %s", shortenHome(other)))
+			continue
+		}
+		err := runSailStop(other)
+		recordHistory(other, "stop", "switched to "+shortenHome(target), err)
+		if err != nil {
+			printError(fmt.Sprintf("Error stopping %s: %v", other, err))
+			continue
+		}
+		printSuccess(tr("project_stopped", shortenHome(other)))
+	}
+
+	if *dryRun {
+		printInfo(fmt.Sprintf("[dry-run] Would start %s", shortenHome(target)))
+		return
+	}
+	if _, err := startProject(bufio.NewReader(os.Stdin), target, false); err != nil {
+		exitWithError(fmt.Sprintf("Error starting %s", shortenHome(target)), err)
+	}
+
+	links := projectSummary(target, nil)
+	printSuccess(fmt.Sprintf("Switched to %s.", shortenHome(target)))
+	fmt.Print(formatSummary(links))
+	if !*noBrowser {
+		if err := openBrowser(links[0].Value); err != nil {
+			printWarning(tr("browser_failed", err))
+		}
+	}
+}
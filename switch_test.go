@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// switchProject creates a project whose sail script reports n running
+// containers.
+func switchProject(t *testing.T, parent, name string, running int) string {
+	t.Helper()
+	projectDir := filepath.Join(parent, name)
+	sailDir := filepath.Join(projectDir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	script := "#!/bin/sh\n" + strings.Repeat("echo running\n", running)
+	os.WriteFile(filepath.Join(sailDir, "sail"), []byte(script), 0755)
+	return projectDir
+}
+
+func TestResolveSwitchTarget(t *testing.T) {
+	parent := t.TempDir()
+	shop := filepath.Join(parent, "clients", "shop")
+	projects := []ProjectInfo{
+		{Path: shop, Suffix: 51},
+		{Path: "/srv/a/blog", Suffix: 52},
+		{Path: "/srv/b/blog", Suffix: 53},
+	}
+
+	tests := []struct {
+		arg     string
+		want    string
+		wantErr string
+	}{
+		{arg: "shop", want: shop},
+		{arg: "52", want: "/srv/a/blog"},
+		{arg: "blog", wantErr: "matches several projects"},
+		{arg: "unknown", wantErr: "no registered project"},
+		{arg: parent, want: parent},
+	}
+	for _, tt := range tests {
+		got, err := resolveSwitchTarget(projects, tt.arg)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("resolveSwitchTarget(%q): expected error containing %q, got %v", tt.arg, tt.wantErr, err)
+			}
+			if err != nil && exitCode(err) != ExitUsage {
+				t.Errorf("resolveSwitchTarget(%q): expected exit code %d, got %d", tt.arg, ExitUsage, exitCode(err))
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveSwitchTarget(%q) = %q, %v; want %q", tt.arg, got, err, tt.want)
+		}
+	}
+}
+
+func TestStacksToStop(t *testing.T) {
+	parent := t.TempDir()
+	target := switchProject(t, parent, "target", 2)
+	current := switchProject(t, parent, "current", 1)
+	other := switchProject(t, parent, "other", 3)
+	stopped := switchProject(t, parent, "stopped", 0)
+	projects := []ProjectInfo{
+		{Path: target, Exists: true},
+		{Path: current, Exists: true},
+		{Path: other, Exists: true},
+		{Path: stopped, Exists: true},
+		{Path: filepath.Join(parent, "gone"), Exists: false},
+	}
+
+	got := stacksToStop(projects, target, current, false)
+	if len(got) != 2 || got[0] != current || got[1] != other {
+		t.Errorf("Expected the running non-target projects, got %v", got)
+	}
+
+	got = stacksToStop(projects, target, current, true)
+	if len(got) != 1 || got[0] != current {
+		t.Errorf("Expected only the current project, got %v", got)
+	}
+
+	got = stacksToStop(projects, current, current, true)
+	if len(got) != 0 {
+		t.Errorf("Expected nothing to stop when switching to the current project, got %v", got)
+	}
+}
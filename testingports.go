@@ -0,0 +1,194 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// testingClientPorts maps the port settings the test suite connects with
+// to the forwarded port they reach from the host, and the host setting
+// that decides whether they do.
+var testingClientPorts = []struct{ Key, Forwarded, Host string }{
+	{"DB_PORT", "FORWARD_DB_PORT", "DB_HOST"},
+	{"REDIS_PORT", "FORWARD_REDIS_PORT", "REDIS_HOST"},
+}
+
+// defaultTestingHost is where Laravel connects when no host is set.
+const defaultTestingHost = "127.0.0.1"
+
+// phpunitFiles are the PHPUnit configurations whose env overrides are
+// kept in sync, in the order PHPUnit prefers them.
+var phpunitFiles = []string{"phpunit.xml", "phpunit.xml.dist"}
+
+var (
+	phpunitEnvTag   = regexp.MustCompile(`<(?:env|server)\b[^>]*>`)
+	phpunitEnvName  = regexp.MustCompile(`\bname\s*=\s*"([^"]*)"`)
+	phpunitEnvValue = regexp.MustCompile(`\bvalue\s*=\s*"([^"]*)"`)
+)
+
+// testingPortUpdates returns the values the test settings of one file
+// should have, given the project's ports and the file's settings. A port
+// key the file sets takes the project's value. DB_PORT and REDIS_PORT
+// take the forwarded port when their host, from the file or else
+// fallbackHosts, is localhost; a service name such as mysql means the
+// tests run inside Sail, where the container port applies.
+func testingPortUpdates(ports, settings, fallbackHosts map[string]string) map[string]string {
+	updates := make(map[string]string)
+	for key, value := range settings {
+		if port, ok := ports[key]; ok && port != value {
+			updates[key] = port
+		}
+	}
+	for _, c := range testingClientPorts {
+		value, set := settings[c.Key]
+		port := ports[c.Forwarded]
+		if !set || port == "" || port == value {
+			continue
+		}
+		host, ok := settings[c.Host]
+		if !ok {
+			host = cmp.Or(fallbackHosts[c.Host], defaultTestingHost)
+		}
+		if isLocalHost(host) {
+			updates[c.Key] = port
+		}
+	}
+	return updates
+}
+
+// syncEnvTesting writes ports to .env.testing's port settings. Laravel
+// reads .env.testing instead of .env, so unset hosts are Laravel's
+// default.
+func syncEnvTesting(path string, ports map[string]string, locks envLocks) ([]string, error) {
+	doc, err := readEnvDocument(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	updates := testingPortUpdates(ports, envValues(doc), nil)
+	var changed []string
+	for _, key := range slices.Sorted(maps.Keys(updates)) {
+		current, _ := doc.Get(key)
+		if locks.skip(key, unquoteEnvValue(current), updates[key]) {
+			continue
+		}
+		doc.Set(key, updates[key])
+		changed = append(changed, key+"="+updates[key])
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	return changed, doc.write(path)
+}
+
+// phpunitSettings returns the <env> and <server> values of a PHPUnit
+// configuration by name.
+func phpunitSettings(data []byte) map[string]string {
+	settings := make(map[string]string)
+	for _, tag := range phpunitEnvTag.FindAll(data, -1) {
+		name := phpunitEnvName.FindSubmatch(tag)
+		value := phpunitEnvValue.FindSubmatch(tag)
+		if name != nil && value != nil {
+			settings[string(name[1])] = string(value[1])
+		}
+	}
+	return settings
+}
+
+// setPHPUnitValues replaces the value attribute of the <env> and <server>
+// entries named in updates, leaving the rest of the file as written.
+func setPHPUnitValues(data []byte, updates map[string]string) []byte {
+	return phpunitEnvTag.ReplaceAllFunc(data, func(tag []byte) []byte {
+		name := phpunitEnvName.FindSubmatch(tag)
+		if name == nil {
+			return tag
+		}
+		value, ok := updates[string(name[1])]
+		if !ok {
+			return tag
+		}
+		loc := phpunitEnvValue.FindSubmatchIndex(tag)
+		if loc == nil {
+			return tag
+		}
+		out := slices.Clone(tag[:loc[2]])
+		out = append(out, value...)
+		return append(out, tag[loc[3]:]...)
+	})
+}
+
+// syncPHPUnitXML writes ports to the env overrides of a PHPUnit
+// configuration. Hosts it doesn't set come from the env file the tests
+// load.
+func syncPHPUnitXML(path string, ports, fallbackHosts map[string]string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	updates := testingPortUpdates(ports, phpunitSettings(data), fallbackHosts)
+	if len(updates) == 0 {
+		return nil, nil
+	}
+	var changed []string
+	for _, key := range slices.Sorted(maps.Keys(updates)) {
+		changed = append(changed, key+"="+updates[key])
+	}
+	return changed, replaceFile(path, setPHPUnitValues(data, updates))
+}
+
+// syncTestingPorts carries the project's ports from .env into
+// .env.testing and the PHPUnit configuration, so a test suite run from the
+// host reaches the forwarded ports. It returns the changes, one line per
+// file.
+func syncTestingPorts(projectDir string) ([]string, error) {
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	ports := make(map[string]string)
+	for _, b := range projectPortBases(projectDir) {
+		if v := env[b.Name]; v != "" {
+			ports[b.Name] = v
+		}
+	}
+
+	var report []string
+	testingPath := filepath.Join(projectDir, ".env.testing")
+	changed, err := syncEnvTesting(testingPath, ports, loadEnvLocks())
+	if err != nil {
+		return report, fmt.Errorf(".env.testing: %w", err)
+	}
+	if len(changed) > 0 {
+		report = append(report, ".env.testing: "+strings.Join(changed, ", "))
+	}
+
+	hosts := env
+	if _, err := os.Stat(testingPath); err == nil {
+		hosts = readEnvValues(testingPath)
+	}
+	for _, name := range phpunitFiles {
+		changed, err := syncPHPUnitXML(filepath.Join(projectDir, name), ports, hosts)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(changed) > 0 {
+			report = append(report, name+": "+strings.Join(changed, ", "))
+		}
+	}
+	return report, nil
+}
+
+// syncTestingEnabled reports whether the config file turns on
+// sync_testing_ports.
+func syncTestingEnabled() bool {
+	cfg, err := loadUserConfig()
+	return err == nil && cfg.SyncTestingPorts
+}
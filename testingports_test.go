@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTestingPortUpdates(t *testing.T) {
+	ports := map[string]string{"FORWARD_DB_PORT": "3355", "FORWARD_REDIS_PORT": "6355", "APP_PORT": "8055"}
+
+	got := testingPortUpdates(ports, map[string]string{"DB_HOST": "127.0.0.1", "DB_PORT": "3306", "REDIS_PORT": "6379", "FORWARD_DB_PORT": "3301"}, nil)
+	if len(got) != 3 || got["DB_PORT"] != "3355" || got["REDIS_PORT"] != "6355" || got["FORWARD_DB_PORT"] != "3355" {
+		t.Errorf("Unexpected updates for a local host: %v", got)
+	}
+
+	// Tests running inside Sail keep the container ports
+	got = testingPortUpdates(ports, map[string]string{"DB_HOST": "mysql", "DB_PORT": "3306"}, nil)
+	if len(got) != 0 {
+		t.Errorf("Expected no updates for a service host, got %v", got)
+	}
+	got = testingPortUpdates(ports, map[string]string{"DB_PORT": "3306"}, map[string]string{"DB_HOST": "mysql"})
+	if len(got) != 0 {
+		t.Errorf("Expected the fallback host to apply, got %v", got)
+	}
+
+	// Settings the file doesn't have are not added
+	if got := testingPortUpdates(ports, map[string]string{"APP_ENV": "testing"}, nil); len(got) != 0 {
+		t.Errorf("Expected nothing to be added, got %v", got)
+	}
+}
+
+func TestSyncTestingPorts(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_HOST=mysql\nAPP_PORT=8055\nFORWARD_DB_PORT=3355\nFORWARD_REDIS_PORT=6355\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.testing"), []byte("# Host tests\nDB_HOST=127.0.0.1\nDB_PORT=3306\n"), 0644)
+	phpunit := `<phpunit>
+    <php>
+        <env name="APP_ENV" value="testing"/>
+        <env name="DB_PORT" value="3306" force="true"/>
+        <server name="REDIS_PORT" value="6379"/>
+    </php>
+</phpunit>
+`
+	os.WriteFile(filepath.Join(dir, "phpunit.xml"), []byte(phpunit), 0644)
+
+	report, err := syncTestingPorts(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 || report[0] != ".env.testing: DB_PORT=3355" || report[1] != "phpunit.xml: DB_PORT=3355, REDIS_PORT=6355" {
+		t.Errorf("Unexpected report: %q", report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, ".env.testing")); string(data) != "# Host tests\nDB_HOST=127.0.0.1\nDB_PORT=3355\n" {
+		t.Errorf("Unexpected .env.testing:\n%s", data)
+	}
+	want := strings.Replace(strings.Replace(phpunit, `"3306"`, `"3355"`, 1), `"6379"`, `"6355"`, 1)
+	if data, _ := os.ReadFile(filepath.Join(dir, "phpunit.xml")); string(data) != want {
+		t.Errorf("Unexpected phpunit.xml:\n%s", data)
+	}
+
+	// A second run changes nothing
+	if report, err := syncTestingPorts(dir); err != nil || len(report) != 0 {
+		t.Errorf("Expected nothing left to sync, got %q, %v", report, err)
+	}
+}
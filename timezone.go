@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hostTimezone is the config value that selects the host's own timezone.
+const hostTimezone = "host"
+
+// composeTZ is the value added to a service's environment so containers
+// follow TZ from .env.
+const composeTZ = "${TZ:-UTC}"
+
+// resolveTimezone turns the configured timezone into an IANA name. An empty
+// value means sailinit leaves timezones alone.
+func resolveTimezone(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if value == hostTimezone {
+		value = detectHostTimezone()
+	}
+	if _, err := time.LoadLocation(value); err != nil {
+		return "", fmt.Errorf("invalid timezone %q: %w", value, err)
+	}
+	return value, nil
+}
+
+// detectHostTimezone reads the host timezone from TZ, the /etc/localtime
+// symlink or /etc/timezone, falling back to UTC.
+func detectHostTimezone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && !filepath.IsAbs(tz) {
+		return tz
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, found := strings.Cut(target, "zoneinfo/"); found && name != "" {
+			return name
+		}
+	}
+	if data, err := os.ReadFile("/etc/timezone"); err == nil {
+		if tz := strings.TrimSpace(string(data)); tz != "" {
+			return tz
+		}
+	}
+	return "UTC"
+}
+
+// ensureComposeTZ adds TZ to the environment of every service in the
+// project's compose file that declares a block-style environment without
+// it. Only the first compose file found is edited. It returns the services
+// that were changed.
+func ensureComposeTZ(projectDir string) ([]string, error) {
+	for _, path := range composeFiles(projectDir) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		lines, layout := splitTextFile(data)
+		services, inserts, err := composeTZInserts(lines)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if len(inserts) == 0 {
+			return nil, nil
+		}
+		// Insert from the bottom up so earlier line numbers stay valid
+		slices.SortFunc(inserts, func(a, b lineInsert) int { return b.Before - a.Before })
+		for _, ins := range inserts {
+			lines = slices.Insert(lines, ins.Before, ins.Line)
+		}
+		return services, writeEnvFile(path, joinTextFile(lines, layout))
+	}
+	return nil, nil
+}
+
+// lineInsert is a line to add in front of the zero-based line Before.
+type lineInsert struct {
+	Before int
+	Line   string
+}
+
+// composeTZInserts finds where TZ must be added to the compose file lines.
+func composeTZInserts(lines []string) ([]string, []lineInsert, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &root); err != nil {
+		return nil, nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, nil, nil
+	}
+	servicesNode := mappingValue(root.Content[0], "services")
+	if servicesNode == nil || servicesNode.Kind != yaml.MappingNode {
+		return nil, nil, nil
+	}
+
+	var services []string
+	var inserts []lineInsert
+	for i := 0; i+1 < len(servicesNode.Content); i += 2 {
+		name := servicesNode.Content[i].Value
+		env := mappingValue(servicesNode.Content[i+1], "environment")
+		if env == nil || env.Style&yaml.FlowStyle != 0 || len(env.Content) == 0 {
+			continue
+		}
+		first := env.Content[0]
+		if first.Line < 1 || first.Line > len(lines) {
+			continue
+		}
+		firstLine := lines[first.Line-1]
+		indent := firstLine[:len(firstLine)-len(strings.TrimLeft(firstLine, " \t"))]
+
+		switch env.Kind {
+		case yaml.MappingNode:
+			if mappingValue(env, "TZ") != nil {
+				continue
+			}
+			inserts = append(inserts, lineInsert{first.Line - 1, indent + "TZ: '" + composeTZ + "'"})
+		case yaml.SequenceNode:
+			if slices.ContainsFunc(env.Content, func(n *yaml.Node) bool {
+				return n.Value == "TZ" || strings.HasPrefix(n.Value, "TZ=")
+			}) {
+				continue
+			}
+			inserts = append(inserts, lineInsert{first.Line - 1, indent + "- 'TZ=" + composeTZ + "'"})
+		default:
+			continue
+		}
+		services = append(services, name)
+	}
+	return services, inserts, nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveTimezone(t *testing.T) {
+	if tz, err := resolveTimezone(""); err != nil || tz != "" {
+		t.Errorf("Expected empty timezone, got %q, %v", tz, err)
+	}
+	if tz, err := resolveTimezone("Europe/Budapest"); err != nil || tz != "Europe/Budapest" {
+		t.Errorf("Expected Europe/Budapest, got %q, %v", tz, err)
+	}
+	if _, err := resolveTimezone("Mars/Olympus"); err == nil {
+		t.Error("Expected an error for an unknown timezone")
+	}
+
+	t.Setenv("TZ", "America/New_York")
+	if tz, err := resolveTimezone("host"); err != nil || tz != "America/New_York" {
+		t.Errorf("Expected the host timezone from TZ, got %q, %v", tz, err)
+	}
+}
+
+const tzCompose = `services:
+    laravel.test:
+        image: sail-8.4/app
+        environment:
+            WWWUSER: '${WWWUSER}'
+            LARAVEL_SAIL: 1
+    mysql:
+        image: 'mysql/mysql-server:8.0'
+        environment:
+            - MYSQL_DATABASE=${DB_DATABASE}
+    redis:
+        image: 'redis:alpine'
+    mailpit:
+        image: 'axllent/mailpit:latest'
+        environment: {MP_MAX_MESSAGES: 5000}
+    worker:
+        image: sail-8.4/app
+        environment:
+            TZ: Europe/Paris
+`
+
+func TestEnsureComposeTZ(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sailinit-tz-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "compose.yaml")
+	os.WriteFile(path, []byte(tzCompose), 0644)
+
+	services, err := ensureComposeTZ(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(services, ",") != "laravel.test,mysql" {
+		t.Errorf("Expected laravel.test and mysql to change, got %v", services)
+	}
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if !strings.Contains(content, "        environment:\n            TZ: '${TZ:-UTC}'\n            WWWUSER:") {
+		t.Errorf("Expected TZ in the laravel.test mapping, got:\n%s", content)
+	}
+	if !strings.Contains(content, "            - 'TZ=${TZ:-UTC}'\n            - MYSQL_DATABASE") {
+		t.Errorf("Expected TZ in the mysql list, got:\n%s", content)
+	}
+	if strings.Count(content, "${TZ:-UTC}") != 2 || !strings.Contains(content, "TZ: Europe/Paris") {
+		t.Errorf("Expected the existing and flow-style environments to stay untouched, got:\n%s", content)
+	}
+
+	project, err := parseCompose(data)
+	if err != nil || len(project.Services) != 5 {
+		t.Errorf("Expected the edited file to stay valid, got %v", err)
+	}
+
+	// A second run changes nothing
+	services, err = ensureComposeTZ(tempDir)
+	if err != nil || len(services) != 0 {
+		t.Errorf("Expected no further changes, got %v, %v", services, err)
+	}
+}
+
+func TestApplySetupPlanTimezone(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	sailDir := filepath.Join(projectDir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	os.WriteFile(filepath.Join(sailDir, "sail"), []byte("#!/bin/sh\nexit 0\n"), 0755)
+	os.WriteFile(filepath.Join(projectDir, "compose.yaml"), []byte(tzCompose), 0644)
+	envPath := filepath.Join(projectDir, ".env")
+	os.WriteFile(envPath, []byte("APP_KEY=base64:abc\nAPP_TIMEZONE=UTC\n"), 0644)
+
+	plan := &setupPlan{Version: planVersion, ProjectDir: projectDir, PHPVersion: "84", Suffix: 52, Timezone: "Europe/Budapest"}
+	if err := applySetupPlan(nil, plan, false); err != nil {
+		t.Fatal(err)
+	}
+	values := readEnvValues(envPath)
+	if values["APP_TIMEZONE"] != "Europe/Budapest" || values["TZ"] != "Europe/Budapest" {
+		t.Errorf("Expected both timezone keys to be set, got %v", values)
+	}
+	data, _ := os.ReadFile(filepath.Join(projectDir, "compose.yaml"))
+	if !strings.Contains(string(data), "TZ: '${TZ:-UTC}'") {
+		t.Error("Expected the compose file to pass TZ through")
+	}
+}
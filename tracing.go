@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceExportTimeout bounds sending the spans at exit, so a collector that
+// is down never holds up the command.
+const traceExportTimeout = 3 * time.Second
+
+// tracingConfig is where the spans of every command go, as OTLP/HTTP
+// JSON. The OTEL_EXPORTER_OTLP_* variables win over it.
+type tracingConfig struct {
+	// Endpoint is the collector's base URL, e.g. http://localhost:4318;
+	// spans are posted to its /v1/traces
+	Endpoint string `yaml:"endpoint"`
+	// Headers are sent with every export, e.g. an API key
+	Headers map[string]string `yaml:"headers"`
+}
+
+// traceSpan is one timed operation of a trace.
+type traceSpan struct {
+	ID       string
+	ParentID string
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Attrs    map[string]string
+	// Err is set when the operation failed
+	Err string
+}
+
+// tracer collects the spans of one command until it exits.
+type tracer struct {
+	mu       sync.Mutex
+	endpoint string
+	headers  map[string]string
+	traceID  string
+	root     *traceSpan
+	// open are the spans not ended yet, innermost last; a new span is a
+	// child of the innermost
+	open  []*traceSpan
+	spans []*traceSpan
+}
+
+// activeTracer is nil unless tracing is configured.
+var activeTracer *tracer
+
+// randomHex returns n random bytes as hex, for trace and span IDs.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// tracesEndpoint returns the URL spans are posted to, from the OTel
+// environment variables or else the config file, or "" when tracing is
+// off.
+func tracesEndpoint(cfg tracingConfig) string {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") || os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return ""
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	base := cmp.Or(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), cfg.Endpoint)
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/v1/traces"
+}
+
+// parseOTLPHeaders reads OTEL_EXPORTER_OTLP_HEADERS ("api-key=abc,x-team=web")
+// over base.
+func parseOTLPHeaders(spec string, base map[string]string) map[string]string {
+	headers := maps.Clone(base)
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	for pair := range strings.SplitSeq(spec, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(key) != "" {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+// parseTraceparent returns the trace and parent span IDs of a W3C
+// traceparent ("00-<trace>-<span>-01"), so a run started from a traced CI
+// job joins its trace.
+func parseTraceparent(value string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(parts[1] + parts[2]); err != nil {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// startTracing turns tracing on when an OTLP endpoint is configured and
+// opens the span covering command. A broken config file leaves tracing off;
+// the command itself reports it.
+func startTracing(command string) {
+	var cfg tracingConfig
+	if c, err := loadUserConfig(); err == nil && c.Tracing != nil {
+		cfg = *c.Tracing
+	}
+	endpoint := tracesEndpoint(cfg)
+	if endpoint == "" {
+		return
+	}
+	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/json" {
+		printWarning(tr("tracing_protocol", protocol))
+		return
+	}
+	t := &tracer{
+		endpoint: endpoint,
+		headers:  parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), cfg.Headers),
+		traceID:  randomHex(16),
+	}
+	parentID := ""
+	if traceID, spanID, ok := parseTraceparent(os.Getenv("TRACEPARENT")); ok {
+		t.traceID, parentID = traceID, spanID
+	}
+	t.root = &traceSpan{
+		ID:       randomHex(8),
+		ParentID: parentID,
+		Name:     "sailinit " + command,
+		Start:    profileStart,
+		Attrs:    map[string]string{"sailinit.command": command, "sailinit.context": contextName},
+	}
+	t.open = []*traceSpan{t.root}
+	activeTracer = t
+}
+
+// begin opens a span called name as a child of the innermost open span.
+func (t *tracer) begin(name string, attrs map[string]string, start time.Time) *traceSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &traceSpan{ID: randomHex(8), Name: name, Start: start, Attrs: attrs}
+	if len(t.open) > 0 {
+		span.ParentID = t.open[len(t.open)-1].ID
+	}
+	t.open = append(t.open, span)
+	return span
+}
+
+// end closes span, marking it failed when err is not nil.
+func (t *tracer) end(span *traceSpan, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span.End = time.Now()
+	if err != nil {
+		span.Err = err.Error()
+	}
+	t.open = slices.DeleteFunc(t.open, func(s *traceSpan) bool { return s == span })
+	t.spans = append(t.spans, span)
+}
+
+// setupPhaseEnd ends the setup phase running now.
+var setupPhaseEnd func(error)
+
+// startSetupPhase ends the running setup phase and starts the one called
+// name, so the trace and the profile show where setup spends its time.
+func startSetupPhase(name string) {
+	endSetupPhase(nil)
+	setupPhaseEnd = startSpan("setup: "+name, nil)
+}
+
+// endSetupPhase ends the running setup phase, marking it failed when err is
+// not nil.
+func endSetupPhase(err error) {
+	if setupPhaseEnd != nil {
+		setupPhaseEnd(err)
+		setupPhaseEnd = nil
+	}
+}
+
+// otlpAttributes renders attrs as OTLP key-values, sorted by key.
+func otlpAttributes(attrs map[string]string) []map[string]any {
+	var out []map[string]any
+	for _, k := range slices.Sorted(maps.Keys(attrs)) {
+		out = append(out, map[string]any{"key": k, "value": map[string]any{"stringValue": attrs[k]}})
+	}
+	return out
+}
+
+// otlpPayload renders the trace's spans as an OTLP/HTTP JSON export
+// request.
+func (t *tracer) otlpPayload() ([]byte, error) {
+	var spans []map[string]any
+	for _, s := range t.spans {
+		span := map[string]any{
+			"traceId":           t.traceID,
+			"spanId":            s.ID,
+			"name":              s.Name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(s.Start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.End.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.Attrs),
+		}
+		if s.ParentID != "" {
+			span["parentSpanId"] = s.ParentID
+		}
+		if s.Err != "" {
+			span["status"] = map[string]any{"code": 2, "message": s.Err} // STATUS_CODE_ERROR
+		}
+		spans = append(spans, span)
+	}
+	resource := map[string]string{
+		"service.name":    cmp.Or(os.Getenv("OTEL_SERVICE_NAME"), "sailinit"),
+		"service.version": version,
+		"os.type":         runtime.GOOS,
+		"host.arch":       runtime.GOARCH,
+	}
+	return json.Marshal(map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{"attributes": otlpAttributes(resource)},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]any{"name": "sailinit", "version": version},
+				"spans": spans,
+			}},
+		}},
+	})
+}
+
+// export posts the collected spans to the collector.
+func (t *tracer) export() error {
+	payload, err := t.otlpPayload()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), traceExportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s answered %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// finishTracing ends every open span, the command's last, with the exit
+// code and sends the trace. Export problems are only warned about.
+func finishTracing(code int) {
+	t := activeTracer
+	if t == nil {
+		return
+	}
+	activeTracer = nil
+	endSetupPhase(nil)
+	var err error
+	if code != ExitOK {
+		err = fmt.Errorf("exit code %d", code)
+	}
+	t.root.Attrs["sailinit.exit_code"] = strconv.Itoa(code)
+	for _, span := range slices.Backward(slices.Clone(t.open)) {
+		if span == t.root {
+			t.end(span, err)
+		} else {
+			t.end(span, nil)
+		}
+	}
+	if err := t.export(); err != nil {
+		printWarning(tr("tracing_export_failed", err))
+	}
+}
+
+// commandAttrs describes cmd for its span: the program and, for docker and
+// sail, the subcommand. Further arguments may hold secrets and are left out.
+func commandAttrs(cmd *exec.Cmd) map[string]string {
+	attrs := map[string]string{"process.executable.name": filepath.Base(cmd.Path)}
+	if len(cmd.Args) > 1 && !strings.HasPrefix(cmd.Args[1], "-") {
+		attrs["sailinit.subcommand"] = cmd.Args[1]
+	}
+	return attrs
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracesEndpoint(t *testing.T) {
+	for _, key := range []string{"OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"} {
+		t.Setenv(key, "")
+	}
+	if got := tracesEndpoint(tracingConfig{}); got != "" {
+		t.Errorf("Expected tracing to be off by default, got %q", got)
+	}
+	cfg := tracingConfig{Endpoint: "http://collector:4318/"}
+	if got := tracesEndpoint(cfg); got != "http://collector:4318/v1/traces" {
+		t.Errorf("Unexpected endpoint from the config file %q", got)
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	if got := tracesEndpoint(cfg); got != "http://localhost:4318/v1/traces" {
+		t.Errorf("Expected the environment to win, got %q", got)
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://localhost:4318/custom")
+	if got := tracesEndpoint(cfg); got != "http://localhost:4318/custom" {
+		t.Errorf("Expected the traces endpoint to be used as is, got %q", got)
+	}
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	if got := tracesEndpoint(cfg); got != "" {
+		t.Errorf("Expected OTEL_SDK_DISABLED to turn tracing off, got %q", got)
+	}
+}
+
+func TestParseOTLPHeadersAndTraceparent(t *testing.T) {
+	headers := parseOTLPHeaders("api-key=abc, x-team = web,broken", map[string]string{"x-team": "ops", "x-org": "acme"})
+	if headers["api-key"] != "abc" || headers["x-team"] != "web" || headers["x-org"] != "acme" || len(headers) != 3 {
+		t.Errorf("Unexpected headers %v", headers)
+	}
+
+	traceID, spanID, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00f067aa0ba902b7" {
+		t.Errorf("Unexpected traceparent result %q %q %v", traceID, spanID, ok)
+	}
+	if _, _, ok := parseTraceparent("00-xyz-00f067aa0ba902b7-01"); ok {
+		t.Error("Expected a malformed traceparent to be ignored")
+	}
+}
+
+// otlpRequest is the part of an OTLP/HTTP JSON export the test checks.
+type otlpRequest struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []struct {
+				TraceID      string `json:"traceId"`
+				SpanID       string `json:"spanId"`
+				ParentSpanID string `json:"parentSpanId"`
+				Name         string `json:"name"`
+				Attributes   []struct {
+					Key   string `json:"key"`
+					Value struct {
+						StringValue string `json:"stringValue"`
+					} `json:"value"`
+				} `json:"attributes"`
+				Status struct {
+					Code int `json:"code"`
+				} `json:"status"`
+			} `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+func TestTracingExportsSpans(t *testing.T) {
+	_, cleanup := setupTestState(t)
+	defer cleanup()
+
+	var received otlpRequest
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("api-key")
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/v1/traces" || json.Unmarshal(body, &received) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret")
+	t.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	startTracing("init")
+	if activeTracer == nil {
+		t.Fatal("Expected tracing to start")
+	}
+	startSetupPhase("composer install")
+	end := startSpan("exec: docker", map[string]string{"sailinit.subcommand": "run"})
+	end(errors.New("exit status 1"))
+	endSetupPhase(nil)
+	finishTracing(ExitDocker)
+
+	if activeTracer != nil {
+		t.Error("Expected tracing to stop after the export")
+	}
+	if apiKey != "secret" {
+		t.Errorf("Expected the OTLP headers to be sent, got %q", apiKey)
+	}
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Unexpected export %+v", received)
+	}
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %+v", spans)
+	}
+	exec, phase, root := spans[0], spans[1], spans[2]
+	if root.Name != "sailinit init" || root.ParentSpanID != "00f067aa0ba902b7" || root.Status.Code != 2 {
+		t.Errorf("Unexpected root span %+v", root)
+	}
+	if phase.Name != "setup: composer install" || phase.ParentSpanID != root.SpanID || phase.Status.Code != 0 {
+		t.Errorf("Unexpected phase span %+v", phase)
+	}
+	if exec.ParentSpanID != phase.SpanID || exec.Status.Code != 2 || exec.Attributes[0].Value.StringValue != "run" {
+		t.Errorf("Unexpected exec span %+v", exec)
+	}
+	for _, s := range spans {
+		if s.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected every span to join the parent trace, got %s", s.TraceID)
+		}
+	}
+}
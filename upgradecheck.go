@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// minSailForLaravel maps a Laravel major version to the oldest laravel/sail
+// release whose stubs and runtimes match it.
+var minSailForLaravel = map[int]string{
+	9:  "1.13.0",
+	10: "1.21.0",
+	11: "1.26.0",
+	12: "1.41.0",
+}
+
+// sailInstallServices are the services `sail:install --with` accepts.
+var sailInstallServices = []string{
+	"mysql", "pgsql", "mariadb", "mongodb", "redis", "valkey", "memcached",
+	"meilisearch", "typesense", "minio", "mailpit", "selenium", "soketi",
+}
+
+// lockedVersions returns the versions of packages in composer.lock, keyed
+// by package name and without a leading "v".
+func lockedVersions(projectDir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(projectDir, "composer.lock"))
+	if err != nil {
+		return nil, err
+	}
+	var lock struct {
+		Packages    []struct{ Name, Version string } `json:"packages"`
+		PackagesDev []struct{ Name, Version string } `json:"packages-dev"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("composer.lock is not valid JSON: %w", err)
+	}
+	versions := make(map[string]string)
+	for _, p := range append(lock.Packages, lock.PackagesDev...) {
+		versions[p.Name] = strings.TrimPrefix(p.Version, "v")
+	}
+	return versions, nil
+}
+
+// sailOutdated returns the Sail version laravel needs when sail is older,
+// or "" when sail is recent enough or either version can't be compared.
+// Laravel versions newer than the table need the newest known minimum.
+func sailOutdated(laravel, sail string) string {
+	major, err := strconv.Atoi(strings.SplitN(laravel, ".", 2)[0])
+	if err != nil {
+		return ""
+	}
+	wanted, best := "", 0
+	for m, v := range minSailForLaravel {
+		if m <= major && m > best {
+			wanted, best = v, m
+		}
+	}
+	if cmp, ok := compareVersions(sail, wanted); wanted == "" || !ok || cmp >= 0 {
+		return ""
+	}
+	return wanted
+}
+
+// sailInstallWith returns the --with value that recreates projectDir's
+// compose services with sail:install.
+func sailInstallWith(projectDir string) string {
+	var with []string
+	if project, err := loadCompose(projectDir); err == nil && project != nil {
+		for _, name := range project.ServiceNames() {
+			if slices.Contains(sailInstallServices, name) {
+				with = append(with, name)
+			}
+		}
+	}
+	if len(with) == 0 {
+		return "none"
+	}
+	return strings.Join(with, ",")
+}
+
+// backupComposeFiles copies every compose file of projectDir next to
+// itself with a .sailinit-bak suffix, before sail:install overwrites it.
+func backupComposeFiles(projectDir string) ([]string, error) {
+	var backups []string
+	for _, f := range composeFiles(projectDir) {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		backup := f + ".sailinit-bak"
+		if err := os.WriteFile(backup, data, 0644); err != nil {
+			return backups, err
+		}
+		backups = append(backups, backup)
+	}
+	return backups, nil
+}
+
+func handleUpgradeCheck(args []string) {
+	fset := commandFlags("upgrade-check")
+	yes := fset.Bool("yes", false, "Update laravel/sail and refresh the compose file without asking")
+	fset.Parse(reorderArgs(args))
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	versions, err := lockedVersions(projectDir)
+	if err != nil {
+		exitWithError("Error reading composer.lock", err)
+	}
+	laravel, sail := versions["laravel/framework"], versions["laravel/sail"]
+	if laravel == "" {
+		exitWithError("Cannot check Sail", usageError(errors.New("composer.lock does not contain laravel/framework")))
+	}
+	if sail == "" {
+		exitWithError("Cannot check Sail", &CLIError{
+			Category: CategoryUsage,
+			Err:      errors.New("composer.lock does not contain laravel/sail"),
+			Hint:     "Install it with: composer require laravel/sail --dev",
+		})
+	}
+	fmt.Printf("laravel/framework %s, laravel/sail %s\n", laravel, sail)
+
+	wanted := sailOutdated(laravel, sail)
+	if wanted == "" {
+		printSuccess("laravel/sail is current enough for this Laravel version.")
+		return
+	}
+	printWarning(tr("sail_outdated", sail, laravel, wanted))
+
+	cfg, err := loadUserConfig()
+	if err != nil {
+		exitWithError("Error reading config", err)
+	}
+	user, err := parseUserMapping(cfg.User)
+	if err != nil {
+		exitWithError("Invalid user mapping", usageError(err))
+	}
+	phpVersion := detectPHPVersion(projectDir)
+	if phpVersion == "" {
+		phpVersion = "84"
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	if !*yes && !askYesNo(reader, tr("sail_update_prompt")) {
+		return
+	}
+	err = runComposerContainer(phpVersion, projectDir, composerUser(user), "composer", "require", "laravel/sail", "--dev", "--with-all-dependencies", "--ignore-platform-reqs")
+	updated := sail
+	if versions, err := lockedVersions(projectDir); err == nil {
+		updated = versions["laravel/sail"]
+	}
+	recordHistory(projectDir, "upgrade", fmt.Sprintf("laravel/sail %s -> %s", sail, updated), err)
+	if err != nil {
+		exitWithError("Error updating laravel/sail", err)
+	}
+	printSuccess(fmt.Sprintf("laravel/sail updated to %s.", updated))
+
+	with := sailInstallWith(projectDir)
+	if !*yes && !askYesNo(reader, tr("sail_install_prompt", with)) {
+		return
+	}
+	backups, err := backupComposeFiles(projectDir)
+	if err != nil {
+		exitWithError("Error backing up the compose file", err)
+	}
+	for _, b := range backups {
+		printInfo(fmt.Sprintf("Saved %s", shortenHome(b)))
+	}
+	err = runComposerContainer(phpVersion, projectDir, composerUser(user), "php", "artisan", "sail:install", "--with="+with)
+	recordHistory(projectDir, "upgrade", "sail:install --with="+with, err)
+	if err != nil {
+		exitWithError("Error running sail:install", err)
+	}
+	printSuccess("Compose file refreshed. Compare it with the backup, then run sailinit to apply your ports again.")
+}
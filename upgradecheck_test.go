@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockedVersions(t *testing.T) {
+	tempDir := t.TempDir()
+	lock := `{"packages": [{"name": "laravel/framework", "version": "v11.9.2"}], "packages-dev": [{"name": "laravel/sail", "version": "v1.22.0"}]}`
+	os.WriteFile(filepath.Join(tempDir, "composer.lock"), []byte(lock), 0644)
+
+	versions, err := lockedVersions(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions["laravel/framework"] != "11.9.2" || versions["laravel/sail"] != "1.22.0" {
+		t.Errorf("Unexpected versions %v", versions)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "composer.lock"), []byte("{"), 0644)
+	if _, err := lockedVersions(tempDir); err == nil {
+		t.Error("Expected an error for an invalid lock file")
+	}
+}
+
+func TestSailOutdated(t *testing.T) {
+	cases := []struct{ laravel, sail, want string }{
+		{"11.9.2", "1.22.0", "1.26.0"},
+		{"11.9.2", "1.26.0", ""},
+		{"12.1.0", "1.30.1", "1.41.0"},
+		{"13.0.0", "1.30.1", "1.41.0"},
+		{"8.83.0", "1.0.0", ""},
+		{"11.9.2", "dev-main", ""},
+	}
+	for _, c := range cases {
+		if got := sailOutdated(c.laravel, c.sail); got != c.want {
+			t.Errorf("sailOutdated(%s, %s) = %q, want %q", c.laravel, c.sail, got, c.want)
+		}
+	}
+}
+
+func TestSailInstallWith(t *testing.T) {
+	tempDir := t.TempDir()
+	if got := sailInstallWith(tempDir); got != "none" {
+		t.Errorf("Expected none without a compose file, got %q", got)
+	}
+	os.WriteFile(filepath.Join(tempDir, "docker-compose.yml"), []byte(mysqlCompose), 0644)
+	if got := sailInstallWith(tempDir); got != "mysql" {
+		t.Errorf("Expected mysql, got %q", got)
+	}
+
+	backups, err := backupComposeFiles(tempDir)
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected one backup, got %v, %v", backups, err)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != mysqlCompose {
+		t.Errorf("Expected the backup to match the compose file, got:\n%s", data)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// User mapping values besides an explicit uid:gid. With auto the host user
+// is mapped on Linux only, since Docker Desktop already hands files created
+// in bind mounts to the host user on macOS and Windows.
+const (
+	userMappingAuto = "auto"
+	userMappingNone = "none"
+)
+
+// parseUserMapping validates a --user or config value. auto is normalized
+// to "".
+func parseUserMapping(spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == userMappingAuto {
+		return "", nil
+	}
+	if spec == userMappingNone {
+		return spec, nil
+	}
+	uid, gid, found := strings.Cut(spec, ":")
+	if !found {
+		return "", fmt.Errorf("invalid user mapping %q: expected uid:gid, auto or none", spec)
+	}
+	for _, id := range []string{uid, gid} {
+		if n, err := strconv.Atoi(id); err != nil || n < 0 {
+			return "", fmt.Errorf("invalid user mapping %q: %q is not a numeric id", spec, id)
+		}
+	}
+	return spec, nil
+}
+
+// composerUser returns the -u value for the composer container, or "" when
+// the container should run as its default user.
+func composerUser(spec string) string {
+	switch spec {
+	case userMappingNone:
+		return ""
+	case "":
+		if runtime.GOOS != "linux" {
+			return ""
+		}
+		return fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	}
+	return spec
+}
+
+// wwwUserEntries returns the WWWUSER and WWWGROUP Sail's containers should
+// run as for an explicit uid:gid mapping. Otherwise Sail derives them from
+// the calling user and nothing is written.
+func wwwUserEntries(spec string) []envEntry {
+	uid, gid, found := strings.Cut(spec, ":")
+	if !found {
+		return nil
+	}
+	return []envEntry{{Key: "WWWUSER", Value: uid}, {Key: "WWWGROUP", Value: gid}}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParseUserMapping(t *testing.T) {
+	valid := map[string]string{"": "", "auto": "", "none": "none", "1001:1001": "1001:1001", " 0:0 ": "0:0"}
+	for in, want := range valid {
+		if got, err := parseUserMapping(in); err != nil || got != want {
+			t.Errorf("parseUserMapping(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"1001", "me:staff", "1001:", "-1:20", "root"} {
+		if _, err := parseUserMapping(in); err == nil {
+			t.Errorf("Expected parseUserMapping(%q) to fail", in)
+		}
+	}
+}
+
+func TestComposerUser(t *testing.T) {
+	if got := composerUser("none"); got != "" {
+		t.Errorf("Expected no mapping for none, got %q", got)
+	}
+	if got := composerUser("1001:100"); got != "1001:100" {
+		t.Errorf("Expected the explicit mapping, got %q", got)
+	}
+	want := ""
+	if runtime.GOOS == "linux" {
+		want = fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	}
+	if got := composerUser(""); got != want {
+		t.Errorf("Expected auto mapping %q, got %q", want, got)
+	}
+}
+
+func TestApplySetupPlanUserMapping(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	sailDir := filepath.Join(projectDir, "vendor", "bin")
+	os.MkdirAll(sailDir, 0755)
+	os.WriteFile(filepath.Join(sailDir, "sail"), []byte("#!/bin/sh\nexit 0\n"), 0755)
+	envPath := filepath.Join(projectDir, ".env")
+	os.WriteFile(envPath, []byte("APP_KEY=base64:abc\n"), 0644)
+
+	plan := &setupPlan{Version: planVersion, ProjectDir: projectDir, PHPVersion: "84", Suffix: 53, User: "1001:1002"}
+	if err := applySetupPlan(nil, plan, false); err != nil {
+		t.Fatal(err)
+	}
+	values := readEnvValues(envPath)
+	if values["WWWUSER"] != "1001" || values["WWWGROUP"] != "1002" {
+		t.Errorf("Expected WWWUSER/WWWGROUP from the mapping, got %v", values)
+	}
+
+	// Automatic mapping leaves Sail to derive them
+	os.WriteFile(envPath, []byte("APP_KEY=base64:abc\n"), 0644)
+	plan.User = ""
+	if err := applySetupPlan(nil, plan, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readEnvValues(envPath)["WWWUSER"]; ok {
+		t.Error("Expected WWWUSER to stay unset without an explicit mapping")
+	}
+}
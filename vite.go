@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// viteProcess is a `sail npm run dev` started by `sailinit vite start`.
+type viteProcess struct {
+	PID     int       `json:"pid"`
+	Port    int       `json:"port"`
+	Log     string    `json:"log"`
+	Started time.Time `json:"started"`
+}
+
+// ViteStatus is the project's running Vite dev server.
+type ViteStatus struct {
+	PID  int `json:"pid"`
+	Port int `json:"port"`
+}
+
+// viteDevArgs returns the sail arguments running the dev server on port.
+// Vite listens on every interface inside the container and fails instead
+// of moving to another port, since only port is forwarded.
+func viteDevArgs(port int) []string {
+	return []string{"npm", "run", "dev", "--", "--host", "--port", strconv.Itoa(port), "--strictPort"}
+}
+
+// viteLogPath is where the dev server's output goes.
+func viteLogPath(projectDir string) string {
+	return filepath.Join(projectDir, "storage", "logs", "vite.log")
+}
+
+// owned reports whether p's pid is still the dev server sailinit started:
+// a live process whose command line carries p's Vite arguments. A pid
+// reused by another process doesn't count.
+func (p viteProcess) owned() bool {
+	if !processAlive(p.PID) {
+		return false
+	}
+	cmdline, ok := processCommandLine(p.PID)
+	return ok && strings.Contains(cmdline, strings.Join(viteDevArgs(p.Port), " "))
+}
+
+// runningVite returns projectDir's recorded dev server when its process is
+// still the one sailinit started.
+func runningVite(state *PortState, absDir string) (viteProcess, bool) {
+	p, ok := state.Vite[absDir]
+	if !ok || !p.owned() {
+		return viteProcess{}, false
+	}
+	return p, true
+}
+
+// saveViteProcess records projectDir's dev server, or drops the record when
+// p is nil.
+func saveViteProcess(absDir string, p *viteProcess) error {
+	return updatePortState(func(state *PortState) error {
+		if p == nil {
+			delete(state.Vite, absDir)
+			return nil
+		}
+		if _, ok := state.Projects[absDir]; !ok {
+			return fmt.Errorf("project not registered: %s", absDir)
+		}
+		if state.Vite == nil {
+			state.Vite = make(map[string]viteProcess)
+		}
+		state.Vite[absDir] = *p
+		return nil
+	})
+}
+
+// startVite runs `sail npm run dev` for the registered project in the
+// background, on its VITE_PORT, with its output in storage/logs/vite.log.
+func startVite(absDir string, suffix int) (viteProcess, error) {
+	env := readEnvValues(filepath.Join(absDir, ".env"))
+	port, err := strconv.Atoi(forwardedPort(env, "VITE_PORT", suffix, ""))
+	if err != nil {
+		return viteProcess{}, envError(fmt.Errorf("VITE_PORT is not a number: %w", err))
+	}
+	running, err := countRunningContainers(absDir)
+	if err != nil {
+		return viteProcess{}, err
+	}
+	if running == 0 {
+		return viteProcess{}, stateError(errors.New("the stack is not running; start it with sail up -d first"))
+	}
+
+	cmd, err := sailCommand(absDir, viteDevArgs(port)...)
+	if err != nil {
+		return viteProcess{}, err
+	}
+	logPath := viteLogPath(absDir)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return viteProcess{}, err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return viteProcess{}, err
+	}
+	defer logFile.Close()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return viteProcess{}, err
+	}
+	p := viteProcess{PID: cmd.Process.Pid, Port: port, Log: logPath, Started: time.Now()}
+	cmd.Process.Release()
+
+	if err := saveViteProcess(absDir, &p); err != nil {
+		stopProcess(p.PID)
+		return viteProcess{}, err
+	}
+	return p, nil
+}
+
+// stopVite stops the recorded dev server and forgets it. Vite itself runs
+// in the app container, where it is stopped too, since killing the sail
+// client doesn't reach it; only processes started with the project's
+// --port <port> --strictPort arguments are matched there. A pid that is no
+// longer the dev server is never signalled.
+func stopVite(absDir string, p viteProcess) error {
+	if p.owned() {
+		if err := stopProcess(p.PID); err != nil {
+			return err
+		}
+	}
+	env := readEnvValues(filepath.Join(absDir, ".env"))
+	pattern := fmt.Sprintf("--port %d --strictPort", p.Port)
+	if cmd, err := sailCommand(absDir, "exec", "-T", orDefault(env["APP_SERVICE"], "laravel.test"), "pkill", "-f", "--", pattern); err == nil {
+		cmd.Run()
+	}
+	return saveViteProcess(absDir, nil)
+}
+
+func handleVite(args []string) {
+	if helpRequested("vite", args) {
+		return
+	}
+	if len(args) != 1 || (args[0] != "start" && args[0] != "stop" && args[0] != "status") {
+		printError("Usage: sailinit vite <start|stop|status>")
+		exit(ExitUsage)
+	}
+
+	projectDir, err := projectDirectory()
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		exitWithError("Error getting current directory", err)
+	}
+	state, _, err := loadPortState()
+	if err != nil {
+		exitWithError("Error reading registry", err)
+	}
+	p, running := runningVite(state, absDir)
+
+	switch args[0] {
+	case "status":
+		if !running {
+			printInfo("Vite is not running.")
+			return
+		}
+		printInfo(fmt.Sprintf("Vite is running at http://localhost:%d (pid %d, log %s).", p.Port, p.PID, shortenHome(p.Log)))
+	case "start":
+		if running {
+			printInfo(fmt.Sprintf("Vite is already running at http://localhost:%d (pid %d).", p.Port, p.PID))
+			return
+		}
+		entry, ok := state.Projects[absDir]
+		if !ok {
+			exitWithError("Error starting Vite", usageError(fmt.Errorf("project not registered: %s (run sailinit first)", absDir)))
+		}
+		requireWritableState()
+		p, err := startVite(absDir, entry.Suffix)
+		recordHistory(absDir, "vite", "start", err)
+		if err != nil {
+			exitWithError("Error starting Vite", err)
+		}
+		printSuccess(fmt.Sprintf("Vite started at http://localhost:%d (pid %d, log %s).", p.Port, p.PID, shortenHome(p.Log)))
+	case "stop":
+		if !running {
+			if _, recorded := state.Vite[absDir]; recorded {
+				requireWritableState()
+				saveViteProcess(absDir, nil)
+			}
+			printInfo("Vite is not running.")
+			return
+		}
+		requireWritableState()
+		err := stopVite(absDir, p)
+		recordHistory(absDir, "vite", "stop", err)
+		if err != nil {
+			exitWithError("Error stopping Vite", err)
+		}
+		printSuccess("Vite stopped.")
+	}
+}
@@ -0,0 +1,42 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// detach is a no-op where process groups are unavailable.
+func detach(cmd *exec.Cmd) {}
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
+
+// processCommandLine returns the command line of the process with pid.
+func processCommandLine(pid int) (string, bool) {
+	filter := fmt.Sprintf("ProcessId=%d", pid)
+	out, err := commandOutput(exec.Command("powershell", "-NoProfile", "-Command",
+		"(Get-CimInstance Win32_Process -Filter '"+filter+"').CommandLine"))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
+}
+
+// stopProcess kills the process started by detach.
+func stopProcess(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	return p.Kill()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSailVite installs a vendor/bin/sail whose stack is running and whose
+// npm runs until it is terminated, noting the signal in a file.
+func fakeSailVite(t *testing.T, projectDir string) string {
+	t.Helper()
+	stopped := filepath.Join(projectDir, "stopped")
+	script := "#!/bin/sh\ncase \"$1\" in\n" +
+		"ps) echo running ;;\n" +
+		"exec) echo \"$@\" > '" + filepath.Join(projectDir, "exec-args") + "' ;;\n" +
+		"npm) echo \"$@\" > '" + filepath.Join(projectDir, "args") + "'\n" +
+		"  trap 'echo term > \"" + stopped + "\"; exit 0' TERM\n" +
+		"  sleep 30 & wait ;;\n" +
+		"esac\n"
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	if err := os.MkdirAll(filepath.Dir(sailPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sailPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return stopped
+}
+
+// waitForFile polls until path exists.
+func waitForFile(t *testing.T, path string) string {
+	t.Helper()
+	for range 100 {
+		if data, err := os.ReadFile(path); err == nil {
+			return string(data)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("%s was never written", path)
+	return ""
+}
+
+func TestViteDevArgs(t *testing.T) {
+	got := strings.Join(viteDevArgs(5155), " ")
+	if got != "npm run dev -- --host --port 5155 --strictPort" {
+		t.Errorf("Unexpected arguments %q", got)
+	}
+}
+
+func TestStartAndStopVite(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	stopped := fakeSailVite(t, projectDir)
+	if err := saveProjectSuffix(projectDir, 55); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := startVite(projectDir, 55)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Port != 5155 || p.Log != viteLogPath(projectDir) {
+		t.Errorf("Unexpected process %+v", p)
+	}
+	if args := waitForFile(t, filepath.Join(projectDir, "args")); !strings.Contains(args, "--port 5155") {
+		t.Errorf("Expected Vite on VITE_PORT, got %q", args)
+	}
+
+	st, err := Status(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Vite == nil || st.Vite.PID != p.PID || st.Vite.Port != 5155 {
+		t.Errorf("Expected the status to show Vite, got %+v", st.Vite)
+	}
+
+	if err := stopVite(projectDir, p); err != nil {
+		t.Fatal(err)
+	}
+	waitForFile(t, stopped)
+	if args := waitForFile(t, filepath.Join(projectDir, "exec-args")); !strings.HasSuffix(strings.TrimSpace(args), "pkill -f -- --port 5155 --strictPort") {
+		t.Errorf("Expected only this project's Vite to be stopped in the container, got %q", args)
+	}
+	state, _, err := loadPortState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Vite[projectDir]; ok {
+		t.Error("Expected the stopped dev server to be forgotten")
+	}
+}
+
+func TestStartViteNeedsRunningStack(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	sailPath := filepath.Join(projectDir, "vendor", "bin", "sail")
+	os.MkdirAll(filepath.Dir(sailPath), 0755)
+	os.WriteFile(sailPath, []byte("#!/bin/sh\nexit 0\n"), 0755)
+	saveProjectSuffix(projectDir, 55)
+
+	if _, err := startVite(projectDir, 55); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("Expected a stopped stack to be reported, got %v", err)
+	}
+}
+
+// startFakeVite starts a process whose command line carries the Vite
+// arguments for port, and stops it when the test ends.
+func startFakeVite(t *testing.T, port int) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command("sh", append([]string{"-c", "sleep 30", "sh"}, viteDevArgs(port)...)...)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd
+}
+
+func TestRunningViteIgnoresDeadProcesses(t *testing.T) {
+	vite := startFakeVite(t, 5155)
+	state := &PortState{Vite: map[string]viteProcess{
+		"/alive":      {PID: vite.Process.Pid, Port: 5155},
+		"/reused":     {PID: os.Getpid(), Port: 5156},
+		"/other-port": {PID: vite.Process.Pid, Port: 5157},
+		"/dead":       {PID: 0, Port: 5158},
+	}}
+	if _, ok := runningVite(state, "/alive"); !ok {
+		t.Error("Expected a live process to count as running")
+	}
+	for _, dir := range []string{"/reused", "/other-port", "/dead", "/other"} {
+		if _, ok := runningVite(state, dir); ok {
+			t.Errorf("Expected %s not to count as running", dir)
+		}
+	}
+}
+
+func TestStopViteSparesReusedPID(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	projectDir := filepath.Join(tempDir, "project")
+	fakeSailVite(t, projectDir)
+	if err := saveProjectSuffix(projectDir, 55); err != nil {
+		t.Fatal(err)
+	}
+	// The recorded pid now belongs to a dev server of another port
+	other := startFakeVite(t, 5160)
+	p := viteProcess{PID: other.Process.Pid, Port: 5155}
+	if err := saveViteProcess(projectDir, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stopVite(projectDir, p); err != nil {
+		t.Fatal(err)
+	}
+	if !(viteProcess{PID: other.Process.Pid, Port: 5160}).owned() {
+		t.Error("Expected a process that isn't the recorded dev server to be left alone")
+	}
+	state, _, _ := loadPortState()
+	if _, ok := state.Vite[projectDir]; ok {
+		t.Error("Expected the stale record to be dropped")
+	}
+}
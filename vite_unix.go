@@ -0,0 +1,44 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// detach starts cmd in its own process group, so it outlives sailinit and
+// can be stopped together with its children.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// processAlive reports whether a process with pid exists and belongs to
+// this user. A pid another user owns (EPERM) was reused, so it isn't ours.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+// processCommandLine returns the command line of the process with pid.
+func processCommandLine(pid int) (string, bool) {
+	out, err := commandOutput(exec.Command("ps", "-o", "args=", "-p", strconv.Itoa(pid)))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
+}
+
+// stopProcess terminates the process group started by detach.
+func stopProcess(pid int) error {
+	err := syscall.Kill(-pid, syscall.SIGTERM)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// warningClass is a kind of warning setup gives about a condition a team
+// may have accepted. Listing its ID or name under suppress_warnings, in
+// the config file or a project's .sailinit.yaml, silences it and skips the
+// question that comes with it.
+type warningClass struct {
+	ID   string
+	Name string
+	// Summary describes the condition, for the schema and the README
+	Summary string
+}
+
+var (
+	warnPortBusy        = warningClass{"W001", "port-busy", "Ports of the suffix are already in use"}
+	warnPHPMismatch     = warningClass{"W002", "php-mismatch", "The requested PHP version differs from the compose file's"}
+	warnHerdValet       = warningClass{"W003", "herd-valet", "Laravel Herd or Valet also serves the project"}
+	warnEnvDrift        = warningClass{"W004", "env-drift", ".env lacks keys that .env.example has"}
+	warnEnvMismatch     = warningClass{"W005", "env-mismatch", "A host, port or driver in .env does not match the compose services"}
+	warnWebPort         = warningClass{"W006", "web-port", "A local server holds port 80 or 443"}
+	warnEnvPermissions  = warningClass{"W007", "env-permissions", ".env secrets are readable by other users"}
+	warnDiskSpace       = warningClass{"W008", "disk-space", "Little free space on the Docker data root or the project"}
+	warnPackageServices = warningClass{"W009", "package-services", "A package in composer.json needs a service or port the compose file lacks"}
+)
+
+// warningClasses lists every class, by ID.
+var warningClasses = []warningClass{
+	warnPortBusy, warnPHPMismatch, warnHerdValet, warnEnvDrift,
+	warnEnvMismatch, warnWebPort, warnEnvPermissions, warnDiskSpace,
+	warnPackageServices,
+}
+
+// warningSelectors returns the IDs and names suppress_warnings accepts.
+func warningSelectors() []string {
+	var selectors []string
+	for _, w := range warningClasses {
+		selectors = append(selectors, w.ID, w.Name)
+	}
+	return selectors
+}
+
+// suppressed reports whether the config file or projectDir's .sailinit.yaml
+// suppresses w. Broken config files suppress nothing; they are reported
+// where they are loaded.
+func (w warningClass) suppressed(projectDir string) bool {
+	var selectors []string
+	if cfg, err := loadUserConfig(); err == nil {
+		selectors = append(selectors, cfg.SuppressWarnings...)
+	}
+	if projectDir != "" {
+		if cfg, err := loadProjectConfig(projectDir); err == nil && cfg != nil {
+			selectors = append(selectors, cfg.SuppressWarnings...)
+		}
+	}
+	return slices.Contains(selectors, w.ID) || slices.Contains(selectors, w.Name)
+}
+
+// tag prefixes msg with w's ID, so the warning can be looked up and
+// suppressed.
+func (w warningClass) tag(msg string) string {
+	return fmt.Sprintf("[%s] %s", w.ID, msg)
+}
+
+// warn prints msg as a w warning unless projectDir suppresses w, and
+// reports whether it did.
+func (w warningClass) warn(projectDir, msg string) bool {
+	if w.suppressed(projectDir) {
+		return false
+	}
+	printWarning(w.tag(msg))
+	return true
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestWarningSuppression(t *testing.T) {
+	dir := t.TempDir()
+	writeTestConfig(t, "")
+	if warnPortBusy.suppressed(dir) {
+		t.Error("Expected nothing to be suppressed by default")
+	}
+
+	writeTestConfig(t, "suppress_warnings: [W001]\n")
+	if !warnPortBusy.suppressed(dir) || warnEnvDrift.suppressed(dir) {
+		t.Error("Expected only W001 to be suppressed by the config file")
+	}
+
+	writeTestConfig(t, "")
+	writeProjectConfig(t, dir, "suppress_warnings:\n  - env-drift\n")
+	if !warnEnvDrift.suppressed(dir) || warnPortBusy.suppressed(dir) {
+		t.Error("Expected only env-drift to be suppressed by the project")
+	}
+	if warnEnvDrift.suppressed(t.TempDir()) {
+		t.Error("Expected another project to keep the warning")
+	}
+	if warnEnvDrift.warn(dir, "hidden") {
+		t.Error("Expected a suppressed warning not to be printed")
+	}
+}
+
+func TestUnknownWarningIsRejected(t *testing.T) {
+	writeTestConfig(t, "suppress_warnings: [W999]\n")
+	if _, err := loadUserConfig(); err == nil {
+		t.Error("Expected an unknown warning ID to be rejected")
+	}
+	dir := t.TempDir()
+	writeProjectConfig(t, dir, "suppress_warnings: [port-bsy]\n")
+	if _, err := loadProjectConfig(dir); err == nil {
+		t.Error("Expected an unknown warning name to be rejected")
+	}
+}
+
+func TestWarningClassesAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, s := range warningSelectors() {
+		if seen[s] {
+			t.Errorf("Duplicate warning selector %s", s)
+		}
+		seen[s] = true
+	}
+	if got := warnPortBusy.tag("Ports busy"); got != "[W001] Ports busy" {
+		t.Errorf("Unexpected tag %q", got)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// webServerProcesses maps process names of common local web servers to
+// the names users know them by.
+var webServerProcesses = []struct{ process, server string }{
+	{"httpd", "Apache"},
+	{"apache2", "Apache"},
+	{"nginx", "nginx"},
+	{"herd", "Herd"},
+	{"caddy", "Caddy"},
+	{"docker-proxy", "a Docker container"},
+	{"com.docker", "a Docker container"},
+	{"php", "a PHP server"},
+}
+
+// webPortClash is a local server holding port 80 or 443.
+type webPortClash struct {
+	Port int
+	// Server names the server, e.g. "Apache (httpd, pid 312)", or is
+	// empty when the owner can't be determined
+	Server string
+}
+
+// identifyWebServer names the server behind process, a portProcess result
+// such as "httpd (pid 312)".
+func identifyWebServer(process string) string {
+	if process == "" {
+		return ""
+	}
+	name := strings.ToLower(strings.Fields(process)[0])
+	for _, w := range webServerProcesses {
+		if strings.Contains(name, w.process) {
+			return fmt.Sprintf("%s (%s)", w.server, process)
+		}
+	}
+	return process
+}
+
+// probeWebPorts returns the servers listening on 80 and 443. listening and
+// owner are isPortListening and portProcess outside tests.
+func probeWebPorts(listening func(int) bool, owner func(int) string) []webPortClash {
+	var clashes []webPortClash
+	for _, port := range []int{80, 443} {
+		if listening(port) {
+			clashes = append(clashes, webPortClash{Port: port, Server: identifyWebServer(owner(port))})
+		}
+	}
+	return clashes
+}
+
+// owner names what holds the port, for warnings and doctor output.
+func (c webPortClash) owner() string {
+	if c.Server == "" {
+		return "another server"
+	}
+	return c.Server
+}
+
+// appURLDefaultPort returns the port APP_URL reaches when it names
+// localhost without a port (80 or 443), or 0 when it names a port or
+// another host.
+func appURLDefaultPort(appURL string) int {
+	u, err := url.Parse(appURL)
+	if err != nil || u.Port() != "" || !isLocalHost(u.Hostname()) {
+		return 0
+	}
+	switch u.Scheme {
+	case "http":
+		return 80
+	case "https":
+		return 443
+	}
+	return 0
+}
+
+// sailAppURL is the address Sail serves a project with suffix on.
+func sailAppURL(suffix int) string {
+	return fmt.Sprintf("http://localhost:%d", basePort("APP_PORT")+suffix)
+}
+
+// webPortClashFor returns the clash APP_URL walks into: the server on the
+// default port a portless localhost APP_URL reaches.
+func webPortClashFor(appURL string, clashes []webPortClash) (webPortClash, bool) {
+	port := appURLDefaultPort(appURL)
+	for _, c := range clashes {
+		if c.Port == port {
+			return c, true
+		}
+	}
+	return webPortClash{}, false
+}
+
+func checkWebPorts(projectDir string) []string {
+	env := readEnvValues(filepath.Join(projectDir, ".env"))
+	clash, found := webPortClashFor(env["APP_URL"], probeWebPorts(isPortListening, portProcess))
+	if !found {
+		return nil
+	}
+	suffix := -1
+	if s, registered, _, err := getSuggestedSuffix(projectDir); err == nil && registered {
+		suffix = s
+	}
+	problem := fmt.Sprintf("APP_URL=%s reaches port %d, which %s holds", env["APP_URL"], clash.Port, clash.owner())
+	if suffix < 0 {
+		return []string{problem + "; Sail serves the app on APP_PORT instead"}
+	}
+	return []string{problem + "; Sail serves this project at " + sailAppURL(suffix)}
+}
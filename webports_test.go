@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIdentifyWebServer(t *testing.T) {
+	for process, want := range map[string]string{
+		"httpd (pid 312)":        "Apache (httpd (pid 312))",
+		"nginx (pid 88)":         "nginx (nginx (pid 88))",
+		"caddy (pid 7)":          "Caddy (caddy (pid 7))",
+		"docker-proxy (pid 901)": "a Docker container (docker-proxy (pid 901))",
+		"node (pid 5)":           "node (pid 5)",
+		"":                       "",
+	} {
+		if got := identifyWebServer(process); got != want {
+			t.Errorf("identifyWebServer(%q) = %q, want %q", process, got, want)
+		}
+	}
+}
+
+func TestProbeWebPorts(t *testing.T) {
+	clashes := probeWebPorts(func(port int) bool { return port == 80 }, func(int) string { return "httpd (pid 312)" })
+	if len(clashes) != 1 || clashes[0].Port != 80 || !strings.HasPrefix(clashes[0].Server, "Apache") {
+		t.Fatalf("Unexpected clashes %+v", clashes)
+	}
+	if c, found := webPortClashFor("http://localhost", clashes); !found || c.Port != 80 {
+		t.Errorf("Expected a portless localhost APP_URL to hit port 80, got %+v %v", c, found)
+	}
+	if _, found := webPortClashFor("http://localhost:8051", clashes); found {
+		t.Error("Expected an APP_URL with the Sail port not to clash")
+	}
+	if _, found := webPortClashFor("https://localhost", clashes); found {
+		t.Error("Expected https to reach 443, which is free")
+	}
+	if (webPortClash{Port: 443}).owner() != "another server" {
+		t.Error("Expected an unknown owner to be described generically")
+	}
+}
+
+func TestAppURLDefaultPort(t *testing.T) {
+	for appURL, want := range map[string]int{
+		"http://localhost":       80,
+		"https://127.0.0.1/":     443,
+		"http://localhost:8051":  0,
+		"http://shop.test":       0,
+		"":                       0,
+		"ftp://localhost/folder": 0,
+	} {
+		if got := appURLDefaultPort(appURL); got != want {
+			t.Errorf("appURLDefaultPort(%q) = %d, want %d", appURL, got, want)
+		}
+	}
+}
+
+func TestCheckWebPortsWithoutClash(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("APP_URL=http://localhost:8051\n"), 0644)
+	if problems := checkWebPorts(dir); len(problems) != 0 {
+		t.Errorf("Expected no problems for an APP_URL with a port, got %v", problems)
+	}
+}
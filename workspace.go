@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// workspaceExtensions are the editor extensions recommended for a Sail
+// project.
+var workspaceExtensions = []string{
+	"bmewburn.vscode-intelephense-client",
+	"onecentlin.laravel-blade",
+	"ms-azuretools.vscode-docker",
+	"xdebug.php-debug",
+	"mikestead.dotenv",
+}
+
+// groupProjects returns the registered projects that still exist at or
+// below groupDir, sorted by path. A group is the directory a client's or
+// team's projects live in.
+func groupProjects(projects []ProjectInfo, groupDir string) []ProjectInfo {
+	var group []ProjectInfo
+	for _, p := range projects {
+		if !p.Exists {
+			continue
+		}
+		if rel, err := filepath.Rel(groupDir, p.Path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			group = append(group, p)
+		}
+	}
+	slices.SortFunc(group, func(a, b ProjectInfo) int { return strings.Compare(a.Path, b.Path) })
+	return group
+}
+
+// vscodeWorkspace renders a .code-workspace file stored in dir with a
+// folder per project, the recommended extensions and a label for each
+// forwarded port, so VS Code doesn't announce them as unknown.
+func vscodeWorkspace(projects []ProjectInfo, dir string) ([]byte, error) {
+	var folders []map[string]string
+	ports := make(map[string]any)
+	for _, p := range projects {
+		name := filepath.Base(p.Path)
+		path := p.Path
+		if rel, err := filepath.Rel(dir, p.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = filepath.ToSlash(rel)
+		}
+		folders = append(folders, map[string]string{"name": name + " (" + strconv.Itoa(p.Suffix) + ")", "path": path})
+		for key, port := range forwardedPorts(p.Path, p.Suffix, true) {
+			ports[strconv.Itoa(port)] = map[string]string{"label": name + " " + key, "onAutoForward": "silent"}
+		}
+	}
+	doc := map[string]any{
+		"folders":    folders,
+		"settings":   map[string]any{"remote.portsAttributes": ports},
+		"extensions": map[string]any{"recommendations": workspaceExtensions},
+	}
+	data, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
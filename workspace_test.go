@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGroupProjects(t *testing.T) {
+	projects := []ProjectInfo{
+		{Path: "/work/acme/shop", Exists: true},
+		{Path: "/work/acme/api", Exists: true},
+		{Path: "/work/acme-two/blog", Exists: true},
+		{Path: "/work/acme/gone", Exists: false},
+		{Path: "/work/other", Exists: true},
+	}
+	group := groupProjects(projects, "/work/acme")
+	if len(group) != 2 || group[0].Path != "/work/acme/api" || group[1].Path != "/work/acme/shop" {
+		t.Errorf("Expected api and shop, got %+v", group)
+	}
+	if got := groupProjects(projects, "/work/acme/shop"); len(got) != 1 {
+		t.Errorf("Expected the project itself to form a group, got %+v", got)
+	}
+}
+
+func TestVSCodeWorkspace(t *testing.T) {
+	tempDir, cleanup := setupTestState(t)
+	defer cleanup()
+
+	shop := filepath.Join(tempDir, "acme", "shop")
+	os.MkdirAll(shop, 0755)
+	os.WriteFile(filepath.Join(shop, ".env"), []byte("APP_PORT=8060\n"), 0644)
+	outside := filepath.Join(t.TempDir(), "api")
+
+	data, err := vscodeWorkspace([]ProjectInfo{{Path: shop, Suffix: 60}, {Path: outside, Suffix: 61}}, filepath.Join(tempDir, "acme"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Folders  []struct{ Name, Path string }
+		Settings struct {
+			Ports map[string]struct{ Label, OnAutoForward string } `json:"remote.portsAttributes"`
+		}
+		Extensions struct{ Recommendations []string }
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, data)
+	}
+	if len(doc.Folders) != 2 || doc.Folders[0].Name != "shop (60)" || doc.Folders[0].Path != "shop" {
+		t.Errorf("Expected shop as a relative folder, got %+v", doc.Folders)
+	}
+	if doc.Folders[1].Path != outside {
+		t.Errorf("Expected an absolute path outside the workspace directory, got %q", doc.Folders[1].Path)
+	}
+	if p := doc.Settings.Ports["8060"]; p.Label != "shop APP_PORT" || p.OnAutoForward != "silent" {
+		t.Errorf("Expected the app port labelled, got %+v", doc.Settings.Ports)
+	}
+	if _, ok := doc.Settings.Ports["3361"]; !ok {
+		t.Errorf("Expected suffix-based ports for a project without .env, got %v", doc.Settings.Ports)
+	}
+	if len(doc.Extensions.Recommendations) == 0 {
+		t.Error("Expected recommended extensions")
+	}
+}